@@ -23,6 +23,12 @@ func (m *BlockResponse) Wrap() proto.Message {
 	return bm
 }
 
+func (m *CompressedBlockResponse) Wrap() proto.Message {
+	bm := &Message{}
+	bm.Sum = &Message_CompressedBlockResponse{CompressedBlockResponse: m}
+	return bm
+}
+
 func (m *NoBlockResponse) Wrap() proto.Message {
 	bm := &Message{}
 	bm.Sum = &Message_NoBlockResponse{NoBlockResponse: m}
@@ -51,6 +57,9 @@ func (m *Message) Unwrap() (proto.Message, error) {
 	case *Message_BlockResponse:
 		return m.GetBlockResponse(), nil
 
+	case *Message_CompressedBlockResponse:
+		return m.GetCompressedBlockResponse(), nil
+
 	case *Message_NoBlockResponse:
 		return m.GetNoBlockResponse(), nil
 
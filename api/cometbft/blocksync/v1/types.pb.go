@@ -26,6 +26,8 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 // BlockRequest requests a block for a specific height
 type BlockRequest struct {
 	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	// Whether the requester accepts a CompressedBlockResponse.
+	AcceptCompressed bool `protobuf:"varint,2,opt,name=accept_compressed,json=acceptCompressed,proto3" json:"accept_compressed,omitempty"`
 }
 
 func (m *BlockRequest) Reset()         { *m = BlockRequest{} }
@@ -68,6 +70,13 @@ func (m *BlockRequest) GetHeight() int64 {
 	return 0
 }
 
+func (m *BlockRequest) GetAcceptCompressed() bool {
+	if m != nil {
+		return m.AcceptCompressed
+	}
+	return false
+}
+
 // NoBlockResponse informs the node that the peer does not have block at the requested height
 type NoBlockResponse struct {
 	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
@@ -256,6 +265,52 @@ func (m *BlockResponse) GetExtCommit() *v1.ExtendedCommit {
 	return nil
 }
 
+// CompressedBlockResponse is a BlockResponse, compressed with snappy. It is only
+// sent in response to a BlockRequest with accept_compressed set.
+type CompressedBlockResponse struct {
+	BlockResponse []byte `protobuf:"bytes,1,opt,name=block_response,json=blockResponse,proto3" json:"block_response,omitempty"`
+}
+
+func (m *CompressedBlockResponse) Reset()         { *m = CompressedBlockResponse{} }
+func (m *CompressedBlockResponse) String() string { return proto.CompactTextString(m) }
+func (*CompressedBlockResponse) ProtoMessage()    {}
+func (*CompressedBlockResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_67182bd6cb30f2ef, []int{5}
+}
+func (m *CompressedBlockResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CompressedBlockResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CompressedBlockResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CompressedBlockResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompressedBlockResponse.Merge(m, src)
+}
+func (m *CompressedBlockResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *CompressedBlockResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompressedBlockResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompressedBlockResponse proto.InternalMessageInfo
+
+func (m *CompressedBlockResponse) GetBlockResponse() []byte {
+	if m != nil {
+		return m.BlockResponse
+	}
+	return nil
+}
+
 // Message is an abstract blocksync message.
 type Message struct {
 	// Sum of all possible messages.
@@ -266,6 +321,7 @@ type Message struct {
 	//	*Message_BlockResponse
 	//	*Message_StatusRequest
 	//	*Message_StatusResponse
+	//	*Message_CompressedBlockResponse
 	Sum isMessage_Sum `protobuf_oneof:"sum"`
 }
 
@@ -273,7 +329,7 @@ func (m *Message) Reset()         { *m = Message{} }
 func (m *Message) String() string { return proto.CompactTextString(m) }
 func (*Message) ProtoMessage()    {}
 func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_67182bd6cb30f2ef, []int{5}
+	return fileDescriptor_67182bd6cb30f2ef, []int{6}
 }
 func (m *Message) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -323,12 +379,16 @@ type Message_StatusRequest struct {
 type Message_StatusResponse struct {
 	StatusResponse *StatusResponse `protobuf:"bytes,5,opt,name=status_response,json=statusResponse,proto3,oneof" json:"status_response,omitempty"`
 }
+type Message_CompressedBlockResponse struct {
+	CompressedBlockResponse *CompressedBlockResponse `protobuf:"bytes,6,opt,name=compressed_block_response,json=compressedBlockResponse,proto3,oneof" json:"compressed_block_response,omitempty"`
+}
 
-func (*Message_BlockRequest) isMessage_Sum()    {}
-func (*Message_NoBlockResponse) isMessage_Sum() {}
-func (*Message_BlockResponse) isMessage_Sum()   {}
-func (*Message_StatusRequest) isMessage_Sum()   {}
-func (*Message_StatusResponse) isMessage_Sum()  {}
+func (*Message_BlockRequest) isMessage_Sum()            {}
+func (*Message_NoBlockResponse) isMessage_Sum()         {}
+func (*Message_BlockResponse) isMessage_Sum()           {}
+func (*Message_StatusRequest) isMessage_Sum()           {}
+func (*Message_StatusResponse) isMessage_Sum()          {}
+func (*Message_CompressedBlockResponse) isMessage_Sum() {}
 
 func (m *Message) GetSum() isMessage_Sum {
 	if m != nil {
@@ -372,6 +432,13 @@ func (m *Message) GetStatusResponse() *StatusResponse {
 	return nil
 }
 
+func (m *Message) GetCompressedBlockResponse() *CompressedBlockResponse {
+	if x, ok := m.GetSum().(*Message_CompressedBlockResponse); ok {
+		return x.CompressedBlockResponse
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Message) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -380,6 +447,7 @@ func (*Message) XXX_OneofWrappers() []interface{} {
 		(*Message_BlockResponse)(nil),
 		(*Message_StatusRequest)(nil),
 		(*Message_StatusResponse)(nil),
+		(*Message_CompressedBlockResponse)(nil),
 	}
 }
 
@@ -389,39 +457,45 @@ func init() {
 	proto.RegisterType((*StatusRequest)(nil), "cometbft.blocksync.v1.StatusRequest")
 	proto.RegisterType((*StatusResponse)(nil), "cometbft.blocksync.v1.StatusResponse")
 	proto.RegisterType((*BlockResponse)(nil), "cometbft.blocksync.v1.BlockResponse")
+	proto.RegisterType((*CompressedBlockResponse)(nil), "cometbft.blocksync.v1.CompressedBlockResponse")
 	proto.RegisterType((*Message)(nil), "cometbft.blocksync.v1.Message")
 }
 
 func init() { proto.RegisterFile("cometbft/blocksync/v1/types.proto", fileDescriptor_67182bd6cb30f2ef) }
 
 var fileDescriptor_67182bd6cb30f2ef = []byte{
-	// 416 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x93, 0xcf, 0x6e, 0xda, 0x40,
-	0x10, 0xc6, 0xed, 0x1a, 0xa8, 0x3a, 0x60, 0xac, 0x5a, 0x6a, 0x85, 0x2a, 0xd5, 0x2a, 0x6e, 0x8b,
-	0xda, 0xcb, 0x5a, 0x50, 0xa9, 0xa7, 0x1e, 0x2a, 0xa2, 0x48, 0x28, 0x12, 0x11, 0x72, 0x72, 0xca,
-	0x05, 0xd9, 0x66, 0x03, 0x56, 0x62, 0xaf, 0xc3, 0xae, 0x11, 0x1c, 0xf3, 0x06, 0x79, 0x86, 0x3c,
-	0x4d, 0x8e, 0x1c, 0x73, 0x8c, 0xe0, 0x45, 0x22, 0xaf, 0xcd, 0xca, 0x58, 0x86, 0xdc, 0xc6, 0xb3,
-	0xdf, 0xf7, 0xf3, 0xfc, 0xd1, 0x40, 0xdb, 0x23, 0x01, 0x66, 0xee, 0x35, 0xb3, 0xdc, 0x5b, 0xe2,
-	0xdd, 0xd0, 0x55, 0xe8, 0x59, 0x8b, 0xae, 0xc5, 0x56, 0x11, 0xa6, 0x28, 0x9a, 0x13, 0x46, 0xf4,
-	0x4f, 0x3b, 0x09, 0x12, 0x12, 0xb4, 0xe8, 0x7e, 0xf9, 0x2a, 0x9c, 0x5c, 0x9c, 0xb8, 0xf8, 0x7b,
-	0xea, 0x2a, 0x7b, 0xce, 0x41, 0xcd, 0x0e, 0x34, 0xfa, 0x89, 0xda, 0xc6, 0x77, 0x31, 0xa6, 0x4c,
-	0xff, 0x0c, 0xb5, 0x19, 0xf6, 0xa7, 0x33, 0xd6, 0x92, 0xbf, 0xc9, 0xbf, 0x14, 0x3b, 0xfb, 0x32,
-	0x7f, 0x83, 0x76, 0x4e, 0x32, 0x25, 0x8d, 0x48, 0x48, 0xf1, 0x41, 0xa9, 0x06, 0xea, 0x05, 0x73,
-	0x58, 0x4c, 0x33, 0xa6, 0xf9, 0x0f, 0x9a, 0xbb, 0xc4, 0x71, 0xab, 0xae, 0x43, 0xc5, 0x75, 0x28,
-	0x6e, 0xbd, 0xe3, 0x59, 0x1e, 0x9b, 0xf7, 0x32, 0xa8, 0xfb, 0x3f, 0x46, 0x50, 0xe5, 0x1d, 0x72,
-	0x73, 0xbd, 0xd7, 0x42, 0x62, 0x30, 0x69, 0x67, 0x8b, 0x2e, 0x4a, 0x0d, 0xa9, 0x4c, 0xff, 0x0f,
-	0x80, 0x97, 0x6c, 0xec, 0x91, 0x20, 0xf0, 0x19, 0x67, 0xd7, 0x7b, 0xed, 0x12, 0xd3, 0xe9, 0x92,
-	0xe1, 0x70, 0x82, 0x27, 0x27, 0x5c, 0x68, 0x7f, 0xc0, 0x4b, 0x96, 0x86, 0xe6, 0xa3, 0x02, 0xef,
-	0x87, 0x98, 0x52, 0x67, 0x8a, 0xf5, 0x33, 0x50, 0x39, 0x76, 0x3c, 0x4f, 0xdb, 0xcb, 0xaa, 0xf8,
-	0x8e, 0x4a, 0xd7, 0x83, 0xf2, 0xd3, 0x1d, 0x48, 0x76, 0xc3, 0xcd, 0x4f, 0xfb, 0x12, 0x3e, 0x86,
-	0x64, 0xbc, 0xc3, 0xa5, 0xed, 0x65, 0x05, 0x76, 0x0e, 0xf0, 0x0a, 0x5b, 0x18, 0x48, 0xb6, 0x16,
-	0x16, 0x16, 0x33, 0x84, 0x66, 0x01, 0xa9, 0x70, 0xe4, 0x8f, 0xe3, 0x25, 0x0a, 0xa0, 0xea, 0x16,
-	0x71, 0x94, 0xaf, 0x4f, 0x74, 0x5c, 0x39, 0x8a, 0xdb, 0x5b, 0x7e, 0x82, 0xa3, 0xf9, 0x84, 0x3e,
-	0x02, 0x4d, 0xe0, 0xb2, 0xf2, 0xaa, 0x9c, 0xf7, 0xf3, 0x0d, 0x9e, 0xa8, 0xaf, 0x49, 0xf7, 0x32,
-	0xfd, 0x2a, 0x28, 0x34, 0x0e, 0xfa, 0xa3, 0xa7, 0x8d, 0x21, 0xaf, 0x37, 0x86, 0xfc, 0xb2, 0x31,
-	0xe4, 0x87, 0xad, 0x21, 0xad, 0xb7, 0x86, 0xf4, 0xbc, 0x35, 0xa4, 0xab, 0xbf, 0x53, 0x9f, 0xcd,
-	0x62, 0x37, 0xe1, 0x5b, 0xe2, 0x1c, 0x44, 0xe0, 0x44, 0xbe, 0x55, 0x7a, 0x7d, 0x6e, 0x8d, 0xdf,
-	0xc8, 0x9f, 0xd7, 0x00, 0x00, 0x00, 0xff, 0xff, 0x7b, 0xa3, 0xca, 0xb4, 0x9d, 0x03, 0x00, 0x00,
+	// 482 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x94, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xc7, 0x6d, 0xf2, 0x01, 0x4c, 0xe3, 0x84, 0x5a, 0x82, 0x06, 0x24, 0xac, 0xd6, 0x50, 0x54,
+	0x84, 0xb4, 0x56, 0x8a, 0xc4, 0x89, 0x43, 0x95, 0x0a, 0x29, 0x42, 0x2a, 0xaa, 0xb6, 0x9c, 0xb8,
+	0x44, 0xb6, 0x33, 0x24, 0x11, 0x8d, 0xd7, 0x64, 0x37, 0x51, 0x7a, 0xe4, 0x0d, 0xfa, 0x58, 0x1c,
+	0x7b, 0xe4, 0x88, 0x92, 0x17, 0x41, 0x19, 0x3b, 0x1b, 0xc7, 0x38, 0xe9, 0x6d, 0xbd, 0x3b, 0xff,
+	0xdf, 0xfe, 0xe7, 0xc3, 0x0b, 0x47, 0xa1, 0x18, 0xa1, 0x0a, 0xbe, 0x2b, 0x2f, 0xb8, 0x16, 0xe1,
+	0x0f, 0x79, 0x13, 0x85, 0xde, 0xb4, 0xe5, 0xa9, 0x9b, 0x18, 0x25, 0x8b, 0xc7, 0x42, 0x09, 0xfb,
+	0xe9, 0x2a, 0x84, 0xe9, 0x10, 0x36, 0x6d, 0xbd, 0x78, 0xa9, 0x95, 0x14, 0xbc, 0x54, 0xd1, 0x79,
+	0xa2, 0x2a, 0x3a, 0xce, 0x40, 0xdd, 0x2b, 0xa8, 0xb5, 0x97, 0xd1, 0x1c, 0x7f, 0x4e, 0x50, 0x2a,
+	0xfb, 0x19, 0x54, 0x07, 0x38, 0xec, 0x0f, 0x54, 0xd3, 0x3c, 0x34, 0x4f, 0x4a, 0x3c, 0xfd, 0xb2,
+	0xdf, 0xc1, 0xbe, 0x1f, 0x86, 0x18, 0xab, 0x6e, 0x28, 0x46, 0xf1, 0x18, 0xa5, 0xc4, 0x5e, 0xf3,
+	0xc1, 0xa1, 0x79, 0xf2, 0x88, 0x3f, 0x49, 0x0e, 0xce, 0xf5, 0xbe, 0xfb, 0x16, 0x1a, 0x5f, 0x44,
+	0x8a, 0x95, 0xb1, 0x88, 0x24, 0x6e, 0xe3, 0xba, 0x0d, 0xb0, 0xae, 0x94, 0xaf, 0x26, 0x32, 0x35,
+	0xe0, 0x7e, 0x84, 0xfa, 0x6a, 0x63, 0xb7, 0xd4, 0xb6, 0xa1, 0x1c, 0xf8, 0x12, 0xc9, 0x45, 0x89,
+	0xd3, 0xda, 0xfd, 0x65, 0x82, 0xb5, 0x79, 0x31, 0x83, 0x0a, 0x95, 0x83, 0xc4, 0x7b, 0xa7, 0x4d,
+	0xa6, 0xab, 0x98, 0x94, 0x61, 0xda, 0x62, 0x89, 0x20, 0x09, 0xb3, 0xcf, 0x00, 0x70, 0x46, 0x59,
+	0x8e, 0x86, 0x8a, 0xd8, 0x7b, 0xa7, 0x47, 0x05, 0xa2, 0x4f, 0x33, 0x85, 0x51, 0x0f, 0x7b, 0xe7,
+	0x14, 0xc8, 0x1f, 0xe3, 0x4c, 0x25, 0x4b, 0xf7, 0x0c, 0x0e, 0xd6, 0xb5, 0xd8, 0x34, 0x73, 0x0c,
+	0x75, 0xba, 0xa5, 0x3b, 0x4e, 0x77, 0xc8, 0x55, 0x8d, 0x5b, 0x41, 0x36, 0xcc, 0xbd, 0x2d, 0xc3,
+	0xc3, 0x0b, 0x94, 0xd2, 0xef, 0xa3, 0xfd, 0x19, 0xac, 0x95, 0x84, 0x0a, 0x94, 0xe6, 0xf1, 0x8a,
+	0x15, 0x4e, 0x03, 0xcb, 0x36, 0xb3, 0x63, 0xf0, 0x5a, 0x90, 0x6d, 0xee, 0x57, 0xd8, 0x8f, 0x44,
+	0x37, 0xe7, 0x20, 0x49, 0xf1, 0xcd, 0x16, 0x5e, 0xae, 0x8f, 0x1d, 0x83, 0x37, 0xa2, 0x5c, 0x6b,
+	0x2f, 0xfe, 0x4b, 0xaa, 0x44, 0xc8, 0xd7, 0xbb, 0x2d, 0x6a, 0xa0, 0x15, 0xe4, 0x71, 0x92, 0x06,
+	0x40, 0x67, 0x5c, 0xde, 0x89, 0xdb, 0x18, 0x9f, 0x25, 0x4e, 0x66, 0x37, 0xec, 0x4b, 0x68, 0x68,
+	0x5c, 0x6a, 0xaf, 0x42, 0xbc, 0xe3, 0x7b, 0x78, 0xda, 0x5f, 0x5d, 0x6e, 0xce, 0xe3, 0x35, 0x3c,
+	0x5f, 0xff, 0x03, 0xf9, 0x6a, 0x56, 0x89, 0xcd, 0xb6, 0xb0, 0xb7, 0xcc, 0x45, 0xc7, 0xe0, 0x07,
+	0x61, 0xf1, 0x51, 0xbb, 0x02, 0x25, 0x39, 0x19, 0xb5, 0x2f, 0x7f, 0xcf, 0x1d, 0xf3, 0x6e, 0xee,
+	0x98, 0x7f, 0xe7, 0x8e, 0x79, 0xbb, 0x70, 0x8c, 0xbb, 0x85, 0x63, 0xfc, 0x59, 0x38, 0xc6, 0xb7,
+	0x0f, 0xfd, 0xa1, 0x1a, 0x4c, 0x82, 0xe5, 0x8d, 0x9e, 0xfe, 0xd7, 0xf5, 0xc2, 0x8f, 0x87, 0x5e,
+	0xe1, 0xd3, 0x12, 0x54, 0xe9, 0x01, 0x78, 0xff, 0x6f, 0x00, 0x47, 0x4c, 0xa1, 0x85, 0x7a, 0x04,
+	0x00, 0x00,
 }
 
 func (m *BlockRequest) Marshal() (dAtA []byte, err error) {
@@ -444,6 +518,16 @@ func (m *BlockRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.AcceptCompressed {
+		i--
+		if m.AcceptCompressed {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
 	if m.Height != 0 {
 		i = encodeVarintTypes(dAtA, i, uint64(m.Height))
 		i--
@@ -583,6 +667,36 @@ func (m *BlockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *CompressedBlockResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CompressedBlockResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CompressedBlockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.BlockResponse) > 0 {
+		i -= len(m.BlockResponse)
+		copy(dAtA[i:], m.BlockResponse)
+		i = encodeVarintTypes(dAtA, i, uint64(len(m.BlockResponse)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Message) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -720,6 +834,27 @@ func (m *Message_StatusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	}
 	return len(dAtA) - i, nil
 }
+func (m *Message_CompressedBlockResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_CompressedBlockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.CompressedBlockResponse != nil {
+		{
+			size, err := m.CompressedBlockResponse.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	return len(dAtA) - i, nil
+}
 func encodeVarintTypes(dAtA []byte, offset int, v uint64) int {
 	offset -= sovTypes(v)
 	base := offset
@@ -740,6 +875,9 @@ func (m *BlockRequest) Size() (n int) {
 	if m.Height != 0 {
 		n += 1 + sovTypes(uint64(m.Height))
 	}
+	if m.AcceptCompressed {
+		n += 2
+	}
 	return n
 }
 
@@ -796,6 +934,19 @@ func (m *BlockResponse) Size() (n int) {
 	return n
 }
 
+func (m *CompressedBlockResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.BlockResponse)
+	if l > 0 {
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	return n
+}
+
 func (m *Message) Size() (n int) {
 	if m == nil {
 		return 0
@@ -868,6 +1019,18 @@ func (m *Message_StatusResponse) Size() (n int) {
 	}
 	return n
 }
+func (m *Message_CompressedBlockResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.CompressedBlockResponse != nil {
+		l = m.CompressedBlockResponse.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	return n
+}
 
 func sovTypes(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
@@ -923,6 +1086,26 @@ func (m *BlockRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptCompressed", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AcceptCompressed = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -1273,6 +1456,90 @@ func (m *BlockResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *CompressedBlockResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CompressedBlockResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CompressedBlockResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockResponse", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BlockResponse = append(m.BlockResponse[:0], dAtA[iNdEx:postIndex]...)
+			if m.BlockResponse == nil {
+				m.BlockResponse = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *Message) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -1477,6 +1744,41 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 			}
 			m.Sum = &Message_StatusResponse{v}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressedBlockResponse", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &CompressedBlockResponse{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Sum = &Message_CompressedBlockResponse{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
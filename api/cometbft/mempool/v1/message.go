@@ -13,6 +13,13 @@ func (m *Txs) Wrap() proto.Message {
 	return mm
 }
 
+// Wrap implements the p2p Wrapper interface and wraps a mempool message.
+func (m *TxsInvalidated) Wrap() proto.Message {
+	mm := &Message{}
+	mm.Sum = &Message_TxsInvalidated{TxsInvalidated: m}
+	return mm
+}
+
 // Unwrap implements the p2p Wrapper interface and unwraps a wrapped mempool
 // message.
 func (m *Message) Unwrap() (proto.Message, error) {
@@ -20,6 +27,9 @@ func (m *Message) Unwrap() (proto.Message, error) {
 	case *Message_Txs:
 		return m.GetTxs(), nil
 
+	case *Message_TxsInvalidated:
+		return m.GetTxsInvalidated(), nil
+
 	default:
 		return nil, fmt.Errorf("unknown message: %T", msg)
 	}
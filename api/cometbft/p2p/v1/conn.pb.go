@@ -104,6 +104,9 @@ type PacketMsg struct {
 	ChannelID int32  `protobuf:"varint,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
 	EOF       bool   `protobuf:"varint,2,opt,name=eof,proto3" json:"eof,omitempty"`
 	Data      []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// Sequence number of the message on its channel, starting at 1. Only set
+	// by senders with sequence numbers enabled, for debugging.
+	Sequence uint64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
 }
 
 func (m *PacketMsg) Reset()         { *m = PacketMsg{} }
@@ -160,6 +163,13 @@ func (m *PacketMsg) GetData() []byte {
 	return nil
 }
 
+func (m *PacketMsg) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
 // Packet is an abstract p2p message.
 type Packet struct {
 	// Sum of all possible messages.
@@ -327,33 +337,34 @@ func init() {
 func init() { proto.RegisterFile("cometbft/p2p/v1/conn.proto", fileDescriptor_3ad66b5863681764) }
 
 var fileDescriptor_3ad66b5863681764 = []byte{
-	// 402 bytes of a gzipped FileDescriptorProto
+	// 417 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0xf5, 0xe2, 0x36, 0xc5, 0x93, 0xf0, 0xa1, 0x15, 0x87, 0x60, 0x54, 0x27, 0xf2, 0x29, 0x07,
-	0x64, 0x53, 0x73, 0x04, 0x21, 0x61, 0x3e, 0x44, 0xa9, 0x22, 0x2a, 0x73, 0xe3, 0x62, 0x6c, 0x67,
-	0xbb, 0x5e, 0xa5, 0xd9, 0x5d, 0x75, 0xd7, 0x95, 0xfc, 0x2f, 0xf8, 0x59, 0x3d, 0x70, 0xe8, 0x91,
-	0x53, 0x84, 0x9c, 0x3f, 0x82, 0xbc, 0x4e, 0x9a, 0x10, 0x09, 0x6e, 0xef, 0xcd, 0xcc, 0x7b, 0x33,
-	0xa3, 0x19, 0x70, 0x0b, 0xb1, 0x20, 0x3a, 0xbf, 0xd0, 0xa1, 0x8c, 0x64, 0x78, 0x7d, 0x12, 0x16,
-	0x82, 0xf3, 0x40, 0x5e, 0x09, 0x2d, 0xf0, 0xa3, 0x4d, 0x2e, 0x90, 0x91, 0x0c, 0xae, 0x4f, 0xdc,
-	0x27, 0x54, 0x50, 0x61, 0x72, 0x61, 0x8b, 0xba, 0x32, 0xf7, 0xf8, 0xce, 0xa2, 0xb8, 0xaa, 0xa5,
-	0x16, 0xad, 0xcb, 0x9c, 0xd4, 0xaa, 0x4b, 0xfb, 0x03, 0x80, 0xf3, 0xac, 0x98, 0x13, 0x7d, 0xce,
-	0x38, 0xdd, 0x61, 0x82, 0x53, 0xbf, 0x04, 0xa7, 0x63, 0x53, 0x45, 0xf1, 0x73, 0x80, 0xa2, 0xcc,
-	0x38, 0x27, 0x97, 0x29, 0x9b, 0x0d, 0xd1, 0x18, 0x4d, 0x0e, 0xe3, 0x07, 0xcd, 0x72, 0xe4, 0xbc,
-	0xeb, 0xa2, 0xa7, 0xef, 0x13, 0x67, 0x5d, 0x70, 0x3a, 0xc3, 0x4f, 0xc1, 0x26, 0xe2, 0x62, 0x78,
-	0x6f, 0x8c, 0x26, 0xf7, 0xe3, 0xa3, 0x66, 0x39, 0xb2, 0x3f, 0x7c, 0xf9, 0x98, 0xb4, 0x31, 0x8c,
-	0xe1, 0x60, 0x96, 0xe9, 0x6c, 0x68, 0x8f, 0xd1, 0x64, 0x90, 0x18, 0xec, 0xff, 0x44, 0xd0, 0xeb,
-	0x5a, 0xe1, 0x37, 0xd0, 0x97, 0x06, 0xa5, 0x92, 0x71, 0x6a, 0x1a, 0xf5, 0xa3, 0x67, 0xc1, 0xde,
-	0xb2, 0xc1, 0x76, 0xe8, 0x4f, 0x56, 0x02, 0xf2, 0x8e, 0xed, 0xea, 0x05, 0xa7, 0x66, 0x82, 0xff,
-	0xe8, 0xc5, 0x5f, 0x7a, 0xc1, 0x29, 0x7e, 0x05, 0x6b, 0x96, 0x2e, 0x14, 0x35, 0x43, 0xf6, 0x23,
-	0xf7, 0x1f, 0xf2, 0xa9, 0x6a, 0xd5, 0x8e, 0xdc, 0x90, 0xf8, 0x10, 0x6c, 0x55, 0x2d, 0xfc, 0xef,
-	0xf0, 0xf0, 0x6d, 0xa5, 0xcb, 0xaf, 0x8c, 0x4e, 0x89, 0x52, 0x19, 0x25, 0xf8, 0x35, 0x1c, 0xc9,
-	0x2a, 0x4f, 0xe7, 0xa4, 0x5e, 0x6f, 0x74, 0xbc, 0xb5, 0xec, 0xee, 0x62, 0x5c, 0xab, 0xfc, 0x92,
-	0x15, 0x67, 0xa4, 0x8e, 0x0f, 0x6e, 0x96, 0x23, 0x2b, 0xe9, 0xc9, 0x2a, 0x3f, 0x23, 0x35, 0x7e,
-	0x0c, 0xb6, 0x62, 0xdd, 0x2e, 0x83, 0xa4, 0x85, 0xf1, 0xe7, 0x9b, 0xc6, 0x43, 0xb7, 0x8d, 0x87,
-	0x7e, 0x37, 0x1e, 0xfa, 0xb1, 0xf2, 0xac, 0xdb, 0x95, 0x67, 0xfd, 0x5a, 0x79, 0xd6, 0xb7, 0x17,
-	0x94, 0xe9, 0xb2, 0xca, 0x5b, 0xfb, 0x70, 0x7b, 0xfa, 0x0d, 0xc8, 0x24, 0x0b, 0xf7, 0x7e, 0x2a,
-	0xef, 0x99, 0x4f, 0x78, 0xf9, 0x27, 0x00, 0x00, 0xff, 0xff, 0xa9, 0x7c, 0x1d, 0x4e, 0x6d, 0x02,
-	0x00, 0x00,
+	0x10, 0xf5, 0xd6, 0x69, 0xda, 0x4c, 0xc2, 0x87, 0x56, 0x1c, 0x8c, 0x51, 0x1d, 0xcb, 0x27, 0x1f,
+	0x90, 0x4d, 0xcd, 0x11, 0x84, 0x84, 0xf9, 0x10, 0xa5, 0x8a, 0xa8, 0xcc, 0x8d, 0x8b, 0xb1, 0x9d,
+	0xed, 0x66, 0x95, 0x66, 0x77, 0xe9, 0xae, 0x2b, 0xf9, 0xce, 0x0f, 0xe0, 0x67, 0xf5, 0xc0, 0xa1,
+	0x47, 0x4e, 0x11, 0x72, 0xfe, 0x08, 0xb2, 0x9d, 0x8f, 0x52, 0x09, 0x6e, 0xef, 0xcd, 0xcc, 0x7b,
+	0x9a, 0xb7, 0x3b, 0x60, 0x17, 0x62, 0x41, 0x74, 0x7e, 0xae, 0x43, 0x19, 0xc9, 0xf0, 0xea, 0x38,
+	0x2c, 0x04, 0xe7, 0x81, 0xbc, 0x14, 0x5a, 0xe0, 0x07, 0x9b, 0x5e, 0x20, 0x23, 0x19, 0x5c, 0x1d,
+	0xdb, 0x8f, 0xa8, 0xa0, 0xa2, 0xed, 0x85, 0x0d, 0xea, 0xc6, 0xec, 0xa3, 0xad, 0x45, 0x71, 0x59,
+	0x49, 0x2d, 0x1a, 0x97, 0x39, 0xa9, 0x54, 0xd7, 0xf6, 0x46, 0x00, 0x67, 0x59, 0x31, 0x27, 0xfa,
+	0x8c, 0x71, 0x7a, 0x8b, 0x09, 0x4e, 0xbd, 0xef, 0x08, 0x06, 0x1d, 0x9d, 0x28, 0x8a, 0x9f, 0x02,
+	0x14, 0xb3, 0x8c, 0x73, 0x72, 0x91, 0xb2, 0xa9, 0x85, 0x5c, 0xe4, 0xef, 0xc7, 0xf7, 0xea, 0xe5,
+	0x78, 0xf0, 0xa6, 0xab, 0x9e, 0xbc, 0x4d, 0x06, 0xeb, 0x81, 0x93, 0x29, 0x7e, 0x0c, 0x26, 0x11,
+	0xe7, 0xd6, 0x9e, 0x8b, 0xfc, 0xc3, 0xf8, 0xa0, 0x5e, 0x8e, 0xcd, 0x77, 0x9f, 0xde, 0x27, 0x4d,
+	0x0d, 0x63, 0xe8, 0x4d, 0x33, 0x9d, 0x59, 0xa6, 0x8b, 0xfc, 0x51, 0xd2, 0x62, 0x6c, 0xc3, 0xa1,
+	0x22, 0xdf, 0x4a, 0xc2, 0x0b, 0x62, 0xf5, 0x5c, 0xe4, 0xf7, 0x92, 0x2d, 0xf7, 0x7e, 0x22, 0xe8,
+	0x77, 0x6b, 0xe0, 0x57, 0x30, 0x94, 0x2d, 0x4a, 0x25, 0xe3, 0xb4, 0x5d, 0x62, 0x18, 0x3d, 0x09,
+	0xee, 0xbc, 0x44, 0xb0, 0x4b, 0xf4, 0xc1, 0x48, 0x40, 0x6e, 0xd9, 0x6d, 0xbd, 0xe0, 0xd4, 0xda,
+	0xfb, 0xbf, 0x5e, 0xfc, 0xa5, 0x17, 0x9c, 0xe2, 0x17, 0xb0, 0x66, 0xe9, 0x42, 0xd1, 0x36, 0xc0,
+	0x30, 0xb2, 0xff, 0x21, 0x9f, 0xa8, 0x46, 0x3d, 0x90, 0x1b, 0x12, 0xef, 0x83, 0xa9, 0xca, 0x85,
+	0xf7, 0x15, 0xee, 0xbf, 0x2e, 0xf5, 0xec, 0x33, 0xa3, 0x13, 0xa2, 0x54, 0x46, 0x09, 0x7e, 0x09,
+	0x07, 0xb2, 0xcc, 0xd3, 0x39, 0xa9, 0xd6, 0x89, 0x8e, 0x76, 0x96, 0xdd, 0xa7, 0xb5, 0xae, 0x65,
+	0x7e, 0xc1, 0x8a, 0x53, 0x52, 0xc5, 0xbd, 0xeb, 0xe5, 0xd8, 0x48, 0xfa, 0xb2, 0xcc, 0x4f, 0x49,
+	0x85, 0x1f, 0x82, 0xa9, 0x58, 0x97, 0x65, 0x94, 0x34, 0x30, 0xfe, 0x78, 0x5d, 0x3b, 0xe8, 0xa6,
+	0x76, 0xd0, 0xef, 0xda, 0x41, 0x3f, 0x56, 0x8e, 0x71, 0xb3, 0x72, 0x8c, 0x5f, 0x2b, 0xc7, 0xf8,
+	0xf2, 0x8c, 0x32, 0x3d, 0x2b, 0xf3, 0xc6, 0x3e, 0xdc, 0xdd, 0xc5, 0x06, 0x64, 0x92, 0x85, 0x77,
+	0x0e, 0x2e, 0xef, 0xb7, 0x67, 0xf2, 0xfc, 0xcf, 0x00, 0xf6, 0x06, 0x21, 0xad, 0x8a, 0x02, 0x00,
+	0x00,
 }
 
 func (m *PacketPing) Marshal() (dAtA []byte, err error) {
@@ -422,6 +433,11 @@ func (m *PacketMsg) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Sequence != 0 {
+		i = encodeVarintConn(dAtA, i, uint64(m.Sequence))
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.Data) > 0 {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
@@ -627,6 +643,9 @@ func (m *PacketMsg) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovConn(uint64(l))
 	}
+	if m.Sequence != 0 {
+		n += 1 + sovConn(uint64(m.Sequence))
+	}
 	return n
 }
 
@@ -901,6 +920,25 @@ func (m *PacketMsg) Unmarshal(dAtA []byte) error {
 				m.Data = []byte{}
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequence", wireType)
+			}
+			m.Sequence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowConn
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Sequence |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipConn(dAtA[iNdEx:])
@@ -156,6 +156,12 @@ type DefaultNodeInfo struct {
 	Channels        []byte               `protobuf:"bytes,6,opt,name=channels,proto3" json:"channels,omitempty"`
 	Moniker         string               `protobuf:"bytes,7,opt,name=moniker,proto3" json:"moniker,omitempty"`
 	Other           DefaultNodeInfoOther `protobuf:"bytes,8,opt,name=other,proto3" json:"other"`
+	// Extra addresses the node can be dialed on, in order of preference, tried
+	// after listen_addr. Peers which do not know this field ignore it.
+	AdditionalListenAddrs []string `protobuf:"bytes,9,rep,name=additional_listen_addrs,json=additionalListenAddrs,proto3" json:"additional_listen_addrs,omitempty"`
+	// Optional capabilities the node supports. Peers ignore the features they
+	// do not know.
+	Features []string `protobuf:"bytes,10,rep,name=features,proto3" json:"features,omitempty"`
 }
 
 func (m *DefaultNodeInfo) Reset()         { *m = DefaultNodeInfo{} }
@@ -247,10 +253,28 @@ func (m *DefaultNodeInfo) GetOther() DefaultNodeInfoOther {
 	return DefaultNodeInfoOther{}
 }
 
+func (m *DefaultNodeInfo) GetAdditionalListenAddrs() []string {
+	if m != nil {
+		return m.AdditionalListenAddrs
+	}
+	return nil
+}
+
+func (m *DefaultNodeInfo) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 // DefaultNodeInfoOther is the misc. application specific data.
 type DefaultNodeInfoOther struct {
 	TxIndex    string `protobuf:"bytes,1,opt,name=tx_index,json=txIndex,proto3" json:"tx_index,omitempty"`
 	RPCAddress string `protobuf:"bytes,2,opt,name=rpc_address,json=rpcAddress,proto3" json:"rpc_address,omitempty"`
+	// Fields for which there is no dedicated one, by name. Nodes keep the
+	// fields they do not know, so new ones can be added without a new version
+	// of the message.
+	Extra map[string]string `protobuf:"bytes,3,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *DefaultNodeInfoOther) Reset()         { *m = DefaultNodeInfoOther{} }
@@ -300,48 +324,62 @@ func (m *DefaultNodeInfoOther) GetRPCAddress() string {
 	return ""
 }
 
+func (m *DefaultNodeInfoOther) GetExtra() map[string]string {
+	if m != nil {
+		return m.Extra
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*NetAddress)(nil), "cometbft.p2p.v1.NetAddress")
 	proto.RegisterType((*ProtocolVersion)(nil), "cometbft.p2p.v1.ProtocolVersion")
 	proto.RegisterType((*DefaultNodeInfo)(nil), "cometbft.p2p.v1.DefaultNodeInfo")
 	proto.RegisterType((*DefaultNodeInfoOther)(nil), "cometbft.p2p.v1.DefaultNodeInfoOther")
+	proto.RegisterMapType((map[string]string)(nil), "cometbft.p2p.v1.DefaultNodeInfoOther.ExtraEntry")
 }
 
 func init() { proto.RegisterFile("cometbft/p2p/v1/types.proto", fileDescriptor_b87302e2cbe06eca) }
 
 var fileDescriptor_b87302e2cbe06eca = []byte{
-	// 485 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x4d, 0x8f, 0xda, 0x30,
-	0x10, 0x25, 0x21, 0x7c, 0xec, 0x50, 0xca, 0xd6, 0x42, 0x55, 0x76, 0x2b, 0x25, 0x08, 0xa9, 0x12,
-	0x27, 0xd2, 0xa5, 0xa7, 0x1e, 0x97, 0x72, 0xa1, 0x87, 0x6d, 0x6a, 0x55, 0x3d, 0xf4, 0x82, 0x20,
-	0x36, 0x60, 0xc1, 0xc6, 0x96, 0xe3, 0xa5, 0xf4, 0x5f, 0xf4, 0x67, 0xed, 0x71, 0x8f, 0x3d, 0x45,
-	0x55, 0x38, 0xf7, 0x3f, 0x54, 0x76, 0x02, 0x42, 0xe9, 0xde, 0xe6, 0xcd, 0xf3, 0xcc, 0xbc, 0x79,
-	0x1e, 0x78, 0x13, 0xf1, 0x7b, 0xaa, 0x16, 0x4b, 0x15, 0x88, 0x91, 0x08, 0x76, 0x37, 0x81, 0xfa,
-	0x29, 0x68, 0x32, 0x14, 0x92, 0x2b, 0x8e, 0x3a, 0x47, 0x72, 0x28, 0x46, 0x62, 0xb8, 0xbb, 0xb9,
-	0xee, 0xae, 0xf8, 0x8a, 0x1b, 0x2e, 0xd0, 0x51, 0xfe, 0xac, 0x1f, 0x02, 0xdc, 0x51, 0x75, 0x4b,
-	0x88, 0xa4, 0x49, 0x82, 0x5e, 0x83, 0xcd, 0x88, 0x6b, 0xf5, 0xac, 0xc1, 0xc5, 0xb8, 0x9e, 0xa5,
-	0xbe, 0x3d, 0x9d, 0x60, 0x9b, 0x11, 0x93, 0x17, 0xae, 0x7d, 0x96, 0x0f, 0xb1, 0xcd, 0x04, 0x42,
-	0xe0, 0x08, 0x2e, 0x95, 0x5b, 0xed, 0x59, 0x83, 0x36, 0x36, 0x71, 0xff, 0x2b, 0x74, 0x42, 0xdd,
-	0x3a, 0xe2, 0xdb, 0x6f, 0x54, 0x26, 0x8c, 0xc7, 0xe8, 0x0a, 0xaa, 0x62, 0x24, 0x4c, 0x5f, 0x67,
-	0xdc, 0xc8, 0x52, 0xbf, 0x1a, 0x8e, 0x42, 0xac, 0x73, 0xa8, 0x0b, 0xb5, 0xc5, 0x96, 0x47, 0x1b,
-	0xd3, 0xdc, 0xc1, 0x39, 0x40, 0x97, 0x50, 0x9d, 0x0b, 0x61, 0xda, 0x3a, 0x58, 0x87, 0xfd, 0xbf,
-	0x36, 0x74, 0x26, 0x74, 0x39, 0x7f, 0xd8, 0xaa, 0x3b, 0x4e, 0xe8, 0x34, 0x5e, 0x72, 0xf4, 0x05,
-	0x2e, 0x45, 0x31, 0x69, 0xb6, 0xcb, 0x47, 0x99, 0x19, 0xad, 0x51, 0x6f, 0x58, 0xda, 0x7e, 0x58,
-	0x92, 0x34, 0x76, 0x1e, 0x53, 0xbf, 0x82, 0x3b, 0xa2, 0xa4, 0xf4, 0x03, 0x74, 0x48, 0x3e, 0x65,
-	0x16, 0x73, 0x42, 0x67, 0x8c, 0x14, 0x5b, 0xbf, 0xca, 0x52, 0xbf, 0x7d, 0x2e, 0x60, 0x82, 0xdb,
-	0xe4, 0x0c, 0x12, 0xe4, 0x43, 0x6b, 0xcb, 0x12, 0x45, 0xe3, 0xd9, 0x9c, 0x10, 0x69, 0xb4, 0x5f,
-	0x60, 0xc8, 0x53, 0xda, 0x5f, 0xe4, 0x42, 0x23, 0xa6, 0xea, 0x07, 0x97, 0x1b, 0xd7, 0x31, 0xe4,
-	0x11, 0x6a, 0xe6, 0xa8, 0xbf, 0x96, 0x33, 0x05, 0x44, 0xd7, 0xd0, 0x8c, 0xd6, 0xf3, 0x38, 0xa6,
-	0xdb, 0xc4, 0xad, 0xf7, 0xac, 0xc1, 0x0b, 0x7c, 0xc2, 0xba, 0xea, 0x9e, 0xc7, 0x6c, 0x43, 0xa5,
-	0xdb, 0xc8, 0xab, 0x0a, 0x88, 0x6e, 0xa1, 0xc6, 0xd5, 0x9a, 0x4a, 0xb7, 0x69, 0xdc, 0x78, 0xfb,
-	0x9f, 0x1b, 0x25, 0x27, 0x3f, 0xeb, 0xc7, 0x85, 0x25, 0x79, 0x65, 0x7f, 0x01, 0xdd, 0xe7, 0x1e,
-	0xa1, 0x2b, 0x68, 0xaa, 0xfd, 0x8c, 0xc5, 0x84, 0xee, 0xf3, 0x3b, 0xc1, 0x0d, 0xb5, 0x9f, 0x6a,
-	0x88, 0x02, 0x68, 0x49, 0x11, 0x99, 0xed, 0x69, 0x92, 0x14, 0xbe, 0xbd, 0xcc, 0x52, 0x1f, 0x70,
-	0xf8, 0xb1, 0xb8, 0x30, 0x0c, 0x52, 0x44, 0x45, 0x3c, 0xfe, 0xf4, 0x98, 0x79, 0xd6, 0x53, 0xe6,
-	0x59, 0x7f, 0x32, 0xcf, 0xfa, 0x75, 0xf0, 0x2a, 0x4f, 0x07, 0xaf, 0xf2, 0xfb, 0xe0, 0x55, 0xbe,
-	0xbf, 0x5b, 0x31, 0xb5, 0x7e, 0x58, 0x68, 0xdd, 0xc1, 0xe9, 0xc8, 0x4f, 0xc1, 0x5c, 0xb0, 0xa0,
-	0x74, 0xfa, 0x8b, 0xba, 0xf9, 0xc9, 0xf7, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0x50, 0x06, 0xfb,
-	0xcd, 0x14, 0x03, 0x00, 0x00,
+	// 583 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0x41, 0x6f, 0xd3, 0x4c,
+	0x10, 0x8d, 0xe3, 0xa4, 0x69, 0x26, 0x5f, 0xbf, 0x94, 0x55, 0x01, 0xb7, 0x48, 0x49, 0x14, 0x09,
+	0x29, 0xa7, 0xb8, 0x0d, 0x12, 0x2a, 0xdc, 0x1a, 0x5a, 0xa4, 0x22, 0x54, 0xcc, 0x0a, 0x71, 0xe0,
+	0x62, 0x39, 0xde, 0x4d, 0xbb, 0x8a, 0xeb, 0x5d, 0xad, 0x37, 0x21, 0xfd, 0x17, 0xfc, 0x25, 0x6e,
+	0x3d, 0xf6, 0xc8, 0x29, 0x42, 0x2e, 0x3f, 0x04, 0xed, 0xae, 0x93, 0x46, 0x81, 0x03, 0xb7, 0x79,
+	0xf3, 0x76, 0xe6, 0xbd, 0x19, 0x8f, 0xe1, 0x59, 0xcc, 0xaf, 0xa9, 0x1a, 0x8d, 0x95, 0x2f, 0x06,
+	0xc2, 0x9f, 0x1d, 0xf9, 0xea, 0x46, 0xd0, 0xac, 0x2f, 0x24, 0x57, 0x1c, 0x35, 0x97, 0x64, 0x5f,
+	0x0c, 0x44, 0x7f, 0x76, 0x74, 0xb0, 0x77, 0xc9, 0x2f, 0xb9, 0xe1, 0x7c, 0x1d, 0xd9, 0x67, 0xdd,
+	0x00, 0xe0, 0x82, 0xaa, 0x13, 0x42, 0x24, 0xcd, 0x32, 0xf4, 0x04, 0xca, 0x8c, 0x78, 0x4e, 0xc7,
+	0xe9, 0xd5, 0x87, 0x5b, 0xf9, 0xa2, 0x5d, 0x3e, 0x3f, 0xc5, 0x65, 0x46, 0x4c, 0x5e, 0x78, 0xe5,
+	0xb5, 0x7c, 0x80, 0xcb, 0x4c, 0x20, 0x04, 0x15, 0xc1, 0xa5, 0xf2, 0xdc, 0x8e, 0xd3, 0xdb, 0xc1,
+	0x26, 0xee, 0x7e, 0x82, 0x66, 0xa0, 0x5b, 0xc7, 0x3c, 0xf9, 0x4c, 0x65, 0xc6, 0x78, 0x8a, 0xf6,
+	0xc1, 0x15, 0x03, 0x61, 0xfa, 0x56, 0x86, 0xb5, 0x7c, 0xd1, 0x76, 0x83, 0x41, 0x80, 0x75, 0x0e,
+	0xed, 0x41, 0x75, 0x94, 0xf0, 0x78, 0x62, 0x9a, 0x57, 0xb0, 0x05, 0x68, 0x17, 0xdc, 0x48, 0x08,
+	0xd3, 0xb6, 0x82, 0x75, 0xd8, 0xfd, 0xee, 0x42, 0xf3, 0x94, 0x8e, 0xa3, 0x69, 0xa2, 0x2e, 0x38,
+	0xa1, 0xe7, 0xe9, 0x98, 0xa3, 0x8f, 0xb0, 0x2b, 0x0a, 0xa5, 0x70, 0x66, 0xa5, 0x8c, 0x46, 0x63,
+	0xd0, 0xe9, 0x6f, 0x4c, 0xdf, 0xdf, 0xb0, 0x34, 0xac, 0xdc, 0x2e, 0xda, 0x25, 0xdc, 0x14, 0x1b,
+	0x4e, 0x5f, 0x41, 0x93, 0x58, 0x95, 0x30, 0xe5, 0x84, 0x86, 0x8c, 0x14, 0x53, 0x3f, 0xca, 0x17,
+	0xed, 0x9d, 0x75, 0x03, 0xa7, 0x78, 0x87, 0xac, 0x41, 0x82, 0xda, 0xd0, 0x48, 0x58, 0xa6, 0x68,
+	0x1a, 0x46, 0x84, 0x48, 0xe3, 0xbd, 0x8e, 0xc1, 0xa6, 0xf4, 0x7e, 0x91, 0x07, 0xb5, 0x94, 0xaa,
+	0xaf, 0x5c, 0x4e, 0xbc, 0x8a, 0x21, 0x97, 0x50, 0x33, 0x4b, 0xff, 0x55, 0xcb, 0x14, 0x10, 0x1d,
+	0xc0, 0x76, 0x7c, 0x15, 0xa5, 0x29, 0x4d, 0x32, 0x6f, 0xab, 0xe3, 0xf4, 0xfe, 0xc3, 0x2b, 0xac,
+	0xab, 0xae, 0x79, 0xca, 0x26, 0x54, 0x7a, 0x35, 0x5b, 0x55, 0x40, 0x74, 0x02, 0x55, 0xae, 0xae,
+	0xa8, 0xf4, 0xb6, 0xcd, 0x36, 0x9e, 0xff, 0xb1, 0x8d, 0x8d, 0x4d, 0x7e, 0xd0, 0x8f, 0x8b, 0x95,
+	0xd8, 0x4a, 0xf4, 0x12, 0x9e, 0x46, 0x84, 0x30, 0xc5, 0x78, 0x1a, 0x25, 0xe1, 0xda, 0x60, 0x99,
+	0x57, 0xef, 0xb8, 0xbd, 0x3a, 0x7e, 0xfc, 0x40, 0xbf, 0x5f, 0xcd, 0x98, 0x69, 0xc3, 0x63, 0x1a,
+	0xa9, 0xa9, 0xa4, 0x99, 0x07, 0xe6, 0xe1, 0x0a, 0x77, 0x7f, 0x39, 0xb0, 0xf7, 0x37, 0x65, 0xb4,
+	0x0f, 0xdb, 0x6a, 0x1e, 0xb2, 0x94, 0xd0, 0xb9, 0x3d, 0x3e, 0x5c, 0x53, 0xf3, 0x73, 0x0d, 0x91,
+	0x0f, 0x0d, 0x29, 0x62, 0xa3, 0x4c, 0xb3, 0xac, 0xf8, 0x18, 0xff, 0xe7, 0x8b, 0x36, 0xe0, 0xe0,
+	0x4d, 0x71, 0xb6, 0x18, 0xa4, 0x88, 0x8b, 0x18, 0xbd, 0x85, 0x2a, 0x9d, 0x2b, 0x19, 0x79, 0x6e,
+	0xc7, 0xed, 0x35, 0x06, 0x87, 0xff, 0x34, 0x7b, 0xff, 0x4c, 0x97, 0x9c, 0xa5, 0x4a, 0xde, 0x60,
+	0x5b, 0x7e, 0x70, 0x0c, 0xf0, 0x90, 0xd4, 0x07, 0x39, 0xa1, 0x37, 0x85, 0x39, 0x1d, 0xea, 0xc3,
+	0x9d, 0x45, 0xc9, 0x94, 0x5a, 0x4b, 0xd8, 0x82, 0xd7, 0xe5, 0x63, 0x67, 0xf8, 0xee, 0x36, 0x6f,
+	0x39, 0x77, 0x79, 0xcb, 0xf9, 0x99, 0xb7, 0x9c, 0x6f, 0xf7, 0xad, 0xd2, 0xdd, 0x7d, 0xab, 0xf4,
+	0xe3, 0xbe, 0x55, 0xfa, 0x72, 0x78, 0xc9, 0xd4, 0xd5, 0x74, 0xa4, 0x2d, 0xf9, 0xab, 0x7f, 0x77,
+	0x15, 0x44, 0x82, 0xf9, 0x1b, 0x7f, 0xf4, 0x68, 0xcb, 0x1c, 0xe8, 0x8b, 0xdf, 0x03, 0x00, 0x93,
+	0xb1, 0x23, 0x39, 0xeb, 0x03, 0x00, 0x00,
 }
 
 func (m *NetAddress) Marshal() (dAtA []byte, err error) {
@@ -444,6 +482,24 @@ func (m *DefaultNodeInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Features) > 0 {
+		for iNdEx := len(m.Features) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Features[iNdEx])
+			copy(dAtA[i:], m.Features[iNdEx])
+			i = encodeVarintTypes(dAtA, i, uint64(len(m.Features[iNdEx])))
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	if len(m.AdditionalListenAddrs) > 0 {
+		for iNdEx := len(m.AdditionalListenAddrs) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.AdditionalListenAddrs[iNdEx])
+			copy(dAtA[i:], m.AdditionalListenAddrs[iNdEx])
+			i = encodeVarintTypes(dAtA, i, uint64(len(m.AdditionalListenAddrs[iNdEx])))
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
 	{
 		size, err := m.Other.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -529,6 +585,25 @@ func (m *DefaultNodeInfoOther) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Extra) > 0 {
+		for k := range m.Extra {
+			v := m.Extra[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintTypes(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintTypes(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintTypes(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
 	if len(m.RPCAddress) > 0 {
 		i -= len(m.RPCAddress)
 		copy(dAtA[i:], m.RPCAddress)
@@ -629,6 +704,18 @@ func (m *DefaultNodeInfo) Size() (n int) {
 	}
 	l = m.Other.Size()
 	n += 1 + l + sovTypes(uint64(l))
+	if len(m.AdditionalListenAddrs) > 0 {
+		for _, s := range m.AdditionalListenAddrs {
+			l = len(s)
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
+	if len(m.Features) > 0 {
+		for _, s := range m.Features {
+			l = len(s)
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -646,6 +733,14 @@ func (m *DefaultNodeInfoOther) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if len(m.Extra) > 0 {
+		for k, v := range m.Extra {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovTypes(uint64(len(k))) + 1 + len(v) + sovTypes(uint64(len(v)))
+			n += mapEntrySize + 1 + sovTypes(uint64(mapEntrySize))
+		}
+	}
 	return n
 }
 
@@ -1184,6 +1279,70 @@ func (m *DefaultNodeInfo) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AdditionalListenAddrs", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AdditionalListenAddrs = append(m.AdditionalListenAddrs, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Features = append(m.Features, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -1298,6 +1457,133 @@ func (m *DefaultNodeInfoOther) Unmarshal(dAtA []byte) error {
 			}
 			m.RPCAddress = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Extra", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Extra == nil {
+				m.Extra = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowTypes
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTypes
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthTypes
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthTypes
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowTypes
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthTypes
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthTypes
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipTypes(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthTypes
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Extra[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -762,6 +762,11 @@ type P2PConfig struct { //nolint: maligned
 	// Address to advertise to peers for them to dial
 	ExternalAddress string `mapstructure:"external_address"`
 
+	// Comma separated list of extra addresses to advertise to peers, dialed in
+	// order if ExternalAddress can't be reached when a peer redials us as an
+	// inbound persistent peer
+	AdditionalExternalAddresses string `mapstructure:"additional_external_addresses"`
+
 	// Comma separated list of seed nodes to connect to
 	// We only use these if we can’t connect to peers in the addrbook
 	Seeds string `mapstructure:"seeds"`
@@ -785,6 +790,14 @@ type P2PConfig struct { //nolint: maligned
 	// List of node IDs, to which a connection will be (re)established ignoring any existing limits
 	UnconditionalPeerIDs string `mapstructure:"unconditional_peer_ids"`
 
+	// Comma separated list of node IDs allowed to connect to this node. If
+	// empty, any peer may connect. Unconditional peers are always allowed.
+	AllowedPeerIDs string `mapstructure:"allowed_peer_ids"`
+
+	// Comma separated list of node IDs rejected when connecting to this node.
+	// Takes precedence over AllowedPeerIDs.
+	DeniedPeerIDs string `mapstructure:"denied_peer_ids"`
+
 	// Maximum pause when redialing a persistent peer (if zero, exponential backoff is used)
 	PersistentPeersMaxDialPeriod time.Duration `mapstructure:"persistent_peers_max_dial_period"`
 
@@ -800,6 +813,16 @@ type P2PConfig struct { //nolint: maligned
 	// Rate at which packets can be received, in bytes/second
 	RecvRate int64 `mapstructure:"recv_rate"`
 
+	// Maximum number of bytes of messages queued for sending to a peer,
+	// summed across channels. Sends to a peer over the limit fail until its
+	// queues drain. 0 means no limit.
+	MaxPeerQueuedBytes int64 `mapstructure:"max_peer_queued_bytes"`
+
+	// Maximum number of bytes of received messages being processed at once,
+	// across all peers. A peer's connection stops reading while the node is
+	// over the limit. 0 means no limit.
+	MaxRecvBufferedBytes int64 `mapstructure:"max_recv_buffered_bytes"`
+
 	// Set true to enable the peer-exchange reactor
 	PexReactor bool `mapstructure:"pex"`
 
@@ -820,6 +843,19 @@ type P2PConfig struct { //nolint: maligned
 	HandshakeTimeout time.Duration `mapstructure:"handshake_timeout"`
 	DialTimeout      time.Duration `mapstructure:"dial_timeout"`
 
+	// Maximum size in bytes of the NodeInfo a peer may send in the handshake.
+	// Peers sending a larger one are rejected before it is read. 0 uses the
+	// default of 10240.
+	MaxNodeInfoSize int `mapstructure:"max_node_info_size"`
+
+	// Disconnect from peers that send no messages for longer than this.
+	// Persistent and unconditional peers are never disconnected. 0 disables
+	// the timeout.
+	PeerInactivityTimeout time.Duration `mapstructure:"peer_inactivity_timeout"`
+	// Whether pings and pongs count as activity for PeerInactivityTimeout.
+	// If false, only messages sent on a channel do.
+	PeerInactivityCountPings bool `mapstructure:"peer_inactivity_count_pings"`
+
 	// Testing params.
 	// Force dial to fail
 	TestDialFail bool `mapstructure:"test_dial_fail"`
@@ -847,6 +883,7 @@ func DefaultP2PConfig() *P2PConfig {
 		AllowDuplicateIP:             false,
 		HandshakeTimeout:             20 * time.Second,
 		DialTimeout:                  3 * time.Second,
+		MaxNodeInfoSize:              10240, // 10 kB
 		TestDialFail:                 false,
 		TestFuzz:                     false,
 		TestFuzzConfig:               DefaultFuzzConnConfig(),
@@ -890,9 +927,47 @@ func (cfg *P2PConfig) ValidateBasic() error {
 	if cfg.RecvRate < 0 {
 		return cmterrors.ErrNegativeField{Field: "recv_rate"}
 	}
+	if cfg.MaxPeerQueuedBytes < 0 {
+		return cmterrors.ErrNegativeField{Field: "max_peer_queued_bytes"}
+	}
+	if cfg.MaxRecvBufferedBytes < 0 {
+		return cmterrors.ErrNegativeField{Field: "max_recv_buffered_bytes"}
+	}
+	if cfg.MaxNodeInfoSize < 0 {
+		return cmterrors.ErrNegativeField{Field: "max_node_info_size"}
+	}
+	if cfg.PeerInactivityTimeout < 0 {
+		return cmterrors.ErrNegativeField{Field: "peer_inactivity_timeout"}
+	}
+	if cfg.DeniedPeerIDs != "" {
+		allowed := make(map[string]struct{})
+		for _, id := range splitIDList(cfg.AllowedPeerIDs) {
+			allowed[id] = struct{}{}
+		}
+		for _, id := range splitIDList(cfg.UnconditionalPeerIDs) {
+			allowed[id] = struct{}{}
+		}
+		for _, id := range splitIDList(cfg.DeniedPeerIDs) {
+			if _, ok := allowed[id]; ok {
+				return fmt.Errorf("denied_peer_ids: %s is also listed in allowed_peer_ids or unconditional_peer_ids", id)
+			}
+		}
+	}
 	return nil
 }
 
+// splitIDList splits a comma separated list of node IDs, dropping empty
+// entries.
+func splitIDList(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // FuzzConnConfig is a FuzzedConnection configuration.
 type FuzzConnConfig struct {
 	Mode         int
@@ -953,6 +1028,11 @@ type MempoolConfig struct {
 	// block. In other words, if Broadcast is disabled, only the peer you send
 	// the tx to will see it until it is included in a block.
 	Broadcast bool `mapstructure:"broadcast"`
+	// FlushNotifyPeers (default: false) makes flushing the mempool, e.g. via
+	// the unsafe_flush_mempool RPC endpoint, tell peers which transactions
+	// were removed so that they don't gossip them back. Only enable it if all
+	// peers understand the message; older ones disconnect on receiving it.
+	FlushNotifyPeers bool `mapstructure:"flush_notify_peers"`
 	// Maximum number of transactions in the mempool
 	Size int `mapstructure:"size"`
 	// Maximum size in bytes of a single transaction accepted into the mempool.
@@ -962,6 +1042,24 @@ type MempoolConfig struct {
 	// transactions and a 5MB maximum mempool byte size, the mempool will
 	// only accept five transactions.
 	MaxTxsBytes int64 `mapstructure:"max_txs_bytes"`
+	// Soft limit in bytes on the size of all transactions stored in the
+	// mempool. When it is crossed, the oldest transactions of the
+	// lowest-priority lanes are evicted until the total size is at most
+	// SoftTargetTxsBytes. MaxTxsBytes remains the hard limit. 0 disables it.
+	SoftMaxTxsBytes int64 `mapstructure:"soft_max_txs_bytes"`
+	// Total size in bytes the mempool is brought down to once SoftMaxTxsBytes
+	// is crossed. Must be lower than SoftMaxTxsBytes.
+	SoftTargetTxsBytes int64 `mapstructure:"soft_target_txs_bytes"`
+	// Fraction of Size and MaxTxsBytes that only txs submitted to this node
+	// (e.g. via RPC) can use, so that txs received from peers can't fill the
+	// whole mempool. Must be in [0, 1). 0 disables it.
+	LocalTxsReservedFraction float64 `mapstructure:"local_txs_reserved_fraction"`
+	// Maximum total size in bytes of the txs that peers are yet to be sent,
+	// summed over all peers. Once it is crossed, peers with more than their
+	// share of it skip their oldest pending txs received from other peers;
+	// txs submitted to this node are always sent. The txs stay in the
+	// mempool. 0 disables it.
+	MaxGossipBacklogBytes int64 `mapstructure:"max_gossip_backlog_bytes"`
 	// Size of the cache (used to filter transactions we saw earlier) in transactions.
 	CacheSize int `mapstructure:"cache_size"`
 	// Do not remove invalid transactions from the cache (default: false)
@@ -1029,6 +1127,26 @@ func (cfg *MempoolConfig) ValidateBasic() error {
 	if cfg.MaxTxsBytes < 0 {
 		return cmterrors.ErrNegativeField{Field: "max_txs_bytes"}
 	}
+	if cfg.SoftMaxTxsBytes < 0 {
+		return cmterrors.ErrNegativeField{Field: "soft_max_txs_bytes"}
+	}
+	if cfg.SoftTargetTxsBytes < 0 {
+		return cmterrors.ErrNegativeField{Field: "soft_target_txs_bytes"}
+	}
+	if cfg.SoftMaxTxsBytes > 0 {
+		if cfg.SoftMaxTxsBytes > cfg.MaxTxsBytes {
+			return errors.New("soft_max_txs_bytes can't be greater than max_txs_bytes")
+		}
+		if cfg.SoftTargetTxsBytes >= cfg.SoftMaxTxsBytes {
+			return errors.New("soft_target_txs_bytes must be lower than soft_max_txs_bytes")
+		}
+	}
+	if cfg.LocalTxsReservedFraction < 0 || cfg.LocalTxsReservedFraction >= 1 {
+		return errors.New("local_txs_reserved_fraction must be in [0, 1)")
+	}
+	if cfg.MaxGossipBacklogBytes < 0 {
+		return cmterrors.ErrNegativeField{Field: "max_gossip_backlog_bytes"}
+	}
 	if cfg.CacheSize < 0 {
 		return cmterrors.ErrNegativeField{Field: "cache_size"}
 	}
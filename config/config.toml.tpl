@@ -252,6 +252,11 @@ laddr = "{{ .P2P.ListenAddress }}"
 # address. IP and port are required. Example: 159.89.10.97:26656
 external_address = "{{ .P2P.ExternalAddress }}"
 
+# Comma separated list of extra addresses to advertise to peers, tried in order
+# if the address above can't be dialed when a peer that has us as a persistent
+# peer redials us. They are not shared through PEX. Example: 10.8.0.5:26656
+additional_external_addresses = "{{ .P2P.AdditionalExternalAddresses }}"
+
 # Comma separated list of seed nodes to connect to
 seeds = "{{ .P2P.Seeds }}"
 
@@ -274,6 +279,14 @@ max_num_outbound_peers = {{ .P2P.MaxNumOutboundPeers }}
 # List of node IDs, to which a connection will be (re)established ignoring any existing limits
 unconditional_peer_ids = "{{ .P2P.UnconditionalPeerIDs }}"
 
+# Comma separated list of node IDs allowed to connect to this node.
+# If empty, any peer may connect. Unconditional peers are always allowed.
+allowed_peer_ids = "{{ .P2P.AllowedPeerIDs }}"
+
+# Comma separated list of node IDs rejected when connecting to this node.
+# Takes precedence over allowed_peer_ids.
+denied_peer_ids = "{{ .P2P.DeniedPeerIDs }}"
+
 # Maximum pause when redialing a persistent peer (if zero, exponential backoff is used)
 persistent_peers_max_dial_period = "{{ .P2P.PersistentPeersMaxDialPeriod }}"
 
@@ -289,6 +302,16 @@ send_rate = {{ .P2P.SendRate }}
 # Rate at which packets can be received, in bytes/second
 recv_rate = {{ .P2P.RecvRate }}
 
+# Maximum number of bytes of messages queued for sending to a peer, summed
+# across channels. Sends to a peer over the limit fail until its queues drain.
+# 0 means no limit.
+max_peer_queued_bytes = {{ .P2P.MaxPeerQueuedBytes }}
+
+# Maximum number of bytes of received messages being processed at once, across
+# all peers. A peer's connection stops reading while the node is over the
+# limit. 0 means no limit.
+max_recv_buffered_bytes = {{ .P2P.MaxRecvBufferedBytes }}
+
 # Set true to enable the peer-exchange reactor
 pex = {{ .P2P.PexReactor }}
 
@@ -308,6 +331,19 @@ allow_duplicate_ip = {{ .P2P.AllowDuplicateIP }}
 handshake_timeout = "{{ .P2P.HandshakeTimeout }}"
 dial_timeout = "{{ .P2P.DialTimeout }}"
 
+# Maximum size in bytes of the NodeInfo a peer may send in the handshake. Peers
+# sending a larger one are rejected before it is read. 0 uses the default of
+# 10240.
+max_node_info_size = {{ .P2P.MaxNodeInfoSize }}
+
+# Disconnect from peers that send no messages for longer than this. Persistent
+# and unconditional peers are never disconnected. "0s" disables the timeout.
+peer_inactivity_timeout = "{{ .P2P.PeerInactivityTimeout }}"
+
+# Whether pings and pongs count as activity for peer_inactivity_timeout. If
+# false, only messages sent on a channel do.
+peer_inactivity_count_pings = {{ .P2P.PeerInactivityCountPings }}
+
 #######################################################
 ###          Mempool Configuration Options          ###
 #######################################################
@@ -343,6 +379,12 @@ recheck_timeout = "{{ .Mempool.RecheckTimeout }}"
 # the tx to will see it until it is included in a block.
 broadcast = {{ .Mempool.Broadcast }}
 
+# flush_notify_peers (default: false) makes flushing the mempool, e.g. via the
+# unsafe_flush_mempool RPC endpoint, tell peers which transactions were removed
+# so that they don't gossip them back. Only enable it if all peers understand
+# the message; older ones disconnect on receiving it.
+flush_notify_peers = {{ .Mempool.FlushNotifyPeers }}
+
 # Maximum number of transactions in the mempool
 size = {{ .Mempool.Size }}
 
@@ -355,6 +397,31 @@ max_tx_bytes = {{ .Mempool.MaxTxBytes }}
 # only accept five transactions.
 max_txs_bytes = {{ .Mempool.MaxTxsBytes }}
 
+# Soft limit in bytes on the size of all transactions stored in the mempool.
+# When it is crossed, the oldest transactions of the lowest-priority lanes are
+# evicted until the total size is at most soft_target_txs_bytes, instead of
+# rejecting new transactions once max_txs_bytes is reached.
+# Set to 0 to disable (default).
+soft_max_txs_bytes = {{ .Mempool.SoftMaxTxsBytes }}
+
+# Total size in bytes the mempool is brought down to once soft_max_txs_bytes
+# is crossed. Must be lower than soft_max_txs_bytes.
+soft_target_txs_bytes = {{ .Mempool.SoftTargetTxsBytes }}
+
+# Fraction of size and max_txs_bytes reserved for transactions submitted to
+# this node (e.g. via RPC). Transactions received from peers are rejected once
+# they fill the rest of the mempool, so they can't crowd out local ones.
+# Must be in [0, 1). Set to 0 to disable (default).
+local_txs_reserved_fraction = {{ .Mempool.LocalTxsReservedFraction }}
+
+# Maximum total size in bytes of the transactions that peers are yet to be
+# sent, summed over all peers. Once it is crossed, peers with more than their
+# share of it, typically slow ones, skip their oldest pending transactions
+# received from other peers. Transactions submitted to this node are always
+# sent. Skipped transactions stay in the mempool.
+# Set to 0 to disable (default).
+max_gossip_backlog_bytes = {{ .Mempool.MaxGossipBacklogBytes }}
+
 # Size of the cache (used to filter transactions we saw earlier) in transactions
 cache_size = {{ .Mempool.CacheSize }}
 
@@ -129,6 +129,10 @@ func TestP2PConfigValidateBasic(t *testing.T) {
 		"MaxPacketMsgPayloadSize",
 		"SendRate",
 		"RecvRate",
+		"MaxPeerQueuedBytes",
+		"MaxRecvBufferedBytes",
+		"MaxNodeInfoSize",
+		"PeerInactivityTimeout",
 	}
 
 	for _, fieldName := range fieldsToTest {
@@ -136,6 +140,15 @@ func TestP2PConfigValidateBasic(t *testing.T) {
 		require.Error(t, cfg.ValidateBasic())
 		reflect.ValueOf(cfg).Elem().FieldByName(fieldName).SetInt(0)
 	}
+
+	cfg.AllowedPeerIDs = "a, b"
+	cfg.UnconditionalPeerIDs = "c"
+	cfg.DeniedPeerIDs = "d"
+	require.NoError(t, cfg.ValidateBasic())
+	cfg.DeniedPeerIDs = "d,b"
+	require.Error(t, cfg.ValidateBasic())
+	cfg.DeniedPeerIDs = "c"
+	require.Error(t, cfg.ValidateBasic())
 }
 
 func TestMempoolConfigValidateBasic(t *testing.T) {
@@ -160,6 +173,7 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 		{"Size", []int64{1}, []int64{-1, 0}},
 		{"MaxTxsBytes", []int64{1}, []int64{-1, 0}},
 		{"CacheSize", []int64{0, 1}, []int64{-1}},
+		{"MaxGossipBacklogBytes", []int64{0, 1}, []int64{-1}},
 		{"MaxTxBytes", []int64{1}, []int64{-1, 0}},
 		{"ExperimentalMaxGossipConnectionsToPersistentPeers", []int64{0, 1}, []int64{-1}},
 		{"ExperimentalMaxGossipConnectionsToNonPersistentPeers", []int64{0, 1}, []int64{-1}},
@@ -178,6 +192,31 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 		}
 	}
 
+	// the soft limit must lie between its target and the hard limit
+	cfg.SoftMaxTxsBytes = -1
+	require.Error(t, cfg.ValidateBasic())
+	cfg.SoftMaxTxsBytes, cfg.SoftTargetTxsBytes = 0, -1
+	require.Error(t, cfg.ValidateBasic())
+	cfg.MaxTxsBytes = 100
+	cfg.SoftMaxTxsBytes = 80
+	cfg.SoftTargetTxsBytes = 50
+	require.NoError(t, cfg.ValidateBasic())
+	cfg.SoftMaxTxsBytes = 101
+	require.Error(t, cfg.ValidateBasic())
+	cfg.SoftMaxTxsBytes = 50
+	require.Error(t, cfg.ValidateBasic())
+	cfg.SoftMaxTxsBytes, cfg.SoftTargetTxsBytes = 0, 0
+	cfg.MaxTxsBytes = 1
+
+	// the reserved fraction must leave room for txs from peers
+	for _, fraction := range []float64{-0.1, 1} {
+		cfg.LocalTxsReservedFraction = fraction
+		require.Error(t, cfg.ValidateBasic())
+	}
+	cfg.LocalTxsReservedFraction = 0.2
+	require.NoError(t, cfg.ValidateBasic())
+	cfg.LocalTxsReservedFraction = 0
+
 	// with noop mempool, zero values are allowed for the fields below
 	reflect.ValueOf(cfg).Elem().FieldByName("Type").SetString(config.MempoolTypeNop)
 	fieldNames := []string{
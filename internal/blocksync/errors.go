@@ -30,6 +30,16 @@ func (e ErrInvalidBase) Error() string {
 	return fmt.Sprintf("invalid base %v: %s", e.Base, e.Reason)
 }
 
+// ErrInvalidCompressedBlock is returned when a peer sends a compressed block
+// response that cannot be decompressed.
+type ErrInvalidCompressedBlock struct {
+	Reason string
+}
+
+func (e ErrInvalidCompressedBlock) Error() string {
+	return "invalid compressed block response: " + e.Reason
+}
+
 type ErrUnknownMessageType struct {
 	Msg proto.Message
 }
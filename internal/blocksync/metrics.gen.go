@@ -44,15 +44,24 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "latest_block_height",
 			Help:      "The height of the latest block.",
 		}, labels).With(labelsAndValues...),
+		BlockCompressionRatio: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "block_compression_ratio",
+			Help:      "Ratio of the compressed to the uncompressed size of the blocks received compressed.",
+
+			Buckets: stdprometheus.LinearBuckets(0.1, 0.1, 10),
+		}, labels).With(labelsAndValues...),
 	}
 }
 
 func NopMetrics() *Metrics {
 	return &Metrics{
-		Syncing:           discard.NewGauge(),
-		NumTxs:            discard.NewGauge(),
-		TotalTxs:          discard.NewGauge(),
-		BlockSizeBytes:    discard.NewGauge(),
-		LatestBlockHeight: discard.NewGauge(),
+		Syncing:               discard.NewGauge(),
+		NumTxs:                discard.NewGauge(),
+		TotalTxs:              discard.NewGauge(),
+		BlockSizeBytes:        discard.NewGauge(),
+		LatestBlockHeight:     discard.NewGauge(),
+		BlockCompressionRatio: discard.NewHistogram(),
 	}
 }
@@ -25,6 +25,9 @@ type Metrics struct {
 	BlockSizeBytes metrics.Gauge
 	// The height of the latest block.
 	LatestBlockHeight metrics.Gauge
+	// Ratio of the compressed to the uncompressed size of the blocks received
+	// compressed.
+	BlockCompressionRatio metrics.Histogram `metrics_bucketsizes:"0.1, 0.1, 10" metrics_buckettype:"lin"`
 }
 
 func (m *Metrics) recordBlockMetrics(block *types.Block) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/cosmos/gogoproto/proto"
+	"github.com/golang/snappy"
 
 	bcproto "github.com/cometbft/cometbft/api/cometbft/blocksync/v1"
 	"github.com/cometbft/cometbft/types"
@@ -33,6 +34,14 @@ func ValidateMsg(pb proto.Message) error {
 		// Avoid double-calling `types.BlockFromProto` for performance reasons.
 		// See https://github.com/cometbft/cometbft/issues/1964
 		return nil
+	case *bcproto.CompressedBlockResponse:
+		n, err := snappy.DecodedLen(msg.BlockResponse)
+		if err != nil {
+			return ErrInvalidCompressedBlock{Reason: err.Error()}
+		}
+		if n > MaxMsgSize {
+			return ErrInvalidCompressedBlock{Reason: fmt.Sprintf("decompressed size %d exceeds max %d", n, MaxMsgSize)}
+		}
 	case *bcproto.NoBlockResponse:
 		if msg.Height < 0 {
 			return ErrInvalidHeight{Height: msg.Height, Reason: "negative height"}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/cosmos/gogoproto/proto"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -52,6 +53,26 @@ func TestBcNoBlockResponseMessageValidateBasic(t *testing.T) {
 	}
 }
 
+func TestBcCompressedBlockResponseMessageValidateBasic(t *testing.T) {
+	tooBig := snappy.Encode(nil, make([]byte, blocksync.MaxMsgSize+1))
+	testCases := []struct {
+		testName  string
+		data      []byte
+		expectErr bool
+	}{
+		{"Valid Compressed Response", snappy.Encode(nil, []byte("block")), false},
+		{"Invalid Compressed Response", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, true},
+		{"Too Big Compressed Response", tooBig, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			resp := bcproto.CompressedBlockResponse{BlockResponse: tc.data}
+			assert.Equal(t, tc.expectErr, blocksync.ValidateMsg(&resp) != nil, "Validate Basic had an unexpected result")
+		})
+	}
+}
+
 func TestBcStatusRequestMessageValidateBasic(t *testing.T) {
 	request := bcproto.StatusRequest{}
 	require.NoError(t, blocksync.ValidateMsg(&request))
@@ -6,6 +6,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/golang/snappy"
+
 	bcproto "github.com/cometbft/cometbft/api/cometbft/blocksync/v1"
 	"github.com/cometbft/cometbft/crypto"
 	"github.com/cometbft/cometbft/libs/log"
@@ -236,15 +239,59 @@ func (bcR *Reactor) respondToPeer(msg *bcproto.BlockRequest, src p2p.Peer) (queu
 		return false
 	}
 
+	resp := &bcproto.BlockResponse{
+		Block:     bl,
+		ExtCommit: extCommit.ToProto(),
+	}
+	if msg.AcceptCompressed {
+		if compressed := compressBlockResponse(resp); compressed != nil {
+			return src.TrySend(p2p.Envelope{
+				ChannelID: BlocksyncChannel,
+				Message:   compressed,
+			})
+		}
+	}
 	return src.TrySend(p2p.Envelope{
 		ChannelID: BlocksyncChannel,
-		Message: &bcproto.BlockResponse{
-			Block:     bl,
-			ExtCommit: extCommit.ToProto(),
-		},
+		Message:   resp,
 	})
 }
 
+// compressBlockResponse returns resp compressed, or nil if compressing it does
+// not make it smaller.
+func compressBlockResponse(resp *bcproto.BlockResponse) *bcproto.CompressedBlockResponse {
+	bz, err := proto.Marshal(resp)
+	if err != nil {
+		return nil
+	}
+	compressed := snappy.Encode(nil, bz)
+	if len(compressed) >= len(bz) {
+		return nil
+	}
+	return &bcproto.CompressedBlockResponse{BlockResponse: compressed}
+}
+
+// handleCompressedPeerResponse decompresses msg and handles the block response
+// it contains.
+func (bcR *Reactor) handleCompressedPeerResponse(msg *bcproto.CompressedBlockResponse, src p2p.Peer) {
+	bz, err := snappy.Decode(nil, msg.BlockResponse)
+	if err != nil {
+		err = ErrInvalidCompressedBlock{Reason: err.Error()}
+		bcR.Logger.Error("Peer sent us invalid compressed block", "peer", src, "err", err)
+		bcR.Switch.StopPeerForError(src, err)
+		return
+	}
+	resp := &bcproto.BlockResponse{}
+	if err := proto.Unmarshal(bz, resp); err != nil {
+		err = ErrInvalidCompressedBlock{Reason: err.Error()}
+		bcR.Logger.Error("Peer sent us invalid compressed block", "peer", src, "err", err)
+		bcR.Switch.StopPeerForError(src, err)
+		return
+	}
+	bcR.metrics.BlockCompressionRatio.Observe(float64(len(msg.BlockResponse)) / float64(len(bz)))
+	bcR.handlePeerResponse(resp, src)
+}
+
 func (bcR *Reactor) handlePeerResponse(msg *bcproto.BlockResponse, src p2p.Peer) {
 	bi, err := types.BlockFromProto(msg.Block)
 	if err != nil {
@@ -285,6 +332,8 @@ func (bcR *Reactor) Receive(e p2p.Envelope) {
 		bcR.respondToPeer(msg, e.Src)
 	case *bcproto.BlockResponse:
 		go bcR.handlePeerResponse(msg, e.Src)
+	case *bcproto.CompressedBlockResponse:
+		go bcR.handleCompressedPeerResponse(msg, e.Src)
 	case *bcproto.StatusRequest:
 		// Send peer our state.
 		e.Src.TrySend(p2p.Envelope{
@@ -439,7 +488,7 @@ func (bcR *Reactor) handleBlockRequest(request BlockRequest) {
 	}
 	queued := peer.TrySend(p2p.Envelope{
 		ChannelID: BlocksyncChannel,
-		Message:   &bcproto.BlockRequest{Height: request.Height},
+		Message:   &bcproto.BlockRequest{Height: request.Height, AcceptCompressed: true},
 	})
 	if !queued {
 		bcR.Logger.Debug("Send queue is full, drop block request", "peer", peer.ID(), "height", request.Height)
@@ -1,6 +1,7 @@
 package blocksync
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"reflect"
@@ -8,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -15,6 +18,7 @@ import (
 	dbm "github.com/cometbft/cometbft-db"
 	abci "github.com/cometbft/cometbft/abci/types"
 	bcproto "github.com/cometbft/cometbft/api/cometbft/blocksync/v1"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
 	cfg "github.com/cometbft/cometbft/config"
 	"github.com/cometbft/cometbft/internal/test"
 	"github.com/cometbft/cometbft/libs/log"
@@ -242,6 +246,25 @@ func TestNoBlockResponse(t *testing.T) {
 	}
 }
 
+func TestCompressBlockResponse(t *testing.T) {
+	block := &cmtproto.Block{Data: cmtproto.Data{Txs: [][]byte{bytes.Repeat([]byte("tx"), 1024)}}}
+	resp := &bcproto.BlockResponse{Block: block}
+
+	compressed := compressBlockResponse(resp)
+	require.NotNil(t, compressed)
+	require.NoError(t, ValidateMsg(compressed))
+	assert.Less(t, len(compressed.BlockResponse), resp.Size())
+
+	bz, err := snappy.Decode(nil, compressed.BlockResponse)
+	require.NoError(t, err)
+	decompressed := &bcproto.BlockResponse{}
+	require.NoError(t, proto.Unmarshal(bz, decompressed))
+	assert.Equal(t, resp, decompressed)
+
+	// responses that don't shrink are sent uncompressed
+	assert.Nil(t, compressBlockResponse(&bcproto.BlockResponse{Block: &cmtproto.Block{}}))
+}
+
 // NOTE: This is too hard to test without
 // an easy way to add test peer to switch
 // or without significant refactoring of the module.
@@ -8,6 +8,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/cosmos/gogoproto/proto"
+
 	cmtcons "github.com/cometbft/cometbft/api/cometbft/consensus/v1"
 	"github.com/cometbft/cometbft/internal/bits"
 	cstypes "github.com/cometbft/cometbft/internal/consensus/types"
@@ -50,6 +52,9 @@ type Reactor struct {
 	rs            *cstypes.RoundState
 	initialHeight int64 // under rsMtx
 
+	// votes and block parts gossiped to all peers, marshaled once
+	gossipMsgs *gossipMsgCache
+
 	Metrics *Metrics
 }
 
@@ -62,6 +67,7 @@ func NewReactor(consensusState *State, waitSync bool, options ...ReactorOption)
 		waitSync:      atomic.Bool{},
 		rs:            consensusState.GetRoundState(),
 		initialHeight: consensusState.state.InitialHeight,
+		gossipMsgs:    newGossipMsgCache(),
 		Metrics:       NopMetrics(),
 	}
 	conR.BaseReactor = *p2p.NewBaseReactor("Consensus", conR)
@@ -193,6 +199,7 @@ func (*Reactor) GetChannels() []*p2p.ChannelDescriptor {
 // InitPeer implements Reactor by creating a state for the peer.
 func (conR *Reactor) InitPeer(peer p2p.Peer) p2p.Peer {
 	peerState := NewPeerState(peer).SetLogger(conR.Logger)
+	peerState.gossipMsgs = conR.gossipMsgs
 	peer.Set(types.PeerStateKey, peerState)
 	return peer
 }
@@ -567,6 +574,7 @@ func (conR *Reactor) updateRoundStateRoutine() {
 		conR.rsMtx.Lock()
 		conR.rs = rs
 		conR.rsMtx.Unlock()
+		conR.gossipMsgs.setHeight(rs.Height)
 	}
 }
 
@@ -576,6 +584,7 @@ func (conR *Reactor) updateRoundStateNoCsLock() {
 	conR.rs = rs
 	conR.initialHeight = conR.conS.state.InitialHeight
 	conR.rsMtx.Unlock()
+	conR.gossipMsgs.setHeight(rs.Height)
 }
 
 func (conR *Reactor) getRoundState() *cstypes.RoundState {
@@ -1086,6 +1095,10 @@ type PeerState struct {
 	peer   p2p.Peer
 	logger log.Logger
 
+	// shared with the other peers of the reactor, nil to marshal every
+	// message sent
+	gossipMsgs *gossipMsgCache
+
 	mtx   sync.Mutex             // NOTE: Modify below using setters, never directly.
 	PRS   cstypes.PeerRoundState `json:"round_state"` // Exposed.
 	Stats *peerStateStats        `json:"stats"`       // Exposed.
@@ -1218,20 +1231,14 @@ func (ps *PeerState) setHasProposalBlockPart(height int64, round int32, index in
 func (ps *PeerState) SendPartSetHasPart(part *types.Part, prs *cstypes.PeerRoundState) bool {
 	// Send the part
 	ps.logger.Debug("Sending block part", "height", prs.Height, "round", prs.Round, "index", part.Index)
-	pp, err := part.ToProto()
+	// Not our height and round, so they don't matter.
+	msg, err := ps.gossipMsgs.blockPart(prs.Height, prs.Round, part)
 	if err != nil {
 		// NOTE: only returns error if part is nil, which it should never be by here
-		ps.logger.Error("Could not convert part to proto", "index", part.Index, "error", err)
+		ps.logger.Error("Could not marshal part", "index", part.Index, "error", err)
 		return false
 	}
-	if ps.peer.Send(p2p.Envelope{
-		ChannelID: DataChannel,
-		Message: &cmtcons.BlockPart{
-			Height: prs.Height, // Not our height, so it doesn't matter.
-			Round:  prs.Round,  // Not our height, so it doesn't matter.
-			Part:   *pp,
-		},
-	}) {
+	if p2p.SendMarshaled(ps.peer, DataChannel, msg) {
 		ps.SetHasProposalBlockPart(prs.Height, prs.Round, int(part.Index))
 		return true
 	}
@@ -1277,12 +1284,12 @@ func (ps *PeerState) SendProposalSetHasProposal(
 // Returns true and marks the peer as having the vote if the vote was sent.
 func (ps *PeerState) sendVoteSetHasVote(vote *types.Vote) bool {
 	ps.logger.Debug("Sending vote message", "ps", ps, "vote", vote)
-	if ps.peer.Send(p2p.Envelope{
-		ChannelID: VoteChannel,
-		Message: &cmtcons.Vote{
-			Vote: vote.ToProto(),
-		},
-	}) {
+	msg, err := ps.gossipMsgs.vote(vote)
+	if err != nil {
+		ps.logger.Error("Could not marshal vote", "vote", vote, "error", err)
+		return false
+	}
+	if p2p.SendMarshaled(ps.peer, VoteChannel, msg) {
 		ps.SetHasVote(vote)
 		return true
 	}
@@ -1652,6 +1659,98 @@ func (ps *PeerState) StringIndented(indent string) string {
 		indent)
 }
 
+// -----------------------------------------------------------------------------
+
+// gossipMsgCache keeps the vote and block part messages of the round state
+// marshaled, so that the gossip routines of all peers sending the same vote
+// or part marshal it once. They are cached by pointer, which is stable for
+// the votes and parts of the round state: the block parts of its height and
+// the votes of its height and last commit. Those loaded from the block store,
+// to help peers catch up, are marshaled every time. A nil gossipMsgCache
+// marshals every message.
+type gossipMsgCache struct {
+	mtx    cmtsync.Mutex
+	height int64
+	msgs   map[any]p2p.MarshaledMessage // by *types.Vote or blockPartKey
+}
+
+type blockPartKey struct {
+	part  *types.Part
+	round int32
+}
+
+func newGossipMsgCache() *gossipMsgCache {
+	return &gossipMsgCache{msgs: make(map[any]p2p.MarshaledMessage)}
+}
+
+// setHeight drops the cached messages if height, the height of the round
+// state, changed.
+func (c *gossipMsgCache) setHeight(height int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if height != c.height {
+		c.height = height
+		clear(c.msgs)
+	}
+}
+
+// vote returns the Vote message for vote, marshaled.
+func (c *gossipMsgCache) vote(vote *types.Vote) (p2p.MarshaledMessage, error) {
+	return c.marshal(vote, func(height int64) bool {
+		// The last commit is one height below the round state.
+		return vote.Height == height || vote.Height == height-1
+	}, func() (proto.Message, error) {
+		return &cmtcons.Vote{Vote: vote.ToProto()}, nil
+	})
+}
+
+// blockPart returns the BlockPart message for part at height and round,
+// marshaled.
+func (c *gossipMsgCache) blockPart(height int64, round int32, part *types.Part) (p2p.MarshaledMessage, error) {
+	return c.marshal(blockPartKey{part: part, round: round}, func(h int64) bool {
+		return height == h
+	}, func() (proto.Message, error) {
+		pp, err := part.ToProto()
+		if err != nil {
+			return nil, err
+		}
+		return &cmtcons.BlockPart{Height: height, Round: round, Part: *pp}, nil
+	})
+}
+
+// marshal returns the message for key, from the cache or built by msg and
+// marshaled. It's cached if cacheable returns true for the height of the
+// round state.
+func (c *gossipMsgCache) marshal(
+	key any,
+	cacheable func(height int64) bool,
+	msg func() (proto.Message, error),
+) (p2p.MarshaledMessage, error) {
+	if c != nil {
+		c.mtx.Lock()
+		mm, ok := c.msgs[key]
+		c.mtx.Unlock()
+		if ok {
+			return mm, nil
+		}
+	}
+
+	m, err := msg()
+	if err != nil {
+		return p2p.MarshaledMessage{}, err
+	}
+	mm, err := p2p.MarshalMessage(m)
+	if err != nil || c == nil {
+		return mm, err
+	}
+	c.mtx.Lock()
+	if cacheable(c.height) {
+		c.msgs[key] = mm
+	}
+	c.mtx.Unlock()
+	return mm, nil
+}
+
 // -----------------------------------------------------------------------------
 // Messages
 
@@ -1088,6 +1088,62 @@ func TestVoteSetBitsMessageValidateBasic(t *testing.T) {
 	}
 }
 
+func TestGossipMsgCache(t *testing.T) {
+	c := newGossipMsgCache()
+	c.setHeight(10)
+
+	vote := func(height int64) *types.Vote {
+		return &types.Vote{Type: types.PrevoteType, Height: height, ValidatorAddress: []byte("validator")}
+	}
+	part := &types.Part{Index: 1, Bytes: []byte("part")}
+	part.Proof.LeafHash = tmhash.Sum(part.Bytes)
+
+	// Messages are marshaled as when sent with Send.
+	current := vote(10)
+	got, err := c.vote(current)
+	require.NoError(t, err)
+	want, err := p2p.MarshalMessage(&cmtcons.Vote{Vote: current.ToProto()})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	pp, err := part.ToProto()
+	require.NoError(t, err)
+	got, err = c.blockPart(10, 2, part)
+	require.NoError(t, err)
+	want, err = p2p.MarshalMessage(&cmtcons.BlockPart{Height: 10, Round: 2, Part: *pp})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// Those of the round state are cached, those loaded from the block store
+	// for lower heights are not.
+	_, err = c.vote(vote(9))
+	require.NoError(t, err)
+	_, err = c.vote(vote(8))
+	require.NoError(t, err)
+	_, err = c.blockPart(9, 0, part)
+	require.NoError(t, err)
+	assert.Len(t, c.msgs, 3)
+	assert.Contains(t, c.msgs, current)
+	assert.Contains(t, c.msgs, blockPartKey{part: part, round: 2})
+
+	// The same ones are sent again from the cache.
+	_, err = c.vote(current)
+	require.NoError(t, err)
+	assert.Len(t, c.msgs, 3)
+
+	c.setHeight(10)
+	assert.Len(t, c.msgs, 3)
+	c.setHeight(11)
+	assert.Empty(t, c.msgs)
+
+	// A PeerState without a cache marshals every message.
+	var nilCache *gossipMsgCache
+	got, err = nilCache.vote(current)
+	require.NoError(t, err)
+	want, err = c.vote(current)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
 func TestMarshalJSONPeerState(t *testing.T) {
 	ps := NewPeerState(nil)
 	data, err := json.Marshal(ps)
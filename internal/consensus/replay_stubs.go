@@ -18,11 +18,15 @@ type emptyMempool struct{}
 
 var _ mempl.Mempool = emptyMempool{}
 
-func (emptyMempool) Lock()            {}
-func (emptyMempool) Unlock()          {}
-func (emptyMempool) PreUpdate()       {}
-func (emptyMempool) Size() int        { return 0 }
-func (emptyMempool) SizeBytes() int64 { return 0 }
+func (emptyMempool) Lock()                      {}
+func (emptyMempool) Unlock()                    {}
+func (emptyMempool) PreUpdate()                 {}
+func (emptyMempool) Size() int                  { return 0 }
+func (emptyMempool) SizeBytes() int64           { return 0 }
+func (emptyMempool) SizeHistogram() map[int]int { return nil }
+func (emptyMempool) RejectedTxStats() map[uint32]int64 {
+	return map[uint32]int64{}
+}
 func (emptyMempool) CheckTx(types.Tx, p2p.ID) (*abcicli.ReqRes, error) {
 	return nil, nil
 }
@@ -30,6 +34,14 @@ func (emptyMempool) RemoveTxByKey(types.TxKey) error           { return nil }
 func (emptyMempool) ReapMaxBytesMaxGas(int64, int64) types.Txs { return types.Txs{} }
 func (emptyMempool) GetTxByHash([]byte) types.Tx               { return types.Tx{} }
 func (emptyMempool) ReapMaxTxs(int) types.Txs                  { return types.Txs{} }
+func (emptyMempool) PreviewReap(int64, int64) types.Txs        { return types.Txs{} }
+func (emptyMempool) MarkDeprioritized([]types.TxKey)           {}
+func (emptyMempool) CheckTxResult(types.TxKey) (*abci.CheckTxResponse, bool) {
+	return nil, false
+}
+func (emptyMempool) TxStatus(types.TxKey) (mempl.TxStatus, bool) {
+	return mempl.TxStatus{}, false
+}
 func (emptyMempool) Update(
 	int64,
 	types.Txs,
@@ -48,6 +60,15 @@ func (emptyMempool) TxsBytes() int64               { return 0 }
 func (emptyMempool) TxsFront() *clist.CElement     { return nil }
 func (emptyMempool) TxsWaitChan() <-chan struct{}  { return nil }
 
+func (emptyMempool) Snapshot() ([]byte, error) { return nil, nil }
+func (emptyMempool) Restore([]byte) error      { return nil }
+
+func (emptyMempool) ReplaceTxs(context.Context, []types.Tx) error { return nil }
+
+func (emptyMempool) WaitForTx(context.Context, types.TxKey) (mempl.TxRemoval, error) {
+	return mempl.TxRemoval{Reason: mempl.TxRemovalCommitted}, nil
+}
+
 // -----------------------------------------------------------------------------
 // newMockProxyApp uses ABCIResponses to give the right results.
 //
@@ -52,6 +52,17 @@ func NewDelimitedReader(r io.Reader, maxSize int) ReadCloser {
 	return &varintReader{r, newByteReader(r), nil, maxSize, closer}
 }
 
+// ErrMessageTooLarge is returned by ReadMsg when the length prefix of a
+// message exceeds the reader's maximum size. The message itself is not read.
+type ErrMessageTooLarge struct {
+	Size int
+	Max  int
+}
+
+func (e ErrMessageTooLarge) Error() string {
+	return fmt.Sprintf("message exceeds max size (%v > %v)", e.Size, e.Max)
+}
+
 type varintReader struct {
 	r io.Reader
 	// ReadUvarint needs an io.ByteReader, and we also need to keep track of the
@@ -79,7 +90,7 @@ func (r *varintReader) ReadMsg(msg proto.Message) (int, error) {
 		return n, fmt.Errorf("invalid out-of-range message length %v", l)
 	}
 	if length > r.maxSize {
-		return n, fmt.Errorf("message exceeds max size (%v > %v)", length, r.maxSize)
+		return n, ErrMessageTooLarge{Size: length, Max: r.maxSize}
 	}
 
 	if len(r.buf) < length {
@@ -204,6 +204,10 @@ func (c *Client) UnconfirmedTx(ctx context.Context, hash []byte) (*ctypes.Result
 	return c.next.UnconfirmedTx(ctx, hash)
 }
 
+func (c *Client) UnconfirmedTxStatus(ctx context.Context, hash []byte) (*ctypes.ResultTxStatus, error) {
+	return c.next.UnconfirmedTxStatus(ctx, hash)
+}
+
 func (c *Client) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
 	return c.next.UnconfirmedTxs(ctx, limit)
 }
@@ -220,6 +224,10 @@ func (c *Client) NetInfo(ctx context.Context) (*ctypes.ResultNetInfo, error) {
 	return c.next.NetInfo(ctx)
 }
 
+func (c *Client) NetInfoDetailed(ctx context.Context) (*ctypes.ResultNetInfoDetailed, error) {
+	return c.next.NetInfoDetailed(ctx)
+}
+
 func (c *Client) DumpConsensusState(ctx context.Context) (*ctypes.ResultDumpConsensusState, error) {
 	return c.next.DumpConsensusState(ctx)
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cometbft/cometbft/abci/example/kvstore"
+	"github.com/cometbft/cometbft/internal/test"
+	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/proxy"
 )
 
@@ -71,6 +73,38 @@ func BenchmarkParallelCheckTx(b *testing.B) {
 	})
 }
 
+// BenchmarkParallelCheckDuplicateTx measures concurrent CheckTx calls for txs
+// already in the mempool, as received from peers gossiping the same txs, with
+// and without a sharded cache.
+func BenchmarkParallelCheckDuplicateTx(b *testing.B) {
+	for _, numShards := range []int{1, 16} {
+		b.Run("shards="+strconv.Itoa(numShards), func(b *testing.B) {
+			app := kvstore.NewInMemoryApplication()
+			cc := proxy.NewLocalClientCreator(app)
+			mp, cleanup := newMempoolWithApp(cc)
+			defer cleanup()
+			const numTxs = 1000
+			// Shards fill up unevenly, leave room so that no tx is evicted.
+			mp.config.CacheSize = 10 * numTxs
+			WithCacheShards(numShards)(mp)
+
+			txs := addTxs(b, mp, 0, numTxs)
+			require.NoError(b, mp.FlushAppConn())
+
+			var next atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := next.Add(1)
+					sender := p2p.ID(strconv.FormatUint(i, 16))
+					_, err := mp.CheckTx(txs[i%numTxs], sender)
+					require.ErrorIs(b, err, ErrTxInCache)
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkCheckDuplicateTx(b *testing.B) {
 	app := kvstore.NewInMemoryApplication()
 	cc := proxy.NewLocalClientCreator(app)
@@ -225,3 +259,33 @@ func BenchmarkConcurrentkBlockingIterators(b *testing.B) {
 		wg.Wait()
 	}
 }
+
+// BenchmarkShardedParallelCheckTx measures concurrent CheckTx calls for new
+// txs with a ShardedMempool, whose shards admit txs under their own locks.
+// The app connection doesn't serialize CheckTx calls, so that the mempool is
+// what is measured. Run it with -cpu, e.g. -cpu 1,4,8, to see how it scales
+// with the number of cores.
+func BenchmarkShardedParallelCheckTx(b *testing.B) {
+	for _, numShards := range []int{1, 2, 4, 8} {
+		b.Run("shards="+strconv.Itoa(numShards), func(b *testing.B) {
+			app := kvstore.NewInMemoryApplication()
+			cc := proxy.NewUnsyncLocalClientCreator(app)
+			cfg := test.ResetTestRoot("mempool_test")
+			cfg.Mempool.Size = 100_000_000
+			cfg.Mempool.MaxTxsBytes = 100_000_000_000
+			mp, cleanup := newShardedMempoolWithAppAndConfig(cc, cfg, numShards)
+			defer cleanup()
+
+			var next atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					tx := kvstore.NewTxFromID(int(next.Add(1)))
+					rr, err := mp.CheckTx(tx, "")
+					require.NoError(b, err, tx)
+					rr.Wait()
+				}
+			})
+		})
+	}
+}
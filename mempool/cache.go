@@ -2,6 +2,7 @@ package mempool
 
 import (
 	"container/list"
+	"encoding/binary"
 
 	cmtsync "github.com/cometbft/cometbft/libs/sync"
 	"github.com/cometbft/cometbft/types"
@@ -63,11 +64,14 @@ func (c *LRUTxCache) Reset() {
 }
 
 func (c *LRUTxCache) Push(tx types.Tx) bool {
+	return c.pushKey(tx.Key())
+}
+
+// The keys are hashed by the callers, outside of the lock.
+func (c *LRUTxCache) pushKey(key types.TxKey) bool {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	key := tx.Key()
-
 	moved, ok := c.cacheMap[key]
 	if ok {
 		c.list.MoveToBack(moved)
@@ -90,10 +94,13 @@ func (c *LRUTxCache) Push(tx types.Tx) bool {
 }
 
 func (c *LRUTxCache) Remove(tx types.Tx) {
+	c.removeKey(tx.Key())
+}
+
+func (c *LRUTxCache) removeKey(key types.TxKey) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	key := tx.Key()
 	e := c.cacheMap[key]
 	delete(c.cacheMap, key)
 
@@ -103,13 +110,67 @@ func (c *LRUTxCache) Remove(tx types.Tx) {
 }
 
 func (c *LRUTxCache) Has(tx types.Tx) bool {
+	return c.hasKey(tx.Key())
+}
+
+func (c *LRUTxCache) hasKey(key types.TxKey) bool {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	_, ok := c.cacheMap[tx.Key()]
+	_, ok := c.cacheMap[key]
 	return ok
 }
 
+var _ TxCache = (*ShardedLRUTxCache)(nil)
+
+// ShardedLRUTxCache is an LRUTxCache split into shards by tx key, each with its
+// own lock, so that concurrent CheckTx calls for different txs don't contend
+// on the cache. Each shard evicts its own least recently used tx once full, so
+// the cache as a whole is only approximately LRU.
+type ShardedLRUTxCache struct {
+	shards []*LRUTxCache
+}
+
+// NewShardedLRUTxCache returns a cache of cacheSize txs split into numShards
+// shards of equal size. As txs are spread unevenly, a shard may start evicting
+// before the cache as a whole is full.
+func NewShardedLRUTxCache(cacheSize, numShards int) *ShardedLRUTxCache {
+	numShards = max(numShards, 1)
+	shardSize := (cacheSize + numShards - 1) / numShards
+	c := &ShardedLRUTxCache{shards: make([]*LRUTxCache, numShards)}
+	for i := range c.shards {
+		c.shards[i] = NewLRUTxCache(shardSize)
+	}
+	return c
+}
+
+func (c *ShardedLRUTxCache) shard(key types.TxKey) *LRUTxCache {
+	return c.shards[binary.BigEndian.Uint64(key[:8])%uint64(len(c.shards))]
+}
+
+// Reset resets all the shards. It is not atomic: txs pushed concurrently may
+// or may not remain in the cache.
+func (c *ShardedLRUTxCache) Reset() {
+	for _, s := range c.shards {
+		s.Reset()
+	}
+}
+
+func (c *ShardedLRUTxCache) Push(tx types.Tx) bool {
+	key := tx.Key()
+	return c.shard(key).pushKey(key)
+}
+
+func (c *ShardedLRUTxCache) Remove(tx types.Tx) {
+	key := tx.Key()
+	c.shard(key).removeKey(key)
+}
+
+func (c *ShardedLRUTxCache) Has(tx types.Tx) bool {
+	key := tx.Key()
+	return c.shard(key).hasKey(key)
+}
+
 // NopTxCache defines a no-op raw transaction cache.
 type NopTxCache struct{}
 
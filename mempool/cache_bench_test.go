@@ -2,6 +2,8 @@ package mempool
 
 import (
 	"encoding/binary"
+	"strconv"
+	"sync/atomic"
 	"testing"
 )
 
@@ -39,3 +41,25 @@ func BenchmarkCacheRemoveTime(b *testing.B) {
 		cache.Remove(txs[i])
 	}
 }
+
+// BenchmarkCacheParallelPush compares the LRU cache with sharded ones under
+// concurrent pushes of distinct txs.
+func BenchmarkCacheParallelPush(b *testing.B) {
+	for _, numShards := range []int{1, 4, 16, 64} {
+		b.Run("shards="+strconv.Itoa(numShards), func(b *testing.B) {
+			var cache TxCache = NewLRUTxCache(100_000)
+			if numShards > 1 {
+				cache = NewShardedLRUTxCache(100_000, numShards)
+			}
+			var next atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				tx := make([]byte, 8)
+				for pb.Next() {
+					binary.BigEndian.PutUint64(tx, next.Add(1))
+					cache.Push(tx)
+				}
+			})
+		})
+	}
+}
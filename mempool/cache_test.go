@@ -41,6 +41,51 @@ func TestCacheRemove(t *testing.T) {
 	}
 }
 
+func TestShardedLRUTxCache(t *testing.T) {
+	const numShards, numTxs = 4, 40
+	cache := NewShardedLRUTxCache(numTxs, numShards)
+	require.Len(t, cache.shards, numShards)
+
+	txs := make([]types.Tx, numTxs)
+	for i := range txs {
+		txs[i] = types.Tx(strconv.Itoa(i))
+		require.True(t, cache.Push(txs[i]))
+		require.False(t, cache.Push(txs[i]), "duplicate")
+	}
+	total := 0
+	for _, s := range cache.shards {
+		total += s.list.Len()
+	}
+	require.LessOrEqual(t, total, numTxs)
+
+	// Each shard evicts its own txs once full.
+	full := cache.shards[0]
+	for i := 0; full.list.Len() < full.size; i++ {
+		tx := types.Tx("fill" + strconv.Itoa(i))
+		if cache.shard(tx.Key()) == full {
+			cache.Push(tx)
+		}
+	}
+	oldest := full.list.Front().Value.(types.TxKey)
+	for i := 0; ; i++ {
+		tx := types.Tx("evict" + strconv.Itoa(i))
+		if cache.shard(tx.Key()) == full {
+			cache.Push(tx)
+			break
+		}
+	}
+	require.NotContains(t, full.cacheMap, oldest)
+	require.Equal(t, full.size, full.list.Len())
+
+	cache.Remove(txs[len(txs)-1])
+	require.False(t, cache.Has(txs[len(txs)-1]))
+
+	cache.Reset()
+	for _, tx := range txs {
+		require.False(t, cache.Has(tx))
+	}
+}
+
 func TestCacheAfterUpdate(t *testing.T) {
 	app := kvstore.NewInMemoryApplication()
 	cc := proxy.NewLocalClientCreator(app)
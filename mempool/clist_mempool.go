@@ -3,13 +3,18 @@ package mempool
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math/bits"
 	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	abcicli "github.com/cometbft/cometbft/abci/client"
 	abci "github.com/cometbft/cometbft/abci/types"
+	protomem "github.com/cometbft/cometbft/api/cometbft/mempool/v1"
 	"github.com/cometbft/cometbft/config"
 	"github.com/cometbft/cometbft/internal/clist"
 	"github.com/cometbft/cometbft/libs/log"
@@ -38,6 +43,9 @@ type CListMempool struct {
 	notifiedTxsAvailable atomic.Bool
 	txsAvailable         chan struct{} // fires once for each height, when the mempool is not empty
 	onNewTx              func(types.Tx)
+	onTxCommitted        func(types.TxKey, int64)
+	onFlush              func([]types.TxKey) // set by the Reactor if config.FlushNotifyPeers
+	gossipBacklog        *gossipBacklog      // set by the Reactor if config.MaxGossipBacklogBytes > 0
 
 	config *config.MempoolConfig
 
@@ -46,6 +54,14 @@ type CListMempool struct {
 	updateMtx cmtsync.RWMutex
 	preCheck  PreCheckFunc
 	postCheck PostCheckFunc
+	verifier  *txVerifier // see WithTxVerifier
+
+	replacementKey    ReplacementKeyFunc
+	replacementPolicy ReplacementPolicy
+	isPinned          PinnedTxFunc
+	laneFunc          LaneFunc
+	maxPinnedBytes    int64
+	cacheCheckTxRes   bool // see WithCheckTxResultCache
 
 	proxyAppConn proxy.AppConnMempool
 
@@ -53,12 +69,22 @@ type CListMempool struct {
 	recheck *recheck
 
 	// Data in the following variables must to be kept in sync and updated atomically.
-	txsMtx    cmtsync.RWMutex
-	lanes     map[LaneID]*clist.CList         // each lane is a linked-list of (valid) txs
-	txsMap    map[types.TxKey]*clist.CElement // for quick access to the mempool entry of a given tx
-	laneBytes map[LaneID]int64                // number of bytes per lane (for metrics)
-	txsBytes  int64                           // total size of mempool, in bytes
-	numTxs    int64                           // total number of txs in the mempool
+	txsMtx         cmtsync.RWMutex
+	lanes          map[LaneID]*clist.CList          // each lane is a linked-list of (valid) txs
+	txsMap         map[types.TxKey]*clist.CElement  // for quick access to the mempool entry of a given tx
+	laneBytes      map[LaneID]int64                 // number of bytes per lane (for metrics)
+	txsBytes       int64                            // total size of mempool, in bytes
+	reservedBytes  int64                            // size of txs whose CheckTx is in flight, see reserveBytes
+	numTxs         int64                            // total number of txs in the mempool
+	localTxs       int64                            // number of txs submitted to this node, see isFull
+	localTxsBytes  int64                            // size of txs submitted to this node, in bytes
+	pinnedBytes    int64                            // size of pinned txs, see WithPinnedTxs
+	sizeHist       map[int]int                      // number of txs per size class, see txSizeClass
+	txsByRK        map[string]types.TxKey           // tx holding each replacement key, see WithReplacementPolicy
+	removalWaiters map[types.TxKey][]chan TxRemoval // see WaitForTx
+
+	rejectedMtx    cmtsync.Mutex
+	rejectedByCode map[uint32]int64 // see RejectedTxStats
 
 	addTxChMtx    cmtsync.RWMutex  // Protects the fields below
 	addTxCh       chan struct{}    // Blocks until the next TX is added
@@ -75,6 +101,11 @@ type CListMempool struct {
 
 	logger  log.Logger
 	metrics *Metrics
+
+	// Set if the mempool is a shard of a ShardedMempool, which then orders
+	// txs across its shards, notifies that txs are available and reports the
+	// size metrics.
+	shardOf *ShardedMempool
 }
 
 var _ Mempool = &CListMempool{}
@@ -108,14 +139,18 @@ func NewCListMempool(
 	options ...CListMempoolOption,
 ) *CListMempool {
 	mp := &CListMempool{
-		config:        cfg,
-		proxyAppConn:  proxyAppConn,
-		txsMap:        make(map[types.TxKey]*clist.CElement),
-		laneBytes:     make(map[LaneID]int64),
-		logger:        log.NewNopLogger(),
-		metrics:       NopMetrics(),
-		addTxCh:       make(chan struct{}),
-		addTxLaneSeqs: make(map[LaneID]int64),
+		config:         cfg,
+		proxyAppConn:   proxyAppConn,
+		txsMap:         make(map[types.TxKey]*clist.CElement),
+		laneBytes:      make(map[LaneID]int64),
+		sizeHist:       make(map[int]int),
+		rejectedByCode: make(map[uint32]int64),
+		txsByRK:        make(map[string]types.TxKey),
+		removalWaiters: make(map[types.TxKey][]chan TxRemoval),
+		logger:         log.NewNopLogger(),
+		metrics:        NopMetrics(),
+		addTxCh:        make(chan struct{}),
+		addTxLaneSeqs:  make(map[LaneID]int64),
 	}
 	mp.height.Store(height)
 
@@ -132,14 +167,17 @@ func NewCListMempool(
 		mp.lanes[id] = clist.New()
 		mp.sortedLanes = append(mp.sortedLanes, lane{id: id, priority: priority})
 	}
-	slices.SortStableFunc(mp.sortedLanes, func(i, j lane) int {
+	// Lanes of equal priority are ordered by ID, so that all nodes iterate,
+	// and thus reap, txs in the same order regardless of map iteration order.
+	// Within a lane, txs remain in insertion order.
+	slices.SortFunc(mp.sortedLanes, func(i, j lane) int {
 		if i.priority > j.priority {
 			return -1
 		}
 		if i.priority < j.priority {
 			return 1
 		}
-		return 0
+		return strings.Compare(string(i.id), string(j.id))
 	})
 
 	mp.recheck = newRecheck(mp)
@@ -174,10 +212,8 @@ func (mem *CListMempool) tryRemoveFromCache(tx types.Tx) {
 	}
 }
 
+// removeAllTxs removes all txs of lane. The caller must hold txsMtx.
 func (mem *CListMempool) removeAllTxs(lane LaneID) {
-	mem.txsMtx.Lock()
-	defer mem.txsMtx.Unlock()
-
 	for e := mem.lanes[lane].Front(); e != nil; e = e.Next() {
 		mem.lanes[lane].Remove(e)
 		e.DetachPrev()
@@ -185,6 +221,20 @@ func (mem *CListMempool) removeAllTxs(lane LaneID) {
 	mem.txsMap = make(map[types.TxKey]*clist.CElement)
 	delete(mem.laneBytes, lane)
 	mem.txsBytes = 0
+	if mem.shardOf == nil {
+		for class := range mem.sizeHist {
+			mem.metrics.TxSizeClass.With("size_class", strconv.Itoa(class)).Set(0)
+		}
+	}
+	mem.sizeHist = make(map[int]int)
+	mem.txsByRK = make(map[string]types.TxKey)
+	if mem.gossipBacklog != nil {
+		mem.gossipBacklog.reset()
+	}
+	removal := TxRemoval{Reason: TxRemovalFlushed, Height: mem.height.Load()}
+	for txKey := range mem.removalWaiters {
+		mem.notifyTxRemoved(txKey, removal)
+	}
 }
 
 // addSender adds a peer ID to the list of senders on the entry corresponding to
@@ -194,8 +244,10 @@ func (mem *CListMempool) addSender(txKey types.TxKey, sender p2p.ID) error {
 		return nil
 	}
 
-	mem.txsMtx.Lock()
-	defer mem.txsMtx.Unlock()
+	// senders is safe for concurrent use, so duplicate txs received from
+	// different peers are recorded in parallel.
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
 
 	elem, ok := mem.txsMap[txKey]
 	if !ok {
@@ -234,6 +286,66 @@ func WithPostCheck(f PostCheckFunc) CListMempoolOption {
 	return func(mem *CListMempool) { mem.postCheck = f }
 }
 
+// WithTxVerifier runs verify, e.g. the signature checks that dominate the
+// CheckTx of some apps, on up to numWorkers txs at a time, concurrently with
+// their CheckTx instead of in it. A tx is added to the mempool, and can thus
+// be reaped, only once both CheckTx and verify accepted it. CheckTx blocks
+// while all workers are busy.
+func WithTxVerifier(verify TxVerifyFunc, numWorkers int) CListMempoolOption {
+	return func(mem *CListMempool) { mem.verifier = newTxVerifier(verify, numWorkers) }
+}
+
+// WithReplacementPolicy enables replace-by-key semantics: when a valid tx has
+// the same replacement key as a tx already in the mempool, policy decides
+// whether the new tx evicts the existing one or is rejected.
+func WithReplacementPolicy(key ReplacementKeyFunc, policy ReplacementPolicy) CListMempoolOption {
+	return func(mem *CListMempool) {
+		mem.replacementKey = key
+		mem.replacementPolicy = policy
+	}
+}
+
+// WithPinnedTxs exempts the txs for which isPinned returns true from the
+// eviction of txs above SoftMaxTxsBytes. Pinned txs don't count towards that
+// soft limit, but still count towards Size and MaxTxsBytes. At most maxBytes
+// of txs are pinned at a time; a tx that would exceed it is added as a
+// regular tx.
+func WithPinnedTxs(isPinned PinnedTxFunc, maxBytes int64) CListMempoolOption {
+	return func(mem *CListMempool) {
+		mem.isPinned = isPinned
+		mem.maxPinnedBytes = maxBytes
+	}
+}
+
+// WithLaneFunc assigns each tx accepted by CheckTx to the lane returned by f,
+// instead of the one the app set in the response. A tx assigned to a lane the
+// mempool doesn't have is rejected.
+func WithLaneFunc(f LaneFunc) CListMempoolOption {
+	return func(mem *CListMempool) { mem.laneFunc = f }
+}
+
+// WithCheckTxResultCache keeps the CheckTx response of each tx in the mempool
+// so that the block builder can reuse it through CheckTxResult, e.g. for
+// execution hints the app computed in CheckTx. The cached response is dropped
+// on Update, as it no longer reflects the latest state, and replaced by the
+// response to the recheck if rechecking is enabled.
+func WithCheckTxResultCache() CListMempoolOption {
+	return func(mem *CListMempool) { mem.cacheCheckTxRes = true }
+}
+
+// WithCacheShards splits the cache of seen txs into n shards with their own
+// lock, see ShardedLRUTxCache, so that concurrent CheckTx calls contend less.
+// It has no effect if the cache is disabled. Only the cache is sharded: the
+// txs themselves, and thus admission, reaping and Update, remain under the
+// mempool's single txs lock. See ShardedMempool to shard the txs as well.
+func WithCacheShards(n int) CListMempoolOption {
+	return func(mem *CListMempool) {
+		if mem.config.CacheSize > 0 && n > 1 {
+			mem.cache = NewShardedLRUTxCache(mem.config.CacheSize, n)
+		}
+	}
+}
+
 // WithMetrics sets the metrics.
 func WithMetrics(metrics *Metrics) CListMempoolOption {
 	return func(mem *CListMempool) { mem.metrics = metrics }
@@ -245,6 +357,16 @@ func WithNewTxCallback(cb func(types.Tx)) CListMempoolOption {
 	return func(mem *CListMempool) { mem.onNewTx = cb }
 }
 
+// WithTxCommittedCallback sets a callback function to be executed for each
+// transaction that Update removes from the mempool because it was included in
+// the committed block at the given height. It is not called for txs removed
+// for any other reason (eviction, replacement, recheck, flush), nor for
+// committed txs that were not in the mempool. The callback runs while the
+// mempool is locked, so it must not call back into the mempool.
+func WithTxCommittedCallback(cb func(key types.TxKey, height int64)) CListMempoolOption {
+	return func(mem *CListMempool) { mem.onTxCommitted = cb }
+}
+
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) Lock() {
 	mem.updateMtx.Lock()
@@ -279,6 +401,67 @@ func (mem *CListMempool) SizeBytes() int64 {
 	return mem.txsBytes
 }
 
+// SizeHistogram returns the number of txs in the mempool per size class. See
+// txSizeClass.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) SizeHistogram() map[int]int {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+
+	hist := make(map[int]int, len(mem.sizeHist))
+	for class, n := range mem.sizeHist {
+		hist[class] = n
+	}
+	return hist
+}
+
+// RejectedTxStats returns the number of new txs the app rejected in CheckTx,
+// by response code. Txs that the app accepted but the PostCheckFunc rejected
+// aren't counted, nor are txs that fail a recheck.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) RejectedTxStats() map[uint32]int64 {
+	mem.rejectedMtx.Lock()
+	defer mem.rejectedMtx.Unlock()
+
+	stats := make(map[uint32]int64, len(mem.rejectedByCode))
+	for code, n := range mem.rejectedByCode {
+		stats[code] = n
+	}
+	return stats
+}
+
+func (mem *CListMempool) recordRejection(code uint32) {
+	mem.metrics.RejectedTxsByCode.With("code", strconv.FormatUint(uint64(code), 10)).Add(1)
+	mem.rejectedMtx.Lock()
+	mem.rejectedByCode[code]++
+	mem.rejectedMtx.Unlock()
+}
+
+// txSizeClass returns the size class of a tx of the given size in bytes: the
+// smallest power of two greater than or equal to size.
+func txSizeClass(size int) int {
+	if size <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(size-1))
+}
+
+// updateSizeHist adds delta to the number of txs in the size class of a tx of
+// the given size. The caller must hold txsMtx.
+func (mem *CListMempool) updateSizeHist(size int, delta int) {
+	class := txSizeClass(size)
+	mem.sizeHist[class] += delta
+	n := mem.sizeHist[class]
+	if n <= 0 {
+		delete(mem.sizeHist, class)
+	}
+	if mem.shardOf == nil {
+		mem.metrics.TxSizeClass.With("size_class", strconv.Itoa(class)).Set(float64(n))
+	}
+}
+
 // LaneSizes returns, the number of transactions in the given lane and the total
 // number of bytes used by all transactions in the lane.
 //
@@ -308,16 +491,51 @@ func (mem *CListMempool) FlushAppConn() error {
 
 // XXX: Unsafe! Calling Flush may leave mempool in inconsistent state.
 func (mem *CListMempool) Flush() {
+	keys := mem.flush()
+	if mem.onFlush != nil && len(keys) > 0 {
+		mem.onFlush(keys)
+	}
+}
+
+// flush removes all txs and returns the keys of those that were removed.
+func (mem *CListMempool) flush() []types.TxKey {
 	mem.updateMtx.Lock()
 	defer mem.updateMtx.Unlock()
+	return mem.flushLocked()
+}
+
+// flushLocked is flush for callers that hold updateMtx.
+func (mem *CListMempool) flushLocked() []types.TxKey {
+	mem.txsMtx.Lock()
+	keys := mem.flushTxs()
+	mem.txsMtx.Unlock()
+
+	mem.cache.Reset()
+	return keys
+}
+
+// flushTxs removes all txs and, if there is an onFlush callback, returns the
+// keys of those that were removed. Unlike flush, it leaves the cache alone.
+// The caller must hold txsMtx.
+func (mem *CListMempool) flushTxs() []types.TxKey {
+	var keys []types.TxKey
+	if mem.onFlush != nil {
+		keys = make([]types.TxKey, 0, len(mem.txsMap))
+		for key := range mem.txsMap {
+			keys = append(keys, key)
+		}
+	}
 
 	mem.txsBytes = 0
 	mem.numTxs = 0
-	mem.cache.Reset()
+	mem.localTxs = 0
+	mem.localTxsBytes = 0
+	mem.pinnedBytes = 0
 
 	for lane := range mem.lanes {
 		mem.removeAllTxs(lane)
 	}
+	return keys
 }
 
 func (mem *CListMempool) Contains(txKey types.TxKey) bool {
@@ -335,9 +553,14 @@ func (mem *CListMempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, e
 	// use defer to unlock mutex because application (*local client*) might panic
 	defer mem.updateMtx.RUnlock()
 
+	return mem.checkTx(tx, sender)
+}
+
+// checkTx is CheckTx for callers that hold updateMtx.
+func (mem *CListMempool) checkTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, error) {
 	txSize := len(tx)
 
-	if err := mem.isFull(txSize); err != nil {
+	if err := mem.isFull(tx, sender == noSender); err != nil {
 		mem.metrics.RejectedTxs.Add(1)
 		return nil, err
 	}
@@ -375,6 +598,22 @@ func (mem *CListMempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, e
 		return nil, ErrTxInCache
 	}
 
+	// A tx that may replace one in the mempool only takes the bytes it adds.
+	// Whether it does replace it is decided by admitTx, once CheckTx accepted
+	// it.
+	reserved := max(int64(txSize)-mem.conflictingSize(tx), 0)
+	if err := mem.reserveBytes(reserved); err != nil {
+		mem.forceRemoveFromCache(tx) // mempool might have space later
+		mem.metrics.RejectedTxs.Add(1)
+		return nil, err
+	}
+
+	var verified <-chan error
+	if mem.verifier != nil {
+		verified = mem.verifier.start(tx)
+	}
+
+	start := time.Now()
 	reqRes, err := mem.proxyAppConn.CheckTxAsync(context.TODO(), &abci.CheckTxRequest{
 		Tx:   tx,
 		Type: abci.CHECK_TX_TYPE_CHECK,
@@ -382,7 +621,25 @@ func (mem *CListMempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, e
 	if err != nil {
 		panic(fmt.Errorf("CheckTx request for tx %s failed: %w", log.NewLazySprintf("%X", tx.Hash()), err))
 	}
-	reqRes.SetCallback(mem.handleCheckTxResponse(tx, sender))
+	handleRes := mem.handleCheckTxResponse(tx, sender, reserved)
+	reqRes.SetCallback(func(res *abci.Response) error {
+		// Update waits for the pending responses, and thus for the
+		// verification, so the tx can't be added after the mempool moved on.
+		var verifyErr error
+		if verified != nil {
+			verifyErr = <-verified
+		}
+		result := "accepted"
+		if res.GetCheckTx().GetCode() != abci.CodeTypeOK || verifyErr != nil {
+			result = "rejected"
+		}
+		mem.metrics.CheckTxDuration.With("result", result).Observe(time.Since(start).Seconds())
+		if verifyErr != nil {
+			mem.releaseBytes(reserved)
+			return mem.rejectUnverifiedTx(tx, verifyErr)
+		}
+		return handleRes(res)
+	})
 
 	return reqRes, nil
 }
@@ -390,8 +647,17 @@ func (mem *CListMempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, e
 // handleCheckTxResponse handles CheckTx responses for transactions validated for the first time.
 //
 //   - sender optionally holds the ID of the peer that sent the transaction, if any.
-func (mem *CListMempool) handleCheckTxResponse(tx types.Tx, sender p2p.ID) func(res *abci.Response) error {
+//   - reserved is the number of bytes set aside for tx by reserveBytes. They
+//     are released by admitTx or, if tx doesn't get that far, on return.
+func (mem *CListMempool) handleCheckTxResponse(tx types.Tx, sender p2p.ID, reserved int64) func(res *abci.Response) error {
 	return func(r *abci.Response) error {
+		admitting := false
+		defer func() {
+			if !admitting {
+				mem.releaseBytes(reserved)
+			}
+		}()
+
 		res := r.GetCheckTx()
 		if res == nil {
 			panic(fmt.Sprintf("unexpected response value %v not of type CheckTx", r))
@@ -417,6 +683,9 @@ func (mem *CListMempool) handleCheckTxResponse(tx types.Tx, sender p2p.ID) func(
 				"err", postCheckErr,
 			)
 			mem.metrics.FailedTxs.Add(1)
+			if res.Code != abci.CodeTypeOK {
+				mem.recordRejection(res.Code)
+			}
 
 			if postCheckErr != nil {
 				return postCheckErr
@@ -424,37 +693,55 @@ func (mem *CListMempool) handleCheckTxResponse(tx types.Tx, sender p2p.ID) func(
 			return ErrInvalidTx
 		}
 
-		// If the app returned a non-empty lane, use it; otherwise use the default lane.
-		lane := mem.defaultLane
-		if res.LaneId != "" {
-			if _, ok := mem.lanes[lane]; !ok {
-				panic(ErrLaneNotFound{laneID: lane})
-			}
-			lane = LaneID(res.LaneId)
+		lane, err := mem.txLane(tx, res)
+		if err != nil {
+			mem.tryRemoveFromCache(tx)
+			mem.logger.Debug("Reject tx", "tx", log.NewLazySprintf("%X", tx.Hash()), "height", mem.height.Load(), "err", err)
+			mem.metrics.RejectedTxs.Add(1)
+			return err
 		}
 
-		if err := mem.isLaneFull(len(tx), lane); err != nil {
-			mem.forceRemoveFromCache(tx) // lane might have space later
-			// use debug level to avoid spamming logs when traffic is high
-			mem.logger.Debug(err.Error())
+		if mem.recheck.consideredFull() {
+			mem.forceRemoveFromCache(tx) // mempool might have space later
+			mem.logger.Debug(ErrRecheckFull.Error())
 			mem.metrics.RejectedTxs.Add(1)
-			return err
+			return ErrRecheckFull
 		}
 
-		// Check that tx is not already in the mempool. This can happen when the
-		// cache overflows. See https://github.com/cometbft/cometbft/pull/890.
+		// Add tx to mempool and notify that new txs are available.
 		txKey := tx.Key()
-		if mem.Contains(txKey) {
+		admitting = true
+		replaced, err := mem.admitTx(tx, res, sender, lane, reserved)
+		switch {
+		case errors.Is(err, ErrTxInMempool):
+			// This can happen when the cache overflows.
+			// See https://github.com/cometbft/cometbft/pull/890.
 			mem.metrics.RejectedTxs.Add(1)
 			if err := mem.addSender(txKey, sender); err != nil {
 				mem.logger.Error("Could not add sender to tx", "tx", tx.Hash(), "sender", sender, "err", err)
 			}
-			mem.logger.Debug("Reject tx", "tx", log.NewLazySprintf("%X", tx.Hash()), "height", mem.height.Load(), "err", ErrTxInMempool)
-			return ErrTxInMempool
+			mem.logger.Debug("Reject tx", "tx", log.NewLazySprintf("%X", tx.Hash()), "height", mem.height.Load(), "err", err)
+			return err
+		case errors.As(err, &ErrLaneIsFull{}) || errors.As(err, &ErrMempoolIsFull{}):
+			mem.forceRemoveFromCache(tx) // lane might have space later
+			// use debug level to avoid spamming logs when traffic is high
+			mem.logger.Debug(err.Error())
+			mem.metrics.RejectedTxs.Add(1)
+			return err
+		case err != nil:
+			mem.metrics.RejectedTxs.Add(1)
+			mem.logger.Debug("Reject tx", "tx", log.NewLazySprintf("%X", tx.Hash()), "height", mem.height.Load(), "err", err)
+			return err
+		}
+		if replaced != nil {
+			mem.metrics.ReplacedTxs.Add(1)
+			mem.updateSizeMetrics(replaced.lane)
+			mem.logger.Debug(
+				"Replaced transaction",
+				"old", log.NewLazySprintf("%X", replaced.tx.Hash()),
+				"new", log.NewLazySprintf("%X", tx.Hash()),
+			)
 		}
-
-		// Add tx to mempool and notify that new txs are available.
-		mem.addTx(tx, res.GasWanted, sender, lane)
 		mem.notifyTxsAvailable()
 
 		if mem.onNewTx != nil {
@@ -462,17 +749,176 @@ func (mem *CListMempool) handleCheckTxResponse(tx types.Tx, sender p2p.ID) func(
 		}
 
 		mem.updateSizeMetrics(lane)
+		mem.evictToSoftTarget(txKey)
 
 		return nil
 	}
 }
 
-// Called from:
-//   - handleCheckTxResponse (lock not held) if tx is valid
-func (mem *CListMempool) addTx(tx types.Tx, gasWanted int64, sender p2p.ID, lane LaneID) {
+// txLane returns the lane of tx, accepted by CheckTx with res: the one picked
+// by the LaneFunc, if any, otherwise the one set by the app, or the default
+// lane if neither picked one.
+func (mem *CListMempool) txLane(tx types.Tx, res *abci.CheckTxResponse) (LaneID, error) {
+	lane := LaneID(res.LaneId)
+	if mem.laneFunc != nil {
+		if l := mem.laneFunc(tx, res); l != "" {
+			lane = LaneID(l)
+		}
+	}
+	if lane == "" {
+		return mem.defaultLane, nil
+	}
+	if _, ok := mem.lanes[lane]; !ok {
+		return "", ErrLaneNotFound{laneID: lane}
+	}
+	return lane, nil
+}
+
+// rejectUnverifiedTx rejects tx, which the TxVerifyFunc failed, whatever the
+// CheckTx response.
+func (mem *CListMempool) rejectUnverifiedTx(tx types.Tx, err error) error {
+	mem.tryRemoveFromCache(tx)
+	mem.logger.Debug(
+		"Rejected unverified transaction",
+		"tx", log.NewLazySprintf("%X", tx.Hash()),
+		"err", err,
+	)
+	mem.metrics.FailedTxs.Add(1)
+	return ErrTxVerification{Err: err}
+}
+
+// conflictingTx returns the tx in the mempool with the same replacement key
+// as tx, if any. The caller must hold txsMtx.
+func (mem *CListMempool) conflictingTx(tx types.Tx) *mempoolTx {
+	if mem.replacementKey == nil || mem.replacementPolicy == nil {
+		return nil
+	}
+	rk := mem.replacementKey(tx)
+	if rk == nil {
+		return nil
+	}
+	existingKey, ok := mem.txsByRK[string(rk)]
+	if !ok {
+		return nil
+	}
+	if elem, ok := mem.txsMap[existingKey]; ok {
+		return elem.Value.(*mempoolTx)
+	}
+	return nil
+}
+
+// conflictingSize returns the size of the tx in the mempool with the same
+// replacement key as tx, or 0 if there is none.
+func (mem *CListMempool) conflictingSize(tx types.Tx) int64 {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+
+	if existing := mem.conflictingTx(tx); existing != nil {
+		return int64(len(existing.tx))
+	}
+	return 0
+}
+
+// admitTx adds tx, accepted by CheckTx with res, to lane. If a tx in the
+// mempool has the same replacement key, tx replaces it if the replacement
+// policy allows it, and is rejected with ErrTxReplacementRejected otherwise.
+// The lookup, the policy, the capacity checks, the removal and the insertion
+// all happen under txsMtx, so that of concurrent txs with the same key only
+// one gets in. reserved is the number of bytes reserveBytes set aside for tx,
+// which are released in the same critical section, whether tx is added or
+// not, so that they are never counted both as reserved and as added.
+// It returns the replaced tx, if any.
+func (mem *CListMempool) admitTx(
+	tx types.Tx,
+	res *abci.CheckTxResponse,
+	sender p2p.ID,
+	lane LaneID,
+	reserved int64,
+) (*mempoolTx, error) {
 	mem.txsMtx.Lock()
 	defer mem.txsMtx.Unlock()
+	mem.reservedBytes -= reserved
+
+	if _, ok := mem.txsMap[tx.Key()]; ok {
+		return nil, ErrTxInMempool
+	}
+	existing := mem.conflictingTx(tx)
+	if existing != nil && !mem.replacementPolicy(existing.tx, tx) {
+		return nil, ErrTxReplacementRejected{Existing: existing.tx.Key()}
+	}
 
+	laneTxs, laneBytes := mem.lanes[lane].Len(), mem.laneBytes[lane]
+	if existing != nil && existing.lane == lane {
+		laneTxs--
+		laneBytes -= int64(len(existing.tx))
+	}
+	if err := mem.laneCapacityError(len(tx), lane, laneTxs, laneBytes); err != nil {
+		return nil, err
+	}
+	// The bytes of other txs whose CheckTx is in flight must still fit, see
+	// reserveBytes.
+	if err := mem.capacityError(len(tx), sender == noSender, existing, mem.reservedBytes); err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		_ = mem.removeTxLocked(existing.tx.Key(), TxRemovalReplaced)
+	}
+	mem.addTx(tx, res, sender, lane)
+	return existing, nil
+}
+
+// evictToSoftTarget evicts txs once the mempool has grown beyond the soft
+// limit on its size in bytes, until it is back to the soft target. Txs are
+// evicted from the lowest-priority lane first and, within a lane, from the
+// oldest. The tx that was just added, keep, is never evicted, and neither
+// are pinned txs, which don't count towards the limit and target either.
+func (mem *CListMempool) evictToSoftTarget(keep types.TxKey) {
+	if mem.config.SoftMaxTxsBytes <= 0 || mem.evictableBytes() <= mem.config.SoftMaxTxsBytes {
+		return
+	}
+
+	target := mem.config.SoftTargetTxsBytes
+	numEvicted := 0
+	for i := len(mem.sortedLanes) - 1; i >= 0 && mem.evictableBytes() > target; i-- {
+		lane := mem.sortedLanes[i].id
+
+		mem.txsMtx.RLock()
+		e := mem.lanes[lane].Front()
+		mem.txsMtx.RUnlock()
+
+		for ; e != nil && mem.evictableBytes() > target; e = e.Next() {
+			memTx := e.Value.(*mempoolTx)
+			txKey := memTx.tx.Key()
+			if txKey == keep || memTx.pinned {
+				continue
+			}
+			if err := mem.removeTx(txKey, TxRemovalEvicted); err != nil {
+				continue
+			}
+			// Let the tx in again once there is room for it.
+			mem.forceRemoveFromCache(memTx.tx)
+			mem.metrics.AutoEvictedTxs.Add(1)
+			numEvicted++
+		}
+		mem.updateSizeMetrics(lane)
+	}
+
+	mem.logger.Debug("Evicted txs above soft limit", "num", numEvicted, "size_bytes", mem.SizeBytes())
+}
+
+// evictableBytes returns the total size of the txs that are not pinned.
+func (mem *CListMempool) evictableBytes() int64 {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+	return mem.txsBytes - mem.pinnedBytes
+}
+
+// addTx adds a tx accepted by CheckTx to the mempool. The caller must hold
+// txsMtx.
+// Called from:
+//   - admitTx if tx is valid
+func (mem *CListMempool) addTx(tx types.Tx, res *abci.CheckTxResponse, sender p2p.ID, lane LaneID) {
 	// Get lane's clist.
 	txs, ok := mem.lanes[lane]
 	if !ok {
@@ -482,25 +928,51 @@ func (mem *CListMempool) addTx(tx types.Tx, gasWanted int64, sender p2p.ID, lane
 	// Increase sequence number.
 	mem.addTxChMtx.Lock()
 	defer mem.addTxChMtx.Unlock()
-	mem.addTxSeq++
+	if mem.shardOf != nil {
+		mem.addTxSeq = mem.shardOf.addTxSeq.Add(1)
+	} else {
+		mem.addTxSeq++
+	}
 	mem.addTxLaneSeqs[lane] = mem.addTxSeq
 
 	// Add new transaction.
 	memTx := &mempoolTx{
 		tx:        tx,
 		height:    mem.height.Load(),
-		gasWanted: gasWanted,
+		gasWanted: res.GasWanted,
 		lane:      lane,
 		seq:       mem.addTxSeq,
+		timestamp: time.Now().UTC(),
+		local:     sender == noSender,
+	}
+	if mem.isPinned != nil && mem.pinnedBytes+int64(len(tx)) <= mem.maxPinnedBytes && mem.isPinned(tx) {
+		memTx.pinned = true
+		mem.pinnedBytes += int64(len(tx))
+	}
+	if mem.cacheCheckTxRes {
+		memTx.checkTxRes = res
 	}
 	_ = memTx.addSender(sender)
 	e := txs.PushBack(memTx)
+	if mem.gossipBacklog != nil {
+		mem.gossipBacklog.txAdded(memTx)
+	}
 
 	// Update auxiliary variables.
 	mem.txsMap[tx.Key()] = e
 	mem.txsBytes += int64(len(tx))
 	mem.numTxs++
+	if memTx.local {
+		mem.localTxs++
+		mem.localTxsBytes += int64(len(tx))
+	}
 	mem.laneBytes[lane] += int64(len(tx))
+	mem.updateSizeHist(len(tx), 1)
+	if mem.replacementKey != nil {
+		if rk := mem.replacementKey(tx); rk != nil {
+			mem.txsByRK[string(rk)] = tx.Key()
+		}
+	}
 
 	// Notify iterators there's a new transaction.
 	close(mem.addTxCh)
@@ -519,13 +991,24 @@ func (mem *CListMempool) addTx(tx types.Tx, gasWanted int64, sender p2p.ID, lane
 }
 
 // RemoveTxByKey removes a transaction from the mempool by its TxKey index.
+func (mem *CListMempool) RemoveTxByKey(txKey types.TxKey) error {
+	return mem.removeTx(txKey, TxRemovalRemoved)
+}
+
+// removeTx removes a transaction from the mempool and notifies WaitForTx
+// callers with the given reason.
 // Called from:
 //   - Update (updateMtx held) if tx was committed
 //   - handleRecheckTxResponse (updateMtx not held) if tx was invalidated
-func (mem *CListMempool) RemoveTxByKey(txKey types.TxKey) error {
+//   - admitTx and evictToSoftTarget (updateMtx not held)
+func (mem *CListMempool) removeTx(txKey types.TxKey, reason TxRemovalReason) error {
 	mem.txsMtx.Lock()
 	defer mem.txsMtx.Unlock()
+	return mem.removeTxLocked(txKey, reason)
+}
 
+// removeTxLocked is removeTx for callers that hold txsMtx.
+func (mem *CListMempool) removeTxLocked(txKey types.TxKey, reason TxRemovalReason) error {
 	elem, ok := mem.txsMap[txKey]
 	if !ok {
 		return ErrTxNotFound
@@ -534,17 +1017,34 @@ func (mem *CListMempool) RemoveTxByKey(txKey types.TxKey) error {
 	memTx := elem.Value.(*mempoolTx)
 
 	label := string(memTx.lane)
-	mem.metrics.TxLifeSpan.With("lane", label).Observe(float64(memTx.timestamp.Sub(time.Now().UTC())))
+	mem.metrics.TxLifeSpan.With("lane", label).Observe(float64(time.Since(memTx.timestamp).Milliseconds()))
 
 	// Remove tx from lane.
 	mem.lanes[memTx.lane].Remove(elem)
 	elem.DetachPrev()
+	if mem.gossipBacklog != nil {
+		mem.gossipBacklog.txRemoved(memTx)
+	}
 
 	// Update auxiliary variables.
 	delete(mem.txsMap, txKey)
 	mem.txsBytes -= int64(len(memTx.tx))
 	mem.numTxs--
+	if memTx.local {
+		mem.localTxs--
+		mem.localTxsBytes -= int64(len(memTx.tx))
+	}
+	if memTx.pinned {
+		mem.pinnedBytes -= int64(len(memTx.tx))
+	}
 	mem.laneBytes[memTx.lane] -= int64(len(memTx.tx))
+	mem.updateSizeHist(len(memTx.tx), -1)
+	if mem.replacementKey != nil {
+		if rk := mem.replacementKey(memTx.tx); rk != nil && mem.txsByRK[string(rk)] == txKey {
+			delete(mem.txsByRK, string(rk))
+		}
+	}
+	mem.notifyTxRemoved(txKey, TxRemoval{Reason: reason, Height: mem.height.Load()})
 
 	mem.logger.Debug(
 		"Removed transaction",
@@ -556,17 +1056,429 @@ func (mem *CListMempool) RemoveTxByKey(txKey types.TxKey) error {
 	return nil
 }
 
-func (mem *CListMempool) isFull(txSize int) error {
-	memSize := mem.Size()
-	txsBytes := mem.SizeBytes()
-	if memSize >= mem.config.Size || uint64(txSize)+uint64(txsBytes) > uint64(mem.config.MaxTxsBytes) {
+// addGossipPeer starts tracking the gossip backlog of a peer whose
+// broadcastTxRoutine is about to iterate over the mempool.
+func (mem *CListMempool) addGossipPeer(peerID p2p.ID) {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+	mem.gossipBacklog.addPeer(peerID, mem.txsBytes)
+}
+
+// gossipTaken takes entry out of the gossip backlog of the peer whose
+// broadcastTxRoutine just reached it.
+func (mem *CListMempool) gossipTaken(peerID p2p.ID, entry Entry) {
+	memTx, ok := entry.(*mempoolTx)
+	if !ok {
+		return
+	}
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+	// The tx may have been removed, and even added again as a new entry.
+	elem, ok := mem.txsMap[memTx.tx.Key()]
+	mem.gossipBacklog.taken(peerID, memTx, ok && elem.Value == memTx)
+}
+
+// notifyTxRemoved wakes up the WaitForTx callers waiting on txKey. The caller
+// must hold txsMtx.
+func (mem *CListMempool) notifyTxRemoved(txKey types.TxKey, removal TxRemoval) {
+	for _, ch := range mem.removalWaiters[txKey] {
+		ch <- removal // buffered, never blocks
+	}
+	delete(mem.removalWaiters, txKey)
+}
+
+// WaitForTx blocks until the tx with the given key is removed from the
+// mempool, or ctx is done.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) WaitForTx(ctx context.Context, txKey types.TxKey) (TxRemoval, error) {
+	mem.txsMtx.Lock()
+	if _, ok := mem.txsMap[txKey]; !ok {
+		mem.txsMtx.Unlock()
+		return TxRemoval{}, ErrTxNotFound
+	}
+	ch := make(chan TxRemoval, 1)
+	mem.removalWaiters[txKey] = append(mem.removalWaiters[txKey], ch)
+	mem.txsMtx.Unlock()
+
+	select {
+	case removal := <-ch:
+		return removal, nil
+	case <-ctx.Done():
+		mem.txsMtx.Lock()
+		defer mem.txsMtx.Unlock()
+		waiters := mem.removalWaiters[txKey]
+		for i, w := range waiters {
+			if w == ch {
+				mem.removalWaiters[txKey] = append(waiters[:i:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(mem.removalWaiters[txKey]) == 0 {
+			delete(mem.removalWaiters, txKey)
+		}
+		// The tx may have been removed while we were waiting for the lock.
+		select {
+		case removal := <-ch:
+			return removal, nil
+		default:
+			return TxRemoval{}, ctx.Err()
+		}
+	}
+}
+
+// Snapshot returns the txs in the mempool, in reap order, encoded as a
+// mempool Snapshot message, along with whether each of them is local and the
+// peers it was received from.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) Snapshot() ([]byte, error) {
+	mem.updateMtx.RLock()
+	entries := reapEntries(NewNonBlockingIterator(mem))
+	mem.updateMtx.RUnlock()
+
+	return encodeSnapshot(entries)
+}
+
+// reapEntries returns the entries iter goes over, in reap order, see
+// ReapMaxTxs.
+func reapEntries(iter *NonBlockingIterator) []*mempoolTx {
+	var entries, deprioritized []*mempoolTx
+	for e := iter.Next(); e != nil; e = iter.Next() {
+		memTx := e.(*mempoolTx)
+		if isDeprioritized(memTx) {
+			deprioritized = append(deprioritized, memTx)
+			continue
+		}
+		entries = append(entries, memTx)
+	}
+	return append(entries, deprioritized...)
+}
+
+// encodeSnapshot encodes entries as a mempool Snapshot message.
+func encodeSnapshot(entries []*mempoolTx) ([]byte, error) {
+	msg := protomem.Snapshot{Txs: make([]*protomem.SnapshotTx, len(entries))}
+	for i, memTx := range entries {
+		stx := &protomem.SnapshotTx{Tx: memTx.tx, Local: memTx.local}
+		for _, id := range memTx.senderIDs() {
+			stx.Senders = append(stx.Senders, string(id))
+		}
+		msg.Txs[i] = stx
+	}
+	return msg.Marshal()
+}
+
+// Restore rechecks the txs of a Snapshot and adds the valid ones to the
+// mempool. Txs already in the cache are skipped. Each tx keeps its origin, so
+// txs received from peers can't take the share of the mempool reserved for
+// local txs, and its senders.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) Restore(snapshot []byte) error {
+	return restoreSnapshot(mem, snapshot, mem.logger)
+}
+
+// snapshotRestorer is a mempool a Snapshot can be restored to.
+type snapshotRestorer interface {
+	CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, error)
+	FlushAppConn() error
+	addSender(txKey types.TxKey, sender p2p.ID) error
+}
+
+// restoreSnapshot is Restore for mp.
+func restoreSnapshot(mp snapshotRestorer, snapshot []byte, logger log.Logger) error {
+	var msg protomem.Snapshot
+	if err := msg.Unmarshal(snapshot); err != nil {
+		return fmt.Errorf("decoding mempool snapshot: %w", err)
+	}
+
+	restored := 0
+	for _, stx := range msg.Txs {
+		_, err := mp.CheckTx(stx.Tx, snapshotTxSender(stx))
+		switch {
+		case err == nil:
+			restored++
+		case errors.As(err, &ErrMempoolIsFull{}):
+			return err
+		case !errors.Is(err, ErrTxInCache):
+			logger.Debug("Dropping tx from mempool snapshot", "tx", log.NewLazySprintf("%X", types.Tx(stx.Tx).Hash()), "err", err)
+		}
+	}
+	logger.Info("Restored mempool snapshot", "txs", len(msg.Txs), "checked", restored)
+
+	if err := mp.FlushAppConn(); err != nil {
+		return err
+	}
+	// The other senders can only be added once the txs are in the mempool.
+	for _, stx := range msg.Txs {
+		for _, id := range stx.Senders {
+			_ = mp.addSender(types.Tx(stx.Tx).Key(), p2p.ID(id))
+		}
+	}
+	return nil
+}
+
+// snapshotTxSender returns the sender to check stx with: none if it is
+// local, so that it is added as a local tx again, or else the first of its
+// senders.
+func snapshotTxSender(stx *protomem.SnapshotTx) p2p.ID {
+	if stx.Local || len(stx.Senders) == 0 {
+		return noSender
+	}
+	return p2p.ID(stx.Senders[0])
+}
+
+// ReplaceTxs flushes the mempool and caches and adds txs instead, running
+// CheckTx on each of them, so that txs the app rejects are dropped. All txs
+// are checked before the mempool is touched, and the old txs are then swapped
+// for the valid ones in a single critical section, under both updateMtx and
+// txsMtx, so CheckTx, reaps, Update and gossip iterators see either the old
+// txs or the new ones, never a mix. Txs that were in the mempool already keep
+// their origin and senders, the others are local. It returns an error,
+// leaving the mempool as it was, if the valid txs don't fit in the mempool or
+// ctx is done before all of them are checked.
+func (mem *CListMempool) ReplaceTxs(ctx context.Context, txs []types.Tx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+
+	checked, err := mem.checkReplacementTxs(ctx, txs)
+	if err != nil {
+		return err
+	}
+
+	mem.txsMtx.Lock()
+	mem.keepOrigins(checked)
+	if err := mem.replacementCapacityError(checked); err != nil {
+		mem.txsMtx.Unlock()
+		return err
+	}
+	keys := mem.flushTxs()
+	for _, c := range checked {
+		mem.addCheckedTx(c)
+	}
+	mem.txsMtx.Unlock()
+
+	mem.txsReplaced(checked, keys)
+	mem.logger.Info("Replaced mempool txs", "removed", len(keys), "txs", len(txs), "checked", len(checked))
+	return nil
+}
+
+// addCheckedTx adds c, checked by checkReplacementTxs, to the mempool with
+// its senders. The caller must hold txsMtx.
+func (mem *CListMempool) addCheckedTx(c checkedTx) {
+	mem.addTx(c.tx, c.res, c.sender, c.lane)
+	memTx := mem.txsMap[c.tx.Key()].Value.(*mempoolTx)
+	for _, id := range c.senders {
+		_ = memTx.addSender(id)
+	}
+}
+
+// txsReplaced updates the cache, callbacks and metrics once ReplaceTxs
+// swapped the txs removed, of which keys are returned by flushTxs, for
+// checked.
+func (mem *CListMempool) txsReplaced(checked []checkedTx, keys []types.TxKey) {
+	mem.cache.Reset()
+	for _, c := range checked {
+		mem.cache.Push(c.tx)
+	}
+	if mem.onFlush != nil && len(keys) > 0 {
+		mem.onFlush(keys)
+	}
+	if len(checked) > 0 {
+		mem.notifyTxsAvailable()
+	}
+	if mem.onNewTx != nil {
+		for _, c := range checked {
+			mem.onNewTx(c.tx)
+		}
+	}
+	for _, l := range mem.sortedLanes {
+		mem.updateSizeMetrics(l.id)
+	}
+	mem.evictToSoftTarget(types.TxKey{})
+}
+
+// checkedTx is a tx accepted by CheckTx with res, to be added to lane.
+type checkedTx struct {
+	tx      types.Tx
+	res     *abci.CheckTxResponse
+	lane    LaneID
+	index   int      // of tx in the txs passed to checkReplacementTxs
+	sender  p2p.ID   // the sender to add the tx with, noSender if it is local
+	senders []p2p.ID // all peers the tx was received from
+}
+
+// keepOrigins sets the sender and senders of each of txs that is already in
+// the mempool to those of its entry, so that it keeps its origin. The other
+// txs are local. The caller must hold txsMtx.
+func (mem *CListMempool) keepOrigins(txs []checkedTx) {
+	for i := range txs {
+		elem, ok := mem.txsMap[txs[i].tx.Key()]
+		if !ok {
+			continue
+		}
+		memTx := elem.Value.(*mempoolTx)
+		txs[i].senders = memTx.senderIDs()
+		if !memTx.local && len(txs[i].senders) > 0 {
+			txs[i].sender = txs[i].senders[0]
+		}
+	}
+}
+
+// checkReplacementTxs runs CheckTx on txs, for ReplaceTxs, and returns the
+// valid ones, in order, without adding them to the mempool. Duplicates are
+// dropped and, of txs with the same replacement key, the one the replacement
+// policy prefers is kept. It returns an error if ctx is done first.
+func (mem *CListMempool) checkReplacementTxs(ctx context.Context, txs []types.Tx) ([]checkedTx, error) {
+	checked := make([]checkedTx, 0, len(txs))
+	seen := make(map[types.TxKey]struct{}, len(txs))
+	byRK := make(map[string]int) // index in checked of the tx holding each replacement key
+	for index, tx := range txs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tx.Key()]; ok {
+			continue
+		}
+		seen[tx.Key()] = struct{}{}
+
+		res, lane, err := mem.checkReplacementTx(ctx, tx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			mem.logger.Debug("Dropping replacement tx", "tx", log.NewLazySprintf("%X", tx.Hash()), "err", err)
+			continue
+		}
+
+		if mem.replacementKey != nil && mem.replacementPolicy != nil {
+			if rk := mem.replacementKey(tx); rk != nil {
+				if i, ok := byRK[string(rk)]; ok {
+					if !mem.replacementPolicy(checked[i].tx, tx) {
+						continue
+					}
+					checked[i] = checkedTx{}
+				}
+				byRK[string(rk)] = len(checked)
+			}
+		}
+		checked = append(checked, checkedTx{tx: tx, res: res, lane: lane, index: index})
+	}
+
+	valid := checked[:0]
+	for _, c := range checked {
+		if c.tx != nil {
+			valid = append(valid, c)
+		}
+	}
+	return valid, nil
+}
+
+// checkReplacementTx runs the same checks on tx as checkTx, and waits for the
+// response of CheckTx, but doesn't add tx to the mempool or the cache.
+func (mem *CListMempool) checkReplacementTx(ctx context.Context, tx types.Tx) (*abci.CheckTxResponse, LaneID, error) {
+	if len(tx) > mem.config.MaxTxBytes {
+		return nil, "", ErrTxTooLarge{
+			Max:    mem.config.MaxTxBytes,
+			Actual: len(tx),
+		}
+	}
+	if mem.preCheck != nil {
+		if err := mem.preCheck(tx); err != nil {
+			return nil, "", ErrPreCheck{Err: err}
+		}
+	}
+
+	var verified <-chan error
+	if mem.verifier != nil {
+		verified = mem.verifier.start(tx)
+	}
+	res, err := mem.proxyAppConn.CheckTx(ctx, &abci.CheckTxRequest{
+		Tx:   tx,
+		Type: abci.CHECK_TX_TYPE_CHECK,
+	})
+	if verified != nil {
+		if verifyErr := <-verified; verifyErr != nil && err == nil {
+			err = ErrTxVerification{Err: verifyErr}
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.Code != abci.CodeTypeOK {
+		return nil, "", ErrInvalidTx
+	}
+	if mem.postCheck != nil {
+		if err := mem.postCheck(tx, res); err != nil {
+			return nil, "", err
+		}
+	}
+	lane, err := mem.txLane(tx, res)
+	if err != nil {
+		return nil, "", err
+	}
+	return res, lane, nil
+}
+
+// replacementCapacityError returns an error if txs don't all fit in an empty
+// mempool, along with the txs whose CheckTx is in flight, see reserveBytes.
+// As in capacityError, txs received from peers can't use the share of the
+// capacity reserved for local txs. The caller must hold txsMtx.
+func (mem *CListMempool) replacementCapacityError(txs []checkedTx) error {
+	txsBytes := mem.reservedBytes
+	peerTxs, peerBytes := 0, int64(0)
+	laneTxs := make(map[LaneID]int)
+	laneBytes := make(map[LaneID]int64)
+	for _, c := range txs {
+		if err := mem.laneCapacityError(len(c.tx), c.lane, laneTxs[c.lane], laneBytes[c.lane]); err != nil {
+			return err
+		}
+		laneTxs[c.lane]++
+		laneBytes[c.lane] += int64(len(c.tx))
+		txsBytes += int64(len(c.tx))
+		if c.sender != noSender {
+			peerTxs++
+			peerBytes += int64(len(c.tx))
+		}
+	}
+	if len(txs) > mem.config.Size || txsBytes > mem.config.MaxTxsBytes {
 		return ErrMempoolIsFull{
-			NumTxs:      memSize,
+			NumTxs:      len(txs),
 			MaxTxs:      mem.config.Size,
 			TxsBytes:    txsBytes,
 			MaxTxsBytes: mem.config.MaxTxsBytes,
 		}
 	}
+	if reserved := mem.config.LocalTxsReservedFraction; reserved > 0 {
+		maxTxs := int(float64(mem.config.Size) * (1 - reserved))
+		maxTxsBytes := int64(float64(mem.config.MaxTxsBytes) * (1 - reserved))
+		if peerTxs > maxTxs || peerBytes > maxTxsBytes {
+			return ErrMempoolIsFull{
+				NumTxs:      peerTxs,
+				MaxTxs:      maxTxs,
+				TxsBytes:    peerBytes,
+				MaxTxsBytes: maxTxsBytes,
+			}
+		}
+	}
+	return nil
+}
+
+// isFull returns ErrMempoolIsFull if there is no room left for tx, once the
+// tx it may replace, if any, is removed. It is only a first check, before
+// CheckTx; admitTx checks again before adding tx.
+func (mem *CListMempool) isFull(tx types.Tx, local bool) error {
+	mem.txsMtx.RLock()
+	err := mem.capacityError(len(tx), local, mem.conflictingTx(tx), 0)
+	mem.txsMtx.RUnlock()
+	if err != nil {
+		return err
+	}
 
 	if mem.recheck.consideredFull() {
 		return ErrRecheckFull
@@ -575,9 +1487,78 @@ func (mem *CListMempool) isFull(txSize int) error {
 	return nil
 }
 
-func (mem *CListMempool) isLaneFull(txSize int, lane LaneID) error {
-	laneTxs, laneBytes := mem.LaneSizes(lane)
+// capacityError returns ErrMempoolIsFull if a tx of txSize bytes doesn't fit
+// in the mempool, along with pending bytes of other txs, once replaced, if
+// not nil, is removed. Txs received from peers can't use the share of the
+// capacity that LocalTxsReservedFraction reserves for local txs. The caller
+// must hold txsMtx.
+func (mem *CListMempool) capacityError(txSize int, local bool, replaced *mempoolTx, pending int64) error {
+	numTxs, txsBytes := int(mem.numTxs), mem.txsBytes+pending
+	peerTxs, peerBytes := int(mem.numTxs-mem.localTxs), mem.txsBytes-mem.localTxsBytes
+	if replaced != nil {
+		numTxs--
+		txsBytes -= int64(len(replaced.tx))
+		if !replaced.local {
+			peerTxs--
+			peerBytes -= int64(len(replaced.tx))
+		}
+	}
+
+	if numTxs >= mem.config.Size || int64(txSize)+txsBytes > mem.config.MaxTxsBytes {
+		return ErrMempoolIsFull{
+			NumTxs:      numTxs,
+			MaxTxs:      mem.config.Size,
+			TxsBytes:    txsBytes,
+			MaxTxsBytes: mem.config.MaxTxsBytes,
+		}
+	}
 
+	if reserved := mem.config.LocalTxsReservedFraction; reserved > 0 && !local {
+		maxTxs := int(float64(mem.config.Size) * (1 - reserved))
+		maxTxsBytes := int64(float64(mem.config.MaxTxsBytes) * (1 - reserved))
+		if peerTxs >= maxTxs || int64(txSize)+peerBytes > maxTxsBytes {
+			return ErrMempoolIsFull{
+				NumTxs:      peerTxs,
+				MaxTxs:      maxTxs,
+				TxsBytes:    peerBytes,
+				MaxTxsBytes: maxTxsBytes,
+			}
+		}
+	}
+
+	return nil
+}
+
+// reserveBytes sets n bytes aside for a tx about to be checked by the app,
+// unless they would take the size of the mempool and of the txs already being
+// checked over MaxTxsBytes. Unlike isFull, which only sees the txs already
+// added, this holds across concurrent CheckTx calls. The bytes are released
+// by admitTx, or with releaseBytes if the tx is rejected before.
+func (mem *CListMempool) reserveBytes(n int64) error {
+	mem.txsMtx.Lock()
+	defer mem.txsMtx.Unlock()
+
+	if mem.txsBytes+mem.reservedBytes+n > mem.config.MaxTxsBytes {
+		return ErrMempoolIsFull{
+			NumTxs:      int(mem.numTxs),
+			MaxTxs:      mem.config.Size,
+			TxsBytes:    mem.txsBytes + mem.reservedBytes,
+			MaxTxsBytes: mem.config.MaxTxsBytes,
+		}
+	}
+	mem.reservedBytes += n
+	return nil
+}
+
+func (mem *CListMempool) releaseBytes(n int64) {
+	mem.txsMtx.Lock()
+	defer mem.txsMtx.Unlock()
+	mem.reservedBytes -= n
+}
+
+// laneCapacityError returns ErrLaneIsFull if a tx of txSize bytes doesn't fit
+// in lane, which holds laneTxs txs of laneBytes bytes.
+func (mem *CListMempool) laneCapacityError(txSize int, lane LaneID, laneTxs int, laneBytes int64) error {
 	// The mempool is partitioned evenly across all lanes.
 	laneTxsCapacity := mem.config.Size / len(mem.sortedLanes)
 	laneBytesCapacity := mem.config.MaxTxsBytes / int64(len(mem.sortedLanes))
@@ -591,11 +1572,6 @@ func (mem *CListMempool) isLaneFull(txSize int, lane LaneID) error {
 			MaxBytes: laneBytesCapacity,
 		}
 	}
-
-	if mem.recheck.consideredFull() {
-		return ErrRecheckFull
-	}
-
 	return nil
 }
 
@@ -630,7 +1606,7 @@ func (mem *CListMempool) handleRecheckTxResponse(tx types.Tx) func(res *abci.Res
 		if (res.Code != abci.CodeTypeOK) || postCheckErr != nil {
 			// Tx became invalidated due to newly committed block.
 			mem.logger.Debug("Tx is no longer valid", "tx", log.NewLazySprintf("%X", tx.Hash()), "res", res, "postCheckErr", postCheckErr)
-			if err := mem.RemoveTxByKey(tx.Key()); err != nil {
+			if err := mem.removeTx(tx.Key(), TxRemovalInvalidated); err != nil {
 				mem.logger.Debug("Transaction could not be removed from mempool", "err", err)
 				return err
 			}
@@ -650,6 +1626,14 @@ func (mem *CListMempool) handleRecheckTxResponse(tx types.Tx) func(res *abci.Res
 			return ErrInvalidTx
 		}
 
+		if mem.cacheCheckTxRes {
+			mem.txsMtx.Lock()
+			if elem, ok := mem.txsMap[tx.Key()]; ok {
+				elem.Value.(*mempoolTx).checkTxRes = res
+			}
+			mem.txsMtx.Unlock()
+		}
+
 		return nil
 	}
 }
@@ -663,6 +1647,10 @@ func (mem *CListMempool) notifyTxsAvailable() {
 	if mem.Size() == 0 {
 		panic("notified txs available but mempool is empty!")
 	}
+	if mem.shardOf != nil {
+		mem.shardOf.notifyTxsAvailable()
+		return
+	}
 	if mem.txsAvailable != nil && mem.notifiedTxsAvailable.CompareAndSwap(false, true) {
 		// channel cap is 1, so this will send once
 		select {
@@ -677,6 +1665,11 @@ func (mem *CListMempool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
 	mem.updateMtx.RLock()
 	defer mem.updateMtx.RUnlock()
 
+	return reapMaxBytesMaxGas(NewNonBlockingIterator(mem), mem.Size(), maxBytes, maxGas)
+}
+
+// reapMaxBytesMaxGas is ReapMaxBytesMaxGas for the numTxs txs iter goes over.
+func reapMaxBytesMaxGas(iter *NonBlockingIterator, numTxs int, maxBytes, maxGas int64) types.Txs {
 	var (
 		totalGas    int64
 		runningSize int64
@@ -685,37 +1678,62 @@ func (mem *CListMempool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
 	// TODO: we will get a performance boost if we have a good estimate of avg
 	// size per tx, and set the initial capacity based off of that.
 	// txs := make([]types.Tx, 0, cmtmath.MinInt(mem.Size(), max/mem.avgTxSize))
-	txs := make([]types.Tx, 0, mem.Size())
-	iter := NewNonBlockingIterator(mem)
-	for {
-		memTx := iter.Next()
-		if memTx == nil {
-			break
-		}
-		txs = append(txs, memTx.Tx())
-
+	txs := make([]types.Tx, 0, numTxs)
+	// add appends memTx to txs unless that would exceed one of the limits, in
+	// which case it returns false.
+	add := func(memTx Entry) bool {
 		dataSize := types.ComputeProtoSizeForTxs([]types.Tx{memTx.Tx()})
 
 		// Check total size requirement
 		if maxBytes > -1 && runningSize+dataSize > maxBytes {
-			return txs[:len(txs)-1]
+			return false
 		}
 
-		runningSize += dataSize
-
 		// Check total gas requirement.
 		// If maxGas is negative, skip this check.
 		// Since newTotalGas < masGas, which
 		// must be non-negative, it follows that this won't overflow.
 		newTotalGas := totalGas + memTx.GasWanted()
 		if maxGas > -1 && newTotalGas > maxGas {
-			return txs[:len(txs)-1]
+			return false
 		}
+
+		runningSize += dataSize
 		totalGas = newTotalGas
+		txs = append(txs, memTx.Tx())
+		return true
+	}
+
+	// Deprioritized txs go after all others, in mempool order.
+	var deprioritized []Entry
+	for {
+		memTx := iter.Next()
+		if memTx == nil {
+			break
+		}
+		if isDeprioritized(memTx) {
+			deprioritized = append(deprioritized, memTx)
+			continue
+		}
+		if !add(memTx) {
+			return txs
+		}
+	}
+	for _, memTx := range deprioritized {
+		if !add(memTx) {
+			break
+		}
 	}
 	return txs
 }
 
+// PreviewReap is ReapMaxBytesMaxGas, which never removes txs from the
+// mempool: they stay until Update or Flush.
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) PreviewReap(maxBytes, maxGas int64) types.Txs {
+	return mem.ReapMaxBytesMaxGas(maxBytes, maxGas)
+}
+
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) ReapMaxTxs(max int) types.Txs {
 	mem.updateMtx.RLock()
@@ -724,19 +1742,131 @@ func (mem *CListMempool) ReapMaxTxs(max int) types.Txs {
 	if max < 0 {
 		max = mem.Size()
 	}
+	return reapMaxTxs(NewNonBlockingIterator(mem), mem.Size(), max)
+}
 
-	txs := make([]types.Tx, 0, cmtmath.MinInt(mem.Size(), max))
-	iter := NewNonBlockingIterator(mem)
+// reapMaxTxs is ReapMaxTxs for the numTxs txs iter goes over.
+func reapMaxTxs(iter *NonBlockingIterator, numTxs, max int) types.Txs {
+	txs := make([]types.Tx, 0, cmtmath.MinInt(numTxs, max))
+	// Deprioritized txs go after all others, in mempool order.
+	var deprioritized types.Txs
 	for len(txs) <= max {
 		memTx := iter.Next()
 		if memTx == nil {
 			break
 		}
+		if isDeprioritized(memTx) {
+			deprioritized = append(deprioritized, memTx.Tx())
+			continue
+		}
 		txs = append(txs, memTx.Tx())
 	}
+	for _, tx := range deprioritized {
+		if len(txs) > max {
+			break
+		}
+		txs = append(txs, tx)
+	}
 	return txs
 }
 
+func isDeprioritized(e Entry) bool {
+	memTx, ok := e.(*mempoolTx)
+	return ok && memTx.deprioritized.Load()
+}
+
+// MarkDeprioritized moves the txs with the given keys, e.g. txs the app left
+// out of its PrepareProposal response, behind all other txs in later reaps.
+// The mark stays until the tx leaves the mempool.
+func (mem *CListMempool) MarkDeprioritized(keys []types.TxKey) {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+
+	for _, key := range keys {
+		if elem, ok := mem.txsMap[key]; ok {
+			elem.Value.(*mempoolTx).deprioritized.Store(true)
+		}
+	}
+}
+
+// CheckTxResult returns the cached CheckTx response of the tx with the given
+// key, see WithCheckTxResultCache. It returns false if the tx is not in the
+// mempool, the cache is disabled, or the response was invalidated by Update
+// and not yet replaced by a recheck.
+func (mem *CListMempool) CheckTxResult(txKey types.TxKey) (*abci.CheckTxResponse, bool) {
+	mem.txsMtx.RLock()
+	defer mem.txsMtx.RUnlock()
+
+	if elem, ok := mem.txsMap[txKey]; ok {
+		if res := elem.Value.(*mempoolTx).checkTxRes; res != nil {
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+// invalidateCheckTxResults drops all cached CheckTx responses.
+func (mem *CListMempool) invalidateCheckTxResults() {
+	mem.txsMtx.Lock()
+	defer mem.txsMtx.Unlock()
+
+	for _, elem := range mem.txsMap {
+		elem.Value.(*mempoolTx).checkTxRes = nil
+	}
+}
+
+// TxStatus returns the lane, reap order position and entry time of the tx
+// with the given key. Finding the position walks the mempool in reap order.
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TxStatus(txKey types.TxKey) (TxStatus, bool) {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	mem.txsMtx.RLock()
+	elem, ok := mem.txsMap[txKey]
+	mem.txsMtx.RUnlock()
+	if !ok {
+		return TxStatus{}, false
+	}
+	return txStatus(elem.Value.(*mempoolTx), mem.sortedLanes, NewNonBlockingIterator(mem)), true
+}
+
+// txStatus returns the TxStatus of memTx, among the txs iter goes over.
+func txStatus(memTx *mempoolTx, sortedLanes []lane, iter *NonBlockingIterator) TxStatus {
+	status := TxStatus{Lane: memTx.lane, Timestamp: memTx.timestamp}
+	for _, l := range sortedLanes {
+		if l.id == memTx.lane {
+			status.Priority = l.priority
+			break
+		}
+	}
+
+	// Deprioritized txs are reaped after all others, see ReapMaxTxs.
+	deprioritized := memTx.deprioritized.Load()
+	var ahead, deprioritizedAhead int
+	seen := false
+	for e := iter.Next(); e != nil; e = iter.Next() {
+		if e == Entry(memTx) {
+			if !deprioritized {
+				break
+			}
+			seen = true
+			continue
+		}
+		switch {
+		case !isDeprioritized(e):
+			ahead++
+		case !seen:
+			deprioritizedAhead++
+		}
+	}
+	status.Position = ahead
+	if deprioritized {
+		status.Position += deprioritizedAhead
+	}
+	return status
+}
+
 // GetTxByHash returns the types.Tx with the given hash if found in the mempool, otherwise returns nil.
 func (mem *CListMempool) GetTxByHash(hash []byte) types.Tx {
 	mem.txsMtx.RLock()
@@ -788,13 +1918,19 @@ func (mem *CListMempool) Update(
 		// Mempool after:
 		//   100
 		// https://github.com/tendermint/tendermint/issues/3322.
-		if err := mem.RemoveTxByKey(tx.Key()); err != nil {
+		if err := mem.removeTx(tx.Key(), TxRemovalCommitted); err != nil {
 			mem.logger.Debug("Committed transaction not in local mempool (not an error)",
 				"tx", log.NewLazySprintf("%X", tx.Hash()),
 				"error", err.Error())
+		} else if mem.onTxCommitted != nil {
+			mem.onTxCommitted(tx.Key(), height)
 		}
 	}
 
+	if mem.cacheCheckTxRes {
+		mem.invalidateCheckTxResults()
+	}
+
 	// Recheck txs left in the mempool to remove them if they became invalid in the new state.
 	if mem.config.Recheck {
 		mem.recheckTxs()
@@ -815,6 +1951,10 @@ func (mem *CListMempool) Update(
 
 // updateSizeMetrics updates the size-related metrics of a given lane.
 func (mem *CListMempool) updateSizeMetrics(laneID LaneID) {
+	if mem.shardOf != nil {
+		mem.shardOf.updateSizeMetrics(laneID)
+		return
+	}
 	laneTxs, laneBytes := mem.LaneSizes(laneID)
 	label := string(laneID)
 	mem.metrics.LaneSize.With("lane", label).Set(float64(laneTxs))
@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	mrand "math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -18,16 +20,19 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	dbm "github.com/cometbft/cometbft-db"
 	abciclient "github.com/cometbft/cometbft/abci/client"
 	abciclimocks "github.com/cometbft/cometbft/abci/client/mocks"
 	"github.com/cometbft/cometbft/abci/example/kvstore"
 	abciserver "github.com/cometbft/cometbft/abci/server"
 	abci "github.com/cometbft/cometbft/abci/types"
+	protomem "github.com/cometbft/cometbft/api/cometbft/mempool/v1"
 	"github.com/cometbft/cometbft/config"
 	cmtrand "github.com/cometbft/cometbft/internal/rand"
 	"github.com/cometbft/cometbft/internal/test"
 	"github.com/cometbft/cometbft/libs/log"
 	"github.com/cometbft/cometbft/libs/service"
+	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/proxy"
 	"github.com/cometbft/cometbft/types"
 )
@@ -302,6 +307,50 @@ func TestMempoolAddTxLane(t *testing.T) {
 	}
 }
 
+func TestMempoolLaneFunc(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	// Route txs by their id instead of the lane set by the app, leaving the
+	// choice to the app for odd ids.
+	WithLaneFunc(func(tx types.Tx, _ *abci.CheckTxResponse) string {
+		id, _ := strconv.Atoi(strings.SplitN(string(tx), "=", 2)[0])
+		switch {
+		case id == 100:
+			return "unknown"
+		case id%2 == 0:
+			return "bar"
+		default:
+			return ""
+		}
+	})(mp)
+
+	for i := 1; i < 20; i++ {
+		tx := kvstore.NewTxFromID(i)
+		rr, err := mp.CheckTx(tx, noSender)
+		require.NoError(t, err)
+		rr.Wait()
+		require.NoError(t, rr.Error())
+
+		want := LaneID("bar")
+		if i%2 == 1 {
+			want = kvstoreAssignLane(i)
+		}
+		entry := mp.txsMap[types.Tx(tx).Key()].Value.(*mempoolTx)
+		require.Equal(t, want, entry.lane, "id %d", i)
+	}
+
+	// A tx assigned to a lane the mempool doesn't have is rejected.
+	rr, err := mp.CheckTx(kvstore.NewTxFromID(100), noSender)
+	require.NoError(t, err)
+	rr.Wait()
+	require.ErrorAs(t, rr.Error(), &ErrLaneNotFound{})
+	require.Equal(t, 19, mp.Size())
+}
+
 func kvstoreAssignLane(key int) LaneID {
 	lane := defaultLane // 3
 	if key%11 == 0 {
@@ -372,6 +421,99 @@ func TestMempoolBuildLanesInfo(t *testing.T) {
 
 // Test dropping CheckTx requests when rechecking transactions. It mocks an asynchronous connection
 // to the app.
+func TestMempoolTxCommittedCallback(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	committed := make(map[types.TxKey]int64)
+	WithTxCommittedCallback(func(key types.TxKey, height int64) {
+		committed[key] = height
+	})(mp)
+
+	txs := []types.Tx{kvstore.NewTxFromID(1), kvstore.NewTxFromID(2), kvstore.NewTxFromID(3)}
+	for _, tx := range txs {
+		_, err := mp.CheckTx(tx, "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, mp.RemoveTxByKey(txs[1].Key()))
+
+	// Only txs[0] is reported: txs[1] left the mempool for another reason
+	// and txs[3] was never in it.
+	txs = append(txs, kvstore.NewTxFromID(4))
+	err := mp.Update(2, []types.Tx{txs[0], txs[1], txs[3]}, abciResponses(3, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, mp.Size())
+	assert.Equal(t, map[types.TxKey]int64{txs[0].Key(): 2}, committed)
+}
+
+func TestMempoolLocalTxsReservedFraction(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 10
+	cfg.Mempool.LocalTxsReservedFraction = 0.3
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	id := 0
+	checkTx := func(sender p2p.ID) error {
+		id++
+		_, err := mp.CheckTx(kvstore.NewTxFromID(id), sender)
+		return err
+	}
+
+	// txs from peers can take up to 7 of the 10 slots
+	for i := 0; i < 7; i++ {
+		require.NoError(t, checkTx("peer"))
+	}
+	require.ErrorAs(t, checkTx("peer"), &ErrMempoolIsFull{})
+
+	// local txs can use the reserved slots
+	for i := 0; i < 3; i++ {
+		require.NoError(t, checkTx(noSender))
+	}
+	require.Equal(t, 10, mp.Size())
+	require.ErrorAs(t, checkTx(noSender), &ErrMempoolIsFull{})
+
+	// removing a local tx doesn't make room for txs from peers
+	require.NoError(t, mp.RemoveTxByKey(types.Tx(kvstore.NewTxFromID(10)).Key()))
+	require.ErrorAs(t, checkTx("peer"), &ErrMempoolIsFull{})
+	require.NoError(t, checkTx(noSender))
+}
+
+func TestMempoolLocalTxsReservedFractionConcurrent(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 10
+	cfg.Mempool.LocalTxsReservedFraction = 0.3
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+	// Keep the CheckTx of many txs in flight at once, past the first check of
+	// the peer share.
+	WithTxVerifier(func(types.Tx) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}, 32)(mp)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if rr, err := mp.CheckTx(kvstore.NewTxFromID(i), "peer"); err == nil {
+				rr.Wait()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// txs from peers still only take their 7 slots
+	require.Equal(t, 7, mp.Size())
+}
+
 func TestMempoolUpdateDoesNotPanicWhenApplicationMissedTx(t *testing.T) {
 	mockClient := new(abciclimocks.Client)
 	mockClient.On("Start").Return(nil)
@@ -763,6 +905,656 @@ func TestMempoolTxsBytes(t *testing.T) {
 	assert.EqualValues(t, 10, mp.SizeBytes())
 }
 
+func TestMempoolSizeHistogram(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	assert.Empty(t, mp.SizeHistogram())
+
+	tx1 := kvstore.NewRandomTx(10)
+	tx2 := kvstore.NewRandomTx(16)
+	tx3 := kvstore.NewRandomTx(17)
+	for _, tx := range []types.Tx{tx1, tx2, tx3} {
+		_, err := mp.CheckTx(tx, "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, map[int]int{16: 2, 32: 1}, mp.SizeHistogram())
+
+	err := mp.Update(1, []types.Tx{tx3}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{16: 2}, mp.SizeHistogram())
+
+	mp.Flush()
+	assert.Empty(t, mp.SizeHistogram())
+}
+
+// Txs of the form "<account>=<fee><payload>" compete for the account's slot,
+// and the highest fee wins.
+func feeReplacementPolicy() CListMempoolOption {
+	key := func(tx types.Tx) []byte {
+		acc, _, found := bytes.Cut(tx, []byte("="))
+		if !found {
+			return nil
+		}
+		return acc
+	}
+	fee := func(tx types.Tx) byte {
+		return tx[bytes.IndexByte(tx, '=')+1]
+	}
+	return WithReplacementPolicy(key, func(existing, incoming types.Tx) bool {
+		return fee(incoming) > fee(existing)
+	})
+}
+
+func TestMempoolReplacementPolicy(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	feeReplacementPolicy()(mp)
+
+	checkTx := func(tx types.Tx) error {
+		rr, err := mp.CheckTx(tx, "")
+		require.NoError(t, err)
+		return rr.Error()
+	}
+
+	tx1 := types.Tx("alice=2a")
+	other := types.Tx("bob=1a")
+	require.NoError(t, checkTx(tx1))
+	require.NoError(t, checkTx(other))
+
+	// a tx with a lower fee is rejected
+	txLow := types.Tx("alice=1aaa")
+	require.ErrorAs(t, checkTx(txLow), &ErrTxReplacementRejected{})
+
+	// on a tie, the existing tx wins
+	txTie := types.Tx("alice=2b")
+	require.ErrorAs(t, checkTx(txTie), &ErrTxReplacementRejected{})
+	require.True(t, mp.Contains(tx1.Key()))
+	require.Equal(t, 2, mp.Size())
+	require.EqualValues(t, len(tx1)+len(other), mp.SizeBytes())
+
+	// a tx with a higher fee evicts the existing one
+	txHigh := types.Tx("alice=3abc")
+	require.NoError(t, checkTx(txHigh))
+	require.False(t, mp.Contains(tx1.Key()))
+	require.True(t, mp.Contains(txHigh.Key()))
+	require.True(t, mp.Contains(other.Key()))
+	require.Equal(t, 2, mp.Size())
+	require.EqualValues(t, len(txHigh)+len(other), mp.SizeBytes())
+	require.Equal(t, txHigh, mp.GetTxByHash(txHigh.Hash()))
+	require.Nil(t, mp.GetTxByHash(tx1.Hash()))
+
+	// once the slot is freed by committing the tx, any tx can take it
+	err := mp.Update(1, []types.Tx{txHigh}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, checkTx(types.Tx("alice=0a")))
+	require.Equal(t, 2, mp.Size())
+}
+
+func TestMempoolReplacementPolicyConcurrent(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	feeReplacementPolicy()(mp)
+
+	// Txs competing for the same slot are checked at once, so that their
+	// responses are handled concurrently.
+	const numTxs = 32
+	var wg sync.WaitGroup
+	for i := 0; i < numTxs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rr, err := mp.CheckTx(types.Tx(fmt.Sprintf("alice=%c", '0'+i)), "")
+			require.NoError(t, err)
+			rr.Wait()
+		}(i)
+	}
+	wg.Wait()
+
+	// Only the tx with the highest fee is left, and it holds the slot.
+	best := types.Tx(fmt.Sprintf("alice=%c", '0'+numTxs-1))
+	require.Equal(t, 1, mp.Size())
+	require.True(t, mp.Contains(best.Key()))
+	require.Equal(t, best.Key(), mp.txsByRK["alice"])
+	require.EqualValues(t, len(best), mp.SizeBytes())
+}
+
+func TestMempoolReplacementPolicyFull(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 2
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+	feeReplacementPolicy()(mp)
+
+	checkTx := func(tx types.Tx) error {
+		rr, err := mp.CheckTx(tx, "")
+		if err != nil {
+			return err
+		}
+		return rr.Error()
+	}
+
+	tx1 := types.Tx("alice=1a")
+	other := types.Tx("bob=1a")
+	require.NoError(t, checkTx(tx1))
+	require.NoError(t, checkTx(other))
+	cfg.Mempool.MaxTxsBytes = mp.SizeBytes()
+
+	// the mempool is full, both in txs and in bytes
+	require.ErrorAs(t, checkTx(types.Tx("carol=1a")), &ErrMempoolIsFull{})
+
+	// but a tx can still replace one, as long as it isn't larger
+	txHigh := types.Tx("alice=2a")
+	require.NoError(t, checkTx(txHigh))
+	require.True(t, mp.Contains(txHigh.Key()))
+	require.False(t, mp.Contains(tx1.Key()))
+	require.Equal(t, 2, mp.Size())
+
+	require.ErrorAs(t, checkTx(types.Tx("alice=3aa")), &ErrMempoolIsFull{})
+	// a tx that may replace one is checked by the app before the policy
+	// rejects it
+	require.ErrorAs(t, checkTx(types.Tx("alice=2b")), &ErrTxReplacementRejected{})
+	require.ErrorIs(t, checkTx(types.Tx("alice=9:")), ErrInvalidTx)
+	require.True(t, mp.Contains(txHigh.Key()))
+}
+
+func TestMempoolReplacementPolicyLocalTxsReservedFraction(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 4
+	cfg.Mempool.LocalTxsReservedFraction = 0.5
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+	feeReplacementPolicy()(mp)
+
+	checkTx := func(tx types.Tx, sender p2p.ID) error {
+		rr, err := mp.CheckTx(tx, sender)
+		if err != nil {
+			return err
+		}
+		return rr.Error()
+	}
+
+	// txs from peers take their whole share of 2 slots
+	require.NoError(t, checkTx(types.Tx("alice=1a"), "peer"))
+	require.NoError(t, checkTx(types.Tx("bob=1a"), "peer"))
+	require.NoError(t, checkTx(types.Tx("carol=1a"), noSender))
+	require.ErrorAs(t, checkTx(types.Tx("dave=1a"), "peer"), &ErrMempoolIsFull{})
+
+	// a tx from a peer can replace one from a peer, but not a local one,
+	// which would take a slot reserved for local txs
+	require.NoError(t, checkTx(types.Tx("alice=2a"), "peer"))
+	require.ErrorAs(t, checkTx(types.Tx("carol=2a"), "peer"), &ErrMempoolIsFull{})
+	require.True(t, mp.Contains(types.Tx("carol=1a").Key()))
+
+	// a local tx can replace a tx from a peer, freeing a slot for peers
+	require.NoError(t, checkTx(types.Tx("bob=2a"), noSender))
+	require.NoError(t, checkTx(types.Tx("dave=1a"), "peer"))
+	require.Equal(t, 4, mp.Size())
+}
+
+func TestMempoolSoftLimitEviction(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.MaxTxsBytes = 1000
+	cfg.Mempool.SoftMaxTxsBytes = 100
+	cfg.Mempool.SoftTargetTxsBytes = 50
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	// Non-numeric keys keep all txs in the default lane, so eviction order only
+	// depends on their age.
+	newTx := func(i int) types.Tx { return kvstore.NewTx(fmt.Sprintf("k%02d", i), cmtrand.Str(6)) }
+
+	// fill the mempool up to the soft limit
+	txs := make([]types.Tx, 10)
+	for i := range txs {
+		txs[i] = newTx(i)
+		_, err := mp.CheckTx(txs[i], "")
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 100, mp.SizeBytes())
+
+	// crossing the soft limit evicts the oldest txs down to the target
+	lastTx := newTx(len(txs))
+	_, err := mp.CheckTx(lastTx, "")
+	require.NoError(t, err)
+	require.LessOrEqual(t, mp.SizeBytes(), cfg.Mempool.SoftTargetTxsBytes)
+	require.Equal(t, 5, mp.Size())
+	require.True(t, mp.Contains(lastTx.Key()))
+	for _, tx := range txs[:6] {
+		require.False(t, mp.Contains(tx.Key()))
+	}
+	for _, tx := range txs[6:] {
+		require.True(t, mp.Contains(tx.Key()))
+	}
+
+	// evicted txs are removed from the cache so they can be resubmitted
+	_, err = mp.CheckTx(txs[0], "")
+	require.NoError(t, err)
+	require.True(t, mp.Contains(txs[0].Key()))
+}
+
+func TestMempoolPinnedTxs(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.MaxTxsBytes = 1000
+	cfg.Mempool.SoftMaxTxsBytes = 100
+	cfg.Mempool.SoftTargetTxsBytes = 50
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	// txs are 10 bytes each; pin the first three, but only two fit the cap
+	newTx := func(i int) types.Tx { return kvstore.NewTx(fmt.Sprintf("k%02d", i), cmtrand.Str(6)) }
+	WithPinnedTxs(func(tx types.Tx) bool {
+		return bytes.Compare(tx, []byte("k03")) < 0
+	}, 20)(mp)
+
+	txs := make([]types.Tx, 13)
+	for i := range txs {
+		txs[i] = newTx(i)
+		_, err := mp.CheckTx(txs[i], "")
+		require.NoError(t, err)
+	}
+
+	// the unpinned bytes crossed the soft limit with the last tx, evicting
+	// the oldest unpinned txs down to the target
+	require.Equal(t, 7, mp.Size())
+	require.LessOrEqual(t, mp.evictableBytes(), cfg.Mempool.SoftTargetTxsBytes)
+	for i, tx := range txs {
+		evicted := i >= 2 && i < 8
+		require.Equal(t, !evicted, mp.Contains(tx.Key()), "tx %d", i)
+	}
+
+	// removing a pinned tx releases its share of the cap
+	require.NoError(t, mp.RemoveTxByKey(txs[0].Key()))
+	require.EqualValues(t, 10, mp.pinnedBytes)
+}
+
+func TestMempoolTxVerifier(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	verifying := make(chan types.Tx)
+	release := make(chan struct{})
+	WithTxVerifier(func(tx types.Tx) error {
+		verifying <- tx
+		<-release
+		if bytes.HasPrefix(tx, []byte("bad")) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}, 2)(mp)
+
+	checkTx := func(tx types.Tx) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			rr, err := mp.CheckTx(tx, "")
+			if err == nil {
+				err = rr.Error()
+			}
+			errc <- err
+		}()
+		return errc
+	}
+
+	good, bad := kvstore.NewTx("good", "1"), kvstore.NewTx("bad", "1")
+	goodErr, badErr := checkTx(good), checkTx(bad)
+	<-verifying
+	<-verifying
+
+	// Neither tx can be reaped until it is verified.
+	require.Zero(t, mp.Size())
+	require.Empty(t, mp.ReapMaxTxs(-1))
+
+	close(release)
+	require.NoError(t, <-goodErr)
+	require.ErrorAs(t, <-badErr, &ErrTxVerification{})
+	require.Equal(t, types.Txs{good}, mp.ReapMaxTxs(-1))
+
+	// The rejected tx is not cached, so it can be sent again.
+	require.False(t, mp.cache.Has(bad))
+}
+
+func TestMempoolMarkDeprioritized(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := make(types.Txs, 4)
+	for i := range txs {
+		txs[i] = kvstore.NewTx(fmt.Sprintf("k%d", i), "v")
+		_, err := mp.CheckTx(txs[i], "")
+		require.NoError(t, err)
+	}
+
+	mp.MarkDeprioritized([]types.TxKey{txs[0].Key(), txs[2].Key(), types.Tx("missing").Key()})
+	reordered := types.Txs{txs[1], txs[3], txs[0], txs[2]}
+	require.Equal(t, reordered, mp.ReapMaxBytesMaxGas(-1, -1))
+	require.Equal(t, reordered, mp.ReapMaxTxs(-1))
+
+	// deprioritized txs only make it in if there is room left
+	require.Equal(t, reordered[:2], mp.ReapMaxBytesMaxGas(-1, 2))
+	require.Equal(t, reordered[:3], mp.ReapMaxBytesMaxGas(-1, 3))
+}
+
+func TestMempoolTxStatus(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	start := time.Now()
+	txs := make(types.Txs, 4)
+	for i := range txs {
+		txs[i] = kvstore.NewTx(fmt.Sprintf("k%d", i), "v")
+		_, err := mp.CheckTx(txs[i], "")
+		require.NoError(t, err)
+	}
+
+	_, ok := mp.TxStatus(types.Tx("missing").Key())
+	require.False(t, ok)
+
+	for i, tx := range txs {
+		status, ok := mp.TxStatus(tx.Key())
+		require.True(t, ok)
+		require.Equal(t, i, status.Position)
+		require.Equal(t, mp.defaultLane, status.Lane)
+		require.EqualValues(t, 1, status.Priority)
+		require.False(t, status.Timestamp.Before(start))
+	}
+
+	// Positions follow the reap order.
+	mp.MarkDeprioritized([]types.TxKey{txs[0].Key(), txs[2].Key()})
+	for i, tx := range mp.ReapMaxTxs(-1) {
+		status, ok := mp.TxStatus(tx.Key())
+		require.True(t, ok)
+		require.Equal(t, i, status.Position)
+	}
+}
+
+func TestMempoolCheckTxResultCache(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	tx0, tx1 := types.Tx(kvstore.NewTx("k0", "v")), types.Tx(kvstore.NewTx("k1", "v"))
+	_, err := mp.CheckTx(tx0, "")
+	require.NoError(t, err)
+
+	// disabled by default
+	_, ok := mp.CheckTxResult(tx0.Key())
+	require.False(t, ok)
+
+	WithCheckTxResultCache()(mp)
+	_, err = mp.CheckTx(tx1, "")
+	require.NoError(t, err)
+	res, ok := mp.CheckTxResult(tx1.Key())
+	require.True(t, ok)
+	require.Equal(t, abci.CodeTypeOK, res.Code)
+	require.EqualValues(t, 1, res.GasWanted)
+
+	// the recheck on Update replaces the cached response
+	mp.Lock()
+	err = mp.Update(1, []types.Tx{tx0}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mp.Unlock()
+	require.NoError(t, err)
+	recheckRes, ok := mp.CheckTxResult(tx1.Key())
+	require.True(t, ok)
+	require.NotSame(t, res, recheckRes)
+
+	// without rechecking the cached response is dropped
+	mp.config.Recheck = false
+	mp.Lock()
+	err = mp.Update(2, nil, nil, nil, nil)
+	mp.Unlock()
+	require.NoError(t, err)
+	_, ok = mp.CheckTxResult(tx1.Key())
+	require.False(t, ok)
+}
+
+func TestMempoolWaitForTx(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	_, err := mp.WaitForTx(context.Background(), types.Tx("missing").Key())
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	tx := types.Tx(kvstore.NewTxFromID(1))
+	_, err = mp.CheckTx(tx, "")
+	require.NoError(t, err)
+
+	// a cancelled wait leaves the tx in the mempool
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = mp.WaitForTx(ctx, tx.Key())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, mp.removalWaiters)
+
+	removalCh := make(chan TxRemoval, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			removal, err := mp.WaitForTx(context.Background(), tx.Key())
+			assert.NoError(t, err)
+			removalCh <- removal
+		}()
+	}
+	require.Eventually(t, func() bool {
+		mp.txsMtx.RLock()
+		defer mp.txsMtx.RUnlock()
+		return len(mp.removalWaiters[tx.Key()]) == 2
+	}, time.Second, time.Millisecond)
+
+	mp.Lock()
+	err = mp.Update(1, []types.Tx{tx}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mp.Unlock()
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, TxRemoval{Reason: TxRemovalCommitted, Height: 1}, <-removalCh)
+	}
+
+	tx = types.Tx(kvstore.NewTxFromID(2))
+	_, err = mp.CheckTx(tx, "")
+	require.NoError(t, err)
+	go func() {
+		removal, err := mp.WaitForTx(context.Background(), tx.Key())
+		assert.NoError(t, err)
+		removalCh <- removal
+	}()
+	require.Eventually(t, func() bool {
+		mp.txsMtx.RLock()
+		defer mp.txsMtx.RUnlock()
+		return len(mp.removalWaiters[tx.Key()]) == 1
+	}, time.Second, time.Millisecond)
+	mp.Flush()
+	assert.Equal(t, TxRemovalFlushed, (<-removalCh).Reason)
+}
+
+func TestMempoolSnapshotRestore(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := addTxs(t, mp, 0, 10)
+	snapshot, err := mp.Snapshot()
+	require.NoError(t, err)
+
+	restored, cleanup2 := newMempoolWithApp(cc)
+	defer cleanup2()
+	require.NoError(t, restored.Restore(snapshot))
+	assert.ElementsMatch(t, txs, restored.ReapMaxTxs(-1))
+
+	// restoring again adds nothing, invalid txs are dropped
+	var msg protomem.Snapshot
+	require.NoError(t, msg.Unmarshal(snapshot))
+	msg.Txs = append(msg.Txs, &protomem.SnapshotTx{Tx: []byte("invalid"), Local: true})
+	snapshot, err = msg.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, restored.Restore(snapshot))
+	assert.Equal(t, len(txs), restored.Size())
+
+	require.Error(t, restored.Restore([]byte{0xff}))
+}
+
+func TestMempoolSnapshotRestoreKeepsOrigin(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 4
+	cfg.Mempool.LocalTxsReservedFraction = 0.5
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	local := types.Tx(kvstore.NewTxFromID(0))
+	fromPeers := types.Tx(kvstore.NewTxFromID(1))
+	_, err := mp.CheckTx(local, noSender)
+	require.NoError(t, err)
+	_, err = mp.CheckTx(fromPeers, "peer2")
+	require.NoError(t, err)
+	_, err = mp.CheckTx(fromPeers, "peer1")
+	require.ErrorIs(t, err, ErrTxInCache)
+	snapshot, err := mp.Snapshot()
+	require.NoError(t, err)
+
+	restored, cleanup2 := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup2()
+	require.NoError(t, restored.Restore(snapshot))
+	require.EqualValues(t, 1, restored.localTxs)
+	restored.txsMtx.RLock()
+	memTx := restored.txsMap[fromPeers.Key()].Value.(*mempoolTx)
+	restored.txsMtx.RUnlock()
+	require.False(t, memTx.local)
+	require.Equal(t, []p2p.ID{"peer1", "peer2"}, memTx.senderIDs())
+
+	// the restored tx from peers takes one of their 2 slots
+	_, err = restored.CheckTx(kvstore.NewTxFromID(2), "peer1")
+	require.NoError(t, err)
+	_, err = restored.CheckTx(kvstore.NewTxFromID(3), "peer1")
+	require.ErrorAs(t, err, &ErrMempoolIsFull{})
+
+	// so does it after ReplaceTxs, while a new tx is local
+	require.NoError(t, restored.ReplaceTxs(context.Background(), []types.Tx{fromPeers, kvstore.NewTxFromID(4)}))
+	require.EqualValues(t, 1, restored.localTxs)
+	restored.txsMtx.RLock()
+	memTx = restored.txsMap[fromPeers.Key()].Value.(*mempoolTx)
+	restored.txsMtx.RUnlock()
+	require.Equal(t, []p2p.ID{"peer1", "peer2"}, memTx.senderIDs())
+}
+
+func TestMempoolReplaceTxs(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	old := addTxs(t, mp, 0, 10)
+
+	// A cancelled context leaves the mempool untouched.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, mp.ReplaceTxs(ctx, []types.Tx{kvstore.NewTx("a", "1")}), context.Canceled)
+	assert.ElementsMatch(t, old, mp.ReapMaxTxs(-1))
+
+	// Invalid and duplicate txs are dropped, and a tx that was in the
+	// mempool before is admitted again.
+	replacement := []types.Tx{kvstore.NewTx("a", "1"), kvstore.NewTx("b", "2"), old[3]}
+	txs := append(append([]types.Tx{}, replacement...), []byte("invalid"), replacement[0])
+	require.NoError(t, mp.ReplaceTxs(context.Background(), txs))
+	assert.ElementsMatch(t, replacement, mp.ReapMaxTxs(-1))
+	assert.Equal(t, len(replacement), mp.Size())
+	assert.False(t, mp.Contains(old[0].Key()))
+
+	require.NoError(t, mp.ReplaceTxs(context.Background(), nil))
+	assert.Zero(t, mp.Size())
+	assert.Zero(t, mp.SizeBytes())
+
+	// Of txs with the same replacement key, the one the policy prefers is kept.
+	feeReplacementPolicy()(mp)
+	txs = []types.Tx{types.Tx("alice=1a"), types.Tx("bob=1a"), types.Tx("alice=2a"), types.Tx("alice=1b")}
+	require.NoError(t, mp.ReplaceTxs(context.Background(), txs))
+	assert.Equal(t, types.Txs{txs[1], txs[2]}, mp.ReapMaxTxs(-1))
+	assert.Equal(t, txs[2].Key(), mp.txsByRK["alice"])
+}
+
+func TestMempoolReplaceTxsLeavesMempoolOnError(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 5
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+
+	old := addTxs(t, mp, 0, 3)
+	sizeBytes := mp.SizeBytes()
+	others := make([]types.Tx, cfg.Mempool.Size+1)
+	for i := range others {
+		others[i] = kvstore.NewTxFromID(100 + i)
+	}
+
+	// More valid txs than fit: none of them is added, and the old txs stay.
+	require.ErrorAs(t, mp.ReplaceTxs(context.Background(), others), &ErrMempoolIsFull{})
+	assert.ElementsMatch(t, old, mp.ReapMaxTxs(-1))
+	assert.Equal(t, sizeBytes, mp.SizeBytes())
+	assert.True(t, mp.cache.Has(old[0]))
+	assert.False(t, mp.cache.Has(others[0]))
+
+	// The context is cancelled half way through checking the txs.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	numChecked := 0
+	WithPreCheck(func(types.Tx) error {
+		if numChecked++; numChecked == 2 {
+			cancel()
+		}
+		return nil
+	})(mp)
+	require.ErrorIs(t, mp.ReplaceTxs(ctx, others[:3]), context.Canceled)
+	assert.ElementsMatch(t, old, mp.ReapMaxTxs(-1))
+	assert.Equal(t, sizeBytes, mp.SizeBytes())
+}
+
+func TestMempoolPreviewReap(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	addTxs(t, mp, 0, 10)
+	size, sizeBytes := mp.Size(), mp.SizeBytes()
+	for _, limits := range [][2]int64{{-1, -1}, {100, -1}, {-1, 3}, {0, 0}} {
+		preview := mp.PreviewReap(limits[0], limits[1])
+		assert.Equal(t, mp.ReapMaxBytesMaxGas(limits[0], limits[1]), preview, "limits %v", limits)
+		assert.Equal(t, size, mp.Size())
+		assert.Equal(t, sizeBytes, mp.SizeBytes())
+	}
+}
+
+func TestTxSizeClass(t *testing.T) {
+	for size, class := range map[int]int{0: 1, 1: 1, 2: 2, 3: 4, 4: 4, 5: 8, 1024: 1024, 1025: 2048} {
+		assert.Equal(t, class, txSizeClass(size), "size %d", size)
+	}
+}
+
 func TestMempoolNoCacheOverflow(t *testing.T) {
 	mp, cleanup := newMempoolWithAsyncConnection(t)
 	defer cleanup()
@@ -876,7 +1668,7 @@ func TestMempoolNotifyTxsAvailable(t *testing.T) {
 	// Adding a new valid tx to the pool will notify a tx is available
 	tx := kvstore.NewTxFromID(1)
 	res := abci.ToCheckTxResponse(&abci.CheckTxResponse{Code: abci.CodeTypeOK})
-	err := mp.handleCheckTxResponse(tx, "")(res)
+	err := mp.handleCheckTxResponse(tx, "", 0)(res)
 	require.NoError(t, err)
 	require.Equal(t, 1, mp.Size(), "pool size mismatch")
 	require.True(t, mp.notifiedTxsAvailable.Load())
@@ -885,7 +1677,7 @@ func TestMempoolNotifyTxsAvailable(t *testing.T) {
 
 	// Receiving CheckTx response for a tx already in the pool should not notify of available txs
 	res = abci.ToCheckTxResponse(&abci.CheckTxResponse{Code: abci.CodeTypeOK})
-	err = mp.handleCheckTxResponse(tx, "")(res)
+	err = mp.handleCheckTxResponse(tx, "", 0)(res)
 	require.ErrorIs(t, ErrTxInMempool, err)
 	require.Equal(t, 1, mp.Size())
 	require.True(t, mp.notifiedTxsAvailable.Load())
@@ -1091,6 +1883,83 @@ func TestMempoolConcurrentCheckTxAndUpdate(t *testing.T) {
 	require.Zero(t, mp.Size())
 }
 
+func TestMempoolConcurrentCheckTxMaxTxsBytes(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.MaxTxsBytes = 1000
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+	// Keep the CheckTx of many txs in flight at once.
+	WithTxVerifier(func(types.Tx) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}, 32)(mp)
+
+	// Many more txs than fit are checked at once, so that the capacity checks
+	// of txs whose CheckTx is in flight overlap.
+	var wg sync.WaitGroup
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				rr, err := mp.CheckTx(kvstore.NewTxFromID(g*1000+i), noSender)
+				if err == nil {
+					rr.Wait()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	require.Positive(t, mp.SizeBytes())
+	require.LessOrEqual(t, mp.SizeBytes(), cfg.Mempool.MaxTxsBytes)
+	require.Zero(t, mp.reservedBytes)
+}
+
+func TestMempoolConcurrentCheckTxFillsToMaxTxsBytes(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	const numTxs = 64
+	txs := make([]types.Tx, numTxs)
+	for i := range txs {
+		txs[i] = kvstore.NewTxFromID(1000 + i) // all of the same size
+	}
+	cfg.Mempool.MaxTxsBytes = int64(numTxs * len(txs[0]))
+	mp, cleanup := newMempoolWithAppAndConfig(cc, cfg)
+	defer cleanup()
+	// Keep the CheckTx of all txs in flight at once.
+	WithTxVerifier(func(types.Tx) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}, numTxs)(mp)
+	// And keep each tx's callback running a while after the tx is added.
+	WithNewTxCallback(func(types.Tx) { time.Sleep(time.Millisecond) })(mp)
+
+	// Exactly as many txs as fit are checked at once: none may be rejected
+	// because the bytes of another one are counted both as reserved and as
+	// added.
+	var wg sync.WaitGroup
+	for _, tx := range txs {
+		wg.Add(1)
+		go func(tx types.Tx) {
+			defer wg.Done()
+			rr, err := mp.CheckTx(tx, noSender)
+			if assert.NoError(t, err) {
+				rr.Wait()
+				assert.NoError(t, rr.Error())
+			}
+		}(tx)
+	}
+	wg.Wait()
+
+	require.Equal(t, numTxs, mp.Size())
+	require.Equal(t, cfg.Mempool.MaxTxsBytes, mp.SizeBytes())
+	require.Zero(t, mp.reservedBytes)
+}
+
 func newMempoolWithAsyncConnection(tb testing.TB) (*CListMempool, cleanupFunc) {
 	tb.Helper()
 	sockPath := fmt.Sprintf("unix:///tmp/echo_%v.sock", cmtrand.Str(6))
@@ -1151,3 +2020,97 @@ func doUpdate(tb testing.TB, mp Mempool, height int64, txs []types.Tx) {
 	require.NoError(tb, err)
 	mp.Unlock()
 }
+
+func TestMempoolRejectedTxStats(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	require.Empty(t, mp.RejectedTxStats())
+
+	for _, tx := range []types.Tx{types.Tx("bad1"), kvstore.NewTx("k", "v"), types.Tx("bad2")} {
+		rr, err := mp.CheckTx(tx, "")
+		require.NoError(t, err)
+		rr.Wait()
+	}
+	require.Equal(t, map[uint32]int64{kvstore.CodeTypeInvalidTxFormat: 2}, mp.RejectedTxStats())
+
+	// the returned map is a copy
+	mp.RejectedTxStats()[kvstore.CodeTypeInvalidTxFormat] = 0
+	require.EqualValues(t, 2, mp.RejectedTxStats()[kvstore.CodeTypeInvalidTxFormat])
+}
+
+func TestMempoolGossipBacklog(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	backlog := newGossipBacklog(20, NopMetrics())
+	mp.gossipBacklog = backlog
+	mp.addGossipPeer("a")
+	mp.addGossipPeer("b")
+
+	// Txs of 5 bytes, received from a peer.
+	txs := []types.Tx{kvstore.NewTx("k1", "vv"), kvstore.NewTx("k2", "vv"), kvstore.NewTx("k3", "vv")}
+	entries := make([]*mempoolTx, len(txs))
+	for i, tx := range txs {
+		rr, err := mp.CheckTx(tx, "c")
+		require.NoError(t, err)
+		rr.Wait()
+		entries[i] = mp.txsMap[tx.Key()].Value.(*mempoolTx)
+	}
+	require.EqualValues(t, 30, backlog.bytes)
+
+	// b has more than its share of a backlog over the limit.
+	mp.gossipTaken("a", entries[0])
+	require.EqualValues(t, 25, backlog.bytes)
+	require.False(t, backlog.skip("a", entries[1]))
+	require.True(t, backlog.skip("b", entries[0]))
+	mp.gossipTaken("b", entries[0])
+	require.False(t, backlog.skip("b", entries[1]))
+
+	// Removed txs are no longer pending, reaching them changes nothing.
+	require.NoError(t, mp.RemoveTxByKey(txs[1].Key()))
+	require.EqualValues(t, 10, backlog.bytes)
+	mp.gossipTaken("a", entries[1])
+	require.EqualValues(t, 10, backlog.bytes)
+
+	// Local txs are never skipped.
+	localTx := types.Tx(kvstore.NewTx("local", "vvvvvvvvvvvvvvvvvvvvvvvv"))
+	callCheckTx(t, mp, types.Txs{localTx})
+	require.EqualValues(t, 70, backlog.bytes)
+	require.False(t, backlog.skip("b", mp.txsMap[localTx.Key()].Value.(*mempoolTx)))
+	require.True(t, backlog.skip("b", entries[2]))
+
+	backlog.removePeer("a")
+	require.EqualValues(t, 35, backlog.bytes)
+	mp.Flush()
+	require.Zero(t, backlog.bytes)
+}
+
+func TestMempoolReapOrderWithEqualLanePriorities(t *testing.T) {
+	lanes := map[string]uint32{"val": 1, "foo": 1, "default": 1, "bar": 1}
+	txs := make(types.Txs, 200)
+	for i := range txs {
+		txs[i] = kvstore.NewTxFromID(i)
+	}
+
+	var firstReap types.Txs
+	for run := 0; run < 10; run++ {
+		app := kvstore.NewApplication(dbm.NewMemDB(), lanes)
+		mp, cleanup := newMempoolWithApp(proxy.NewLocalClientCreator(app))
+		require.Equal(t, []LaneID{"bar", "default", "foo", "val"}, []LaneID{
+			mp.sortedLanes[0].id, mp.sortedLanes[1].id, mp.sortedLanes[2].id, mp.sortedLanes[3].id,
+		})
+		callCheckTx(t, mp, txs)
+		reaped := mp.ReapMaxTxs(-1)
+		require.Len(t, reaped, len(txs))
+		if run == 0 {
+			firstReap = reaped
+		} else {
+			require.Equal(t, firstReap, reaped, "run %d", run)
+		}
+		cleanup()
+	}
+}
@@ -3,6 +3,8 @@ package mempool
 import (
 	"errors"
 	"fmt"
+
+	"github.com/cometbft/cometbft/types"
 )
 
 // ErrTxNotFound is returned to the client if tx is not found in mempool.
@@ -31,6 +33,16 @@ var ErrLateRecheckResponse = errors.New("rechecking has finished; discard late r
 // rechecking is still in progress after a new block was committed.
 var ErrRecheckFull = errors.New("mempool is still rechecking after a new committed block, so it is considered as full")
 
+// ErrTxReplacementRejected is returned when a valid tx conflicts with a tx
+// already in the mempool and the replacement policy keeps the existing one.
+type ErrTxReplacementRejected struct {
+	Existing types.TxKey
+}
+
+func (e ErrTxReplacementRejected) Error() string {
+	return fmt.Sprintf("tx conflicts with tx %X already in mempool and was not allowed to replace it", e.Existing[:])
+}
+
 // ErrTxTooLarge defines an error when a transaction is too big to be sent in a
 // message to other peers.
 type ErrTxTooLarge struct {
@@ -100,6 +112,20 @@ func IsPreCheckError(err error) bool {
 	return errors.As(err, &ErrPreCheck{})
 }
 
+// ErrTxVerification defines an error where a transaction fails the check of
+// the TxVerifyFunc.
+type ErrTxVerification struct {
+	Err error
+}
+
+func (e ErrTxVerification) Error() string {
+	return fmt.Sprintf("tx verification: %v", e.Err)
+}
+
+func (e ErrTxVerification) Unwrap() error {
+	return e.Err
+}
+
 type ErrAppConnMempool struct {
 	Err error
 }
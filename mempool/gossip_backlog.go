@@ -0,0 +1,126 @@
+package mempool
+
+import (
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
+	"github.com/cometbft/cometbft/p2p"
+)
+
+// gossipBacklog keeps track of how many bytes of txs each peer's
+// broadcastTxRoutine is yet to reach, and decides when a peer should skip a
+// tx to bound their total, see config.MempoolConfig.MaxGossipBacklogBytes.
+//
+// The mempool calls txAdded, txRemoved and reset with txsMtx write-locked, and
+// addPeer and taken with it read-locked, so that they see a consistent view of
+// the mempool.
+type gossipBacklog struct {
+	maxBytes int64
+	metrics  *Metrics
+
+	mtx   cmtsync.Mutex
+	bytes int64 // sum of the backlogs of all peers
+	peers map[p2p.ID]*peerBacklog
+}
+
+type peerBacklog struct {
+	bytes int64
+	// Sequence number of the last tx taken from each lane. Lanes are iterated
+	// in order, so the txs of a lane with a higher one are still pending.
+	taken map[LaneID]int64
+}
+
+func newGossipBacklog(maxBytes int64, metrics *Metrics) *gossipBacklog {
+	return &gossipBacklog{
+		maxBytes: maxBytes,
+		metrics:  metrics,
+		peers:    make(map[p2p.ID]*peerBacklog),
+	}
+}
+
+// addPeer starts tracking a peer that is about to iterate over the mempool
+// from the front, with every tx still to be sent.
+func (b *gossipBacklog) addPeer(id p2p.ID, mempoolBytes int64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.peers[id] = &peerBacklog{bytes: mempoolBytes, taken: make(map[LaneID]int64)}
+	b.add(mempoolBytes)
+}
+
+func (b *gossipBacklog) removePeer(id p2p.ID) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if p, ok := b.peers[id]; ok {
+		b.add(-p.bytes)
+		delete(b.peers, id)
+	}
+}
+
+func (b *gossipBacklog) txAdded(memTx *mempoolTx) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	size := int64(len(memTx.tx))
+	for _, p := range b.peers {
+		p.bytes += size
+	}
+	b.add(size * int64(len(b.peers)))
+}
+
+func (b *gossipBacklog) txRemoved(memTx *mempoolTx) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	size := int64(len(memTx.tx))
+	for _, p := range b.peers {
+		if memTx.seq > p.taken[memTx.lane] {
+			p.bytes -= size
+			b.add(-size)
+		}
+	}
+}
+
+// reset forgets all pending txs, after the mempool was flushed.
+func (b *gossipBacklog) reset() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for _, p := range b.peers {
+		p.bytes = 0
+	}
+	b.add(-b.bytes)
+}
+
+// taken records that the peer's routine reached memTx. If it is no longer in
+// the mempool, txRemoved already took it out of the backlog.
+func (b *gossipBacklog) taken(id p2p.ID, memTx *mempoolTx, inMempool bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	p, ok := b.peers[id]
+	if !ok || memTx.seq <= p.taken[memTx.lane] {
+		return
+	}
+	p.taken[memTx.lane] = memTx.seq
+	if inMempool {
+		p.bytes -= int64(len(memTx.tx))
+		b.add(-int64(len(memTx.tx)))
+	}
+}
+
+// skip reports whether the peer should not be sent memTx because the total
+// backlog is over the limit and the peer has more than its share of it. Txs
+// submitted locally are never skipped.
+func (b *gossipBacklog) skip(id p2p.ID, memTx *mempoolTx) bool {
+	if memTx.local {
+		return false
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	p, ok := b.peers[id]
+	if !ok || b.bytes <= b.maxBytes || p.bytes*int64(len(b.peers)) <= b.maxBytes {
+		return false
+	}
+	b.metrics.GossipBacklogSkippedTxs.Add(1)
+	return true
+}
+
+// add must be called with mtx held.
+func (b *gossipBacklog) add(delta int64) {
+	b.bytes += delta
+	b.metrics.GossipBacklogBytes.Set(float64(b.bytes))
+}
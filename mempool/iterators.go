@@ -40,15 +40,21 @@ type NonBlockingIterator struct {
 }
 
 func NewNonBlockingIterator(mem *CListMempool) *NonBlockingIterator {
+	return newNonBlockingIterator(mem.sortedLanes, mem.lanes)
+}
+
+// newNonBlockingIterator returns an iterator over lanes, which hold the
+// entries of each of sortedLanes.
+func newNonBlockingIterator(sortedLanes []lane, lanes map[LaneID]*clist.CList) *NonBlockingIterator {
 	baseIter := IWRRIterator{
-		sortedLanes: mem.sortedLanes,
-		cursors:     make(map[LaneID]*clist.CElement, len(mem.lanes)),
+		sortedLanes: sortedLanes,
+		cursors:     make(map[LaneID]*clist.CElement, len(lanes)),
 		round:       1,
 	}
 	iter := &NonBlockingIterator{
 		IWRRIterator: baseIter,
 	}
-	iter.reset(mem.lanes)
+	iter.reset(lanes)
 	return iter
 }
 
@@ -231,7 +231,7 @@ func TestIteratorEmptyLanes(t *testing.T) {
 
 	tx := kvstore.NewTxFromID(1)
 	res := abci.ToCheckTxResponse(&abci.CheckTxResponse{Code: abci.CodeTypeOK})
-	err := mp.handleCheckTxResponse(tx, "")(res)
+	err := mp.handleCheckTxResponse(tx, "", 0)(res)
 	require.NoError(t, err)
 	require.Equal(t, 1, mp.Size(), "pool size mismatch")
 }
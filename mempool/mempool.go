@@ -1,8 +1,10 @@
 package mempool
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
+	"time"
 
 	abcicli "github.com/cometbft/cometbft/abci/client"
 	abci "github.com/cometbft/cometbft/abci/types"
@@ -25,7 +27,9 @@ const (
 // applications can reset their transient state on Commit.
 type Mempool interface {
 	// CheckTx executes a new transaction against the application to determine
-	// its validity and whether it should be added to the mempool.
+	// its validity and whether it should be added to the mempool. sender is
+	// the peer the tx was received from, or empty if it was submitted to this
+	// node directly (e.g. via RPC).
 	CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, error)
 
 	// RemoveTxByKey removes a transaction, identified by its key,
@@ -45,10 +49,29 @@ type Mempool interface {
 	// (~ all available transactions).
 	ReapMaxTxs(max int) types.Txs
 
+	// PreviewReap returns the txs ReapMaxBytesMaxGas would return for the
+	// same limits, in the same order, leaving the mempool untouched. It is
+	// meant for tooling showing what the next block would contain.
+	PreviewReap(maxBytes, maxGas int64) types.Txs
+
 	// GetTxByHash returns the types.Tx with the given hash if found in the mempool,
 	// otherwise returns nil.
 	GetTxByHash(hash []byte) types.Tx
 
+	// MarkDeprioritized records that the app declined the txs with the given
+	// keys when building a proposal, so that later reaps return them after
+	// all other txs. Keys of txs not in the mempool are ignored.
+	MarkDeprioritized(keys []types.TxKey)
+
+	// CheckTxResult returns the CheckTx response of the tx with the given key
+	// if the mempool caches them, so that the block builder can reuse the
+	// app's work. It returns false if no response is cached for the tx.
+	CheckTxResult(txKey types.TxKey) (*abci.CheckTxResponse, bool)
+
+	// TxStatus returns where the tx with the given key stands in the
+	// mempool. It returns false if the tx is not in the mempool.
+	TxStatus(txKey types.TxKey) (TxStatus, bool)
+
 	// Lock locks the mempool. The consensus must be able to hold lock to safely
 	// update.
 	Lock()
@@ -104,6 +127,38 @@ type Mempool interface {
 
 	// SizeBytes returns the total size of all txs in the mempool.
 	SizeBytes() int64
+
+	// SizeHistogram returns the number of txs in the mempool per size class,
+	// keyed by the smallest power of two greater than or equal to the size of
+	// the txs in the class, in bytes.
+	SizeHistogram() map[int]int
+
+	// RejectedTxStats returns the number of new txs the app rejected in
+	// CheckTx since the mempool was created, keyed by response code.
+	RejectedTxStats() map[uint32]int64
+
+	// WaitForTx blocks until the tx with the given key leaves the mempool and
+	// returns the reason it was removed. It returns ErrTxNotFound if the tx is
+	// not in the mempool, or ctx.Err() if ctx is done first.
+	WaitForTx(ctx context.Context, txKey types.TxKey) (TxRemoval, error)
+
+	// Snapshot serializes the txs currently in the mempool, along with where
+	// they came from, so they can be reloaded with Restore, e.g. after a
+	// restart.
+	Snapshot() ([]byte, error)
+
+	// Restore adds the txs from a Snapshot back to the mempool. Each tx goes
+	// through CheckTx again, so txs that are no longer valid are dropped. It
+	// returns once the app has processed all of them. Restore stops and
+	// returns an error if the mempool fills up.
+	Restore(snapshot []byte) error
+
+	// ReplaceTxs atomically replaces all txs in the mempool with txs, which go
+	// through CheckTx, e.g. for recovery tooling after a chain halt. Other
+	// callers never observe the mempool half replaced. It returns once the
+	// app has processed all txs, or an error, leaving the mempool as it was,
+	// if the valid txs don't fit or ctx is done first.
+	ReplaceTxs(ctx context.Context, txs []types.Tx) error
 }
 
 // PreCheckFunc is an optional filter executed before CheckTx and rejects
@@ -116,6 +171,78 @@ type PreCheckFunc func(types.Tx) error
 // transaction doesn't require more gas than available for the block.
 type PostCheckFunc func(types.Tx, *abci.CheckTxResponse) error
 
+// TxVerifyFunc is an optional check, e.g. of the signatures of a tx, executed
+// concurrently with CheckTx, see WithTxVerifier. The tx is rejected if an
+// error is returned.
+type TxVerifyFunc func(types.Tx) error
+
+// ReplacementKeyFunc returns the key under which txs compete for a single slot
+// in the mempool, e.g. the sender and nonce of an account-based tx. Txs for
+// which nil is returned never replace nor are replaced by other txs.
+type ReplacementKeyFunc func(types.Tx) []byte
+
+// PinnedTxFunc reports whether a tx, e.g. a governance or other protocol tx,
+// must be kept in the mempool until it is committed or becomes invalid,
+// rather than being evicted to make room for other txs.
+type PinnedTxFunc func(types.Tx) bool
+
+// LaneFunc returns the name of the lane a tx accepted by CheckTx goes into,
+// e.g. based on the fee tier the app reported in res. An empty name leaves the
+// choice to the app's LaneId in res, or the default lane if that is empty too.
+type LaneFunc func(tx types.Tx, res *abci.CheckTxResponse) string
+
+// ReplacementPolicy reports whether incoming should evict existing, a tx
+// already in the mempool with the same replacement key. If it returns false,
+// incoming is rejected and existing is kept, so ties are won by the tx that
+// arrived first.
+type ReplacementPolicy func(existing, incoming types.Tx) bool
+
+// TxRemovalReason is the reason a tx was removed from the mempool.
+type TxRemovalReason int
+
+const (
+	// TxRemovalCommitted means the tx was included in a block.
+	TxRemovalCommitted TxRemovalReason = iota + 1
+	// TxRemovalInvalidated means the tx failed a recheck.
+	TxRemovalInvalidated
+	// TxRemovalReplaced means the tx was replaced by a tx with the same
+	// replacement key.
+	TxRemovalReplaced
+	// TxRemovalEvicted means the tx was evicted to make room for new txs.
+	TxRemovalEvicted
+	// TxRemovalRemoved means the tx was removed with RemoveTxByKey.
+	TxRemovalRemoved
+	// TxRemovalFlushed means the mempool was flushed.
+	TxRemovalFlushed
+)
+
+func (r TxRemovalReason) String() string {
+	switch r {
+	case TxRemovalCommitted:
+		return "committed"
+	case TxRemovalInvalidated:
+		return "invalidated"
+	case TxRemovalReplaced:
+		return "replaced"
+	case TxRemovalEvicted:
+		return "evicted"
+	case TxRemovalRemoved:
+		return "removed"
+	case TxRemovalFlushed:
+		return "flushed"
+	default:
+		return "unknown"
+	}
+}
+
+// TxRemoval describes the removal of a tx from the mempool.
+type TxRemoval struct {
+	Reason TxRemovalReason
+	// Height of the mempool when the tx was removed. For committed txs, this
+	// is the height of the block including the tx.
+	Height int64
+}
+
 // PreCheckMaxBytes checks that the size of the transaction is smaller or equal
 // to the expected maxBytes.
 func PreCheckMaxBytes(maxBytes int64) PreCheckFunc {
@@ -168,6 +295,16 @@ type Entry interface {
 	IsSender(peerID p2p.ID) bool
 }
 
+// TxStatus describes a tx waiting in the mempool.
+type TxStatus struct {
+	Lane     LaneID
+	Priority LanePriority
+	// Index of the tx in the order txs are reaped, counting from 0.
+	Position int
+	// When the tx entered the mempool.
+	Timestamp time.Time
+}
+
 // An iterator is used to iterate through the mempool entries.
 // Multiple iterators should be allowed to run concurrently.
 type Iterator interface {
@@ -1,10 +1,12 @@
 package mempool
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	abci "github.com/cometbft/cometbft/abci/types"
 	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/types"
 )
@@ -17,6 +19,15 @@ type mempoolTx struct {
 	lane      LaneID
 	seq       int64
 	timestamp time.Time // time when entry was created
+	local     bool      // whether the tx was submitted locally rather than received from a peer
+	pinned    bool      // exempt from eviction, see WithPinnedTxs
+
+	// reaped after the other txs, see MarkDeprioritized
+	deprioritized atomic.Bool
+
+	// last CheckTx response, see WithCheckTxResultCache; guarded by the
+	// mempool's txsMtx
+	checkTxRes *abci.CheckTxResponse
 
 	// ids of peers who've sent us this tx (as a map for quick lookups).
 	// senders: PeerID -> struct{}
@@ -50,3 +61,14 @@ func (memTx *mempoolTx) addSender(peerID p2p.ID) bool {
 	}
 	return false
 }
+
+// senderIDs returns the IDs of the peers who've sent us this tx, sorted.
+func (memTx *mempoolTx) senderIDs() []p2p.ID {
+	var ids []p2p.ID
+	memTx.senders.Range(func(key, _ any) bool {
+		ids = append(ids, key.(p2p.ID))
+		return true
+	})
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
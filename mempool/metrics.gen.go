@@ -54,12 +54,24 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 
 			Buckets: stdprometheus.ExponentialBuckets(1, 3, 7),
 		}, labels).With(labelsAndValues...),
+		TxSizeClass: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "tx_size_class",
+			Help:      "Number of transactions currently in the mempool per size class. A size class is the smallest power of two greater than or equal to the size of a transaction in bytes.",
+		}, append(labels, "size_class")).With(labelsAndValues...),
 		FailedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
 			Name:      "failed_txs",
 			Help:      "Number of failed transactions.",
 		}, labels).With(labelsAndValues...),
+		RejectedTxsByCode: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "rejected_txs_by_code",
+			Help:      "Number of new transactions the application rejected in CheckTx, by response code.",
+		}, append(labels, "code")).With(labelsAndValues...),
 		RejectedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
@@ -72,6 +84,26 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "evicted_txs",
 			Help:      "Number of evicted transactions.",
 		}, labels).With(labelsAndValues...),
+		ReplacedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "replaced_txs",
+			Help:      "Number of replaced transactions.",
+		}, labels).With(labelsAndValues...),
+		AutoEvictedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "auto_evicted_txs",
+			Help:      "Number of transactions evicted above the soft size limit.",
+		}, labels).With(labelsAndValues...),
+		CheckTxDuration: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "check_tx_duration",
+			Help:      "Duration in seconds of CheckTx calls to the application.",
+
+			Buckets: stdprometheus.ExponentialBucketsRange(0.0001, 10, 6),
+		}, append(labels, "result")).With(labelsAndValues...),
 		RecheckTimes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
@@ -84,12 +116,30 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "already_received_txs",
 			Help:      "Number of duplicate transaction reception.",
 		}, labels).With(labelsAndValues...),
+		GossipAmplification: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "gossip_amplification",
+			Help:      "Average number of times each transaction is received from peers.",
+		}, labels).With(labelsAndValues...),
 		ActiveOutboundConnections: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
 			Name:      "active_outbound_connections",
 			Help:      "Number of connections being actively used for gossiping transactions (experimental feature).",
 		}, labels).With(labelsAndValues...),
+		GossipBacklogBytes: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "gossip_backlog_bytes",
+			Help:      "Total size in bytes of transactions pending gossip to peers.",
+		}, labels).With(labelsAndValues...),
+		GossipBacklogSkippedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "gossip_backlog_skipped_txs",
+			Help:      "Number of transactions not gossiped to a peer to bound the gossip backlog.",
+		}, labels).With(labelsAndValues...),
 		RecheckDurationSeconds: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
@@ -107,12 +157,20 @@ func NopMetrics() *Metrics {
 		LaneBytes:                 discard.NewGauge(),
 		TxLifeSpan:                discard.NewHistogram(),
 		TxSizeBytes:               discard.NewHistogram(),
+		TxSizeClass:               discard.NewGauge(),
 		FailedTxs:                 discard.NewCounter(),
+		RejectedTxsByCode:         discard.NewCounter(),
 		RejectedTxs:               discard.NewCounter(),
 		EvictedTxs:                discard.NewCounter(),
+		ReplacedTxs:               discard.NewCounter(),
+		AutoEvictedTxs:            discard.NewCounter(),
+		CheckTxDuration:           discard.NewHistogram(),
 		RecheckTimes:              discard.NewCounter(),
 		AlreadyReceivedTxs:        discard.NewCounter(),
+		GossipAmplification:       discard.NewGauge(),
 		ActiveOutboundConnections: discard.NewGauge(),
+		GossipBacklogBytes:        discard.NewGauge(),
+		GossipBacklogSkippedTxs:   discard.NewCounter(),
 		RecheckDurationSeconds:    discard.NewGauge(),
 	}
 }
@@ -39,12 +39,21 @@ type Metrics struct {
 	// Histogram of transaction sizes in bytes.
 	TxSizeBytes metrics.Histogram `metrics_bucketsizes:"1,3,7" metrics_buckettype:"exp"`
 
+	// Number of transactions currently in the mempool per size class. A size
+	// class is the smallest power of two greater than or equal to the size of
+	// a transaction in bytes.
+	TxSizeClass metrics.Gauge `metrics_labels:"size_class"`
+
 	// FailedTxs defines the number of failed transactions. These are
 	// transactions that failed to make it into the mempool because they were
 	// deemed invalid.
 	// metrics:Number of failed transactions.
 	FailedTxs metrics.Counter
 
+	// Number of new transactions the application rejected in CheckTx, by
+	// response code.
+	RejectedTxsByCode metrics.Counter `metrics_labels:"code"`
+
 	// RejectedTxs defines the number of rejected transactions. These are
 	// transactions that failed to make it into the mempool due to resource
 	// limits, e.g. mempool is full.
@@ -57,6 +66,23 @@ type Metrics struct {
 	// metrics:Number of evicted transactions.
 	EvictedTxs metrics.Counter
 
+	// ReplacedTxs defines the number of transactions evicted from the mempool
+	// because a conflicting transaction replaced them, according to the
+	// configured replacement policy.
+	// metrics:Number of replaced transactions.
+	ReplacedTxs metrics.Counter
+
+	// AutoEvictedTxs defines the number of valid transactions evicted because
+	// the mempool grew beyond its soft size limit.
+	// metrics:Number of transactions evicted above the soft size limit.
+	AutoEvictedTxs metrics.Counter
+
+	// CheckTxDuration measures how long the application takes to answer a
+	// CheckTx request for a new transaction, by whether it accepted or
+	// rejected the transaction.
+	// metrics:Duration in seconds of CheckTx calls to the application.
+	CheckTxDuration metrics.Histogram `metrics_bucketsizes:"0.0001, 10, 6" metrics_buckettype:"exprange" metrics_labels:"result"`
+
 	// Number of times transactions are rechecked in the mempool.
 	RecheckTimes metrics.Counter
 
@@ -64,10 +90,27 @@ type Metrics struct {
 	// metrics:Number of duplicate transaction reception.
 	AlreadyReceivedTxs metrics.Counter
 
+	// GossipAmplification is the number of transactions received from peers,
+	// including duplicates, divided by the number of those that were new to
+	// the mempool.
+	// metrics:Average number of times each transaction is received from peers.
+	GossipAmplification metrics.Gauge
+
 	// Number of connections being actively used for gossiping transactions
 	// (experimental feature).
 	ActiveOutboundConnections metrics.Gauge
 
+	// GossipBacklogBytes is the total size of the transactions that peers are
+	// yet to be sent, summed over all peers. Only tracked if
+	// max_gossip_backlog_bytes is set.
+	// metrics:Total size in bytes of transactions pending gossip to peers.
+	GossipBacklogBytes metrics.Gauge
+
+	// GossipBacklogSkippedTxs is the number of times a peer was not sent a
+	// transaction because the gossip backlog was over max_gossip_backlog_bytes.
+	// metrics:Number of transactions not gossiped to a peer to bound the gossip backlog.
+	GossipBacklogSkippedTxs metrics.Counter
+
 	// Cumulative time spent rechecking transactions
 	RecheckDurationSeconds metrics.Gauge
 }
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	abcicli "github.com/cometbft/cometbft/abci/client"
 	mempool "github.com/cometbft/cometbft/mempool"
 
@@ -50,6 +52,36 @@ func (_m *Mempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, error)
 	return r0, r1
 }
 
+// CheckTxResult provides a mock function with given fields: txKey
+func (_m *Mempool) CheckTxResult(txKey types.TxKey) (*v1.CheckTxResponse, bool) {
+	ret := _m.Called(txKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckTxResult")
+	}
+
+	var r0 *v1.CheckTxResponse
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(types.TxKey) (*v1.CheckTxResponse, bool)); ok {
+		return rf(txKey)
+	}
+	if rf, ok := ret.Get(0).(func(types.TxKey) *v1.CheckTxResponse); ok {
+		r0 = rf(txKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.CheckTxResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(types.TxKey) bool); ok {
+		r1 = rf(txKey)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // Contains provides a mock function with given fields: txKey
 func (_m *Mempool) Contains(txKey types.TxKey) bool {
 	ret := _m.Called(txKey)
@@ -121,11 +153,36 @@ func (_m *Mempool) Lock() {
 	_m.Called()
 }
 
+// MarkDeprioritized provides a mock function with given fields: keys
+func (_m *Mempool) MarkDeprioritized(keys []types.TxKey) {
+	_m.Called(keys)
+}
+
 // PreUpdate provides a mock function with given fields:
 func (_m *Mempool) PreUpdate() {
 	_m.Called()
 }
 
+// PreviewReap provides a mock function with given fields: maxBytes, maxGas
+func (_m *Mempool) PreviewReap(maxBytes int64, maxGas int64) types.Txs {
+	ret := _m.Called(maxBytes, maxGas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewReap")
+	}
+
+	var r0 types.Txs
+	if rf, ok := ret.Get(0).(func(int64, int64) types.Txs); ok {
+		r0 = rf(maxBytes, maxGas)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(types.Txs)
+		}
+	}
+
+	return r0
+}
+
 // ReapMaxBytesMaxGas provides a mock function with given fields: maxBytes, maxGas
 func (_m *Mempool) ReapMaxBytesMaxGas(maxBytes int64, maxGas int64) types.Txs {
 	ret := _m.Called(maxBytes, maxGas)
@@ -166,6 +223,26 @@ func (_m *Mempool) ReapMaxTxs(max int) types.Txs {
 	return r0
 }
 
+// RejectedTxStats provides a mock function with given fields:
+func (_m *Mempool) RejectedTxStats() map[uint32]int64 {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectedTxStats")
+	}
+
+	var r0 map[uint32]int64
+	if rf, ok := ret.Get(0).(func() map[uint32]int64); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint32]int64)
+		}
+	}
+
+	return r0
+}
+
 // RemoveTxByKey provides a mock function with given fields: txKey
 func (_m *Mempool) RemoveTxByKey(txKey types.TxKey) error {
 	ret := _m.Called(txKey)
@@ -184,6 +261,42 @@ func (_m *Mempool) RemoveTxByKey(txKey types.TxKey) error {
 	return r0
 }
 
+// ReplaceTxs provides a mock function with given fields: ctx, txs
+func (_m *Mempool) ReplaceTxs(ctx context.Context, txs []types.Tx) error {
+	ret := _m.Called(ctx, txs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceTxs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []types.Tx) error); ok {
+		r0 = rf(ctx, txs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: snapshot
+func (_m *Mempool) Restore(snapshot []byte) error {
+	ret := _m.Called(snapshot)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]byte) error); ok {
+		r0 = rf(snapshot)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Size provides a mock function with given fields:
 func (_m *Mempool) Size() int {
 	ret := _m.Called()
@@ -220,6 +333,84 @@ func (_m *Mempool) SizeBytes() int64 {
 	return r0
 }
 
+// SizeHistogram provides a mock function with given fields:
+func (_m *Mempool) SizeHistogram() map[int]int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SizeHistogram")
+	}
+
+	var r0 map[int]int
+	if rf, ok := ret.Get(0).(func() map[int]int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]int)
+		}
+	}
+
+	return r0
+}
+
+// Snapshot provides a mock function with given fields:
+func (_m *Mempool) Snapshot() ([]byte, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Snapshot")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]byte, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TxStatus provides a mock function with given fields: txKey
+func (_m *Mempool) TxStatus(txKey types.TxKey) (mempool.TxStatus, bool) {
+	ret := _m.Called(txKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TxStatus")
+	}
+
+	var r0 mempool.TxStatus
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(types.TxKey) (mempool.TxStatus, bool)); ok {
+		return rf(txKey)
+	}
+	if rf, ok := ret.Get(0).(func(types.TxKey) mempool.TxStatus); ok {
+		r0 = rf(txKey)
+	} else {
+		r0 = ret.Get(0).(mempool.TxStatus)
+	}
+
+	if rf, ok := ret.Get(1).(func(types.TxKey) bool); ok {
+		r1 = rf(txKey)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // TxsAvailable provides a mock function with given fields:
 func (_m *Mempool) TxsAvailable() <-chan struct{} {
 	ret := _m.Called()
@@ -263,6 +454,34 @@ func (_m *Mempool) Update(blockHeight int64, blockTxs types.Txs, deliverTxRespon
 	return r0
 }
 
+// WaitForTx provides a mock function with given fields: ctx, txKey
+func (_m *Mempool) WaitForTx(ctx context.Context, txKey types.TxKey) (mempool.TxRemoval, error) {
+	ret := _m.Called(ctx, txKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WaitForTx")
+	}
+
+	var r0 mempool.TxRemoval
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.TxKey) (mempool.TxRemoval, error)); ok {
+		return rf(ctx, txKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, types.TxKey) mempool.TxRemoval); ok {
+		r0 = rf(ctx, txKey)
+	} else {
+		r0 = ret.Get(0).(mempool.TxRemoval)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, types.TxKey) error); ok {
+		r1 = rf(ctx, txKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMempool creates a new instance of Mempool. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMempool(t interface {
@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"context"
 	"errors"
 
 	abcicli "github.com/cometbft/cometbft/abci/client"
@@ -35,9 +36,21 @@ func (*NopMempool) ReapMaxBytesMaxGas(int64, int64) types.Txs { return nil }
 // ReapMaxTxs always returns nil.
 func (*NopMempool) ReapMaxTxs(int) types.Txs { return nil }
 
+// PreviewReap always returns nil.
+func (*NopMempool) PreviewReap(int64, int64) types.Txs { return nil }
+
 // GetTxByHash always returns nil.
 func (*NopMempool) GetTxByHash([]byte) types.Tx { return nil }
 
+// MarkDeprioritized does nothing.
+func (*NopMempool) MarkDeprioritized([]types.TxKey) {}
+
+// CheckTxResult always returns false.
+func (*NopMempool) CheckTxResult(types.TxKey) (*abci.CheckTxResponse, bool) { return nil, false }
+
+// TxStatus always returns false.
+func (*NopMempool) TxStatus(types.TxKey) (TxStatus, bool) { return TxStatus{}, false }
+
 // Lock does nothing.
 func (*NopMempool) Lock() {}
 
@@ -80,6 +93,26 @@ func (*NopMempool) Size() int { return 0 }
 // SizeBytes always returns 0.
 func (*NopMempool) SizeBytes() int64 { return 0 }
 
+// SizeHistogram always returns nil.
+func (*NopMempool) SizeHistogram() map[int]int { return nil }
+
+// RejectedTxStats always returns an empty map.
+func (*NopMempool) RejectedTxStats() map[uint32]int64 { return map[uint32]int64{} }
+
+// WaitForTx always returns an error.
+func (*NopMempool) WaitForTx(context.Context, types.TxKey) (TxRemoval, error) {
+	return TxRemoval{}, errNotAllowed
+}
+
+// Snapshot always returns an empty snapshot.
+func (*NopMempool) Snapshot() ([]byte, error) { return nil, nil }
+
+// Restore does nothing.
+func (*NopMempool) Restore([]byte) error { return nil }
+
+// ReplaceTxs does nothing.
+func (*NopMempool) ReplaceTxs(context.Context, []types.Tx) error { return nil }
+
 // NopMempoolReactor is a mempool reactor that does nothing.
 type NopMempoolReactor struct {
 	service.BaseService
@@ -117,5 +150,8 @@ func (*NopMempoolReactor) TryAddTx(_ types.Tx, _ p2p.Peer) (*abcicli.ReqRes, err
 	return nil, nil
 }
 
+// GossipAmplification always returns 0.
+func (*NopMempoolReactor) GossipAmplification() float64 { return 0 }
+
 // SetSwitch does nothing.
 func (*NopMempoolReactor) SetSwitch(*p2p.Switch) {}
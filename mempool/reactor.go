@@ -12,6 +12,7 @@ import (
 	abcicli "github.com/cometbft/cometbft/abci/client"
 	protomem "github.com/cometbft/cometbft/api/cometbft/mempool/v1"
 	cfg "github.com/cometbft/cometbft/config"
+	cmtrand "github.com/cometbft/cometbft/internal/rand"
 	"github.com/cometbft/cometbft/libs/log"
 	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/types"
@@ -33,6 +34,16 @@ type Reactor struct {
 	// connections for different groups of peers.
 	activePersistentPeersSemaphore    *semaphore.Weighted
 	activeNonPersistentPeersSemaphore *semaphore.Weighted
+
+	// Bounds of the random delay before gossiping a tx received from a peer,
+	// see SetGossipDelay.
+	gossipDelayMin time.Duration
+	gossipDelayMax time.Duration
+
+	// Txs received from peers, including duplicates, and how many of them
+	// were new, see GossipAmplification.
+	receivedTxs       atomic.Int64
+	uniqueReceivedTxs atomic.Int64
 }
 
 // NewReactor returns a new Reactor with the given config and mempool.
@@ -49,10 +60,46 @@ func NewReactor(config *cfg.MempoolConfig, mempool *CListMempool, waitSync bool)
 	}
 	memR.activePersistentPeersSemaphore = semaphore.NewWeighted(int64(memR.config.ExperimentalMaxGossipConnectionsToPersistentPeers))
 	memR.activeNonPersistentPeersSemaphore = semaphore.NewWeighted(int64(memR.config.ExperimentalMaxGossipConnectionsToNonPersistentPeers))
+	if config.FlushNotifyPeers {
+		mempool.onFlush = memR.broadcastTxsInvalidated
+	}
+	if config.MaxGossipBacklogBytes > 0 {
+		mempool.gossipBacklog = newGossipBacklog(config.MaxGossipBacklogBytes, mempool.metrics)
+	}
 
 	return memR
 }
 
+// SetGossipDelay makes the reactor wait a random duration between min and max
+// after receiving a tx from a peer before gossiping it to another peer. If
+// that peer sends us the tx in the meantime, we don't send it back. Txs
+// submitted locally are always gossiped immediately. The default is no
+// delay. It must be called before the reactor is started.
+func (memR *Reactor) SetGossipDelay(min, max time.Duration) {
+	if max < min {
+		max = min
+	}
+	memR.gossipDelayMin, memR.gossipDelayMax = min, max
+}
+
+// gossipDelay returns how long to wait before sending entry to a peer.
+func (memR *Reactor) gossipDelay(entry Entry) time.Duration {
+	if memR.gossipDelayMax <= 0 {
+		return 0
+	}
+	memTx, ok := entry.(*mempoolTx)
+	if !ok || memTx.local {
+		return 0
+	}
+	delay := memR.gossipDelayMin
+	if jitter := memR.gossipDelayMax - memR.gossipDelayMin; jitter > 0 {
+		delay += time.Duration(cmtrand.Int63n(int64(jitter)))
+	}
+	// The delay runs from the time we received the tx, so that txs queued
+	// behind it for this peer don't accumulate delays.
+	return time.Until(memTx.timestamp.Add(delay))
+}
+
 // SetLogger sets the Logger on the reactor and the underlying mempool.
 func (memR *Reactor) SetLogger(l log.Logger) {
 	memR.Logger = l
@@ -151,7 +198,18 @@ func (memR *Reactor) Receive(e p2p.Envelope) {
 		}
 
 		for _, txBytes := range protoTxs {
-			_, _ = memR.TryAddTx(types.Tx(txBytes), e.Src)
+			_, err := memR.TryAddTx(types.Tx(txBytes), e.Src)
+			memR.countReceivedTx(err)
+		}
+
+	case *protomem.TxsInvalidated:
+		// The peer flushed these txs; don't send them back to it.
+		for _, keyBytes := range msg.GetTxKeys() {
+			if len(keyBytes) != types.TxKeySize {
+				memR.Logger.Debug("Invalid tx key in TxsInvalidated", "src", e.Src, "key", log.NewLazySprintf("%X", keyBytes))
+				continue
+			}
+			_ = memR.mempool.addSender(types.TxKey(keyBytes), e.Src.ID())
 		}
 
 	default:
@@ -163,6 +221,55 @@ func (memR *Reactor) Receive(e p2p.Envelope) {
 	// broadcasting happens from go routines per peer
 }
 
+// countReceivedTx updates the gossip amplification with the result of adding
+// a tx received from a peer. Txs rejected for other reasons than being
+// already known are not counted.
+func (memR *Reactor) countReceivedTx(err error) {
+	switch {
+	case err == nil:
+		memR.uniqueReceivedTxs.Add(1)
+	case !errors.Is(err, ErrTxInCache):
+		return
+	}
+	memR.receivedTxs.Add(1)
+	memR.mempool.metrics.GossipAmplification.Set(memR.GossipAmplification())
+}
+
+// GossipAmplification returns the average number of times each tx new to the
+// mempool was received from peers, or 0 if no new tx was received yet.
+func (memR *Reactor) GossipAmplification() float64 {
+	unique := memR.uniqueReceivedTxs.Load()
+	if unique == 0 {
+		return 0
+	}
+	return float64(memR.receivedTxs.Load()) / float64(unique)
+}
+
+// broadcastTxsInvalidated tells all peers that the txs with the given keys
+// were flushed from the mempool, so that they don't send them back. Keys are
+// sent in batches that fit in a message on the mempool channel.
+func (memR *Reactor) broadcastTxsInvalidated(keys []types.TxKey) {
+	if memR.Switch == nil {
+		return
+	}
+	batchSize := memR.config.MaxTxBytes / (types.TxKeySize + 2)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for len(keys) > 0 {
+		n := min(batchSize, len(keys))
+		keysBytes := make([][]byte, n)
+		for i := range keysBytes {
+			keysBytes[i] = keys[i][:]
+		}
+		memR.Switch.Broadcast(p2p.Envelope{
+			ChannelID: MempoolChannel,
+			Message:   &protomem.TxsInvalidated{TxKeys: keysBytes},
+		})
+		keys = keys[n:]
+	}
+}
+
 // TryAddTx attempts to add an incoming transaction to the mempool.
 // When the sender is nil, it means the transaction comes from an RPC endpoint.
 func (memR *Reactor) TryAddTx(tx types.Tx, sender p2p.Peer) (*abcicli.ReqRes, error) {
@@ -231,6 +338,11 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 		}
 	}()
 
+	if backlog := memR.mempool.gossipBacklog; backlog != nil {
+		memR.mempool.addGossipPeer(peer.ID())
+		defer backlog.removePeer(peer.ID())
+	}
+
 	iter := NewBlockingIterator(ctx, memR.mempool, string(peer.ID()))
 	for {
 		// In case of both next.NextWaitChan() and peer.Quit() are variable at the same time
@@ -244,6 +356,16 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 			continue
 		}
 
+		// Bound the txs pending for slow peers by skipping their oldest ones.
+		if backlog := memR.mempool.gossipBacklog; backlog != nil {
+			memR.mempool.gossipTaken(peer.ID(), entry)
+			if memTx, ok := entry.(*mempoolTx); ok && backlog.skip(peer.ID(), memTx) {
+				memR.Logger.Debug("Skipping transaction, gossip backlog is full",
+					"tx", log.NewLazySprintf("%X", entry.Tx().Hash()), "peer", peer.ID())
+				continue
+			}
+		}
+
 		// If we suspect that the peer is lagging behind, at least by more than
 		// one block, we don't send the transaction immediately. This code
 		// reduces the mempool size and the recheck-tx rate of the receiving
@@ -285,6 +407,23 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 			continue
 		}
 
+		// Give the peer a chance to send us the transaction first, in which
+		// case it doesn't need it from us.
+		if delay := memR.gossipDelay(entry); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-peer.Quit():
+				return
+			case <-memR.Quit():
+				return
+			}
+			if entry.IsSender(peer.ID()) {
+				memR.Logger.Debug("Skipping transaction, peer sent it during gossip delay",
+					"tx", log.NewLazySprintf("%X", txHash), "peer", peer.ID())
+				continue
+			}
+		}
+
 		for {
 			// The entry may have been removed from the mempool since it was
 			// chosen at the beginning of the loop. Skip it if that's the case.
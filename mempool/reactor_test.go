@@ -19,6 +19,7 @@ import (
 	cmtrand "github.com/cometbft/cometbft/internal/rand"
 	"github.com/cometbft/cometbft/libs/log"
 	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/p2p/mock"
 	"github.com/cometbft/cometbft/proxy"
 	"github.com/cometbft/cometbft/types"
 )
@@ -181,6 +182,40 @@ func TestReactorNoBroadcastToSender(t *testing.T) {
 }
 
 // Test that a lagging peer does not receive txs.
+func TestReactorFlushNotifyPeers(t *testing.T) {
+	config := cfg.TestConfig()
+	config.Mempool.Broadcast = false
+	config.Mempool.FlushNotifyPeers = true
+	reactors, _ := makeAndConnectReactorsNoLanes(config, 2, nil)
+	defer func() {
+		for _, r := range reactors {
+			if err := r.Stop(); err != nil {
+				require.NoError(t, err)
+			}
+		}
+	}()
+
+	txs := newUniqueTxs(10)
+	for _, r := range reactors {
+		tryAddTxs(t, r, txs)
+	}
+
+	reactors[1].mempool.Flush()
+	require.Zero(t, reactors[1].mempool.Size())
+
+	// The first node learns that the second one doesn't want the txs back.
+	flushedID := reactors[1].Switch.NodeInfo().ID()
+	isSender := func(tx types.Tx) bool {
+		reactors[0].mempool.txsMtx.RLock()
+		defer reactors[0].mempool.txsMtx.RUnlock()
+		elem, ok := reactors[0].mempool.txsMap[tx.Key()]
+		return ok && elem.Value.(*mempoolTx).IsSender(flushedID)
+	}
+	for _, tx := range txs {
+		require.Eventually(t, func() bool { return isSender(tx) }, time.Second, 10*time.Millisecond)
+	}
+}
+
 func TestMempoolReactorSendLaggingPeer(t *testing.T) {
 	config := cfg.TestConfig()
 	const n = 2
@@ -251,6 +286,56 @@ func TestMempoolReactorSendRemovedTx(t *testing.T) {
 	waitForReactors(t, txsLeft, reactors, checkTxsInMempool)
 }
 
+func TestReactorGossipDelay(t *testing.T) {
+	config := cfg.TestConfig()
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	memR := NewReactor(config.Mempool, mp, false)
+
+	remote := &mempoolTx{timestamp: time.Now(), local: false}
+	local := &mempoolTx{timestamp: time.Now(), local: true}
+	assert.Zero(t, memR.gossipDelay(remote), "no delay by default")
+
+	memR.SetGossipDelay(time.Minute, 2*time.Minute)
+	assert.Zero(t, memR.gossipDelay(local), "local txs are not delayed")
+	delay := memR.gossipDelay(remote)
+	assert.Greater(t, delay, 59*time.Second)
+	assert.LessOrEqual(t, delay, 2*time.Minute)
+
+	// the delay counts from when we received the tx
+	remote.timestamp = time.Now().Add(-3 * time.Minute)
+	assert.LessOrEqual(t, memR.gossipDelay(remote), time.Duration(0))
+}
+
+func TestReactorGossipAmplification(t *testing.T) {
+	config := cfg.TestConfig()
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	memR := NewReactor(config.Mempool, mp, false)
+	assert.Zero(t, memR.GossipAmplification())
+
+	txs := newUniqueTxs(2)
+	peer1, peer2 := mock.NewPeer(nil), mock.NewPeer(nil)
+	receive := func(src p2p.Peer, tx types.Tx) {
+		memR.Receive(p2p.Envelope{Src: src, ChannelID: MempoolChannel, Message: &memproto.Txs{Txs: [][]byte{tx}}})
+	}
+
+	receive(peer1, txs[0])
+	assert.Equal(t, 1.0, memR.GossipAmplification())
+	receive(peer2, txs[0])
+	assert.Equal(t, 2.0, memR.GossipAmplification())
+	receive(peer2, txs[1])
+	assert.Equal(t, 1.5, memR.GossipAmplification())
+
+	// Txs that are not added for other reasons are not counted.
+	receive(peer1, kvstore.NewRandomTx(config.Mempool.MaxTxBytes+1))
+	assert.Equal(t, 1.5, memR.GossipAmplification())
+}
+
 func TestMempoolReactorMaxTxBytes(t *testing.T) {
 	config := cfg.TestConfig()
 
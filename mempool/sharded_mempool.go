@@ -0,0 +1,534 @@
+package mempool
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	abcicli "github.com/cometbft/cometbft/abci/client"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/config"
+	"github.com/cometbft/cometbft/internal/clist"
+	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/proxy"
+	"github.com/cometbft/cometbft/types"
+)
+
+// ShardedMempool is a mempool that partitions txs across shards keyed by tx
+// hash, each of them a CListMempool with its own locks, so that the CheckTx
+// calls of txs in different shards are handled concurrently, up until the
+// app connection.
+//
+// Each shard gets an equal share of the capacity set by the config, i.e.
+// Size, MaxTxsBytes, the soft limits, CacheSize and the pinned bytes: a tx is
+// rejected with ErrMempoolIsFull once its own shard is full. All shards draw
+// the sequence numbers of the txs they add from the same counter, so that
+// reaping, which goes over all shards at once, returns txs in the same order
+// as a CListMempool that had admitted them in the same order would.
+//
+// If the mempool has a replacement policy, txs are keyed by their replacement
+// key instead, so that txs competing for the same slot end up in the same
+// shard. Looking txs up by key then goes through all shards.
+//
+// Lock and Unlock lock and unlock all shards, so that Update sees a consistent
+// mempool as with a CListMempool. Update then updates, and rechecks, all
+// shards concurrently.
+//
+// ShardedMempool can't be gossiped by a Reactor yet, which needs a
+// CListMempool.
+type ShardedMempool struct {
+	shards         []*CListMempool
+	replacementKey ReplacementKeyFunc // of the shards, see WithReplacementPolicy
+	sortedLanes    []lane             // of the shards
+	metrics        *Metrics
+
+	addTxSeq atomic.Int64 // sequence numbers of the txs added to all shards
+
+	notifiedTxsAvailable atomic.Bool
+	txsAvailable         chan struct{} // fires once for each height, when the mempool is not empty
+
+	sizeClassesMtx sync.Mutex
+	sizeClasses    map[int]int // the last TxSizeClass reported for each size class
+}
+
+var _ Mempool = (*ShardedMempool)(nil)
+
+// NewShardedMempool returns a new mempool with numShards shards, which share
+// the given configuration and connection to an application. The options are
+// applied to each shard.
+func NewShardedMempool(
+	cfg *config.MempoolConfig,
+	proxyAppConn proxy.AppConnMempool,
+	lanesInfo *LanesInfo,
+	height int64,
+	numShards int,
+	options ...CListMempoolOption,
+) *ShardedMempool {
+	if numShards < 1 {
+		numShards = 1
+	}
+	shardCfg := *cfg
+	shardCfg.Size = max(cfg.Size/numShards, 1)
+	shardCfg.MaxTxsBytes = cfg.MaxTxsBytes / int64(numShards)
+	shardCfg.SoftMaxTxsBytes = cfg.SoftMaxTxsBytes / int64(numShards)
+	shardCfg.SoftTargetTxsBytes = cfg.SoftTargetTxsBytes / int64(numShards)
+	if cfg.CacheSize > 0 {
+		shardCfg.CacheSize = max(cfg.CacheSize/numShards, 1)
+	}
+
+	sm := &ShardedMempool{
+		shards:      make([]*CListMempool, numShards),
+		sizeClasses: make(map[int]int),
+	}
+	for i := range sm.shards {
+		shard := NewCListMempool(&shardCfg, proxyAppConn, lanesInfo, height, options...)
+		shard.shardOf = sm
+		shard.maxPinnedBytes /= int64(numShards)
+		sm.shards[i] = shard
+	}
+	first := sm.shards[0]
+	for _, shard := range sm.shards[1:] {
+		// The workers verifying txs are shared, so that there are as many
+		// of them as set by WithTxVerifier.
+		shard.verifier = first.verifier
+	}
+	if first.replacementPolicy != nil {
+		sm.replacementKey = first.replacementKey
+	}
+	sm.sortedLanes = first.sortedLanes
+	sm.metrics = first.metrics
+	return sm
+}
+
+// shardIndex returns the index of the shard of the tx with the given key.
+func (sm *ShardedMempool) shardIndex(txKey types.TxKey) int {
+	return int(binary.BigEndian.Uint64(txKey[:8]) % uint64(len(sm.shards)))
+}
+
+// shardFor returns the index of the shard tx goes to: the one of its key or,
+// if the mempool has a replacement policy and tx has a replacement key, of
+// the key of its replacement key.
+func (sm *ShardedMempool) shardFor(tx types.Tx) int {
+	if sm.replacementKey != nil {
+		if rk := sm.replacementKey(tx); rk != nil {
+			return sm.shardIndex(types.Tx(rk).Key())
+		}
+	}
+	return sm.shardIndex(tx.Key())
+}
+
+// shardOfKey returns the shard holding the tx with the given key, if any, or
+// else the shard a tx with that key and no replacement key goes to.
+func (sm *ShardedMempool) shardOfKey(txKey types.TxKey) *CListMempool {
+	if sm.replacementKey != nil {
+		for _, shard := range sm.shards {
+			if shard.Contains(txKey) {
+				return shard
+			}
+		}
+	}
+	return sm.shards[sm.shardIndex(txKey)]
+}
+
+// CheckTx checks tx in its shard, see CListMempool.CheckTx.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) CheckTx(tx types.Tx, sender p2p.ID) (*abcicli.ReqRes, error) {
+	return sm.shards[sm.shardFor(tx)].CheckTx(tx, sender)
+}
+
+func (sm *ShardedMempool) addSender(txKey types.TxKey, sender p2p.ID) error {
+	return sm.shardOfKey(txKey).addSender(txKey, sender)
+}
+
+// RemoveTxByKey removes a transaction from its shard.
+func (sm *ShardedMempool) RemoveTxByKey(txKey types.TxKey) error {
+	return sm.shardOfKey(txKey).RemoveTxByKey(txKey)
+}
+
+// rLockShards read-locks the update lock of all shards, in order, so that a
+// reap sees no Update. It returns the function unlocking them.
+func (sm *ShardedMempool) rLockShards() func() {
+	for _, shard := range sm.shards {
+		shard.updateMtx.RLock()
+	}
+	return func() {
+		for i := len(sm.shards) - 1; i >= 0; i-- {
+			sm.shards[i].updateMtx.RUnlock()
+		}
+	}
+}
+
+// mergedIterator returns an iterator over the txs of all shards, with the
+// txs of each lane in the order they were added, across shards. The caller
+// must hold the update lock of all shards.
+func (sm *ShardedMempool) mergedIterator() *NonBlockingIterator {
+	lanes := make(map[LaneID]*clist.CList, len(sm.sortedLanes))
+	for _, l := range sm.sortedLanes {
+		var entries []*mempoolTx
+		for _, shard := range sm.shards {
+			shard.txsMtx.RLock()
+			for e := shard.lanes[l.id].Front(); e != nil; e = e.Next() {
+				entries = append(entries, e.Value.(*mempoolTx))
+			}
+			shard.txsMtx.RUnlock()
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+		lanes[l.id] = clist.New()
+		for _, memTx := range entries {
+			lanes[l.id].PushBack(memTx)
+		}
+	}
+	return newNonBlockingIterator(sm.sortedLanes, lanes)
+}
+
+// ReapMaxBytesMaxGas reaps txs from all shards, see
+// CListMempool.ReapMaxBytesMaxGas.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
+	defer sm.rLockShards()()
+	return reapMaxBytesMaxGas(sm.mergedIterator(), sm.Size(), maxBytes, maxGas)
+}
+
+// ReapMaxTxs reaps up to max txs from all shards, see CListMempool.ReapMaxTxs.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) ReapMaxTxs(max int) types.Txs {
+	defer sm.rLockShards()()
+	if max < 0 {
+		max = sm.Size()
+	}
+	return reapMaxTxs(sm.mergedIterator(), sm.Size(), max)
+}
+
+// PreviewReap is ReapMaxBytesMaxGas, which never removes txs from the
+// mempool.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) PreviewReap(maxBytes, maxGas int64) types.Txs {
+	return sm.ReapMaxBytesMaxGas(maxBytes, maxGas)
+}
+
+// GetTxByHash returns the tx with the given hash from its shard.
+func (sm *ShardedMempool) GetTxByHash(hash []byte) types.Tx {
+	return sm.shardOfKey(types.TxKey(hash)).GetTxByHash(hash)
+}
+
+// MarkDeprioritized marks the txs with the given keys in their shards, see
+// CListMempool.MarkDeprioritized.
+func (sm *ShardedMempool) MarkDeprioritized(keys []types.TxKey) {
+	for _, key := range keys {
+		sm.shardOfKey(key).MarkDeprioritized([]types.TxKey{key})
+	}
+}
+
+// CheckTxResult returns the cached CheckTx response of the tx with the given
+// key, see CListMempool.CheckTxResult.
+func (sm *ShardedMempool) CheckTxResult(txKey types.TxKey) (*abci.CheckTxResponse, bool) {
+	return sm.shardOfKey(txKey).CheckTxResult(txKey)
+}
+
+// TxStatus returns where the tx with the given key stands among the txs of
+// all shards, see CListMempool.TxStatus.
+func (sm *ShardedMempool) TxStatus(txKey types.TxKey) (TxStatus, bool) {
+	defer sm.rLockShards()()
+
+	shard := sm.shardOfKey(txKey)
+	shard.txsMtx.RLock()
+	elem, ok := shard.txsMap[txKey]
+	shard.txsMtx.RUnlock()
+	if !ok {
+		return TxStatus{}, false
+	}
+	return txStatus(elem.Value.(*mempoolTx), sm.sortedLanes, sm.mergedIterator()), true
+}
+
+// Lock locks all shards, in order.
+func (sm *ShardedMempool) Lock() {
+	for _, shard := range sm.shards {
+		shard.Lock()
+	}
+}
+
+// Unlock unlocks all shards.
+func (sm *ShardedMempool) Unlock() {
+	for i := len(sm.shards) - 1; i >= 0; i-- {
+		sm.shards[i].Unlock()
+	}
+}
+
+// PreUpdate signals all shards that a new update is coming.
+func (sm *ShardedMempool) PreUpdate() {
+	for _, shard := range sm.shards {
+		shard.PreUpdate()
+	}
+}
+
+// Update removes the committed txs from their shards, and updates all shards
+// concurrently, see CListMempool.Update. The caller must hold Lock.
+func (sm *ShardedMempool) Update(
+	height int64,
+	txs types.Txs,
+	txResults []*abci.ExecTxResult,
+	preCheck PreCheckFunc,
+	postCheck PostCheckFunc,
+) error {
+	shardTxs := make([]types.Txs, len(sm.shards))
+	shardResults := make([][]*abci.ExecTxResult, len(sm.shards))
+	for i, tx := range txs {
+		s := sm.shardFor(tx)
+		shardTxs[s] = append(shardTxs[s], tx)
+		shardResults[s] = append(shardResults[s], txResults[i])
+	}
+
+	sm.notifiedTxsAvailable.Store(false)
+	errs := make([]error, len(sm.shards))
+	var wg sync.WaitGroup
+	for i, shard := range sm.shards {
+		wg.Add(1)
+		go func(i int, shard *CListMempool) {
+			defer wg.Done()
+			errs[i] = shard.Update(height, shardTxs[i], shardResults[i], preCheck, postCheck)
+		}(i, shard)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// FlushAppConn flushes the app connection the shards share.
+//
+// NOTE: Lock/Unlock must be managed by the caller.
+func (sm *ShardedMempool) FlushAppConn() error {
+	return sm.shards[0].FlushAppConn()
+}
+
+// Flush removes all txs from all shards and their caches.
+func (sm *ShardedMempool) Flush() {
+	sm.Lock()
+	keys := make([][]types.TxKey, len(sm.shards))
+	for s, shard := range sm.shards {
+		keys[s] = shard.flushLocked()
+	}
+	sm.Unlock()
+
+	for s, shard := range sm.shards {
+		if shard.onFlush != nil && len(keys[s]) > 0 {
+			shard.onFlush(keys[s])
+		}
+	}
+}
+
+// Contains returns true iff the tx with the given key is in its shard.
+func (sm *ShardedMempool) Contains(txKey types.TxKey) bool {
+	return sm.shardOfKey(txKey).Contains(txKey)
+}
+
+// TxsAvailable returns a channel which fires once for every height, and only
+// when transactions are available in one of the shards.
+func (sm *ShardedMempool) TxsAvailable() <-chan struct{} {
+	return sm.txsAvailable
+}
+
+// EnableTxsAvailable initializes the TxsAvailable channel.
+func (sm *ShardedMempool) EnableTxsAvailable() {
+	sm.txsAvailable = make(chan struct{}, 1)
+}
+
+// notifyTxsAvailable is called by the shards.
+func (sm *ShardedMempool) notifyTxsAvailable() {
+	if sm.txsAvailable != nil && sm.notifiedTxsAvailable.CompareAndSwap(false, true) {
+		// channel cap is 1, so this will send once
+		select {
+		case sm.txsAvailable <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SetLogger sets the logger of each shard.
+func (sm *ShardedMempool) SetLogger(l log.Logger) {
+	for i, shard := range sm.shards {
+		shard.SetLogger(l.With("shard", i))
+	}
+}
+
+// Size returns the number of txs in all shards.
+func (sm *ShardedMempool) Size() int {
+	size := 0
+	for _, shard := range sm.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// SizeBytes returns the total size of the txs in all shards.
+func (sm *ShardedMempool) SizeBytes() int64 {
+	var size int64
+	for _, shard := range sm.shards {
+		size += shard.SizeBytes()
+	}
+	return size
+}
+
+// SizeHistogram returns the number of txs in all shards per size class, see
+// CListMempool.SizeHistogram.
+func (sm *ShardedMempool) SizeHistogram() map[int]int {
+	hist := make(map[int]int)
+	for _, shard := range sm.shards {
+		for class, n := range shard.SizeHistogram() {
+			hist[class] += n
+		}
+	}
+	return hist
+}
+
+// RejectedTxStats returns the number of new txs the app rejected in CheckTx,
+// in all shards, keyed by response code.
+func (sm *ShardedMempool) RejectedTxStats() map[uint32]int64 {
+	stats := make(map[uint32]int64)
+	for _, shard := range sm.shards {
+		for code, n := range shard.RejectedTxStats() {
+			stats[code] += n
+		}
+	}
+	return stats
+}
+
+// LaneSizes returns the number of txs in the given lane, in all shards, and
+// their total size.
+func (sm *ShardedMempool) LaneSizes(lane LaneID) (numTxs int, bytes int64) {
+	for _, shard := range sm.shards {
+		n, b := shard.LaneSizes(lane)
+		numTxs += n
+		bytes += b
+	}
+	return numTxs, bytes
+}
+
+// updateSizeMetrics updates the size-related metrics of a given lane, and of
+// the whole mempool, for the shards.
+func (sm *ShardedMempool) updateSizeMetrics(laneID LaneID) {
+	laneTxs, laneBytes := sm.LaneSizes(laneID)
+	label := string(laneID)
+	sm.metrics.LaneSize.With("lane", label).Set(float64(laneTxs))
+	sm.metrics.LaneBytes.With("lane", label).Set(float64(laneBytes))
+	sm.metrics.Size.Set(float64(sm.Size()))
+	sm.metrics.SizeBytes.Set(float64(sm.SizeBytes()))
+
+	hist := sm.SizeHistogram()
+	sm.sizeClassesMtx.Lock()
+	defer sm.sizeClassesMtx.Unlock()
+	for class := range sm.sizeClasses {
+		if _, ok := hist[class]; !ok {
+			sm.metrics.TxSizeClass.With("size_class", strconv.Itoa(class)).Set(0)
+		}
+	}
+	for class, n := range hist {
+		sm.metrics.TxSizeClass.With("size_class", strconv.Itoa(class)).Set(float64(n))
+	}
+	sm.sizeClasses = hist
+}
+
+// WaitForTx blocks until the tx with the given key is removed from its
+// shard, see CListMempool.WaitForTx.
+func (sm *ShardedMempool) WaitForTx(ctx context.Context, txKey types.TxKey) (TxRemoval, error) {
+	return sm.shardOfKey(txKey).WaitForTx(ctx, txKey)
+}
+
+// Snapshot returns the txs in all shards, in reap order, see
+// CListMempool.Snapshot.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) Snapshot() ([]byte, error) {
+	unlock := sm.rLockShards()
+	entries := reapEntries(sm.mergedIterator())
+	unlock()
+
+	return encodeSnapshot(entries)
+}
+
+// Restore rechecks the txs of a Snapshot and adds the valid ones to their
+// shards, in the order of the snapshot, see CListMempool.Restore.
+//
+// Safe for concurrent use by multiple goroutines.
+func (sm *ShardedMempool) Restore(snapshot []byte) error {
+	return restoreSnapshot(sm, snapshot, sm.shards[0].logger)
+}
+
+// ReplaceTxs replaces the txs in all shards with txs, see
+// CListMempool.ReplaceTxs. All shards stay locked until the txs of all of
+// them are checked, and all of them are swapped at once, so that the mempool
+// is left as it was if the txs of one shard don't fit.
+func (sm *ShardedMempool) ReplaceTxs(ctx context.Context, txs []types.Tx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	// Check the txs of each shard, then swap them all at once.
+	shardTxs := make([][]types.Tx, len(sm.shards))
+	indexes := make([][]int, len(sm.shards)) // in txs, of shardTxs
+	for i, tx := range txs {
+		s := sm.shardFor(tx)
+		shardTxs[s] = append(shardTxs[s], tx)
+		indexes[s] = append(indexes[s], i)
+	}
+	checked := make([][]checkedTx, len(sm.shards))
+	for s, shard := range sm.shards {
+		var err error
+		if checked[s], err = shard.checkReplacementTxs(ctx, shardTxs[s]); err != nil {
+			return err
+		}
+	}
+
+	for _, shard := range sm.shards {
+		shard.txsMtx.Lock()
+	}
+	unlock := func() {
+		for i := len(sm.shards) - 1; i >= 0; i-- {
+			sm.shards[i].txsMtx.Unlock()
+		}
+	}
+	for s, shard := range sm.shards {
+		shard.keepOrigins(checked[s])
+		if err := shard.replacementCapacityError(checked[s]); err != nil {
+			unlock()
+			return err
+		}
+	}
+	keys := make([][]types.TxKey, len(sm.shards))
+	for s, shard := range sm.shards {
+		keys[s] = shard.flushTxs()
+	}
+	// Add the txs in the order of txs, so that they are reaped in that order.
+	type shardTx struct {
+		shard int
+		index int // in txs
+		tx    checkedTx
+	}
+	var all []shardTx
+	for s := range sm.shards {
+		for _, c := range checked[s] {
+			all = append(all, shardTx{shard: s, index: indexes[s][c.index], tx: c})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].index < all[j].index })
+	for _, c := range all {
+		sm.shards[c.shard].addCheckedTx(c.tx)
+	}
+	unlock()
+
+	for s, shard := range sm.shards {
+		shard.txsReplaced(checked[s], keys[s])
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package mempool
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cometbft/cometbft/abci/example/kvstore"
+	"github.com/cometbft/cometbft/config"
+	"github.com/cometbft/cometbft/internal/test"
+	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/proxy"
+	"github.com/cometbft/cometbft/types"
+)
+
+func newShardedMempoolWithAppAndConfig(
+	cc proxy.ClientCreator,
+	cfg *config.Config,
+	numShards int,
+	options ...CListMempoolOption,
+) (*ShardedMempool, cleanupFunc) {
+	appConnMem, _ := cc.NewABCIMempoolClient()
+	appConnMem.SetLogger(log.TestingLogger().With("module", "abci-client", "connection", "mempool"))
+	if err := appConnMem.Start(); err != nil {
+		panic(err)
+	}
+
+	appConnQuery, _ := cc.NewABCIQueryClient()
+	appConnQuery.SetLogger(log.TestingLogger().With("module", "abci-client", "connection", "query"))
+	if err := appConnQuery.Start(); err != nil {
+		panic(err)
+	}
+	appInfoRes, err := appConnQuery.Info(context.TODO(), proxy.InfoRequest)
+	if err != nil {
+		panic(err)
+	}
+	lanesInfo, err := BuildLanesInfo(appInfoRes.LanePriorities, appInfoRes.DefaultLane)
+	if err != nil {
+		panic(err)
+	}
+	mp := NewShardedMempool(cfg.Mempool, appConnMem, lanesInfo, 0, numShards, options...)
+	mp.SetLogger(*mempoolLogger("info"))
+
+	return mp, func() { os.RemoveAll(cfg.RootDir) }
+}
+
+func newShardedMempoolWithApp(cc proxy.ClientCreator, numShards int, options ...CListMempoolOption) (*ShardedMempool, cleanupFunc) {
+	return newShardedMempoolWithAppAndConfig(cc, test.ResetTestRoot("mempool_test"), numShards, options...)
+}
+
+func TestShardedMempoolReapOrder(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	smp, cleanup2 := newShardedMempoolWithApp(cc, 4)
+	defer cleanup2()
+
+	txs := addTxs(t, mp, 0, 100)
+	addTxs(t, smp, 0, 100)
+	require.NoError(t, mp.FlushAppConn())
+	require.NoError(t, smp.FlushAppConn())
+	for _, shard := range smp.shards {
+		require.NotZero(t, shard.Size(), "all shards should get txs")
+	}
+	require.Equal(t, len(txs), smp.Size())
+	require.Equal(t, mp.SizeBytes(), smp.SizeBytes())
+	require.Equal(t, mp.SizeHistogram(), smp.SizeHistogram())
+
+	// Txs are reaped in the same order, across lanes and shards, as from a
+	// single mempool.
+	assert.Equal(t, mp.ReapMaxTxs(-1), smp.ReapMaxTxs(-1))
+	assert.Equal(t, mp.ReapMaxTxs(10), smp.ReapMaxTxs(10))
+	assert.Equal(t, mp.ReapMaxBytesMaxGas(-1, -1), smp.ReapMaxBytesMaxGas(-1, -1))
+	assert.Equal(t, mp.ReapMaxBytesMaxGas(500, -1), smp.ReapMaxBytesMaxGas(500, -1))
+	for _, tx := range []types.Tx{txs[0], txs[42], txs[99]} {
+		want, ok := mp.TxStatus(tx.Key())
+		require.True(t, ok)
+		got, ok := smp.TxStatus(tx.Key())
+		require.True(t, ok)
+		assert.Equal(t, want.Lane, got.Lane)
+		assert.Equal(t, want.Position, got.Position)
+	}
+
+	// A snapshot restores to the same order.
+	snapshot, err := smp.Snapshot()
+	require.NoError(t, err)
+	restored, cleanup3 := newShardedMempoolWithApp(cc, 3)
+	defer cleanup3()
+	require.NoError(t, restored.Restore(snapshot))
+	assert.Equal(t, mp.ReapMaxTxs(-1), restored.ReapMaxTxs(-1))
+}
+
+func TestShardedMempoolUpdate(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newShardedMempoolWithApp(cc, 4)
+	defer cleanup()
+	mp.EnableTxsAvailable()
+
+	txs := addTxs(t, mp, 0, 20)
+	require.NoError(t, mp.FlushAppConn())
+	// TxsAvailable fires once for all shards.
+	ensureFire(t, mp.TxsAvailable(), 100)
+	ensureNoFire(t, mp.TxsAvailable())
+
+	doUpdate(t, mp, 1, txs[:10])
+	assert.Equal(t, 10, mp.Size())
+	for _, tx := range txs[:10] {
+		assert.False(t, mp.Contains(tx.Key()))
+	}
+	for _, tx := range txs[10:] {
+		assert.True(t, mp.Contains(tx.Key()))
+	}
+	// Committed txs stay in the cache of their shard.
+	_, err := mp.CheckTx(txs[0], "")
+	require.ErrorIs(t, err, ErrTxInCache)
+
+	// Txs are left, so it fires again for the new height.
+	ensureFire(t, mp.TxsAvailable(), 100)
+
+	mp.Flush()
+	assert.Zero(t, mp.Size())
+	assert.Zero(t, mp.SizeBytes())
+}
+
+func TestShardedMempoolConcurrentCheckTx(t *testing.T) {
+	app := kvstore.NewInMemoryApplication()
+	cc := proxy.NewUnsyncLocalClientCreator(app)
+	mp, cleanup := newShardedMempoolWithApp(cc, 4)
+	defer cleanup()
+
+	const numWorkers, numTxs = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numTxs; i++ {
+				rr, err := mp.CheckTx(kvstore.NewTxFromID(w*numTxs+i), p2p.ID("peer"))
+				if assert.NoError(t, err) {
+					rr.Wait()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.NoError(t, mp.FlushAppConn())
+
+	reaped := mp.ReapMaxTxs(-1)
+	assert.Len(t, reaped, numWorkers*numTxs)
+	assert.Equal(t, numWorkers*numTxs, mp.Size())
+	seen := make(map[types.TxKey]struct{}, len(reaped))
+	for _, tx := range reaped {
+		seen[tx.Key()] = struct{}{}
+	}
+	assert.Len(t, seen, len(reaped))
+}
+
+func TestShardedMempoolReplacementPolicy(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	mp, cleanup := newShardedMempoolWithApp(cc, 4, feeReplacementPolicy())
+	defer cleanup()
+
+	checkTx := func(tx types.Tx) error {
+		rr, err := mp.CheckTx(tx, "")
+		if err != nil {
+			return err
+		}
+		return rr.Error()
+	}
+
+	// Txs with the same replacement key go to the same shard, where the
+	// policy decides between them.
+	for _, tx := range []types.Tx{types.Tx("alice=1a"), types.Tx("bob=1a"), types.Tx("alice=2a")} {
+		require.NoError(t, checkTx(tx))
+	}
+	require.ErrorAs(t, checkTx(types.Tx("alice=1b")), &ErrTxReplacementRejected{})
+	assert.Equal(t, types.Txs{types.Tx("bob=1a"), types.Tx("alice=2a")}, mp.ReapMaxTxs(-1))
+
+	// Txs are found by key whatever their shard.
+	tx := types.Tx("alice=2a")
+	assert.True(t, mp.Contains(tx.Key()))
+	assert.Equal(t, tx, mp.GetTxByHash(tx.Hash()))
+	require.NoError(t, mp.RemoveTxByKey(tx.Key()))
+	assert.False(t, mp.Contains(tx.Key()))
+	assert.Equal(t, 1, mp.Size())
+}
+
+func TestShardedMempoolReplaceTxs(t *testing.T) {
+	app := kvstore.NewInMemoryApplicationWithoutLanes()
+	cc := proxy.NewLocalClientCreator(app)
+	cfg := test.ResetTestRoot("mempool_test")
+	cfg.Mempool.Size = 8 // 4 txs per shard
+	mp, cleanup := newShardedMempoolWithAppAndConfig(cc, cfg, 2)
+	defer cleanup()
+
+	old := addTxs(t, mp, 0, 4)
+	require.NoError(t, mp.FlushAppConn())
+
+	// Txs are added back in order, across shards.
+	txs := make([]types.Tx, 0, 6)
+	perShard := make([]int, 2)
+	for i := 100; len(txs) < cap(txs); i++ {
+		tx := types.Tx(kvstore.NewTxFromID(i))
+		if s := mp.shardFor(tx); perShard[s] < 3 {
+			perShard[s]++
+			txs = append(txs, tx)
+		}
+	}
+	require.NoError(t, mp.ReplaceTxs(context.Background(), txs))
+	assert.Equal(t, types.Txs(txs), mp.ReapMaxTxs(-1))
+	for _, tx := range old {
+		assert.False(t, mp.Contains(tx.Key()))
+	}
+
+	// If the txs of one shard don't fit, no shard is touched.
+	tooMany := make([]types.Tx, 0, 5)
+	for i := 200; len(tooMany) < cap(tooMany); i++ {
+		if tx := types.Tx(kvstore.NewTxFromID(i)); mp.shardFor(tx) == 0 {
+			tooMany = append(tooMany, tx)
+		}
+	}
+	for i := 300; ; i++ {
+		if tx := types.Tx(kvstore.NewTxFromID(i)); mp.shardFor(tx) == 1 {
+			tooMany = append(tooMany, tx)
+			break
+		}
+	}
+	require.ErrorAs(t, mp.ReplaceTxs(context.Background(), tooMany), &ErrMempoolIsFull{})
+	assert.Equal(t, types.Txs(txs), mp.ReapMaxTxs(-1))
+	assert.Equal(t, len(txs), mp.Size())
+}
@@ -0,0 +1,30 @@
+package mempool
+
+import "github.com/cometbft/cometbft/types"
+
+// txVerifier runs the TxVerifyFunc of the mempool on up to a fixed number of
+// txs at a time, concurrently with their CheckTx, see WithTxVerifier.
+type txVerifier struct {
+	verify TxVerifyFunc
+	sem    chan struct{} // holds a token for each tx being verified
+}
+
+func newTxVerifier(verify TxVerifyFunc, numWorkers int) *txVerifier {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	return &txVerifier{verify: verify, sem: make(chan struct{}, numWorkers)}
+}
+
+// start verifies tx in the background and returns the channel the result will
+// be sent on. It blocks while all workers are busy, so that CheckTx callers
+// can't queue up more work than the workers can take.
+func (v *txVerifier) start(tx types.Tx) <-chan error {
+	v.sem <- struct{}{}
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-v.sem }()
+		done <- v.verify(tx)
+	}()
+	return done
+}
@@ -142,6 +142,15 @@ func CustomReactors(reactors map[string]p2p.Reactor) Option {
 	}
 }
 
+// AcceptPolicy sets the policy the node's transport consults before taking on
+// an inbound connection, for example to reject peers while the node is
+// overloaded. By default all connections are accepted.
+func AcceptPolicy(policy p2p.AcceptPolicy) Option {
+	return func(n *Node) {
+		p2p.MultiplexTransportAcceptPolicy(policy)(n.transport)
+	}
+}
+
 // StateProvider overrides the state provider used by state sync to retrieve trusted app hashes and
 // build a State object for bootstrapping the node.
 // WARNING: this interface is considered unstable and subject to change.
@@ -535,7 +544,7 @@ func NewNodeWithCliParams(ctx context.Context,
 	// Note we currently use the addrBook regardless at least for AddOurAddress
 	var pexReactor *pex.Reactor
 	if config.P2P.PexReactor {
-		pexReactor = createPEXReactorAndAddToSwitch(addrBook, config, sw, logger)
+		pexReactor = createPEXReactorAndAddToSwitch(addrBook, config, sw, p2pMetrics, logger)
 	}
 
 	// Add private IDs to addrbook to block those peers being added
@@ -1063,6 +1072,9 @@ func makeNodeInfo(
 	}
 
 	nodeInfo.ListenAddr = lAddr
+	if config.P2P.AdditionalExternalAddresses != "" {
+		nodeInfo.AdditionalListenAddrs = splitAndTrimEmpty(config.P2P.AdditionalExternalAddresses, ",", " ")
+	}
 
 	err := nodeInfo.Validate()
 	return nodeInfo, err
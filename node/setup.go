@@ -39,7 +39,14 @@ import (
 	"github.com/cometbft/cometbft/version"
 )
 
-const readHeaderTimeout = 10 * time.Second
+const (
+	readHeaderTimeout = 10 * time.Second
+
+	// maxUnknownPeerChannels is the number of channels a peer may advertise
+	// without us having a reactor for them. It leaves room for peers running
+	// newer versions with additional reactors.
+	maxUnknownPeerChannels = 8
+)
 
 // ChecksummedGenesisDoc combines a GenesisDoc together with its
 // SHA256 checksum.
@@ -466,8 +473,33 @@ func createTransport(
 
 	p2p.MultiplexTransportConnFilters(connFilters...)(transport)
 
+	unconditionalPeerIDs := splitAndTrimEmpty(config.P2P.UnconditionalPeerIDs, ",", " ")
+
+	// Restrict inbound connections by peer ID. Unconditional peers are always
+	// allowed, so they don't need to be repeated in the allow list.
+	allowed := splitAndTrimEmpty(config.P2P.AllowedPeerIDs, ",", " ")
+	denied := splitAndTrimEmpty(config.P2P.DeniedPeerIDs, ",", " ")
+	if len(allowed) > 0 || len(denied) > 0 {
+		var allowedIDs, deniedIDs []p2p.ID
+		if len(allowed) > 0 {
+			for _, id := range append(allowed, unconditionalPeerIDs...) {
+				allowedIDs = append(allowedIDs, p2p.ID(id))
+			}
+		}
+		for _, id := range denied {
+			deniedIDs = append(deniedIDs, p2p.ID(id))
+		}
+		p2p.MultiplexTransportPeerIDPolicy(allowedIDs, deniedIDs)(transport)
+	}
+
+	// Reject peers sending bloated NodeInfos.
+	p2p.MultiplexTransportMaxNodeInfoSize(config.P2P.MaxNodeInfoSize)(transport)
+
+	// Reject peers advertising many channels we have no reactor for.
+	p2p.MultiplexTransportMaxUnknownChannels(maxUnknownPeerChannels)(transport)
+
 	// Limit the number of incoming connections.
-	max := config.P2P.MaxNumInboundPeers + len(splitAndTrimEmpty(config.P2P.UnconditionalPeerIDs, ",", " "))
+	max := config.P2P.MaxNumInboundPeers + len(unconditionalPeerIDs)
 	p2p.MultiplexTransportMaxIncomingConnections(max)(transport)
 
 	return transport, peerFilters
@@ -536,7 +568,7 @@ func createAddrBookAndSetOnSwitch(config *cfg.Config, sw *p2p.Switch,
 }
 
 func createPEXReactorAndAddToSwitch(addrBook pex.AddrBook, config *cfg.Config,
-	sw *p2p.Switch, logger log.Logger,
+	sw *p2p.Switch, p2pMetrics *p2p.Metrics, logger log.Logger,
 ) *pex.Reactor {
 	// TODO persistent peers ? so we can have their DNS addrs saved
 	pexReactor := pex.NewReactor(addrBook,
@@ -550,7 +582,9 @@ func createPEXReactorAndAddToSwitch(addrBook pex.AddrBook, config *cfg.Config,
 			// https://github.com/tendermint/tendermint/issues/3523
 			SeedDisconnectWaitPeriod:     28 * time.Hour,
 			PersistentPeersMaxDialPeriod: config.P2P.PersistentPeersMaxDialPeriod,
-		})
+		},
+		pex.WithMetrics(p2pMetrics),
+	)
 	pexReactor.SetLogger(logger.With("module", "pex"))
 	sw.AddReactor("PEX", pexReactor)
 	return pexReactor
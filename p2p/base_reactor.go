@@ -43,6 +43,36 @@ type Reactor interface {
 	Receive(e Envelope)
 }
 
+// PeerStateReactor is an optional interface a Reactor can implement to have
+// the switch manage its per-peer state.
+type PeerStateReactor interface {
+	// InitPeerState is called by the switch after InitPeer, before the peer
+	// is started, so the state is in place before the first call to Receive.
+	// The returned value is stored in the peer's data under
+	// PeerStateKey(name), where name is the name the reactor was added to the
+	// switch with. The switch clears it after calling RemovePeer.
+	InitPeerState(peer Peer) any
+}
+
+// PeerStateKey returns the key the state of the reactor added to the switch
+// as name is stored under in the peer's data, see PeerStateReactor.
+func PeerStateKey(name string) string {
+	return "p2p/reactor-state/" + name
+}
+
+// BackpressureReactor is an optional interface a Reactor can implement to
+// learn when it is the bottleneck of a peer connection. Messages are handed
+// to Receive by the connection's receive routine, so a slow Receive blocks
+// reading from that peer altogether.
+type BackpressureReactor interface {
+	// OnChannelBackpressure is called after a call to Receive for a message
+	// from peer on chID took longer than the connection's
+	// RecvBackpressureThreshold. The reactor may use it to slow down its own
+	// gossip to that peer. It is called from the peer's receive routine and
+	// must not block.
+	OnChannelBackpressure(peer Peer, chID byte)
+}
+
 // --------------------------------------
 
 type BaseReactor struct {
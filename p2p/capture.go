@@ -0,0 +1,75 @@
+package p2p
+
+import (
+	"fmt"
+
+	"github.com/cosmos/gogoproto/proto"
+
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
+)
+
+// captureBuffer keeps the raw bytes of the last messages received on a
+// channel, see PeerCaptureReceive.
+type captureBuffer struct {
+	mtx  cmtsync.Mutex
+	msgs [][]byte // ring buffer, next is the oldest once full
+	next int
+	full bool
+}
+
+func newCaptureBuffer(size int) *captureBuffer {
+	return &captureBuffer{msgs: make([][]byte, size)}
+}
+
+// add records a copy of msgBytes, dropping the oldest message if the buffer
+// is full.
+func (b *captureBuffer) add(msgBytes []byte) {
+	bz := make([]byte, len(msgBytes))
+	copy(bz, msgBytes)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.msgs[b.next] = bz
+	b.next = (b.next + 1) % len(b.msgs)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// list returns the recorded messages, oldest first.
+func (b *captureBuffer) list() [][]byte {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if !b.full {
+		return append([][]byte(nil), b.msgs[:b.next]...)
+	}
+	msgs := make([][]byte, 0, len(b.msgs))
+	msgs = append(msgs, b.msgs[b.next:]...)
+	return append(msgs, b.msgs[:b.next]...)
+}
+
+// captureConfig is the PeerCaptureReceive configuration applied to every
+// peer of a switch, see SwitchCaptureReceive.
+type captureConfig struct {
+	size  int
+	chIDs []byte
+}
+
+// ReplayCapture decodes msgs, as returned by Peer.CaptureBuffer, as messages
+// of type msgType and passes them to reactor.Receive in order, as if src had
+// sent them on channel chID. It's meant for reproducing reactor bugs in
+// tests. It stops at the first message that can't be decoded.
+func ReplayCapture(reactor Reactor, src Peer, chID byte, msgType proto.Message, msgs [][]byte) error {
+	for i, msgBytes := range msgs {
+		msg, err := decodeMsg(msgType, msgBytes)
+		if err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+		reactor.Receive(Envelope{
+			ChannelID: chID,
+			Src:       src,
+			Message:   msg,
+		})
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"os"
 	"reflect"
 	"runtime/debug"
 	"sync/atomic"
@@ -28,9 +29,15 @@ const (
 	defaultMaxPacketMsgPayloadSize = 1024
 
 	numBatchPacketMsgs = 10
-	minReadBufferSize  = 1024
-	minWriteBufferSize = 65536
-	updateStats        = 2 * time.Second
+	// Messages waiting to be handed to the reactors when some channel has a
+	// MaxQueueAge, see dispatchRoutine.
+	recvQueueSize = 16
+	// Goroutines handing the messages of unordered channels to onReceive,
+	// see ChannelDescriptor.Unordered.
+	numUnorderedWorkers = 4
+	minReadBufferSize   = 1024
+	minWriteBufferSize  = 65536
+	updateStats         = 2 * time.Second
 
 	// some of these defaults are written in the user config
 	// flushThrottle, sendRate, recvRate
@@ -45,6 +52,8 @@ const (
 	defaultSendTimeout         = 10 * time.Second
 	defaultPingInterval        = 60 * time.Second
 	defaultPongTimeout         = 45 * time.Second
+
+	defaultRecvBackpressureThreshold = 1 * time.Second
 )
 
 type (
@@ -87,8 +96,11 @@ type MConnection struct {
 	recvMonitor   *flow.Monitor
 	send          chan struct{}
 	pong          chan struct{}
+	flushReq      chan chan error // see Flush
+	channelsMtx   cmtsync.RWMutex // guards the fields below, replaced by AddChannel and RemoveChannel
 	channels      []*Channel
 	channelsIdx   map[byte]*Channel
+	removed       map[byte]struct{} // channels taken out by RemoveChannel
 	onReceive     receiveCbFunc
 	onError       errorCbFunc
 	errored       uint32
@@ -102,6 +114,19 @@ type MConnection struct {
 	// Closing quitRecvRouting will cause the recvRouting to eventually quit.
 	quitRecvRoutine chan struct{}
 
+	// Complete messages read by recvRoutine, for dispatchRoutine to hand to
+	// onReceive. Nil if no channel has a MaxQueueAge, in which case
+	// recvRoutine calls onReceive itself.
+	recvQueue chan receivedMsg
+
+	// Complete messages of unordered channels, for the unorderedRoutines to
+	// hand to onReceive concurrently. Nil if no channel is unordered.
+	unorderedQueue chan receivedMsg
+
+	// sendRoutine, recvRoutine, dispatchRoutine and unorderedRoutines still
+	// running, see Goroutines
+	goroutines atomic.Int32
+
 	// used to ensure FlushStop and OnStop
 	// are safe to call concurrently.
 	stopMtx cmtsync.Mutex
@@ -117,6 +142,24 @@ type MConnection struct {
 
 	created time.Time // time of creation
 
+	lastErrMtx  cmtsync.Mutex // guards the fields below
+	lastErr     error
+	lastErrTime time.Time
+
+	// Unix nanoseconds of the last packet of any kind, and of the last
+	// PacketMsg, received. Both start at the time of creation.
+	lastRecv    int64 // atomic
+	lastMsgRecv int64 // atomic
+
+	// Unix nanoseconds of when the ping awaiting a pong was sent, 0 if none,
+	// and the round-trip time of the last ping answered, 0 before the first.
+	pingSent int64 // atomic
+	pingRTT  int64 // atomic
+
+	// Bytes of the messages waiting in the send queues of all channels, see
+	// MConnConfig.MaxQueuedBytes.
+	queuedBytes int64 // atomic
+
 	_maxPacketMsgSize int
 }
 
@@ -128,7 +171,8 @@ type MConnConfig struct {
 	// Maximum payload size
 	MaxPacketMsgPayloadSize int `mapstructure:"max_packet_msg_payload_size"`
 
-	// Interval to flush writes (throttled)
+	// Interval to flush writes (throttled). 0 flushes after every batch of
+	// packets, trading throughput for latency.
 	FlushThrottle time.Duration `mapstructure:"flush_throttle"`
 
 	// Interval to send pings
@@ -137,6 +181,38 @@ type MConnConfig struct {
 	// Maximum wait time for pongs
 	PongTimeout time.Duration `mapstructure:"pong_timeout"`
 
+	// Time a reactor may spend processing a single received message before
+	// it is notified of backpressure on the channel. 0 disables notifications.
+	RecvBackpressureThreshold time.Duration `mapstructure:"recv_backpressure_threshold"`
+
+	// Number the messages sent on each channel so the remote end can detect
+	// messages lost in transit. Sequence numbers are always checked when
+	// received. Only enable for debugging, and only when all peers run a
+	// version that knows about sequence numbers, as they enlarge packets.
+	DebugSequenceNumbers bool `mapstructure:"debug_sequence_numbers"`
+
+	// Maximum number of bytes of messages queued for sending, summed across
+	// all channels. Send and TrySend fail immediately while a message would
+	// make the total exceed it. 0 means no limit.
+	MaxQueuedBytes int64 `mapstructure:"max_queued_bytes"`
+
+	// OnSendOverflow, if set, is called with the action taken, see
+	// OverflowPolicy, whenever a message is sent on a channel whose send
+	// queue is full. It must not block.
+	OnSendOverflow func(chID byte, action OverflowPolicy) `mapstructure:"-"`
+
+	// OnRecvStale, if set, is called whenever a message is dropped for being
+	// older than its channel's MaxQueueAge. It must not block.
+	OnRecvStale func(chID byte) `mapstructure:"-"`
+
+	// Maximum time a read from or a write to the connection may block. The
+	// deadline is set before each read and write, so a stalled connection
+	// fails the MConnection instead of hanging. As an idle peer only sends a
+	// ping every PingInterval, ReadTimeout should be well above it. 0 means
+	// no timeout.
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
 	// Fuzz connection
 	TestFuzz       bool                   `mapstructure:"test_fuzz"`
 	TestFuzzConfig *config.FuzzConnConfig `mapstructure:"test_fuzz_config"`
@@ -151,6 +227,8 @@ func DefaultMConnConfig() MConnConfig {
 		FlushThrottle:           defaultFlushThrottle,
 		PingInterval:            defaultPingInterval,
 		PongTimeout:             defaultPongTimeout,
+
+		RecvBackpressureThreshold: defaultRecvBackpressureThreshold,
 	}
 }
 
@@ -181,18 +259,27 @@ func NewMConnectionWithConfig(
 		panic("pongTimeout must be less than pingInterval (otherwise, next ping will reset pong timer)")
 	}
 
+	var rw io.ReadWriter = conn
+	if config.ReadTimeout > 0 || config.WriteTimeout > 0 {
+		rw = &deadlineConn{Conn: conn, readTimeout: config.ReadTimeout, writeTimeout: config.WriteTimeout}
+	}
+
+	now := time.Now()
 	mconn := &MConnection{
 		conn:          conn,
-		bufConnReader: bufio.NewReaderSize(conn, minReadBufferSize),
-		bufConnWriter: bufio.NewWriterSize(conn, minWriteBufferSize),
+		bufConnReader: bufio.NewReaderSize(rw, minReadBufferSize),
+		bufConnWriter: bufio.NewWriterSize(rw, minWriteBufferSize),
 		sendMonitor:   flow.New(0, 0),
 		recvMonitor:   flow.New(0, 0),
 		send:          make(chan struct{}, 1),
 		pong:          make(chan struct{}, 1),
+		flushReq:      make(chan chan error),
 		onReceive:     onReceive,
 		onError:       onError,
 		config:        config,
-		created:       time.Now(),
+		created:       now,
+		lastRecv:      now.UnixNano(),
+		lastMsgRecv:   now.UnixNano(),
 	}
 
 	// Create channels
@@ -215,13 +302,134 @@ func NewMConnectionWithConfig(
 	return mconn
 }
 
+// deadlineConn sets a deadline on the connection before each read and write,
+// see MConnConfig.ReadTimeout and WriteTimeout.
+type deadlineConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if c.readTimeout > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	if c.writeTimeout > 0 {
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Write(b)
+}
+
 func (c *MConnection) SetLogger(l log.Logger) {
 	c.BaseService.SetLogger(l)
-	for _, ch := range c.channels {
+	for _, ch := range c.channelList() {
 		ch.SetLogger(l)
 	}
 }
 
+// AddChannel adds a channel to the connection, e.g. for a reactor added to a
+// running node. It may be called while the connection is running, in which
+// case messages can be sent and received on the channel as soon as it returns.
+func (c *MConnection) AddChannel(desc *ChannelDescriptor) error {
+	c.channelsMtx.Lock()
+	defer c.channelsMtx.Unlock()
+
+	if _, ok := c.channelsIdx[desc.ID]; ok {
+		return ErrDuplicateChannel{ID: desc.ID}
+	}
+
+	channel := newChannel(c, *desc)
+	channel.SetLogger(c.Logger)
+
+	// Copy rather than modify in place, so that slices handed out by
+	// channelList remain valid.
+	channelsIdx := make(map[byte]*Channel, len(c.channelsIdx)+1)
+	for id, ch := range c.channelsIdx {
+		channelsIdx[id] = ch
+	}
+	channelsIdx[desc.ID] = channel
+	channels := make([]*Channel, len(c.channels), len(c.channels)+1)
+	copy(channels, c.channels)
+
+	c.channels = append(channels, channel)
+	c.channelsIdx = channelsIdx
+	return nil
+}
+
+// RemoveChannel takes a channel out of a possibly running connection.
+// Messages still queued on the channel are discarded, including one that was
+// partly written, and later sends on it fail. Messages the peer keeps sending
+// on the channel are dropped instead of failing the connection.
+func (c *MConnection) RemoveChannel(chID byte) error {
+	c.channelsMtx.Lock()
+	channel, ok := c.channelsIdx[chID]
+	if !ok {
+		c.channelsMtx.Unlock()
+		return ErrChannelNotFound{ID: chID}
+	}
+	channelsIdx := make(map[byte]*Channel, len(c.channelsIdx)-1)
+	for id, ch := range c.channelsIdx {
+		if id != chID {
+			channelsIdx[id] = ch
+		}
+	}
+	channels := make([]*Channel, 0, len(c.channels)-1)
+	for _, ch := range c.channels {
+		if ch != channel {
+			channels = append(channels, ch)
+		}
+	}
+	removed := make(map[byte]struct{}, len(c.removed)+1)
+	for id := range c.removed {
+		removed[id] = struct{}{}
+	}
+	removed[chID] = struct{}{}
+	c.channels = channels
+	c.channelsIdx = channelsIdx
+	c.removed = removed
+	c.channelsMtx.Unlock()
+
+	// The sendRoutine no longer sees the channel, so only the queue is left.
+	for {
+		msg, ok := channel.pop()
+		if !ok {
+			return nil
+		}
+		atomic.AddInt32(&channel.sendQueueSize, -1)
+		channel.release(msg)
+	}
+}
+
+func (c *MConnection) channelRemoved(chID byte) bool {
+	c.channelsMtx.RLock()
+	defer c.channelsMtx.RUnlock()
+	_, ok := c.removed[chID]
+	return ok
+}
+
+func (c *MConnection) channel(chID byte) (*Channel, bool) {
+	c.channelsMtx.RLock()
+	defer c.channelsMtx.RUnlock()
+	ch, ok := c.channelsIdx[chID]
+	return ch, ok
+}
+
+// channelList returns the channels of the connection. The returned slice
+// must not be modified.
+func (c *MConnection) channelList() []*Channel {
+	c.channelsMtx.RLock()
+	defer c.channelsMtx.RUnlock()
+	return c.channels
+}
+
 // OnStart implements BaseService.
 func (c *MConnection) OnStart() error {
 	if err := c.BaseService.OnStart(); err != nil {
@@ -234,8 +442,27 @@ func (c *MConnection) OnStart() error {
 	c.quitSendRoutine = make(chan struct{})
 	c.doneSendRoutine = make(chan struct{})
 	c.quitRecvRoutine = make(chan struct{})
+	for _, ch := range c.channelList() {
+		if ch.desc.MaxQueueAge > 0 && c.recvQueue == nil {
+			c.recvQueue = make(chan receivedMsg, recvQueueSize)
+		}
+		if ch.desc.Unordered && c.unorderedQueue == nil {
+			c.unorderedQueue = make(chan receivedMsg, recvQueueSize)
+		}
+	}
+	c.goroutines.Add(2)
 	go c.sendRoutine()
 	go c.recvRoutine()
+	if c.recvQueue != nil {
+		c.goroutines.Add(1)
+		go c.dispatchRoutine(c.recvQueue)
+	}
+	if c.unorderedQueue != nil {
+		c.goroutines.Add(numUnorderedWorkers)
+		for i := 0; i < numUnorderedWorkers; i++ {
+			go c.unorderedRoutine(c.unorderedQueue)
+		}
+	}
 	return nil
 }
 
@@ -327,12 +554,49 @@ func (c *MConnection) String() string {
 	return fmt.Sprintf("MConn{%v}", c.conn.RemoteAddr())
 }
 
-func (c *MConnection) flush() {
+// Flush writes out the messages queued by successful Send calls and flushes
+// them to the connection right away, rather than after the flush throttle,
+// without stopping the connection. It blocks until they are written, and
+// returns the error writing them, if any. Under sustained sends, it returns
+// once as many packets were written as were queued when it was called.
+func (c *MConnection) Flush() error {
+	done := make(chan error, 1)
+	select {
+	case c.flushReq <- done:
+	case <-c.Quit():
+		return ErrNotRunning
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-c.Quit():
+		return ErrNotRunning
+	}
+}
+
+func (c *MConnection) flush() error {
 	c.Logger.Debug("Flush", "conn", c)
 	err := c.bufConnWriter.Flush()
 	if err != nil {
 		c.Logger.Debug("MConnection flush failed", "err", err)
+		if errors.Is(err, os.ErrDeadlineExceeded) && c.IsRunning() {
+			// The writer is broken for good, don't wait for the next write
+			// to notice.
+			c.stopForError(err)
+			return err
+		}
+		c.recordError(err)
 	}
+	return err
+}
+
+// recordError remembers err as the most recent error seen on the
+// connection, for reporting through Status.
+func (c *MConnection) recordError(err error) {
+	c.lastErrMtx.Lock()
+	c.lastErr = err
+	c.lastErrTime = time.Now()
+	c.lastErrMtx.Unlock()
 }
 
 // Catch panics, usually caused by remote disconnects.
@@ -344,6 +608,11 @@ func (c *MConnection) _recover() {
 }
 
 func (c *MConnection) stopForError(r any) {
+	if err, ok := r.(error); ok {
+		c.recordError(err)
+	} else {
+		c.recordError(fmt.Errorf("%v", r))
+	}
 	if err := c.Stop(); err != nil {
 		c.Logger.Error("Error stopping connection", "err", err)
 	}
@@ -356,50 +625,75 @@ func (c *MConnection) stopForError(r any) {
 
 // Queues a message to be sent to channel.
 func (c *MConnection) Send(chID byte, msgBytes []byte) bool {
+	return c.SendE(chID, msgBytes) == nil
+}
+
+// SendE is like Send but returns why the message was not queued:
+// ErrNotRunning, ErrChannelNotFound, ErrMaxQueuedBytes or ErrSendQueueFull.
+func (c *MConnection) SendE(chID byte, msgBytes []byte) error {
+	return c.SendKeyedE(chID, "", msgBytes)
+}
+
+// SendKeyedE is like SendE, but if key is not empty and a message sent with
+// the same key is still queued on the channel, it returns ErrDuplicateKey
+// instead of queuing msgBytes, e.g. for requests that only need to reach the
+// peer once.
+func (c *MConnection) SendKeyedE(chID byte, key string, msgBytes []byte) error {
 	if !c.IsRunning() {
-		return false
+		return ErrNotRunning
 	}
 
 	c.Logger.Debug("Send", "channel", chID, "conn", c, "msgBytes", log.NewLazySprintf("%X", msgBytes))
 
 	// Send message to channel.
-	channel, ok := c.channelsIdx[chID]
+	channel, ok := c.channel(chID)
 	if !ok {
 		c.Logger.Error(fmt.Sprintf("Cannot send bytes, unknown channel %X", chID))
-		return false
+		return ErrChannelNotFound{ID: chID}
 	}
 
-	success := channel.sendBytes(msgBytes)
-	if success {
+	err := channel.sendBytes(key, msgBytes)
+	if err == nil {
 		// Wake up sendRoutine if necessary
 		select {
 		case c.send <- struct{}{}:
 		default:
 		}
 	} else {
-		c.Logger.Debug("Send failed", "channel", chID, "conn", c, "msgBytes", log.NewLazySprintf("%X", msgBytes))
+		c.Logger.Debug("Send failed", "channel", chID, "conn", c, "msgBytes", log.NewLazySprintf("%X", msgBytes), "err", err)
 	}
-	return success
+	return err
 }
 
 // Queues a message to be sent to channel.
 // Nonblocking, returns true if successful.
 func (c *MConnection) TrySend(chID byte, msgBytes []byte) bool {
+	return c.TrySendE(chID, msgBytes) == nil
+}
+
+// TrySendE is like TrySend but returns why the message was not queued, see
+// SendE.
+func (c *MConnection) TrySendE(chID byte, msgBytes []byte) error {
+	return c.TrySendKeyedE(chID, "", msgBytes)
+}
+
+// TrySendKeyedE is like TrySendE, with the deduplication of SendKeyedE.
+func (c *MConnection) TrySendKeyedE(chID byte, key string, msgBytes []byte) error {
 	if !c.IsRunning() {
-		return false
+		return ErrNotRunning
 	}
 
 	c.Logger.Debug("TrySend", "channel", chID, "conn", c, "msgBytes", log.NewLazySprintf("%X", msgBytes))
 
 	// Send message to channel.
-	channel, ok := c.channelsIdx[chID]
+	channel, ok := c.channel(chID)
 	if !ok {
 		c.Logger.Error(fmt.Sprintf("Cannot send bytes, unknown channel %X", chID))
-		return false
+		return ErrChannelNotFound{ID: chID}
 	}
 
-	ok = channel.trySendBytes(msgBytes)
-	if ok {
+	err := channel.trySendBytes(key, msgBytes)
+	if err == nil {
 		// Wake up sendRoutine if necessary
 		select {
 		case c.send <- struct{}{}:
@@ -407,7 +701,47 @@ func (c *MConnection) TrySend(chID byte, msgBytes []byte) bool {
 		}
 	}
 
-	return ok
+	return err
+}
+
+// Goroutines returns the number of goroutines of the connection that are
+// still running. It drops to 0 once the connection is stopped and its send
+// and receive routines have returned.
+func (c *MConnection) Goroutines() int {
+	return int(c.goroutines.Load())
+}
+
+// QueuedBytes returns the number of bytes of the messages waiting in the send
+// queues of all channels.
+func (c *MConnection) QueuedBytes() int64 {
+	return atomic.LoadInt64(&c.queuedBytes)
+}
+
+// reserveQueuedBytes adds n to the queued bytes, unless that exceeds
+// MaxQueuedBytes.
+func (c *MConnection) reserveQueuedBytes(n int64) bool {
+	total := atomic.AddInt64(&c.queuedBytes, n)
+	if c.config.MaxQueuedBytes > 0 && total > c.config.MaxQueuedBytes {
+		atomic.AddInt64(&c.queuedBytes, -n)
+		return false
+	}
+	return true
+}
+
+func (c *MConnection) releaseQueuedBytes(n int64) {
+	atomic.AddInt64(&c.queuedBytes, -n)
+}
+
+func (c *MConnection) sendOverflow(chID byte, action OverflowPolicy) {
+	if c.config.OnSendOverflow != nil {
+		c.config.OnSendOverflow(chID, action)
+	}
+}
+
+func (c *MConnection) recvStale(chID byte) {
+	if c.config.OnRecvStale != nil {
+		c.config.OnRecvStale(chID)
+	}
 }
 
 // CanSend returns true if you can send more data onto the chID, false
@@ -417,7 +751,7 @@ func (c *MConnection) CanSend(chID byte) bool {
 		return false
 	}
 
-	channel, ok := c.channelsIdx[chID]
+	channel, ok := c.channel(chID)
 	if !ok {
 		c.Logger.Error(fmt.Sprintf("Unknown channel %X", chID))
 		return false
@@ -427,10 +761,20 @@ func (c *MConnection) CanSend(chID byte) bool {
 
 // sendRoutine polls for packets to send from channels.
 func (c *MConnection) sendRoutine() {
+	defer c.goroutines.Add(-1)
 	defer c._recover()
 
 	protoWriter := protoio.NewDelimitedWriter(c.bufConnWriter)
 
+	// Flush requests being served, a batch at a time in between the other
+	// cases, so pings and pongs keep going out, see Flush.
+	var (
+		flushes  []pendingFlush
+		flushing <-chan struct{} // ready while there are flushes
+	)
+	ready := make(chan struct{})
+	close(ready)
+
 FOR_LOOP:
 	for {
 		var _n int
@@ -442,11 +786,12 @@ FOR_LOOP:
 			// something is written to .bufConnWriter.
 			c.flush()
 		case <-c.chStatsTimer.C:
-			for _, channel := range c.channels {
+			for _, channel := range c.channelList() {
 				channel.updateStats()
 			}
 		case <-c.pingTimer.C:
 			c.Logger.Debug("Send Ping")
+			atomic.StoreInt64(&c.pingSent, time.Now().UnixNano())
 			_n, err = protoWriter.WriteMsg(mustWrapPacket(&tmp2p.PacketPing{}))
 			if err != nil {
 				c.Logger.Error("Failed to send PacketPing", "err", err)
@@ -479,6 +824,30 @@ FOR_LOOP:
 			c.flush()
 		case <-c.quitSendRoutine:
 			break FOR_LOOP
+		case done := <-c.flushReq:
+			flushes = append(flushes, pendingFlush{done: done, batches: c.flushBatches()})
+			flushing = ready
+		case <-flushing:
+			eof := c.sendSomePacketMsgs(protoWriter)
+			var done []chan error
+			rest := flushes[:0]
+			for _, f := range flushes {
+				if f.batches--; eof || f.batches <= 0 {
+					done = append(done, f.done)
+				} else {
+					rest = append(rest, f)
+				}
+			}
+			flushes = rest
+			if len(flushes) == 0 {
+				flushing = nil
+			}
+			if len(done) > 0 {
+				flushErr := c.flush()
+				for _, d := range done {
+					d <- flushErr
+				}
+			}
 		case <-c.send:
 			// Send some PacketMsgs
 			eof := c.sendSomePacketMsgs(protoWriter)
@@ -506,6 +875,29 @@ FOR_LOOP:
 	close(c.doneSendRoutine)
 }
 
+// pendingFlush is a Flush call waiting for the messages queued when it was
+// made to be written.
+type pendingFlush struct {
+	done    chan error
+	batches int // left to write, see flushBatches
+}
+
+// flushBatches returns how many sendSomePacketMsgs batches it takes, at most,
+// to write out the messages queued now. Messages queued later may go out
+// first, on channels of a higher priority, so a Flush under sustained sends
+// still returns once this many batches are written.
+// Must only be called from sendRoutine.
+func (c *MConnection) flushBatches() int {
+	packets := 0
+	for _, channel := range c.channelList() {
+		// Each message takes one packet per full payload and at most one
+		// more for the rest.
+		bytes := int(atomic.LoadInt64(&channel.queuedBytes)) + len(channel.sending)
+		packets += bytes/channel.maxPacketMsgPayloadSize + channel.loadSendQueueSize()
+	}
+	return (packets + numBatchPacketMsgs - 1) / numBatchPacketMsgs
+}
+
 // Returns true if messages from channels were exhausted.
 // Blocks in accordance to .sendMonitor throttling.
 func (c *MConnection) sendSomePacketMsgs(w protoio.Writer) bool {
@@ -525,10 +917,14 @@ func (c *MConnection) sendBatchPacketMsgs(w protoio.Writer, batchSize int) bool
 	defer func() {
 		if totalBytesWritten > 0 {
 			c.sendMonitor.Update(totalBytesWritten)
+			if c.config.FlushThrottle <= 0 {
+				c.flush()
+			}
 		}
 	}()
+	channels := c.channelList()
 	for i := 0; i < batchSize; i++ {
-		channel := selectChannelToGossipOn(c.channels)
+		channel := selectChannelToGossipOn(channels)
 		// nothing to send across any channel.
 		if channel == nil {
 			return true
@@ -579,7 +975,9 @@ func (c *MConnection) sendPacketMsgOnChannel(w protoio.Writer, sendChannel *Chan
 		return n, true
 	}
 	// TODO: Change this to only add flush signals at the start and end of the batch.
-	c.flushTimer.Set()
+	if c.config.FlushThrottle > 0 {
+		c.flushTimer.Set()
+	}
 	return n, false
 }
 
@@ -588,6 +986,7 @@ func (c *MConnection) sendPacketMsgOnChannel(w protoio.Writer, sendChannel *Chan
 // Blocks depending on how the connection is throttled.
 // Otherwise, it never blocks.
 func (c *MConnection) recvRoutine() {
+	defer c.goroutines.Add(-1)
 	defer c._recover()
 
 	protoReader := protoio.NewDelimitedReader(c.bufConnReader, c._maxPacketMsgSize)
@@ -636,6 +1035,8 @@ FOR_LOOP:
 			break FOR_LOOP
 		}
 
+		atomic.StoreInt64(&c.lastRecv, time.Now().UnixNano())
+
 		// Read more depending on packet type.
 		switch pkt := packet.Sum.(type) {
 		case *tmp2p.Packet_PacketPing:
@@ -649,14 +1050,22 @@ FOR_LOOP:
 			}
 		case *tmp2p.Packet_PacketPong:
 			c.Logger.Debug("Receive Pong")
+			if sent := atomic.SwapInt64(&c.pingSent, 0); sent != 0 {
+				atomic.StoreInt64(&c.pingRTT, time.Now().UnixNano()-sent)
+			}
 			select {
 			case c.pongTimeoutCh <- false:
 			default:
 				// never block
 			}
 		case *tmp2p.Packet_PacketMsg:
+			atomic.StoreInt64(&c.lastMsgRecv, time.Now().UnixNano())
 			channelID := byte(pkt.PacketMsg.ChannelID)
-			channel, ok := c.channelsIdx[channelID]
+			channel, ok := c.channel(channelID)
+			if !ok && pkt.PacketMsg.ChannelID >= 0 && pkt.PacketMsg.ChannelID <= math.MaxUint8 && c.channelRemoved(channelID) {
+				c.Logger.Debug("Dropping packet for removed channel", "chID", channelID)
+				continue
+			}
 			if pkt.PacketMsg.ChannelID < 0 || pkt.PacketMsg.ChannelID > math.MaxUint8 || !ok || channel == nil {
 				err := fmt.Errorf("unknown channel %X", pkt.PacketMsg.ChannelID)
 				c.Logger.Debug("Connection failed @ recvRoutine", "conn", c, "err", err)
@@ -672,7 +1081,19 @@ FOR_LOOP:
 				}
 				break FOR_LOOP
 			}
-			if msgBytes != nil {
+			if msgBytes != nil && c.recvQueue != nil {
+				msg := receivedMsg{chID: channelID, msgBytes: append([]byte(nil), msgBytes...), at: time.Now()}
+				select {
+				case c.recvQueue <- msg:
+				case <-c.quitRecvRoutine:
+					break FOR_LOOP
+				}
+			} else if msgBytes != nil && channel.desc.Unordered && c.unorderedQueue != nil {
+				msg := receivedMsg{chID: channelID, msgBytes: append([]byte(nil), msgBytes...), at: time.Now()}
+				if !c.dispatchUnordered(msg) {
+					break FOR_LOOP
+				}
+			} else if msgBytes != nil {
 				c.Logger.Debug("Received bytes", "chID", channelID, "msgBytes", msgBytes)
 				// NOTE: This means the reactor.Receive runs in the same thread as the p2p recv routine
 				c.onReceive(channelID, msgBytes)
@@ -687,6 +1108,82 @@ FOR_LOOP:
 
 	// Cleanup
 	close(c.pong)
+	if c.recvQueue != nil {
+		close(c.recvQueue)
+	}
+}
+
+// receivedMsg is a complete message read by recvRoutine, see recvQueue.
+type receivedMsg struct {
+	chID     byte
+	msgBytes []byte
+	at       time.Time // when its last packet was read
+}
+
+// dispatchRoutine hands the messages read by recvRoutine to onReceive, in the
+// order they were read. Those that waited in queue longer than their
+// channel's MaxQueueAge, because the reactors took too long to process the
+// messages before them, are dropped instead.
+func (c *MConnection) dispatchRoutine(queue <-chan receivedMsg) {
+	defer c.goroutines.Add(-1)
+	defer c._recover()
+
+	for {
+		select {
+		case msg, ok := <-queue:
+			if !ok {
+				return
+			}
+			channel, ok := c.channel(msg.chID)
+			if !ok {
+				continue // removed meanwhile
+			}
+			if maxAge := channel.desc.MaxQueueAge; maxAge > 0 && time.Since(msg.at) > maxAge {
+				c.Logger.Debug("Dropping stale message", "chID", msg.chID, "age", time.Since(msg.at))
+				c.recvStale(msg.chID)
+				continue
+			}
+			if channel.desc.Unordered && c.unorderedQueue != nil {
+				if !c.dispatchUnordered(msg) {
+					return
+				}
+				continue
+			}
+			c.Logger.Debug("Received bytes", "chID", msg.chID, "msgBytes", msg.msgBytes)
+			c.onReceive(msg.chID, msg.msgBytes)
+		case <-c.quitRecvRoutine:
+			return
+		}
+	}
+}
+
+// dispatchUnordered queues msg, of an unordered channel, for the
+// unorderedRoutines. It returns false if the connection stopped meanwhile.
+func (c *MConnection) dispatchUnordered(msg receivedMsg) bool {
+	select {
+	case c.unorderedQueue <- msg:
+		return true
+	case <-c.quitRecvRoutine:
+		return false
+	}
+}
+
+// unorderedRoutine hands the messages of unordered channels to onReceive.
+// Several run at once, so that a message that takes the reactor long to
+// process doesn't hold up the ones after it.
+func (c *MConnection) unorderedRoutine(queue <-chan receivedMsg) {
+	defer c.goroutines.Add(-1)
+	defer c._recover()
+
+	for {
+		select {
+		case msg := <-queue:
+			c.Logger.Debug("Received bytes", "chID", msg.chID, "msgBytes", msg.msgBytes)
+			c.onReceive(msg.chID, msg.msgBytes)
+		case <-c.quitRecvRoutine:
+			return
+		}
+	}
 }
 
 // not goroutine-safe.
@@ -703,6 +1200,7 @@ func (c *MConnection) maxPacketMsgSize() int {
 		ChannelID: 0x01,
 		EOF:       true,
 		Data:      make([]byte, c.config.MaxPacketMsgPayloadSize),
+		Sequence:  math.MaxUint64, // accept packets from peers with sequence numbers enabled
 	}))
 	if err != nil {
 		panic(err)
@@ -715,6 +1213,20 @@ type ConnectionStatus struct {
 	SendMonitor flow.Status
 	RecvMonitor flow.Status
 	Channels    []ChannelStatus
+	// Most recent error seen on the connection, fatal or not, and when it
+	// happened. Empty if there has been none.
+	LastError     string
+	LastErrorTime time.Time
+	// When the last packet of any kind, including pings and pongs, and the
+	// last channel message packet were received. Both are the time the
+	// connection was created if nothing has been received yet.
+	LastReceiveTime    time.Time
+	LastMsgReceiveTime time.Time
+	// Round-trip time of the last ping the peer answered, 0 if it has not
+	// answered any yet.
+	PingRTT time.Duration
+	// Bytes of the messages waiting in the send queues of all channels.
+	QueuedBytes int64
 }
 
 type ChannelStatus struct {
@@ -723,6 +1235,10 @@ type ChannelStatus struct {
 	SendQueueSize     int
 	Priority          int
 	RecentlySent      int64
+	// Number of messages the remote end sent on the channel that were never
+	// received, based on sequence numbers. Always 0 if the remote end does
+	// not send sequence numbers.
+	MissedMessages int64
 }
 
 func (c *MConnection) Status() ConnectionStatus {
@@ -730,14 +1246,26 @@ func (c *MConnection) Status() ConnectionStatus {
 	status.Duration = time.Since(c.created)
 	status.SendMonitor = c.sendMonitor.Status()
 	status.RecvMonitor = c.recvMonitor.Status()
-	status.Channels = make([]ChannelStatus, len(c.channels))
-	for i, channel := range c.channels {
+	c.lastErrMtx.Lock()
+	if c.lastErr != nil {
+		status.LastError = c.lastErr.Error()
+		status.LastErrorTime = c.lastErrTime
+	}
+	c.lastErrMtx.Unlock()
+	status.LastReceiveTime = time.Unix(0, atomic.LoadInt64(&c.lastRecv))
+	status.LastMsgReceiveTime = time.Unix(0, atomic.LoadInt64(&c.lastMsgRecv))
+	status.PingRTT = time.Duration(atomic.LoadInt64(&c.pingRTT))
+	status.QueuedBytes = c.QueuedBytes()
+	channels := c.channelList()
+	status.Channels = make([]ChannelStatus, len(channels))
+	for i, channel := range channels {
 		status.Channels[i] = ChannelStatus{
 			ID:                channel.desc.ID,
 			SendQueueCapacity: cap(channel.sendQueue),
 			SendQueueSize:     int(atomic.LoadInt32(&channel.sendQueueSize)),
 			Priority:          channel.desc.Priority,
 			RecentlySent:      atomic.LoadInt64(&channel.recentlySent),
+			MissedMessages:    atomic.LoadInt64(&channel.missedMessages),
 		}
 	}
 	return status
@@ -752,6 +1280,79 @@ type ChannelDescriptor struct {
 	RecvBufferCapacity  int
 	RecvMessageCapacity int
 	MessageType         proto.Message
+
+	// Unordered declares that the reactor owning this channel tolerates
+	// messages being delivered to Receive out of order. The zero value keeps
+	// the default guarantee: messages received on a channel from a given peer
+	// are handed to the reactor in the order they were received. If any
+	// channel is unordered when the connection starts, the messages of the
+	// unordered channels are handed to the reactors by a few goroutines at
+	// once, so Receive must be safe to call concurrently for them.
+	Unordered bool
+
+	// OverflowPolicy decides what happens to messages sent while the send
+	// queue is full. Defaults to OverflowBlock.
+	OverflowPolicy OverflowPolicy
+
+	// SendQueueByteCapacity, if positive, bounds the send queue by the total
+	// size of the queued messages, on top of SendQueueCapacity: the queue is
+	// full as soon as either limit is reached. Unlike SendQueueCapacity, Send
+	// does not wait for room under this limit, so a message that does not fit
+	// is dropped, unless OverflowPolicy is OverflowDropOldest. A message
+	// larger than SendQueueByteCapacity is always dropped.
+	SendQueueByteCapacity int64
+
+	// DedupKey, if set, is used by the p2p package to key the messages sent
+	// on the channel, see MConnection.SendKeyedE: a message is not queued if
+	// one with the same key still is. An empty key disables deduplication for
+	// the message.
+	DedupKey func(msg proto.Message) string
+
+	// MaxQueueAge, if positive, drops received messages that waited longer
+	// than MaxQueueAge, from when they were read in full, for the reactors to
+	// process the messages before them, instead of handing them to the
+	// reactor, e.g. for votes that are obsolete by the time a lagging
+	// consensus reactor sees them. If any channel has one when the connection
+	// starts, the connection reads ahead up to a few messages while the
+	// reactors are busy, on every channel, so that they can be timed.
+	MaxQueueAge time.Duration
+}
+
+// OverflowPolicy is what a channel does with a message sent while its send
+// queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Send wait for room in the queue, until
+	// defaultSendTimeout, after which the message is dropped. TrySend drops
+	// the message right away.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the message, without waiting, for both Send
+	// and TrySend.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued message to make room, so
+	// Send and TrySend always queue the message, e.g. for messages that
+	// supersede the ones sent before them.
+	OverflowDropOldest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// Ordered returns true if messages on this channel must be processed in the
+// order they were received.
+func (chDesc ChannelDescriptor) Ordered() bool {
+	return !chDesc.Unordered
 }
 
 func (chDesc ChannelDescriptor) FillDefaults() (filled ChannelDescriptor) {
@@ -773,12 +1374,22 @@ func (chDesc ChannelDescriptor) FillDefaults() (filled ChannelDescriptor) {
 type Channel struct {
 	conn          *MConnection
 	desc          ChannelDescriptor
-	sendQueue     chan []byte
+	sendQueue     chan queuedMsg
 	sendQueueSize int32 // atomic.
+	queuedBytes   int64 // atomic, see ChannelDescriptor.SendQueueByteCapacity
 	recving       []byte
 	sending       []byte
 	recentlySent  int64 // exponential moving average
 
+	// keys of the queued messages, see MConnection.SendKeyedE
+	keysMtx    cmtsync.Mutex
+	queuedKeys map[string]struct{}
+
+	// Sequence numbers, see MConnConfig.DebugSequenceNumbers.
+	sendSeq        uint64 // of the message being sent
+	recvSeq        uint64 // of the last message received
+	missedMessages int64  // atomic
+
 	nextPacketMsg           *tmp2p.PacketMsg
 	nextP2pWrapperPacketMsg *tmp2p.Packet_PacketMsg
 	nextPacket              *tmp2p.Packet
@@ -796,7 +1407,8 @@ func newChannel(conn *MConnection, desc ChannelDescriptor) *Channel {
 	return &Channel{
 		conn:                    conn,
 		desc:                    desc,
-		sendQueue:               make(chan []byte, desc.SendQueueCapacity),
+		sendQueue:               make(chan queuedMsg, desc.SendQueueCapacity),
+		queuedKeys:              make(map[string]struct{}),
 		recving:                 make([]byte, 0, desc.RecvBufferCapacity),
 		nextPacketMsg:           &tmp2p.PacketMsg{ChannelID: int32(desc.ID)},
 		nextP2pWrapperPacketMsg: &tmp2p.Packet_PacketMsg{},
@@ -809,32 +1421,169 @@ func (ch *Channel) SetLogger(l log.Logger) {
 	ch.Logger = l
 }
 
-// Queues message to send to this channel.
+// queuedMsg is a message in a channel's send queue.
+type queuedMsg struct {
+	bytes []byte
+	key   string // see MConnection.SendKeyedE
+}
+
+// Queues message to send to this channel. If the queue is full, the
+// channel's OverflowPolicy applies.
 // Goroutine-safe
-// Times out (and returns false) after defaultSendTimeout.
-func (ch *Channel) sendBytes(bytes []byte) bool {
+// Times out (and returns ErrSendQueueFull) after defaultSendTimeout.
+func (ch *Channel) sendBytes(key string, bytes []byte) error {
+	msg, err := ch.reserve(key, bytes)
+	if err != nil {
+		return err
+	}
+	if ch.pushNonBlocking(msg) {
+		return nil
+	}
+	switch ch.desc.OverflowPolicy {
+	case OverflowDropNewest:
+		return ch.dropNewest(msg)
+	case OverflowDropOldest:
+		return ch.pushDroppingOldest(msg)
+	}
+
+	if !ch.reserveBytes(int64(len(msg.bytes))) {
+		return ch.dropNewest(msg)
+	}
+	ch.conn.sendOverflow(ch.desc.ID, OverflowBlock)
 	select {
-	case ch.sendQueue <- bytes:
+	case ch.sendQueue <- msg:
 		atomic.AddInt32(&ch.sendQueueSize, 1)
-		return true
+		return nil
 	case <-time.After(defaultSendTimeout):
-		return false
+		ch.releaseBytes(int64(len(msg.bytes)))
+		return ch.dropNewest(msg)
 	}
 }
 
-// Queues message to send to this channel.
-// Nonblocking, returns true if successful.
+// Queues message to send to this channel. If the queue is full, the message
+// is dropped, unless the channel's OverflowPolicy is OverflowDropOldest.
+// Nonblocking, returns nil if successful.
 // Goroutine-safe.
-func (ch *Channel) trySendBytes(bytes []byte) bool {
+func (ch *Channel) trySendBytes(key string, bytes []byte) error {
+	msg, err := ch.reserve(key, bytes)
+	if err != nil {
+		return err
+	}
+	if ch.pushNonBlocking(msg) {
+		return nil
+	}
+	if ch.desc.OverflowPolicy == OverflowDropOldest {
+		return ch.pushDroppingOldest(msg)
+	}
+	return ch.dropNewest(msg)
+}
+
+// reserve claims the key and the queued bytes of a message about to be
+// queued, which must be released if it ends up not being queued.
+func (ch *Channel) reserve(key string, bytes []byte) (queuedMsg, error) {
+	if key != "" {
+		ch.keysMtx.Lock()
+		_, dup := ch.queuedKeys[key]
+		if !dup {
+			ch.queuedKeys[key] = struct{}{}
+		}
+		ch.keysMtx.Unlock()
+		if dup {
+			return queuedMsg{}, ErrDuplicateKey
+		}
+	}
+	if !ch.conn.reserveQueuedBytes(int64(len(bytes))) {
+		ch.releaseKey(key)
+		return queuedMsg{}, ErrMaxQueuedBytes
+	}
+	return queuedMsg{bytes: bytes, key: key}, nil
+}
+
+// releaseKey lets messages with key be queued again.
+func (ch *Channel) releaseKey(key string) {
+	if key == "" {
+		return
+	}
+	ch.keysMtx.Lock()
+	delete(ch.queuedKeys, key)
+	ch.keysMtx.Unlock()
+}
+
+// release undoes reserve, for a message that left the queue or never made it
+// there.
+func (ch *Channel) release(msg queuedMsg) {
+	ch.conn.releaseQueuedBytes(int64(len(msg.bytes)))
+	ch.releaseKey(msg.key)
+}
+
+// reserveBytes adds n to the bytes of the channel's queued messages, unless
+// that exceeds SendQueueByteCapacity.
+func (ch *Channel) reserveBytes(n int64) bool {
+	total := atomic.AddInt64(&ch.queuedBytes, n)
+	if ch.desc.SendQueueByteCapacity > 0 && total > ch.desc.SendQueueByteCapacity {
+		atomic.AddInt64(&ch.queuedBytes, -n)
+		return false
+	}
+	return true
+}
+
+func (ch *Channel) releaseBytes(n int64) {
+	atomic.AddInt64(&ch.queuedBytes, -n)
+}
+
+// pushNonBlocking queues msg if there is room, under both the message count
+// and byte capacities.
+func (ch *Channel) pushNonBlocking(msg queuedMsg) bool {
+	n := int64(len(msg.bytes))
+	if !ch.reserveBytes(n) {
+		return false
+	}
 	select {
-	case ch.sendQueue <- bytes:
+	case ch.sendQueue <- msg:
 		atomic.AddInt32(&ch.sendQueueSize, 1)
 		return true
 	default:
+		ch.releaseBytes(n)
 		return false
 	}
 }
 
+// pop removes the oldest message from the queue, if any. sendQueueSize is
+// left to the caller, as it also counts the message being sent.
+func (ch *Channel) pop() (queuedMsg, bool) {
+	select {
+	case msg := <-ch.sendQueue:
+		ch.releaseBytes(int64(len(msg.bytes)))
+		return msg, true
+	default:
+		return queuedMsg{}, false
+	}
+}
+
+// dropNewest gives up on queuing msg, which was reserved.
+func (ch *Channel) dropNewest(msg queuedMsg) error {
+	ch.release(msg)
+	ch.conn.sendOverflow(ch.desc.ID, OverflowDropNewest)
+	return ErrSendQueueFull
+}
+
+// pushDroppingOldest queues msg, discarding the oldest queued messages until
+// there is room. Other senders may fill the room first, hence the loop. Only
+// a message that would not fit in an empty queue is dropped.
+func (ch *Channel) pushDroppingOldest(msg queuedMsg) error {
+	if limit := ch.desc.SendQueueByteCapacity; limit > 0 && int64(len(msg.bytes)) > limit {
+		return ch.dropNewest(msg)
+	}
+	for !ch.pushNonBlocking(msg) {
+		if old, ok := ch.pop(); ok {
+			atomic.AddInt32(&ch.sendQueueSize, -1)
+			ch.release(old)
+			ch.conn.sendOverflow(ch.desc.ID, OverflowDropOldest)
+		}
+	}
+	return nil
+}
+
 // Goroutine-safe.
 func (ch *Channel) loadSendQueueSize() (size int) {
 	return int(atomic.LoadInt32(&ch.sendQueueSize))
@@ -851,10 +1600,13 @@ func (ch *Channel) canSend() bool {
 // Goroutine-safe.
 func (ch *Channel) isSendPending() bool {
 	if len(ch.sending) == 0 {
-		if len(ch.sendQueue) == 0 {
+		msg, ok := ch.pop()
+		if !ok {
 			return false
 		}
-		ch.sending = <-ch.sendQueue
+		ch.sending = msg.bytes
+		ch.sendSeq++
+		ch.release(msg)
 	}
 	return true
 }
@@ -874,6 +1626,10 @@ func (ch *Channel) updateNextPacket() {
 		ch.sending = ch.sending[maxSize:]
 	}
 
+	if ch.conn.config.DebugSequenceNumbers {
+		ch.nextPacketMsg.Sequence = ch.sendSeq
+	}
+
 	ch.nextP2pWrapperPacketMsg.PacketMsg = ch.nextPacketMsg
 	ch.nextPacket.Sum = ch.nextP2pWrapperPacketMsg
 }
@@ -903,6 +1659,9 @@ func (ch *Channel) recvPacketMsg(packet tmp2p.PacketMsg) ([]byte, error) {
 
 	ch.recving = append(ch.recving, packet.Data...)
 	if packet.EOF {
+		if packet.Sequence != 0 {
+			ch.checkRecvSeq(packet.Sequence)
+		}
 		msgBytes := ch.recving
 
 		// clear the slice without re-allocating.
@@ -915,6 +1674,21 @@ func (ch *Channel) recvPacketMsg(packet tmp2p.PacketMsg) ([]byte, error) {
 	return nil, nil
 }
 
+// checkRecvSeq logs and counts the messages missing between the last message
+// received and the one with sequence number seq.
+// Not goroutine-safe.
+func (ch *Channel) checkRecvSeq(seq uint64) {
+	if ch.recvSeq != 0 && seq != ch.recvSeq+1 {
+		expected := ch.recvSeq + 1
+		if seq > expected {
+			atomic.AddInt64(&ch.missedMessages, int64(seq-expected))
+		}
+		ch.Logger.Error("Gap in channel sequence numbers",
+			"conn", ch.conn, "chID", ch.desc.ID, "expected", expected, "got", seq)
+	}
+	ch.recvSeq = seq
+}
+
 // Call this periodically to update stats for throttling purposes.
 // Not goroutine-safe.
 func (ch *Channel) updateStats() {
@@ -2,7 +2,10 @@ package conn
 
 import (
 	"encoding/hex"
+	"io"
 	"net"
+	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	pbtypes "github.com/cometbft/cometbft/api/cometbft/types/v1"
 	"github.com/cometbft/cometbft/libs/log"
 	"github.com/cometbft/cometbft/libs/protoio"
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
 )
 
 const maxPingPongPacketSize = 1024 // bytes
@@ -112,6 +116,116 @@ func TestMConnectionSend(t *testing.T) {
 	assert.False(t, mconn.Send(0x05, []byte("Absorbing Man")), "Send should return false because channel is unknown")
 }
 
+func TestMConnectionSendFlushImmediate(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	cfg := DefaultMConnConfig()
+	cfg.FlushThrottle = 0
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 1}}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	// nothing arms the flush timer, so the message only arrives if it is
+	// flushed right after being written.
+	msg := []byte("Quicksilver")
+	assert.True(t, mconn.Send(0x01, msg))
+	errc := make(chan error, 1)
+	go func() {
+		_, err := server.Read(make([]byte, len(msg)))
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("message was not flushed")
+	}
+}
+
+func TestMConnectionFlush(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	cfg := DefaultMConnConfig()
+	cfg.FlushThrottle = time.Hour
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 1}}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+
+	// the flush throttle holds the message back until it is flushed
+	// explicitly.
+	msg := []byte("Hawkeye")
+	assert.True(t, mconn.Send(0x01, msg))
+	errc := make(chan error, 1)
+	go func() {
+		_, err := server.Read(make([]byte, len(msg)))
+		errc <- err
+		// keep reading the rest of the packet, for the flush to complete.
+		_, _ = io.Copy(io.Discard, server)
+	}()
+	require.NoError(t, mconn.Flush())
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("message was not flushed")
+	}
+	assert.True(t, mconn.IsRunning())
+
+	require.NoError(t, mconn.Stop())
+	require.ErrorIs(t, mconn.Flush(), ErrNotRunning)
+}
+
+func TestMConnectionFlushSustainedSends(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+
+	cfg := DefaultMConnConfig()
+	cfg.FlushThrottle = time.Hour
+	// throttled, so that the senders keep up with the connection.
+	cfg.SendRate = 100_000
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 100}}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	// keep the queue from ever running empty.
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 2; i++ {
+		go func() {
+			msg := make([]byte, 100)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					mconn.Send(0x01, msg)
+				}
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	errc := make(chan error, 1)
+	go func() { errc <- mconn.Flush() }()
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush did not return under sustained sends")
+	}
+}
+
 func TestMConnectionReceive(t *testing.T) {
 	server, client := NetPipe()
 	defer server.Close()
@@ -148,6 +262,219 @@ func TestMConnectionReceive(t *testing.T) {
 	}
 }
 
+func TestMConnectionReceiveOrdered(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	const numMsgs = 20
+	receivedCh := make(chan []byte, numMsgs)
+	onReceive := func(_ byte, msgBytes []byte) {
+		// msgBytes is reused by the connection, so copy it
+		receivedCh <- append([]byte(nil), msgBytes...)
+	}
+	mconn1 := createMConnectionWithCallbacks(client, onReceive, func(_ any) {})
+	require.True(t, mconn1.channels[0].desc.Ordered())
+	err := mconn1.Start()
+	require.NoError(t, err)
+	defer mconn1.Stop() //nolint:errcheck // ignore for tests
+
+	mconn2 := createTestMConnection(server)
+	err = mconn2.Start()
+	require.NoError(t, err)
+	defer mconn2.Stop() //nolint:errcheck // ignore for tests
+
+	go func() {
+		for i := byte(0); i < numMsgs; i++ {
+			mconn2.Send(0x01, []byte{i})
+		}
+	}()
+
+	for i := byte(0); i < numMsgs; i++ {
+		select {
+		case receivedBytes := <-receivedCh:
+			require.Equal(t, []byte{i}, receivedBytes)
+		case <-time.After(time.Second):
+			t.Fatalf("Did not receive message %d in 1s", i)
+		}
+	}
+}
+
+func TestMConnectionReceiveUnordered(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	// The first message on the unordered channel blocks its Receive, the
+	// messages after it are handed over regardless.
+	block := make(chan struct{})
+	defer close(block)
+	receivedCh := make(chan []byte, 3)
+	onReceive := func(_ byte, msgBytes []byte) {
+		if msgBytes[0] == 0 {
+			<-block
+		}
+		receivedCh <- msgBytes
+	}
+	chDescs := []*ChannelDescriptor{
+		{ID: 0x01, Priority: 1, Unordered: true},
+		{ID: 0x02, Priority: 1},
+	}
+	mconn1 := NewMConnectionWithConfig(client, chDescs, onReceive, func(_ any) {}, DefaultMConnConfig())
+	mconn1.SetLogger(log.TestingLogger())
+	require.False(t, mconn1.channels[0].desc.Ordered())
+	require.NoError(t, mconn1.Start())
+	defer mconn1.Stop() //nolint:errcheck // ignore for tests
+
+	mconn2 := NewMConnection(server, chDescs, func(byte, []byte) {}, func(any) {})
+	mconn2.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn2.Start())
+	defer mconn2.Stop() //nolint:errcheck // ignore for tests
+
+	assert.True(t, mconn2.Send(0x01, []byte{0}))
+	assert.True(t, mconn2.Send(0x01, []byte{1}))
+	assert.True(t, mconn2.Send(0x02, []byte{2}))
+	var received [][]byte
+	for len(received) < 2 {
+		select {
+		case receivedBytes := <-receivedCh:
+			received = append(received, receivedBytes)
+		case <-time.After(time.Second):
+			t.Fatalf("Received only %v in 1s", received)
+		}
+	}
+	assert.ElementsMatch(t, [][]byte{{1}, {2}}, received)
+}
+
+func TestMConnectionAddChannel(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	receivedCh := make(chan byte, 1)
+	onReceive := func(chID byte, _ []byte) {
+		receivedCh <- chID
+	}
+	mconn1 := createMConnectionWithCallbacks(client, onReceive, func(_ any) {})
+	err := mconn1.Start()
+	require.NoError(t, err)
+	defer mconn1.Stop() //nolint:errcheck // ignore for tests
+
+	mconn2 := createTestMConnection(server)
+	err = mconn2.Start()
+	require.NoError(t, err)
+	defer mconn2.Stop() //nolint:errcheck // ignore for tests
+
+	desc := &ChannelDescriptor{ID: 0x02, Priority: 1, SendQueueCapacity: 1}
+	assert.False(t, mconn2.Send(0x02, []byte("abc")))
+	require.NoError(t, mconn1.AddChannel(desc))
+	require.NoError(t, mconn2.AddChannel(desc))
+	require.ErrorIs(t, mconn1.AddChannel(desc), ErrDuplicateChannel{ID: 0x02})
+	assert.Len(t, mconn1.Status().Channels, 2)
+
+	assert.True(t, mconn2.Send(0x02, []byte("abc")))
+	select {
+	case chID := <-receivedCh:
+		assert.Equal(t, byte(0x02), chID)
+	case <-time.After(time.Second):
+		t.Fatal("Did not receive the message in 1s")
+	}
+}
+
+func TestMConnectionRemoveChannel(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	receivedCh := make(chan byte, 2)
+	onReceive := func(chID byte, _ []byte) {
+		receivedCh <- chID
+	}
+	errorsCh := make(chan any, 1)
+	onError := func(r any) {
+		errorsCh <- r
+	}
+	mconn1 := createMConnectionWithCallbacks(client, onReceive, onError)
+	desc := &ChannelDescriptor{ID: 0x02, Priority: 1, SendQueueCapacity: 1}
+	require.NoError(t, mconn1.AddChannel(desc))
+	require.NoError(t, mconn1.Start())
+	defer mconn1.Stop() //nolint:errcheck // ignore for tests
+
+	mconn2 := createTestMConnection(server)
+	require.NoError(t, mconn2.AddChannel(desc))
+	require.NoError(t, mconn2.Start())
+	defer mconn2.Stop() //nolint:errcheck // ignore for tests
+
+	require.NoError(t, mconn1.RemoveChannel(0x01))
+	require.ErrorIs(t, mconn1.RemoveChannel(0x01), ErrChannelNotFound{ID: 0x01})
+	assert.False(t, mconn1.Send(0x01, []byte("abc")))
+	assert.Len(t, mconn1.Status().Channels, 1)
+
+	// the message on the removed channel is dropped without an error
+	assert.True(t, mconn2.Send(0x01, []byte("abc")))
+	assert.True(t, mconn2.Send(0x02, []byte("abc")))
+	select {
+	case chID := <-receivedCh:
+		assert.Equal(t, byte(0x02), chID)
+	case err := <-errorsCh:
+		t.Fatalf("Connection failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("Did not receive the message in 1s")
+	}
+	assert.True(t, mconn1.IsRunning())
+}
+
+func TestMConnectionSequenceNumbers(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		server, client := NetPipe()
+		defer server.Close()
+		defer client.Close()
+
+		cfg := DefaultMConnConfig()
+		cfg.DebugSequenceNumbers = true
+		chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1}}
+		mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+		mconn.SetLogger(log.TestingLogger())
+		require.NoError(t, mconn.Start())
+		defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+		require.True(t, mconn.Send(0x01, []byte("a")))
+		require.True(t, mconn.Send(0x01, []byte("b")))
+
+		protoReader := protoio.NewDelimitedReader(server, mconn._maxPacketMsgSize)
+		for seq := uint64(1); seq <= 2; {
+			var pkt tmp2p.Packet
+			_, err := protoReader.ReadMsg(&pkt)
+			require.NoError(t, err)
+			if msg := pkt.GetPacketMsg(); msg != nil {
+				assert.Equal(t, seq, msg.Sequence)
+				seq++
+			}
+		}
+	})
+
+	t.Run("receive", func(t *testing.T) {
+		server, client := NetPipe()
+		defer server.Close()
+		defer client.Close()
+
+		receivedCh := make(chan struct{}, 3)
+		mconn := createMConnectionWithCallbacks(server, func(byte, []byte) { receivedCh <- struct{}{} }, func(any) {})
+		require.NoError(t, mconn.Start())
+		defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+		// messages 3 and 4 are lost
+		protoWriter := protoio.NewDelimitedWriter(client)
+		for _, seq := range []uint64{1, 2, 5} {
+			packet := tmp2p.PacketMsg{ChannelID: 0x01, EOF: true, Data: []byte("x"), Sequence: seq}
+			_, err := protoWriter.WriteMsg(mustWrapPacket(&packet))
+			require.NoError(t, err)
+			assert.True(t, expectSend(receivedCh), "message %d", seq)
+		}
+		assert.EqualValues(t, 2, mconn.Status().Channels[0].MissedMessages)
+	})
+}
+
 func TestMConnectionStatus(t *testing.T) {
 	server, client := NetPipe()
 	defer server.Close()
@@ -161,6 +488,52 @@ func TestMConnectionStatus(t *testing.T) {
 	status := mconn.Status()
 	assert.NotNil(t, status)
 	assert.Zero(t, status.Channels[0].SendQueueSize)
+	assert.Empty(t, status.LastError)
+	assert.True(t, status.LastErrorTime.IsZero())
+}
+
+func TestMConnectionReadWriteTimeout(t *testing.T) {
+	testCases := []struct {
+		name         string
+		readTimeout  time.Duration
+		writeTimeout time.Duration
+		send         bool
+	}{
+		{"read", 100 * time.Millisecond, 0, false},
+		{"write", 0, 100 * time.Millisecond, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Nothing is ever read from or written to server.
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+
+			errorsCh := make(chan any, 1)
+			cfg := DefaultMConnConfig()
+			cfg.ReadTimeout = tc.readTimeout
+			cfg.WriteTimeout = tc.writeTimeout
+			chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 1}}
+			mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(r any) { errorsCh <- r }, cfg)
+			mconn.SetLogger(log.TestingLogger())
+			require.NoError(t, mconn.Start())
+			defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+			start := time.Now()
+			if tc.send {
+				require.True(t, mconn.Send(0x01, []byte("abc")))
+			}
+			select {
+			case r := <-errorsCh:
+				err, ok := r.(error)
+				require.True(t, ok)
+				require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+				assert.Less(t, time.Since(start), time.Second)
+			case <-time.After(5 * time.Second):
+				t.Fatal("connection did not fail on the blackholed conn")
+			}
+		})
+	}
 }
 
 func TestMConnectionPongTimeoutResultsInError(t *testing.T) {
@@ -197,6 +570,9 @@ func TestMConnectionPongTimeoutResultsInError(t *testing.T) {
 		t.Fatalf("Expected error, but got %v", msgBytes)
 	case err := <-errorsCh:
 		assert.NotNil(t, err)
+		status := mconn.Status()
+		assert.Equal(t, "pong timeout", status.LastError)
+		assert.False(t, status.LastErrorTime.IsZero())
 	case <-time.After(pongTimerExpired):
 		t.Fatalf("Expected to receive error after %v", pongTimerExpired)
 	}
@@ -302,6 +678,59 @@ func TestMConnectionMultiplePings(t *testing.T) {
 	assert.True(t, mconn.IsRunning())
 }
 
+func TestMConnectionStatusLastReceiveTime(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	mconn := createTestMConnection(client)
+	err := mconn.Start()
+	require.NoError(t, err)
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	created := mconn.Status()
+	assert.Equal(t, created.LastReceiveTime, created.LastMsgReceiveTime)
+
+	// A ping only counts as a received packet, not as a message.
+	protoReader := protoio.NewDelimitedReader(server, maxPingPongPacketSize)
+	protoWriter := protoio.NewDelimitedWriter(server)
+	_, err = protoWriter.WriteMsg(mustWrapPacket(&tmp2p.PacketPing{}))
+	require.NoError(t, err)
+	var pkt tmp2p.Packet
+	_, err = protoReader.ReadMsg(&pkt)
+	require.NoError(t, err)
+
+	status := mconn.Status()
+	assert.True(t, status.LastReceiveTime.After(created.LastReceiveTime))
+	assert.Equal(t, created.LastMsgReceiveTime, status.LastMsgReceiveTime)
+}
+
+func TestMConnectionStatusPingRTT(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	mconn := createTestMConnection(client)
+	err := mconn.Start()
+	require.NoError(t, err)
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+	assert.Zero(t, mconn.Status().PingRTT)
+
+	protoReader := protoio.NewDelimitedReader(server, maxPingPongPacketSize)
+	protoWriter := protoio.NewDelimitedWriter(server)
+	var pkt tmp2p.Packet
+	_, err = protoReader.ReadMsg(&pkt)
+	require.NoError(t, err)
+	require.NotNil(t, pkt.GetPacketPing())
+	time.Sleep(10 * time.Millisecond)
+	_, err = protoWriter.WriteMsg(mustWrapPacket(&tmp2p.PacketPong{}))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return mconn.Status().PingRTT >= 10*time.Millisecond
+	}, time.Second, 5*time.Millisecond)
+}
+
 func TestMConnectionPingPongs(t *testing.T) {
 	// check that we are not leaking any go-routines
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
@@ -565,6 +994,260 @@ func TestMConnectionTrySend(t *testing.T) {
 	assert.Equal(t, "TrySend", <-resultCh)
 }
 
+func TestMConnectionMaxQueuedBytes(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	const limit = 4 * minWriteBufferSize
+	cfg := DefaultMConnConfig()
+	cfg.MaxQueuedBytes = limit
+	chDescs := []*ChannelDescriptor{
+		{ID: 0x01, Priority: 1, SendQueueCapacity: 10},
+		{ID: 0x02, Priority: 1, SendQueueCapacity: 10},
+	}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	err := mconn.Start()
+	require.NoError(t, err)
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	require.ErrorIs(t, mconn.TrySendE(0x01, make([]byte, limit+1)), ErrMaxQueuedBytes)
+
+	// Nothing reads from the server, so the sendRoutine blocks writing a
+	// message larger than its write buffer and all later ones stay queued.
+	require.NoError(t, mconn.TrySendE(0x01, make([]byte, 2*minWriteBufferSize)))
+	require.Eventually(t, func() bool { return mconn.QueuedBytes() == 0 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, mconn.TrySendE(0x01, make([]byte, limit*3/4)))
+	assert.EqualValues(t, limit*3/4, mconn.Status().QueuedBytes)
+
+	// The limit is shared by all channels, and Send fails without waiting.
+	start := time.Now()
+	err = mconn.SendE(0x02, make([]byte, limit/2))
+	require.ErrorIs(t, err, ErrMaxQueuedBytes)
+	assert.Less(t, time.Since(start), defaultSendTimeout)
+	require.ErrorIs(t, mconn.TrySendE(0x01, make([]byte, limit/4+1)), ErrMaxQueuedBytes)
+	assert.True(t, mconn.Send(0x02, make([]byte, limit/4)))
+	assert.EqualValues(t, limit, mconn.QueuedBytes())
+
+	// Removing a channel frees what was queued on it.
+	require.NoError(t, mconn.RemoveChannel(0x01))
+	assert.EqualValues(t, limit/4, mconn.QueuedBytes())
+}
+
+func TestMConnectionOverflowPolicy(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	var (
+		mtx      cmtsync.Mutex
+		actions  []OverflowPolicy
+		overflow = func(_ byte, action OverflowPolicy) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			actions = append(actions, action)
+		}
+		lastAction = func() OverflowPolicy {
+			mtx.Lock()
+			defer mtx.Unlock()
+			require.NotEmpty(t, actions)
+			return actions[len(actions)-1]
+		}
+	)
+	cfg := DefaultMConnConfig()
+	cfg.OnSendOverflow = overflow
+	chDescs := []*ChannelDescriptor{
+		{ID: 0x01, Priority: 1, SendQueueCapacity: 2},
+		{ID: 0x02, Priority: 1, SendQueueCapacity: 2, OverflowPolicy: OverflowDropNewest},
+		{ID: 0x03, Priority: 1, SendQueueCapacity: 2, OverflowPolicy: OverflowDropOldest},
+	}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	err := mconn.Start()
+	require.NoError(t, err)
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	// Nothing reads from the server, so the sendRoutine blocks writing a
+	// message larger than its write buffer and all later ones stay queued.
+	require.NoError(t, mconn.TrySendE(0x01, make([]byte, 2*minWriteBufferSize)))
+	require.Eventually(t, func() bool { return mconn.QueuedBytes() == 0 }, time.Second, 10*time.Millisecond)
+
+	for _, chID := range []byte{0x01, 0x02, 0x03} {
+		require.NoError(t, mconn.TrySendE(chID, []byte("a")))
+		require.NoError(t, mconn.TrySendE(chID, []byte("b")))
+	}
+
+	// OverflowBlock: TrySend can't wait.
+	require.ErrorIs(t, mconn.TrySendE(0x01, []byte("c")), ErrSendQueueFull)
+	assert.Equal(t, OverflowDropNewest, lastAction())
+
+	// OverflowDropNewest: nor can Send.
+	start := time.Now()
+	require.ErrorIs(t, mconn.SendE(0x02, []byte("c")), ErrSendQueueFull)
+	assert.Less(t, time.Since(start), defaultSendTimeout)
+	assert.Equal(t, OverflowDropNewest, lastAction())
+
+	// OverflowDropOldest: the message replaces the oldest one.
+	require.NoError(t, mconn.SendE(0x03, []byte("c")))
+	assert.Equal(t, OverflowDropOldest, lastAction())
+	require.NoError(t, mconn.TrySendE(0x03, []byte("d")))
+	assert.Equal(t, OverflowDropOldest, lastAction())
+	assert.EqualValues(t, len("ab")*2+len("cd"), mconn.QueuedBytes())
+	ch, ok := mconn.channel(0x03)
+	require.True(t, ok)
+	assert.Equal(t, []byte("c"), (<-ch.sendQueue).bytes)
+	assert.Equal(t, []byte("d"), (<-ch.sendQueue).bytes)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Len(t, actions, 4)
+}
+
+func TestMConnectionSendQueueByteCapacity(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	chDescs := []*ChannelDescriptor{
+		{ID: 0x01, Priority: 1, SendQueueCapacity: 10},
+		{ID: 0x02, Priority: 1, SendQueueCapacity: 10, SendQueueByteCapacity: 4},
+		{ID: 0x03, Priority: 1, SendQueueCapacity: 2, SendQueueByteCapacity: 4, OverflowPolicy: OverflowDropOldest},
+	}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, DefaultMConnConfig())
+	mconn.SetLogger(log.TestingLogger())
+	err := mconn.Start()
+	require.NoError(t, err)
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	// Nothing reads from the server, so the sendRoutine blocks writing a
+	// message larger than its write buffer and all later ones stay queued.
+	require.NoError(t, mconn.TrySendE(0x01, make([]byte, 2*minWriteBufferSize)))
+	require.Eventually(t, func() bool { return mconn.QueuedBytes() == 0 }, time.Second, 10*time.Millisecond)
+
+	// The byte limit is hit before the message count one, and Send doesn't
+	// wait for room.
+	require.NoError(t, mconn.TrySendE(0x02, []byte("abc")))
+	require.ErrorIs(t, mconn.TrySendE(0x02, []byte("de")), ErrSendQueueFull)
+	start := time.Now()
+	require.ErrorIs(t, mconn.SendE(0x02, []byte("de")), ErrSendQueueFull)
+	assert.Less(t, time.Since(start), defaultSendTimeout)
+	assert.True(t, mconn.Send(0x02, []byte("d")))
+
+	// OverflowDropOldest discards as many messages as needed to fit, under
+	// whichever limit is hit.
+	require.NoError(t, mconn.TrySendE(0x03, []byte("a")))
+	require.NoError(t, mconn.TrySendE(0x03, []byte("b")))
+	require.NoError(t, mconn.TrySendE(0x03, []byte("c")))
+	require.NoError(t, mconn.TrySendE(0x03, []byte("def")))
+	require.ErrorIs(t, mconn.SendE(0x03, []byte("ghijk")), ErrSendQueueFull)
+	ch, ok := mconn.channel(0x03)
+	require.True(t, ok)
+	assert.EqualValues(t, len("cdef"), atomic.LoadInt64(&ch.queuedBytes))
+	assert.EqualValues(t, len("abcd")+len("cdef"), mconn.QueuedBytes())
+
+	// Removing a channel frees what was queued on it.
+	require.NoError(t, mconn.RemoveChannel(0x03))
+	assert.Zero(t, atomic.LoadInt64(&ch.queuedBytes))
+}
+
+func TestMConnectionSendKeyed(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 4}}
+	mconn := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, DefaultMConnConfig())
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	// Nothing reads from the server yet, so later messages stay queued.
+	require.NoError(t, mconn.TrySendE(0x01, make([]byte, 2*minWriteBufferSize)))
+	require.Eventually(t, func() bool { return mconn.QueuedBytes() == 0 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, mconn.TrySendKeyedE(0x01, "a", []byte("a1")))
+	require.ErrorIs(t, mconn.TrySendKeyedE(0x01, "a", []byte("a2")), ErrDuplicateKey)
+	require.ErrorIs(t, mconn.SendKeyedE(0x01, "a", []byte("a3")), ErrDuplicateKey)
+	require.NoError(t, mconn.SendKeyedE(0x01, "b", []byte("b1")))
+	require.NoError(t, mconn.TrySendKeyedE(0x01, "", []byte("c")))
+	require.NoError(t, mconn.TrySendKeyedE(0x01, "", []byte("c")))
+	assert.EqualValues(t, len("a1b1cc"), mconn.QueuedBytes())
+
+	// Once sent, a message with the same key may be queued again.
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+	require.Eventually(t, func() bool {
+		return mconn.TrySendKeyedE(0x01, "a", []byte("a4")) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMConnectionMaxQueueAge(t *testing.T) {
+	server, client := NetPipe()
+	defer server.Close()
+	defer client.Close()
+
+	receivedCh := make(chan []byte, 4)
+	staleCh := make(chan byte, 4)
+	release := make(chan struct{})
+	cfg := DefaultMConnConfig()
+	cfg.OnRecvStale = func(chID byte) { staleCh <- chID }
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, MaxQueueAge: 50 * time.Millisecond}}
+	mconn := NewMConnectionWithConfig(server, chDescs, func(_ byte, msgBytes []byte) {
+		if string(msgBytes) == "slow" {
+			<-release
+		}
+		receivedCh <- append([]byte(nil), msgBytes...)
+	}, func(any) {}, cfg)
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	defer mconn.Stop() //nolint:errcheck // ignore for tests
+
+	protoWriter := protoio.NewDelimitedWriter(client)
+	write := func(data string, eof bool) {
+		_, err := protoWriter.WriteMsg(mustWrapPacket(&tmp2p.PacketMsg{ChannelID: 0x01, EOF: eof, Data: []byte(data)}))
+		require.NoError(t, err)
+	}
+	receive := func(want string) {
+		t.Helper()
+		select {
+		case msgBytes := <-receivedCh:
+			assert.Equal(t, want, string(msgBytes))
+		case <-time.After(time.Second):
+			t.Fatalf("%q was not received", want)
+		}
+	}
+
+	// A message whose packets are slow to arrive is not stale: it only ages
+	// once it is read in full.
+	write("multi", false)
+	time.Sleep(100 * time.Millisecond)
+	write("packet", true)
+	receive("multipacket")
+
+	// Messages read while the reactor is busy with the one before them get
+	// stale, even if they were sent in a single packet.
+	write("slow", true)
+	write("vote1", true)
+	write("vote2", true)
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	receive("slow")
+	for i := 0; i < 2; i++ {
+		select {
+		case chID := <-staleCh:
+			assert.EqualValues(t, 0x01, chID)
+		case <-time.After(time.Second):
+			t.Fatal("stale message was not dropped")
+		}
+	}
+
+	write("fresh", true)
+	receive("fresh")
+	assert.Empty(t, receivedCh)
+	assert.Empty(t, staleCh)
+}
+
 //nolint:lll //ignore line length for tests
 func TestConnVectors(t *testing.T) {
 	testCases := []struct {
@@ -629,3 +1312,70 @@ func stopAll(t *testing.T, stoppers ...stopper) func() {
 		}
 	}
 }
+
+// BenchmarkMConnectionFlushThrottle compares flushing after every batch of
+// packets with a batched flush. "latency" sends one message at a time and
+// waits for it to be received, "throughput" keeps the send queue full.
+func BenchmarkMConnectionFlushThrottle(b *testing.B) {
+	for _, tc := range []struct {
+		name          string
+		flushThrottle time.Duration
+	}{
+		{"immediate", 0},
+		{"batched_1ms", time.Millisecond},
+		{"batched_10ms", 10 * time.Millisecond},
+	} {
+		b.Run(tc.name+"/latency", func(b *testing.B) {
+			send, received := benchmarkMConnectionPair(b, tc.flushThrottle)
+			msg := make([]byte, 256)
+			b.SetBytes(int64(len(msg)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				send.Send(0x01, msg)
+				<-received
+			}
+		})
+		b.Run(tc.name+"/throughput", func(b *testing.B) {
+			send, received := benchmarkMConnectionPair(b, tc.flushThrottle)
+			msg := make([]byte, 256)
+			b.SetBytes(int64(len(msg)))
+			b.ResetTimer()
+			go func() {
+				for i := 0; i < b.N; i++ {
+					send.Send(0x01, msg)
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				<-received
+			}
+		})
+	}
+}
+
+func benchmarkMConnectionPair(b *testing.B, flushThrottle time.Duration) (*MConnection, <-chan struct{}) {
+	b.Helper()
+	server, client := NetPipe()
+	b.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	cfg := DefaultMConnConfig()
+	cfg.FlushThrottle = flushThrottle
+	cfg.SendRate = 0
+	cfg.RecvRate = 0
+	chDescs := []*ChannelDescriptor{{ID: 0x01, Priority: 1, SendQueueCapacity: 100}}
+	received := make(chan struct{}, 100)
+	onReceive := func(byte, []byte) { received <- struct{}{} }
+
+	send := NewMConnectionWithConfig(client, chDescs, func(byte, []byte) {}, func(any) {}, cfg)
+	recv := NewMConnectionWithConfig(server, chDescs, onReceive, func(any) {}, cfg)
+	for _, c := range []*MConnection{send, recv} {
+		c.SetLogger(log.NewNopLogger())
+		if err := c.Start(); err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { _ = c.Stop() })
+	}
+	return send, received
+}
@@ -9,6 +9,12 @@ var (
 	ErrInvalidSecretConnKeySend = errors.New("send invalid secret connection key")
 	ErrInvalidSecretConnKeyRecv = errors.New("invalid receive SecretConnection Key")
 	ErrChallengeVerification    = errors.New("challenge verification failed")
+
+	// Errors returned by MConnection.SendE and TrySendE.
+	ErrNotRunning     = errors.New("connection is not running")
+	ErrSendQueueFull  = errors.New("channel send queue is full")
+	ErrMaxQueuedBytes = errors.New("connection has too many bytes queued for sending")
+	ErrDuplicateKey   = errors.New("a message with the same key is already queued")
 )
 
 // ErrPacketWrite Packet error when writing.
@@ -62,3 +68,19 @@ type ErrChunkTooBig struct {
 func (e ErrChunkTooBig) Error() string {
 	return fmt.Sprintf("chunk too big (max: %d, got %d)", e.Max, e.Received)
 }
+
+type ErrDuplicateChannel struct {
+	ID byte
+}
+
+func (e ErrDuplicateChannel) Error() string {
+	return fmt.Sprintf("channel %X already exists", e.ID)
+}
+
+type ErrChannelNotFound struct {
+	ID byte
+}
+
+func (e ErrChannelNotFound) Error() string {
+	return fmt.Sprintf("channel %X does not exist", e.ID)
+}
@@ -49,6 +49,12 @@ const (
 	defaultReadBufferSize = 65 * 1024
 )
 
+// Algorithms used by every SecretConnection, reported by SecurityInfo.
+const (
+	KeyExchangeX25519      = "X25519"
+	CipherChaCha20Poly1305 = "ChaCha20-Poly1305"
+)
+
 var (
 	ErrSmallOrderRemotePubKey    = errors.New("detected low order point from remote peer")
 	secretConnKeyAndChallengeGen = []byte("TENDERMINT_SECRET_CONNECTION_KEY_AND_CHALLENGE_GEN")
@@ -201,6 +207,23 @@ func (sc *SecretConnection) RemotePubKey() crypto.PubKey {
 	return sc.remPubKey
 }
 
+// SecurityInfo describes how a connection to a peer is secured.
+type SecurityInfo struct {
+	KeyExchange      string `json:"key_exchange"`        // key agreement of the handshake
+	Cipher           string `json:"cipher"`              // AEAD encrypting the frames
+	RemotePubKeyType string `json:"remote_pub_key_type"` // type of the key the peer authenticated with
+}
+
+// SecurityInfo returns the algorithms securing the connection and the type of
+// the remote pubkey.
+func (sc *SecretConnection) SecurityInfo() SecurityInfo {
+	return SecurityInfo{
+		KeyExchange:      KeyExchangeX25519,
+		Cipher:           CipherChaCha20Poly1305,
+		RemotePubKeyType: sc.remPubKey.Type(),
+	}
+}
+
 // Writes encrypted frames of `totalFrameSize + aeadSizeOverhead`.
 // CONTRACT: data smaller than dataMaxSize is written atomically.
 func (sc *SecretConnection) Write(data []byte) (n int, err error) {
@@ -63,6 +63,20 @@ func TestSecretConnectionHandshake(t *testing.T) {
 	}
 }
 
+func TestSecretConnectionSecurityInfo(t *testing.T) {
+	fooSecConn, barSecConn := makeSecretConnPair(t)
+	defer fooSecConn.Close()
+	defer barSecConn.Close()
+
+	want := SecurityInfo{
+		KeyExchange:      KeyExchangeX25519,
+		Cipher:           CipherChaCha20Poly1305,
+		RemotePubKeyType: ed25519.KeyType,
+	}
+	assert.Equal(t, want, fooSecConn.SecurityInfo())
+	assert.Equal(t, want, barSecConn.SecurityInfo())
+}
+
 func TestConcurrentWrite(t *testing.T) {
 	fooSecConn, barSecConn := makeSecretConnPair(t)
 	fooWriteText := cmtrand.Str(dataMaxSize)
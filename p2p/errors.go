@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
+	"time"
 
 	"github.com/cometbft/cometbft/libs/bytes"
 )
@@ -13,6 +15,17 @@ var (
 	ErrNoIP       = errors.New("no IP address found")
 	ErrNoNodeInfo = errors.New("no node info found")
 	ErrInvalidIP  = errors.New("invalid IP address")
+
+	ErrSwitchNotRunning = errors.New("switch is not running")
+
+	// Errors returned by Peer.SendE.
+	ErrPeerStopped    = errors.New("peer is not running")
+	ErrUnknownChannel = errors.New("peer does not have the channel")
+	ErrChannelRemoved = errors.New("channel was removed from the peer")
+	ErrQueueFull      = errors.New("peer send queue is full")
+	ErrSendMemoryCap  = errors.New("peer has too many bytes queued for sending")
+	ErrMarshal        = errors.New("failed to marshal message")
+	ErrObserverPeer   = errors.New("nothing is sent to observer peers")
 )
 
 // ErrFilterTimeout indicates that a filter operation timed out.
@@ -25,16 +38,18 @@ func (ErrFilterTimeout) Error() string {
 // ErrRejected indicates that a Peer was rejected carrying additional
 // information as to the reason.
 type ErrRejected struct {
-	addr              NetAddress
-	conn              net.Conn
-	err               error
-	id                ID
-	isAuthFailure     bool
-	isDuplicate       bool
-	isFiltered        bool
-	isIncompatible    bool
-	isNodeInfoInvalid bool
-	isSelf            bool
+	addr               NetAddress
+	conn               net.Conn
+	err                error
+	id                 ID
+	isAuthFailure      bool
+	isDuplicate        bool
+	isFiltered         bool
+	isIncompatible     bool
+	isNodeInfoInvalid  bool
+	isOverloaded       bool
+	isRejectedByPolicy bool
+	isSelf             bool
 }
 
 // Addr returns the NetAddress for the rejected Peer.
@@ -81,6 +96,17 @@ func (e ErrRejected) Error() string {
 		return fmt.Sprintf("invalid NodeInfo: %s", e.err)
 	}
 
+	if e.isOverloaded {
+		if e.conn != nil {
+			return fmt.Sprintf("overloaded, rejected CONN<%s>: %s", e.conn.RemoteAddr().String(), e.err)
+		}
+		return fmt.Sprintf("overloaded: %s", e.err)
+	}
+
+	if e.isRejectedByPolicy {
+		return fmt.Sprintf("rejected by policy ID<%v>: %s", e.id, e.err)
+	}
+
 	if e.isSelf {
 		return fmt.Sprintf("self ID<%v>", e.id)
 	}
@@ -103,9 +129,26 @@ func (e ErrRejected) IsIncompatible() bool { return e.isIncompatible }
 // IsNodeInfoInvalid when the sent NodeInfo is not valid.
 func (e ErrRejected) IsNodeInfoInvalid() bool { return e.isNodeInfoInvalid }
 
+// IsOverloaded when the AcceptPolicy rejected the connection.
+func (e ErrRejected) IsOverloaded() bool { return e.isOverloaded }
+
+// IsRejectedByPolicy when Peer ID is denied or not on the allow list.
+func (e ErrRejected) IsRejectedByPolicy() bool { return e.isRejectedByPolicy }
+
 // IsSelf when Peer is our own node.
 func (e ErrRejected) IsSelf() bool { return e.isSelf }
 
+// ErrNodeInfoTooLarge is returned by the handshake when the peer announces a
+// NodeInfo larger than the maximum size.
+type ErrNodeInfoTooLarge struct {
+	Size int
+	Max  int
+}
+
+func (e ErrNodeInfoTooLarge) Error() string {
+	return fmt.Sprintf("NodeInfo of %d bytes exceeds the maximum of %d", e.Size, e.Max)
+}
+
 // ErrSwitchDuplicatePeerID to be raised when a peer is connecting with a known
 // ID.
 type ErrSwitchDuplicatePeerID struct {
@@ -162,6 +205,70 @@ func (ErrPeerRemoval) Error() string {
 	return "peer removal failed"
 }
 
+// ErrPeerInactive is raised when a peer is disconnected for having sent
+// nothing for longer than the configured inactivity timeout.
+type ErrPeerInactive struct {
+	Idle time.Duration
+}
+
+func (e ErrPeerInactive) Error() string {
+	return fmt.Sprintf("peer inactive for %v", e.Idle)
+}
+
+// ErrPeerEvicted is raised when an inbound peer is disconnected to make room
+// for a higher scoring one, see SwitchPeerEviction.
+type ErrPeerEvicted struct {
+	By ID
+}
+
+func (e ErrPeerEvicted) Error() string {
+	return fmt.Sprintf("peer evicted for %v", e.By)
+}
+
+// ErrPeerReplaced is raised when a peer is disconnected because both nodes
+// dialed each other and they keep the other connection, see
+// Switch.replacesDuplicate.
+type ErrPeerReplaced struct {
+	Outbound bool // direction of the connection kept
+}
+
+func (e ErrPeerReplaced) Error() string {
+	if e.Outbound {
+		return "peer replaced by an outbound connection"
+	}
+	return "peer replaced by an inbound connection"
+}
+
+// ErrEnvelopeNilMessage is raised when an envelope carries no message.
+type ErrEnvelopeNilMessage struct {
+	ChannelID byte
+}
+
+func (e ErrEnvelopeNilMessage) Error() string {
+	return fmt.Sprintf("envelope for channel %#x has a nil message", e.ChannelID)
+}
+
+// ErrEnvelopeNilSrc is raised when an envelope's Src is set to a nil peer.
+type ErrEnvelopeNilSrc struct {
+	ChannelID byte
+}
+
+func (e ErrEnvelopeNilSrc) Error() string {
+	return fmt.Sprintf("envelope for channel %#x has a nil source peer", e.ChannelID)
+}
+
+// ErrChannelMessageType is raised when a message is sent on a channel whose
+// reactor registered another message type for it, see SendTyped.
+type ErrChannelMessageType struct {
+	ChannelID byte
+	Want      reflect.Type
+	Got       reflect.Type
+}
+
+func (e ErrChannelMessageType) Error() string {
+	return fmt.Sprintf("message of type %v sent on channel %#x, which carries %v", e.Got, e.ChannelID, e.Want)
+}
+
 // -------------------------------------------------------------------
 
 type ErrNetAddressNoID struct {
@@ -194,6 +301,21 @@ func (e ErrNetAddressLookup) Error() string {
 
 func (e ErrNetAddressLookup) Unwrap() error { return e.Err }
 
+// ErrProxyDial is returned when dialing a peer through the SOCKS5 proxy
+// fails, either because the proxy can't be reached or because it couldn't
+// connect to the peer, see MultiplexTransportSOCKS5Proxy.
+type ErrProxyDial struct {
+	Proxy string
+	Addr  string
+	Err   error
+}
+
+func (e ErrProxyDial) Error() string {
+	return fmt.Sprintf("error dialing %s through proxy %s: %v", e.Addr, e.Proxy, e.Err)
+}
+
+func (e ErrProxyDial) Unwrap() error { return e.Err }
+
 // ErrCurrentlyDialingOrExistingAddress indicates that we're currently
 // dialing this address or it belongs to an existing peer.
 type ErrCurrentlyDialingOrExistingAddress struct {
@@ -250,6 +372,35 @@ func (e ErrChannelsTooLong) Error() string {
 	return fmt.Sprintf("channels is too long (max: %d, got: %d)", e.Max, e.Length)
 }
 
+// ErrTooManyUnknownChannels is returned when a peer advertises more channels
+// we don't have a reactor for than allowed by
+// MultiplexTransportMaxUnknownChannels.
+type ErrTooManyUnknownChannels struct {
+	Unknown int
+	Max     int
+}
+
+func (e ErrTooManyUnknownChannels) Error() string {
+	return fmt.Sprintf("too many unknown channels (max: %d, got: %d)", e.Max, e.Unknown)
+}
+
+type ErrTooManyListenAddrs struct {
+	Length int
+	Max    int
+}
+
+func (e ErrTooManyListenAddrs) Error() string {
+	return fmt.Sprintf("too many additional listen addresses (max: %d, got: %d)", e.Max, e.Length)
+}
+
+type ErrInvalidFeature struct {
+	Feature string
+}
+
+func (e ErrInvalidFeature) Error() string {
+	return fmt.Sprintf("feature must be valid non-empty ASCII text without tabs, but got %v", e.Feature)
+}
+
 type ErrInvalidMoniker struct {
 	Moniker string
 }
@@ -271,7 +422,25 @@ type ErrInvalidRPCAddress struct {
 }
 
 func (e ErrInvalidRPCAddress) Error() string {
-	return fmt.Sprintf("rpc address must be valid ASCII text without tabs, but got %v", e.RPCAddress)
+	return fmt.Sprintf("rpc address must be a valid listen address in ASCII text without tabs, but got %v", e.RPCAddress)
+}
+
+type ErrTooManyOtherFields struct {
+	Length int
+	Max    int
+}
+
+func (e ErrTooManyOtherFields) Error() string {
+	return fmt.Sprintf("too many extra other fields (max: %d, got: %d)", e.Max, e.Length)
+}
+
+type ErrInvalidOtherField struct {
+	Name  string
+	Value string
+}
+
+func (e ErrInvalidOtherField) Error() string {
+	return fmt.Sprintf("other field name must be valid non-empty ASCII text and its value valid ASCII text, but got %q: %q", e.Name, e.Value)
 }
 
 type ErrInvalidNodeInfoType struct {
@@ -293,6 +462,18 @@ func (e ErrDifferentBlockVersion) Error() string {
 		e.Other, e.Our)
 }
 
+// ErrUnsupportedP2PVersion is returned when a peer's P2P protocol version is
+// older than the oldest one we can speak.
+type ErrUnsupportedP2PVersion struct {
+	Other uint64
+	Min   uint64
+}
+
+func (e ErrUnsupportedP2PVersion) Error() string {
+	return fmt.Sprintf("peer is on an unsupported P2P version. Got %d, expected at least %d",
+		e.Other, e.Min)
+}
+
 type ErrDifferentNetwork struct {
 	Other string
 	Our   string
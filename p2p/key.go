@@ -12,7 +12,8 @@ import (
 	cmtjson "github.com/cometbft/cometbft/libs/json"
 )
 
-// ID is a hex-encoded crypto.Address.
+// ID is a hex-encoded crypto.Address. IDs are comparable and can be used as
+// map keys; use SamePeer to compare two peers.
 type ID string
 
 // IDByteLength is the length of a crypto.Address. Currently only 20.
@@ -38,6 +38,26 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "message_send_bytes_total",
 			Help:      "Number of bytes of each message type sent.",
 		}, append(labels, "message_type")).With(labelsAndValues...),
+		ReactorProcessDuration: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "reactor_process_duration",
+			Help:      "Time in seconds a reactor took to process a received message, by reactor and message type.",
+
+			Buckets: stdprometheus.ExponentialBucketsRange(0.0001, 10, 6),
+		}, append(labels, "reactor", "message_type")).With(labelsAndValues...),
+		RecvBudgetBytes: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "recv_budget_bytes",
+			Help:      "Bytes of received messages being processed across all peers, see max_recv_buffered_bytes.",
+		}, labels).With(labelsAndValues...),
+		RecvBudgetWaits: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "recv_budget_waits",
+			Help:      "Number of messages whose processing waited for others to finish because the node was over max_recv_buffered_bytes.",
+		}, labels).With(labelsAndValues...),
 		RecvRateLimiterDelay: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
@@ -50,16 +70,140 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "send_rate_limiter_delay",
 			Help:      "Time in seconds spent sleeping by the send rate limiter",
 		}, append(labels, "peer_id")).With(labelsAndValues...),
+		PeerSendLatency: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_send_latency",
+			Help:      "Time in seconds a Send or TrySend took to enqueue a message on a channel, whether it succeeded or not.",
+
+			Buckets: stdprometheus.ExponentialBucketsRange(0.0001, 10, 6),
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PeerRejectedByPolicy: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_rejected_by_policy",
+			Help:      "Number of inbound connections rejected by the allowed/denied peer ID policy.",
+		}, labels).With(labelsAndValues...),
+		PeerRejectedUnknownChannels: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_rejected_unknown_channels",
+			Help:      "Number of peers rejected for advertising too many channels we don't have a reactor for.",
+		}, labels).With(labelsAndValues...),
+		HandshakeRejectedTooLarge: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "handshake_rejected_too_large",
+			Help:      "Number of peers rejected for sending a NodeInfo larger than the maximum size in the handshake.",
+		}, labels).With(labelsAndValues...),
+		PeerRejectedOverload: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_rejected_overload",
+			Help:      "Number of inbound connections rejected by the accept policy, because the node is overloaded.",
+		}, labels).With(labelsAndValues...),
+		PeerSendUnknownChannelTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_send_unknown_channel_total",
+			Help:      "Number of messages not sent because the peer does not support the channel.",
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PeerSendMemoryCapHit: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_send_memory_cap_hit",
+			Help:      "Number of messages not sent because the peer already had max_peer_queued_bytes queued for sending.",
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PeerSendQueueOverflowTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_send_queue_overflow_total",
+			Help:      "Number of messages sent on a channel whose send queue was full, by the action the channel's overflow policy took: block, drop_newest or drop_oldest.",
+		}, append(labels, "ch_id", "action")).With(labelsAndValues...),
+		PeerSendDeduped: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_send_deduped",
+			Help:      "Number of messages not queued because one with the same DedupKey already was.",
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PeerReceiveStaleDropped: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_receive_stale_dropped",
+			Help:      "Number of received messages dropped for being older than their channel's MaxQueueAge.",
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PeerReceiveInvalid: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_receive_invalid",
+			Help:      "Number of received messages dropped for failing to decode into the message type of their channel; the peer is stopped.",
+		}, append(labels, "ch_id")).With(labelsAndValues...),
+		PexRequestsThrottled: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "pex_requests_throttled",
+			Help:      "Number of PEX requests dropped because the peer exceeded its request rate.",
+		}, append(labels, "peer_id")).With(labelsAndValues...),
+		NodeSendBytesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "node_send_bytes_total",
+			Help:      "Number of bytes sent to all peers.",
+		}, labels).With(labelsAndValues...),
+		NodeReceiveBytesTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "node_receive_bytes_total",
+			Help:      "Number of bytes received from all peers.",
+		}, labels).With(labelsAndValues...),
+		NodeSendRate: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "node_send_rate",
+			Help:      "Current rate in bytes per second at which data is sent to all peers.",
+		}, labels).With(labelsAndValues...),
+		NodeReceiveRate: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "node_receive_rate",
+			Help:      "Current rate in bytes per second at which data is received from all peers.",
+		}, labels).With(labelsAndValues...),
+		PeerDisconnectsTotal: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "peer_disconnects_total",
+			Help:      "Number of peers disconnected by the switch itself, by reason.",
+		}, append(labels, "reason")).With(labelsAndValues...),
 	}
 }
 
 func NopMetrics() *Metrics {
 	return &Metrics{
-		Peers:                    discard.NewGauge(),
-		PeerPendingSendBytes:     discard.NewGauge(),
-		MessageReceiveBytesTotal: discard.NewCounter(),
-		MessageSendBytesTotal:    discard.NewCounter(),
-		RecvRateLimiterDelay:     discard.NewCounter(),
-		SendRateLimiterDelay:     discard.NewCounter(),
+		Peers:                       discard.NewGauge(),
+		PeerPendingSendBytes:        discard.NewGauge(),
+		MessageReceiveBytesTotal:    discard.NewCounter(),
+		MessageSendBytesTotal:       discard.NewCounter(),
+		ReactorProcessDuration:      discard.NewHistogram(),
+		RecvBudgetBytes:             discard.NewGauge(),
+		RecvBudgetWaits:             discard.NewCounter(),
+		RecvRateLimiterDelay:        discard.NewCounter(),
+		SendRateLimiterDelay:        discard.NewCounter(),
+		PeerSendLatency:             discard.NewHistogram(),
+		PeerRejectedByPolicy:        discard.NewCounter(),
+		PeerRejectedUnknownChannels: discard.NewCounter(),
+		HandshakeRejectedTooLarge:   discard.NewCounter(),
+		PeerRejectedOverload:        discard.NewCounter(),
+		PeerSendUnknownChannelTotal: discard.NewCounter(),
+		PeerSendMemoryCapHit:        discard.NewCounter(),
+		PeerSendQueueOverflowTotal:  discard.NewCounter(),
+		PeerSendDeduped:             discard.NewCounter(),
+		PeerReceiveStaleDropped:     discard.NewCounter(),
+		PeerReceiveInvalid:          discard.NewCounter(),
+		PexRequestsThrottled:        discard.NewCounter(),
+		NodeSendBytesTotal:          discard.NewCounter(),
+		NodeReceiveBytesTotal:       discard.NewCounter(),
+		NodeSendRate:                discard.NewGauge(),
+		NodeReceiveRate:             discard.NewGauge(),
+		PeerDisconnectsTotal:        discard.NewCounter(),
 	}
 }
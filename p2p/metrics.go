@@ -32,10 +32,67 @@ type Metrics struct {
 	MessageReceiveBytesTotal metrics.Counter `metrics_labels:"message_type"`
 	// Number of bytes of each message type sent.
 	MessageSendBytesTotal metrics.Counter `metrics_labels:"message_type"`
+	// Time in seconds a reactor took to process a received message, by
+	// reactor and message type.
+	ReactorProcessDuration metrics.Histogram `metrics_bucketsizes:"0.0001, 10, 6" metrics_buckettype:"exprange" metrics_labels:"reactor, message_type"`
+	// Bytes of received messages being processed across all peers, see
+	// max_recv_buffered_bytes.
+	RecvBudgetBytes metrics.Gauge
+	// Number of messages whose processing waited for others to finish
+	// because the node was over max_recv_buffered_bytes.
+	RecvBudgetWaits metrics.Counter
 	// Time in seconds spent sleeping by the receive rate limiter
 	RecvRateLimiterDelay metrics.Counter `metrics_labels:"peer_id"`
 	// Time in seconds spent sleeping by the send rate limiter
 	SendRateLimiterDelay metrics.Counter `metrics_labels:"peer_id"`
+	// Time in seconds a Send or TrySend took to enqueue a message on a
+	// channel, whether it succeeded or not.
+	PeerSendLatency metrics.Histogram `metrics_bucketsizes:"0.0001, 10, 6" metrics_buckettype:"exprange" metrics_labels:"ch_id"`
+	// Number of inbound connections rejected by the allowed/denied peer ID
+	// policy.
+	PeerRejectedByPolicy metrics.Counter
+	// Number of peers rejected for advertising too many channels we don't
+	// have a reactor for.
+	PeerRejectedUnknownChannels metrics.Counter
+	// Number of peers rejected for sending a NodeInfo larger than the
+	// maximum size in the handshake.
+	HandshakeRejectedTooLarge metrics.Counter
+	// Number of inbound connections rejected by the accept policy, because
+	// the node is overloaded.
+	PeerRejectedOverload metrics.Counter
+	// Number of messages not sent because the peer does not support the
+	// channel.
+	PeerSendUnknownChannelTotal metrics.Counter `metrics_labels:"ch_id"`
+	// Number of messages not sent because the peer already had
+	// max_peer_queued_bytes queued for sending.
+	PeerSendMemoryCapHit metrics.Counter `metrics_labels:"ch_id"`
+	// Number of messages sent on a channel whose send queue was full, by
+	// the action the channel's overflow policy took: block, drop_newest or
+	// drop_oldest.
+	PeerSendQueueOverflowTotal metrics.Counter `metrics_labels:"ch_id, action"`
+	// Number of messages not queued because one with the same DedupKey
+	// already was.
+	PeerSendDeduped metrics.Counter `metrics_labels:"ch_id"`
+	// Number of received messages dropped for being older than their
+	// channel's MaxQueueAge.
+	PeerReceiveStaleDropped metrics.Counter `metrics_labels:"ch_id"`
+	// Number of received messages dropped for failing to decode into the
+	// message type of their channel; the peer is stopped.
+	PeerReceiveInvalid metrics.Counter `metrics_labels:"ch_id"`
+	// Number of PEX requests dropped because the peer exceeded its request
+	// rate.
+	PexRequestsThrottled metrics.Counter `metrics_labels:"peer_id"`
+	// Number of bytes sent to all peers.
+	NodeSendBytesTotal metrics.Counter
+	// Number of bytes received from all peers.
+	NodeReceiveBytesTotal metrics.Counter
+	// Current rate in bytes per second at which data is sent to all peers.
+	NodeSendRate metrics.Gauge
+	// Current rate in bytes per second at which data is received from all
+	// peers.
+	NodeReceiveRate metrics.Gauge
+	// Number of peers disconnected by the switch itself, by reason.
+	PeerDisconnectsTotal metrics.Counter `metrics_labels:"reason"`
 }
 
 type peerPendingMetricsCache struct {
@@ -81,6 +138,18 @@ func (c *peerPendingMetricsCache) AddPendingRecvBytes(msgType reflect.Type, addB
 	}
 }
 
+// label returns the metric label of msgType, built once per message type.
+func (c *peerPendingMetricsCache) label(msgType reflect.Type) string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	entry, ok := c.perMessageCache[msgType]
+	if !ok {
+		entry = &peerPendingMetricsCacheEntry{label: buildLabel(msgType)}
+		c.perMessageCache[msgType] = entry
+	}
+	return entry.label
+}
+
 func buildLabel(msgType reflect.Type) string {
 	s := msgType.String()
 	ss := valueToLabelRegexp.FindStringSubmatch(s)
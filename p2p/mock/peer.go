@@ -2,6 +2,10 @@ package mock
 
 import (
 	"net"
+	"sync"
+	"time"
+
+	"github.com/cosmos/gogoproto/proto"
 
 	"github.com/cometbft/cometbft/crypto/ed25519"
 	"github.com/cometbft/cometbft/libs/service"
@@ -16,6 +20,15 @@ type Peer struct {
 	addr                 *p2p.NetAddress
 	kv                   map[string]any
 	Outbound, Persistent bool
+	// ConnectedAt is returned by ConnectedSince. NewPeer sets it to the time
+	// the peer was started.
+	ConnectedAt time.Time
+
+	// send failure policy, see FailSendAfter and FailSendOnChannel.
+	mtx          sync.Mutex
+	numSends     int
+	failAfter    int
+	failChannels map[byte]struct{}
 }
 
 // NewPeer creates and starts a new mock peer. If the ip
@@ -39,23 +52,88 @@ func NewPeer(ip net.IP) *Peer {
 	if err := mp.Start(); err != nil {
 		panic(err)
 	}
+	mp.ConnectedAt = time.Now()
 	return mp
 }
 
-func (mp *Peer) FlushStop()               { mp.Stop() } //nolint:errcheck //ignore error
-func (*Peer) HasChannel(_ byte) bool      { return true }
-func (*Peer) TrySend(_ p2p.Envelope) bool { return true }
-func (*Peer) Send(_ p2p.Envelope) bool    { return true }
+// FailSendAfter makes all Send or TrySend calls after the first n made on
+// this peer return false. n <= 0 disables the policy.
+func (mp *Peer) FailSendAfter(n int) {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+	mp.failAfter = n
+}
+
+// FailSendOnChannel makes every Send or TrySend on the given channel return
+// false. Other channels are unaffected.
+func (mp *Peer) FailSendOnChannel(chID byte) {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+	if mp.failChannels == nil {
+		mp.failChannels = make(map[byte]struct{})
+	}
+	mp.failChannels[chID] = struct{}{}
+}
+
+// trySend records a send attempt and reports whether it should succeed.
+func (mp *Peer) trySend(e p2p.Envelope) bool {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+	mp.numSends++
+	if mp.failAfter > 0 && mp.numSends > mp.failAfter {
+		return false
+	}
+	_, fail := mp.failChannels[e.ChannelID]
+	return !fail
+}
+
+func (mp *Peer) FlushStop()                  { mp.Stop() } //nolint:errcheck //ignore error
+func (*Peer) Flush() error                   { return nil }
+func (*Peer) HasChannel(_ byte) bool         { return true }
+func (*Peer) SupportsFeature(string) bool    { return false }
+func (*Peer) CaptureBuffer(byte) [][]byte    { return nil }
+func (mp *Peer) TrySend(e p2p.Envelope) bool { return mp.trySend(e) }
+func (mp *Peer) Send(e p2p.Envelope) bool    { return mp.trySend(e) }
+func (mp *Peer) SendE(e p2p.Envelope) error {
+	if !mp.trySend(e) {
+		return p2p.ErrQueueFull
+	}
+	return nil
+}
+func (mp *Peer) SendWithFallback(primary, fallback p2p.Envelope) bool {
+	return mp.trySend(primary) || mp.trySend(fallback)
+}
+func (mp *Peer) TrySendMany(chID byte, msgs []proto.Message) int {
+	for i, msg := range msgs {
+		if !mp.trySend(p2p.Envelope{ChannelID: chID, Message: msg}) {
+			return i
+		}
+	}
+	return len(msgs)
+}
+func (*Peer) RemoveChannel(byte) error { return nil }
+
+// SecurityInfo returns what a SecretConnection to a peer with an ed25519 node
+// key would.
+func (*Peer) SecurityInfo() conn.SecurityInfo {
+	return conn.SecurityInfo{
+		KeyExchange:      conn.KeyExchangeX25519,
+		Cipher:           conn.CipherChaCha20Poly1305,
+		RemotePubKeyType: ed25519.KeyType,
+	}
+}
+
 func (mp *Peer) NodeInfo() p2p.NodeInfo {
 	return p2p.DefaultNodeInfo{
 		DefaultNodeID: mp.addr.ID,
 		ListenAddr:    mp.addr.DialString(),
 	}
 }
-func (*Peer) Status() conn.ConnectionStatus { return conn.ConnectionStatus{} }
-func (mp *Peer) ID() p2p.ID                 { return mp.id }
-func (mp *Peer) IsOutbound() bool           { return mp.Outbound }
-func (mp *Peer) IsPersistent() bool         { return mp.Persistent }
+func (*Peer) Status() conn.ConnectionStatus        { return conn.ConnectionStatus{} }
+func (*Peer) ProtocolVersion() p2p.ProtocolVersion { return p2p.ProtocolVersion{} }
+func (mp *Peer) ID() p2p.ID                        { return mp.id }
+func (mp *Peer) IsOutbound() bool                  { return mp.Outbound }
+func (mp *Peer) IsPersistent() bool                { return mp.Persistent }
 func (mp *Peer) Get(key string) any {
 	if value, ok := mp.kv[key]; ok {
 		return value
@@ -66,9 +144,12 @@ func (mp *Peer) Get(key string) any {
 func (mp *Peer) Set(key string, value any) {
 	mp.kv[key] = value
 }
-func (mp *Peer) RemoteIP() net.IP            { return mp.ip }
-func (mp *Peer) SocketAddr() *p2p.NetAddress { return mp.addr }
-func (mp *Peer) RemoteAddr() net.Addr        { return &net.TCPAddr{IP: mp.ip, Port: 8800} }
-func (*Peer) CloseConn() error               { return nil }
-func (*Peer) SetRemovalFailed()              {}
-func (*Peer) GetRemovalFailed() bool         { return false }
+func (mp *Peer) RemoteIP() net.IP                { return mp.ip }
+func (mp *Peer) SocketAddr() *p2p.NetAddress     { return mp.addr }
+func (mp *Peer) ConfiguredAddr() *p2p.NetAddress { return mp.addr }
+func (mp *Peer) Addr() *p2p.NetAddress           { return mp.addr }
+func (mp *Peer) RemoteAddr() net.Addr            { return &net.TCPAddr{IP: mp.ip, Port: 8800} }
+func (*Peer) CloseConn() error                   { return nil }
+func (*Peer) SetRemovalFailed()                  {}
+func (*Peer) GetRemovalFailed() bool             { return false }
+func (mp *Peer) ConnectedSince() time.Time       { return mp.ConnectedAt }
@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cometbft/cometbft/p2p"
+)
+
+func TestPeerFailSendAfter(t *testing.T) {
+	mp := NewPeer(nil)
+	mp.FailSendAfter(3)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, mp.Send(p2p.Envelope{ChannelID: 0x01}), "send %d", i)
+	}
+	assert.False(t, mp.Send(p2p.Envelope{ChannelID: 0x01}))
+	assert.False(t, mp.TrySend(p2p.Envelope{ChannelID: 0x02}))
+	assert.ErrorIs(t, mp.SendE(p2p.Envelope{ChannelID: 0x01}), p2p.ErrQueueFull)
+
+	// n <= 0 disables the policy.
+	mp.FailSendAfter(0)
+	assert.True(t, mp.Send(p2p.Envelope{ChannelID: 0x01}))
+}
+
+func TestPeerFailSendOnChannel(t *testing.T) {
+	mp := NewPeer(nil)
+	mp.FailSendOnChannel(0x01)
+
+	assert.False(t, mp.Send(p2p.Envelope{ChannelID: 0x01}))
+	assert.False(t, mp.TrySend(p2p.Envelope{ChannelID: 0x01}))
+	assert.True(t, mp.Send(p2p.Envelope{ChannelID: 0x02}))
+	assert.True(t, mp.TrySend(p2p.Envelope{ChannelID: 0x02}))
+	assert.True(t, mp.SendWithFallback(p2p.Envelope{ChannelID: 0x01}, p2p.Envelope{ChannelID: 0x02}))
+}
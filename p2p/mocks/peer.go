@@ -10,7 +10,11 @@ import (
 
 	net "net"
 
+	time "time"
+
 	p2p "github.com/cometbft/cometbft/p2p"
+
+	proto "github.com/cosmos/gogoproto/proto"
 )
 
 // Peer is an autogenerated mock type for the Peer type
@@ -18,6 +22,46 @@ type Peer struct {
 	mock.Mock
 }
 
+// Addr provides a mock function with given fields:
+func (_m *Peer) Addr() *p2p.NetAddress {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Addr")
+	}
+
+	var r0 *p2p.NetAddress
+	if rf, ok := ret.Get(0).(func() *p2p.NetAddress); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*p2p.NetAddress)
+		}
+	}
+
+	return r0
+}
+
+// CaptureBuffer provides a mock function with given fields: chID
+func (_m *Peer) CaptureBuffer(chID byte) [][]byte {
+	ret := _m.Called(chID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CaptureBuffer")
+	}
+
+	var r0 [][]byte
+	if rf, ok := ret.Get(0).(func(byte) [][]byte); ok {
+		r0 = rf(chID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]byte)
+		}
+	}
+
+	return r0
+}
+
 // CloseConn provides a mock function with given fields:
 func (_m *Peer) CloseConn() error {
 	ret := _m.Called()
@@ -36,6 +80,62 @@ func (_m *Peer) CloseConn() error {
 	return r0
 }
 
+// ConfiguredAddr provides a mock function with given fields:
+func (_m *Peer) ConfiguredAddr() *p2p.NetAddress {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfiguredAddr")
+	}
+
+	var r0 *p2p.NetAddress
+	if rf, ok := ret.Get(0).(func() *p2p.NetAddress); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*p2p.NetAddress)
+		}
+	}
+
+	return r0
+}
+
+// ConnectedSince provides a mock function with given fields:
+func (_m *Peer) ConnectedSince() time.Time {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConnectedSince")
+	}
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func() time.Time); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	return r0
+}
+
+// Flush provides a mock function with given fields:
+func (_m *Peer) Flush() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Flush")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // FlushStop provides a mock function with given fields:
 func (_m *Peer) FlushStop() {
 	_m.Called()
@@ -230,6 +330,24 @@ func (_m *Peer) OnStop() {
 	_m.Called()
 }
 
+// ProtocolVersion provides a mock function with given fields:
+func (_m *Peer) ProtocolVersion() p2p.ProtocolVersion {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProtocolVersion")
+	}
+
+	var r0 p2p.ProtocolVersion
+	if rf, ok := ret.Get(0).(func() p2p.ProtocolVersion); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(p2p.ProtocolVersion)
+	}
+
+	return r0
+}
+
 // Quit provides a mock function with given fields:
 func (_m *Peer) Quit() <-chan struct{} {
 	ret := _m.Called()
@@ -290,6 +408,24 @@ func (_m *Peer) RemoteIP() net.IP {
 	return r0
 }
 
+// RemoveChannel provides a mock function with given fields: chID
+func (_m *Peer) RemoveChannel(chID byte) error {
+	ret := _m.Called(chID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveChannel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(byte) error); ok {
+		r0 = rf(chID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Reset provides a mock function with given fields:
 func (_m *Peer) Reset() error {
 	ret := _m.Called()
@@ -308,6 +444,24 @@ func (_m *Peer) Reset() error {
 	return r0
 }
 
+// SecurityInfo provides a mock function with given fields:
+func (_m *Peer) SecurityInfo() conn.SecurityInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for SecurityInfo")
+	}
+
+	var r0 conn.SecurityInfo
+	if rf, ok := ret.Get(0).(func() conn.SecurityInfo); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(conn.SecurityInfo)
+	}
+
+	return r0
+}
+
 // Send provides a mock function with given fields: e
 func (_m *Peer) Send(e p2p.Envelope) bool {
 	ret := _m.Called(e)
@@ -326,6 +480,42 @@ func (_m *Peer) Send(e p2p.Envelope) bool {
 	return r0
 }
 
+// SendE provides a mock function with given fields: e
+func (_m *Peer) SendE(e p2p.Envelope) error {
+	ret := _m.Called(e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendE")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(p2p.Envelope) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendWithFallback provides a mock function with given fields: primary, fallback
+func (_m *Peer) SendWithFallback(primary p2p.Envelope, fallback p2p.Envelope) bool {
+	ret := _m.Called(primary, fallback)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendWithFallback")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(p2p.Envelope, p2p.Envelope) bool); ok {
+		r0 = rf(primary, fallback)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // Set provides a mock function with given fields: key, value
 func (_m *Peer) Set(key string, value any) {
 	_m.Called(key, value)
@@ -433,6 +623,24 @@ func (_m *Peer) String() string {
 	return r0
 }
 
+// SupportsFeature provides a mock function with given fields: name
+func (_m *Peer) SupportsFeature(name string) bool {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SupportsFeature")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // TrySend provides a mock function with given fields: e
 func (_m *Peer) TrySend(e p2p.Envelope) bool {
 	ret := _m.Called(e)
@@ -451,6 +659,24 @@ func (_m *Peer) TrySend(e p2p.Envelope) bool {
 	return r0
 }
 
+// TrySendMany provides a mock function with given fields: chID, msgs
+func (_m *Peer) TrySendMany(chID byte, msgs []proto.Message) int {
+	ret := _m.Called(chID, msgs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TrySendMany")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(byte, []proto.Message) int); ok {
+		r0 = rf(chID, msgs)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
 // NewPeer creates a new instance of Peer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPeer(t interface {
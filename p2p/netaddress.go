@@ -5,7 +5,9 @@
 package p2p
 
 import (
+	"context"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
@@ -21,10 +23,16 @@ const EmptyNetAddress = "<nil-NetAddress>"
 
 // NetAddress defines information about a peer on the network
 // including its ID, IP address, and port.
+//
+// If Hostname is set, the address was given as a DNS name and IP is what it
+// resolved to when the NetAddress was created. Dial and DialTimeout resolve
+// the hostname again on every call, so a peer whose IP changes can still be
+// reached. The hostname is not sent to other peers.
 type NetAddress struct {
-	ID   ID     `json:"id"`
-	IP   net.IP `json:"ip"`
-	Port uint16 `json:"port"`
+	ID       ID     `json:"id"`
+	IP       net.IP `json:"ip"`
+	Port     uint16 `json:"port"`
+	Hostname string `json:"hostname,omitempty"`
 }
 
 // IDAddressString returns id@hostPort. It strips the leading
@@ -64,9 +72,28 @@ func NewNetAddress(id ID, addr net.Addr) *NetAddress {
 
 // NewNetAddressString returns a new NetAddress using the provided address in
 // the form of "ID@IP:Port".
-// Also resolves the host if host is not an IP.
+// Also resolves the host if host is not an IP, and keeps it as the Hostname
+// to be resolved again at dial time.
 // Errors are of type ErrNetAddressXxx where Xxx is in (NoID, Invalid, Lookup).
 func NewNetAddressString(addr string) (*NetAddress, error) {
+	na, err := newUnresolvedNetAddressString(addr)
+	if err != nil {
+		return nil, err
+	}
+	if na.Hostname != "" {
+		ips, err := net.LookupIP(na.Hostname)
+		if err != nil {
+			return nil, ErrNetAddressLookup{na.Hostname, err}
+		}
+		na.IP = ips[0]
+	}
+	return na, nil
+}
+
+// newUnresolvedNetAddressString is NewNetAddressString without the lookup: if
+// the host is not an IP, it's only kept as the Hostname and the IP is left nil
+// until the address is dialed.
+func newUnresolvedNetAddressString(addr string) (*NetAddress, error) {
 	addrWithoutProtocol := removeProtocolIfDefined(addr)
 	spl := strings.Split(addrWithoutProtocol, "@")
 	if len(spl) != 2 {
@@ -92,22 +119,16 @@ func NewNetAddressString(addr string) (*NetAddress, error) {
 		}
 	}
 
-	ip := net.ParseIP(host)
-	if ip == nil {
-		ips, err := net.LookupIP(host)
-		if err != nil {
-			return nil, ErrNetAddressLookup{host, err}
-		}
-		ip = ips[0]
-	}
-
 	port, err := strconv.ParseUint(portStr, 10, 16)
 	if err != nil {
 		return nil, ErrNetAddressInvalid{portStr, err}
 	}
 
-	na := NewNetAddressIPPort(ip, uint16(port))
+	na := NewNetAddressIPPort(net.ParseIP(host), uint16(port))
 	na.ID = id
+	if na.IP == nil {
+		na.Hostname = host
+	}
 	return na, nil
 }
 
@@ -222,32 +243,65 @@ func (na *NetAddress) String() string {
 	return addrStr
 }
 
+// DialString returns the IP and port of the address, or its Hostname and port
+// if it's not resolved yet.
 func (na *NetAddress) DialString() string {
 	if na == nil {
 		return "<nil-NetAddress>"
 	}
+	host := na.IP.String()
+	if na.IP == nil && na.Hostname != "" {
+		host = na.Hostname
+	}
 	return net.JoinHostPort(
-		na.IP.String(),
+		host,
 		strconv.FormatUint(uint64(na.Port), 10),
 	)
 }
 
 // Dial calls net.Dial on the address.
 func (na *NetAddress) Dial() (net.Conn, error) {
-	conn, err := net.Dial("tcp", na.DialString())
-	if err != nil {
-		return nil, err
-	}
-	return conn, nil
+	return na.DialTimeout(0)
 }
 
-// DialTimeout calls net.DialTimeout on the address.
+// DialTimeout calls net.DialTimeout on the address. If the address has a
+// Hostname, it is resolved first and the IPs it resolves to are tried in
+// order until one accepts the connection, all within timeout. A timeout of 0
+// means no timeout.
 func (na *NetAddress) DialTimeout(timeout time.Duration) (net.Conn, error) {
-	conn, err := net.DialTimeout("tcp", na.DialString(), timeout)
+	if na.Hostname == "" {
+		conn, err := net.DialTimeout("tcp", na.DialString(), timeout)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, na.Hostname)
 	if err != nil {
-		return nil, err
+		return nil, ErrNetAddressLookup{na.Hostname, err}
+	}
+
+	var dialer net.Dialer
+	port := strconv.FormatUint(uint64(na.Port), 10)
+	errs := make([]error, 0, len(ips))
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
 	}
-	return conn, nil
+	return nil, errors.Join(errs...)
 }
 
 // Routable returns true if the address is routable.
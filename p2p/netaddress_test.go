@@ -4,6 +4,7 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -138,6 +139,48 @@ func TestNewNetAddressIPPort(t *testing.T) {
 	assert.Equal(t, "127.0.0.1:8080", addr.String())
 }
 
+func TestNetAddressHostname(t *testing.T) {
+	addr, err := NewNetAddressString("deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@localhost:8080")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", addr.Hostname)
+	assert.NotNil(t, addr.IP)
+
+	addr, err = NewNetAddressString("deadbeefdeadbeefdeadbeefdeadbeefdeadbeef@127.0.0.1:8080")
+	require.NoError(t, err)
+	assert.Empty(t, addr.Hostname)
+}
+
+func TestNetAddressDialHostname(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	// IP is stale, dialing must resolve the hostname again. localhost may
+	// also resolve to ::1, which we don't listen on, before 127.0.0.1.
+	addr := &NetAddress{
+		ID:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+		IP:       net.ParseIP("192.0.2.1"),
+		Port:     uint16(ln.Addr().(*net.TCPAddr).Port),
+		Hostname: "localhost",
+	}
+	c, err := addr.DialTimeout(time.Second)
+	require.NoError(t, err)
+	c.Close()
+
+	addr.Hostname = "nonexistent.invalid"
+	_, err = addr.DialTimeout(time.Second)
+	require.ErrorAs(t, err, &ErrNetAddressLookup{})
+}
+
 func TestNetAddressProperties(t *testing.T) {
 	// TODO add more test cases
 	testCases := []struct {
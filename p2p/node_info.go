@@ -3,9 +3,11 @@ package p2p
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"reflect"
 
 	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
+	cmtnet "github.com/cometbft/cometbft/internal/net"
 	cmtstrings "github.com/cometbft/cometbft/internal/strings"
 	cmtbytes "github.com/cometbft/cometbft/libs/bytes"
 	"github.com/cometbft/cometbft/version"
@@ -14,6 +16,9 @@ import (
 const (
 	maxNodeInfoSize = 10240 // 10KB
 	maxNumChannels  = 16    // plenty of room for upgrades, for now
+
+	maxNumAdditionalListenAddrs = 8
+	maxNumOtherExtra            = 32
 )
 
 // Max size of the NodeInfo struct.
@@ -33,6 +38,7 @@ type NodeInfo interface {
 
 type nodeInfoAddress interface {
 	NetAddress() (*NetAddress, error)
+	NetAddresses() ([]*NetAddress, error)
 }
 
 // nodeInfoTransport validates a nodeInfo and checks
@@ -68,6 +74,36 @@ func NewProtocolVersion(p2p, block, app uint64) ProtocolVersion {
 	}
 }
 
+// NegotiateP2PVersion returns the P2P protocol version to speak with a peer
+// that advertised theirs, when we advertised ours: the highest version both
+// support. Nodes advertise only the newest version they support, so the one
+// with the newer version downgrades to the version of the other, which fails
+// if that is older than version.P2PProtocolMin.
+func NegotiateP2PVersion(ours, theirs uint64) (uint64, error) {
+	if theirs >= ours {
+		return ours, nil
+	}
+	if theirs < version.P2PProtocolMin {
+		return 0, ErrUnsupportedP2PVersion{Other: theirs, Min: version.P2PProtocolMin}
+	}
+	return theirs, nil
+}
+
+// negotiateProtocolVersion returns the protocol versions to speak with a peer
+// whose NodeInfo is theirs, see Peer.ProtocolVersion. It must only be called
+// once ours.CompatibleWith(theirs) succeeded.
+func negotiateProtocolVersion(ours, theirs NodeInfo) ProtocolVersion {
+	their, ok := theirs.(DefaultNodeInfo)
+	if !ok {
+		return ProtocolVersion{}
+	}
+	pv := their.ProtocolVersion
+	if our, ok := ours.(DefaultNodeInfo); ok {
+		pv.P2P, _ = NegotiateP2PVersion(our.ProtocolVersion.P2P, their.ProtocolVersion.P2P)
+	}
+	return pv
+}
+
 // -------------------------------------------------------------
 
 // Assert DefaultNodeInfo satisfies NodeInfo.
@@ -83,6 +119,13 @@ type DefaultNodeInfo struct {
 	DefaultNodeID ID     `json:"id"`          // authenticated identifier
 	ListenAddr    string `json:"listen_addr"` // accepting incoming
 
+	// AdditionalListenAddrs are other addresses the node accepts incoming
+	// connections on, e.g. a VPN address next to a public one. They are only
+	// tried, in order after ListenAddr, when redialing the node as an inbound
+	// persistent peer, or by callers of Switch.DialPeerWithAddresses. They are
+	// not gossiped, so peers learned through PEX are dialed at ListenAddr.
+	AdditionalListenAddrs []string `json:"additional_listen_addrs,omitempty"`
+
 	// Check compatibility.
 	// Channels are HexBytes so easier to read as JSON
 	Network  string            `json:"network"`  // network/chain ID
@@ -92,12 +135,21 @@ type DefaultNodeInfo struct {
 	// ASCIIText fields
 	Moniker string               `json:"moniker"` // arbitrary moniker
 	Other   DefaultNodeInfoOther `json:"other"`   // other application specific data
+
+	// Features are the optional capabilities the node supports, e.g. a
+	// message format, see SupportsFeature. Names we don't know are ignored,
+	// so nodes with different feature sets can still connect.
+	Features []string `json:"features,omitempty"`
 }
 
 // DefaultNodeInfoOther is the misc. application specific data.
 type DefaultNodeInfoOther struct {
 	TxIndex    string `json:"tx_index"`
 	RPCAddress string `json:"rpc_address"`
+
+	// Extra holds the fields without a dedicated one, by name. They are kept
+	// as received, whether we know them or not, see DefaultNodeInfo.OtherField.
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 // ID returns the node's peer ID.
@@ -108,8 +160,11 @@ func (info DefaultNodeInfo) ID() ID {
 // Validate checks the self-reported DefaultNodeInfo is safe.
 // It returns an error if there
 // are too many Channels, if there are any duplicate Channels,
-// if the ListenAddr is malformed, or if the ListenAddr is a host name
-// that can not be resolved to some IP.
+// if the ListenAddr or any of the AdditionalListenAddrs is malformed, if
+// the ListenAddr is a host name that can not be resolved to some IP, if a
+// feature name is not valid ASCII text, or if one of the Other fields is
+// malformed.
+// Unknown features and Other.Extra fields are valid.
 // TODO: constraints for Moniker/Other? Or is that for the UI ?
 // JAE: It needs to be done on the client, but to prevent ambiguous
 // unicode characters, maybe it's worth sanitizing it here.
@@ -127,6 +182,18 @@ func (info DefaultNodeInfo) Validate() error {
 		return err
 	}
 
+	// Validate AdditionalListenAddrs.
+	if len(info.AdditionalListenAddrs) > maxNumAdditionalListenAddrs {
+		return ErrTooManyListenAddrs{Length: len(info.AdditionalListenAddrs), Max: maxNumAdditionalListenAddrs}
+	}
+	// Host names are only resolved when dialed, so that a peer can't have us
+	// look up a bunch of them during the handshake.
+	for _, addr := range info.AdditionalListenAddrs {
+		if _, err := newUnresolvedNetAddressString(IDAddressString(info.ID(), addr)); err != nil {
+			return err
+		}
+	}
+
 	// Network is validated in CompatibleWith.
 
 	// Validate Version
@@ -149,6 +216,14 @@ func (info DefaultNodeInfo) Validate() error {
 		channels[ch] = struct{}{}
 	}
 
+	// Validate Features; we only check they are printable, whether a feature
+	// is known is up to the reactors using it.
+	for _, feature := range info.Features {
+		if !cmtstrings.IsASCIIText(feature) || cmtstrings.ASCIITrim(feature) == "" {
+			return ErrInvalidFeature{Feature: feature}
+		}
+	}
+
 	// Validate Moniker.
 	if !cmtstrings.IsASCIIText(info.Moniker) || cmtstrings.ASCIITrim(info.Moniker) == "" {
 		return ErrInvalidMoniker{Moniker: info.Moniker}
@@ -162,18 +237,42 @@ func (info DefaultNodeInfo) Validate() error {
 	default:
 		return ErrInvalidTxIndex{TxIndex: txIndex}
 	}
-	// XXX: Should we be more strict about address formats?
 	rpcAddr := other.RPCAddress
-	if len(rpcAddr) > 0 && (!cmtstrings.IsASCIIText(rpcAddr) || cmtstrings.ASCIITrim(rpcAddr) == "") {
+	if len(rpcAddr) > 0 && !validRPCAddress(rpcAddr) {
 		return ErrInvalidRPCAddress{RPCAddress: rpcAddr}
 	}
+	if len(other.Extra) > maxNumOtherExtra {
+		return ErrTooManyOtherFields{Length: len(other.Extra), Max: maxNumOtherExtra}
+	}
+	for name, value := range other.Extra {
+		if !cmtstrings.IsASCIIText(name) || cmtstrings.ASCIITrim(name) == "" ||
+			(len(value) > 0 && !cmtstrings.IsASCIIText(value)) {
+			return ErrInvalidOtherField{Name: name, Value: value}
+		}
+	}
 
 	return nil
 }
 
+// validRPCAddress returns whether addr is a printable listen address, e.g.
+// tcp://0.0.0.0:26657 or unix:///tmp/rpc.sock. The protocol defaults to tcp,
+// whose addresses must have a host and a port.
+func validRPCAddress(addr string) bool {
+	if !cmtstrings.IsASCIIText(addr) || cmtstrings.ASCIITrim(addr) == "" {
+		return false
+	}
+	protocol, address := cmtnet.ProtocolAndAddress(addr)
+	if protocol != "tcp" {
+		return address != ""
+	}
+	_, _, err := net.SplitHostPort(address)
+	return err == nil
+}
+
 // CompatibleWith checks if two DefaultNodeInfo are compatible with each other.
-// CONTRACT: two nodes are compatible if the Block version and network match
-// and they have at least one channel in common.
+// CONTRACT: two nodes are compatible if the Block version and network match,
+// they can agree on a P2P version (see NegotiateP2PVersion) and they have at
+// least one channel in common.
 func (info DefaultNodeInfo) CompatibleWith(otherInfo NodeInfo) error {
 	other, ok := otherInfo.(DefaultNodeInfo)
 	if !ok {
@@ -190,6 +289,10 @@ func (info DefaultNodeInfo) CompatibleWith(otherInfo NodeInfo) error {
 		}
 	}
 
+	if _, err := NegotiateP2PVersion(info.ProtocolVersion.P2P, other.ProtocolVersion.P2P); err != nil {
+		return err
+	}
+
 	// nodes must be on the same network
 	if info.Network != other.Network {
 		return ErrDifferentNetwork{
@@ -232,10 +335,55 @@ func (info DefaultNodeInfo) NetAddress() (*NetAddress, error) {
 	return NewNetAddressString(idAddr)
 }
 
+// NetAddresses returns the NetAddress derived from the ListenAddr followed by
+// those derived from the AdditionalListenAddrs, in the order they should be
+// dialed. Host names are not resolved: they are looked up when the address
+// is dialed. As with NetAddress, none of them are authenticated.
+func (info DefaultNodeInfo) NetAddresses() ([]*NetAddress, error) {
+	addrs := make([]*NetAddress, 0, 1+len(info.AdditionalListenAddrs))
+	for _, a := range append([]string{info.ListenAddr}, info.AdditionalListenAddrs...) {
+		addr, err := newUnresolvedNetAddressString(IDAddressString(info.ID(), a))
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
 func (info DefaultNodeInfo) HasChannel(chID byte) bool {
 	return bytes.Contains(info.Channels, []byte{chID})
 }
 
+// TxIndexEnabled returns whether the node indexes transactions, so that they
+// can be queried through its RPC.
+func (info DefaultNodeInfo) TxIndexEnabled() bool {
+	return info.Other.TxIndex == "on"
+}
+
+// RPCAddress returns the address the node's RPC listens on, if it advertised
+// one. It is not authenticated, and may only be reachable locally.
+func (info DefaultNodeInfo) RPCAddress() string {
+	return info.Other.RPCAddress
+}
+
+// OtherField returns the value of the Other.Extra field name, if the node
+// sent it.
+func (info DefaultNodeInfo) OtherField(name string) (string, bool) {
+	value, ok := info.Other.Extra[name]
+	return value, ok
+}
+
+// SupportsFeature returns whether the node advertised the feature.
+func (info DefaultNodeInfo) SupportsFeature(name string) bool {
+	for _, feature := range info.Features {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (info DefaultNodeInfo) ToProto() *tmp2p.DefaultNodeInfo {
 	dni := new(tmp2p.DefaultNodeInfo)
 	dni.ProtocolVersion = tmp2p.ProtocolVersion{
@@ -246,13 +394,16 @@ func (info DefaultNodeInfo) ToProto() *tmp2p.DefaultNodeInfo {
 
 	dni.DefaultNodeID = string(info.DefaultNodeID)
 	dni.ListenAddr = info.ListenAddr
+	dni.AdditionalListenAddrs = info.AdditionalListenAddrs
 	dni.Network = info.Network
 	dni.Version = info.Version
 	dni.Channels = info.Channels
 	dni.Moniker = info.Moniker
+	dni.Features = info.Features
 	dni.Other = tmp2p.DefaultNodeInfoOther{
 		TxIndex:    info.Other.TxIndex,
 		RPCAddress: info.Other.RPCAddress,
+		Extra:      info.Other.Extra,
 	}
 
 	return dni
@@ -275,9 +426,13 @@ func DefaultNodeInfoFromToProto(pb *tmp2p.DefaultNodeInfo) (DefaultNodeInfo, err
 		Version:       pb.Version,
 		Channels:      pb.Channels,
 		Moniker:       pb.Moniker,
+
+		AdditionalListenAddrs: pb.AdditionalListenAddrs,
+		Features:              pb.Features,
 		Other: DefaultNodeInfoOther{
 			TxIndex:    pb.Other.TxIndex,
 			RPCAddress: pb.Other.RPCAddress,
+			Extra:      pb.Other.Extra,
 		},
 	}
 
@@ -1,12 +1,14 @@
 package p2p
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/cometbft/cometbft/version"
 )
 
 func TestNodeInfoValidate(t *testing.T) {
@@ -41,12 +43,25 @@ func TestNodeInfoValidate(t *testing.T) {
 
 		{"Invalid NetAddress", func(ni *DefaultNodeInfo) { ni.ListenAddr = "not-an-address" }, true},
 		{"Good NetAddress", func(ni *DefaultNodeInfo) { ni.ListenAddr = "0.0.0.0:26656" }, false},
+		{"Invalid Additional NetAddress", func(ni *DefaultNodeInfo) { ni.AdditionalListenAddrs = []string{"not-an-address"} }, true},
+		{"Too Many Additional NetAddresses", func(ni *DefaultNodeInfo) {
+			ni.AdditionalListenAddrs = make([]string, maxNumAdditionalListenAddrs+1)
+			for i := range ni.AdditionalListenAddrs {
+				ni.AdditionalListenAddrs[i] = "10.0.0.1:26656"
+			}
+		}, true},
+		{"Good Additional NetAddresses", func(ni *DefaultNodeInfo) { ni.AdditionalListenAddrs = []string{"10.0.0.1:26656"} }, false},
+		{"Unresolved Additional Host Name", func(ni *DefaultNodeInfo) { ni.AdditionalListenAddrs = []string{"nonexistent.invalid:26656"} }, false},
 
 		{"Non-ASCII Version", func(ni *DefaultNodeInfo) { ni.Version = nonASCII }, true},
 		{"Empty tab Version", func(ni *DefaultNodeInfo) { ni.Version = emptyTab }, true},
 		{"Empty space Version", func(ni *DefaultNodeInfo) { ni.Version = emptySpace }, true},
 		{"Empty Version", func(ni *DefaultNodeInfo) { ni.Version = "" }, false},
 
+		{"Non-ASCII Feature", func(ni *DefaultNodeInfo) { ni.Features = []string{nonASCII} }, true},
+		{"Empty Feature", func(ni *DefaultNodeInfo) { ni.Features = []string{""} }, true},
+		{"Unknown Feature", func(ni *DefaultNodeInfo) { ni.Features = []string{"some-future-feature"} }, false},
+
 		{"Non-ASCII Moniker", func(ni *DefaultNodeInfo) { ni.Moniker = nonASCII }, true},
 		{"Empty tab Moniker", func(ni *DefaultNodeInfo) { ni.Moniker = emptyTab }, true},
 		{"Empty space Moniker", func(ni *DefaultNodeInfo) { ni.Moniker = emptySpace }, true},
@@ -64,6 +79,21 @@ func TestNodeInfoValidate(t *testing.T) {
 		{"Empty space RPCAddress", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = emptySpace }, true},
 		{"Empty RPCAddress", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "" }, false},
 		{"Good RPCAddress", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "0.0.0.0:26657" }, false},
+		{"Good RPCAddress with protocol", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "tcp://127.0.0.1:26657" }, false},
+		{"Good unix RPCAddress", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "unix:///tmp/rpc.sock" }, false},
+		{"RPCAddress without port", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "tcp://127.0.0.1" }, true},
+		{"RPCAddress without address", func(ni *DefaultNodeInfo) { ni.Other.RPCAddress = "unix://" }, true},
+
+		{"Non-ASCII Extra name", func(ni *DefaultNodeInfo) { ni.Other.Extra = map[string]string{nonASCII: "v"} }, true},
+		{"Empty Extra name", func(ni *DefaultNodeInfo) { ni.Other.Extra = map[string]string{"": "v"} }, true},
+		{"Non-ASCII Extra value", func(ni *DefaultNodeInfo) { ni.Other.Extra = map[string]string{"k": nonASCII} }, true},
+		{"Empty Extra value", func(ni *DefaultNodeInfo) { ni.Other.Extra = map[string]string{"k": ""} }, false},
+		{"Too Many Extra", func(ni *DefaultNodeInfo) {
+			ni.Other.Extra = make(map[string]string)
+			for i := 0; i <= maxNumOtherExtra; i++ {
+				ni.Other.Extra[fmt.Sprintf("k%d", i)] = "v"
+			}
+		}, true},
 	}
 
 	nodeKey := NodeKey{PrivKey: ed25519.GenPrivKey()}
@@ -99,6 +129,11 @@ func TestNodeInfoCompatible(t *testing.T) {
 	ni2 := testNodeInfo(nodeKey2.ID(), name).(DefaultNodeInfo)
 	require.NoError(t, ni1.CompatibleWith(ni2))
 
+	// a newer P2P version; still compatible, we downgrade
+	ni2.ProtocolVersion.P2P++
+	require.NoError(t, ni1.CompatibleWith(ni2))
+	require.NoError(t, ni2.CompatibleWith(ni1))
+
 	// add another channel; still compatible
 	ni2.Channels = append(ni2.Channels, newTestChannel)
 	assert.True(t, ni2.HasChannel(newTestChannel))
@@ -114,6 +149,7 @@ func TestNodeInfoCompatible(t *testing.T) {
 		malleateNodeInfo func(*DefaultNodeInfo)
 	}{
 		{"Wrong block version", func(ni *DefaultNodeInfo) { ni.ProtocolVersion.Block++ }},
+		{"Unsupported P2P version", func(ni *DefaultNodeInfo) { ni.ProtocolVersion.P2P = version.P2PProtocolMin - 1 }},
 		{"Wrong network", func(ni *DefaultNodeInfo) { ni.Network += "-wrong" }},
 		{"No common channels", func(ni *DefaultNodeInfo) { ni.Channels = []byte{newTestChannel} }},
 	}
@@ -124,3 +160,130 @@ func TestNodeInfoCompatible(t *testing.T) {
 		require.Error(t, ni1.CompatibleWith(ni))
 	}
 }
+
+func TestNegotiateP2PVersion(t *testing.T) {
+	ours := version.P2PProtocol
+	testCases := []struct {
+		name   string
+		theirs uint64
+		exp    uint64
+		expErr bool
+	}{
+		{"same", ours, ours, false},
+		{"newer", ours + 1, ours, false},
+		{"much newer", ours + 10, ours, false},
+		{"oldest supported", version.P2PProtocolMin, version.P2PProtocolMin, false},
+		{"too old", version.P2PProtocolMin - 1, 0, true},
+		{"zero", 0, 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := NegotiateP2PVersion(ours, tc.theirs)
+			if tc.expErr {
+				require.ErrorAs(t, err, &ErrUnsupportedP2PVersion{})
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, v)
+
+			// Both ends agree on the version.
+			v, err = NegotiateP2PVersion(tc.theirs, ours)
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, v)
+		})
+	}
+
+	// A node only downgrades, so an old version it runs itself is fine.
+	v, err := NegotiateP2PVersion(version.P2PProtocolMin-1, ours)
+	require.NoError(t, err)
+	assert.Equal(t, version.P2PProtocolMin-1, v)
+}
+
+func TestNodeInfoNetAddresses(t *testing.T) {
+	nodeKey := NodeKey{PrivKey: ed25519.GenPrivKey()}
+	ni := testNodeInfo(nodeKey.ID(), "testing").(DefaultNodeInfo)
+
+	addrs, err := ni.NetAddresses()
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, ni.ListenAddr, addrs[0].DialString())
+
+	ni.AdditionalListenAddrs = []string{"10.0.0.1:26656", "192.168.0.1:26656"}
+	addrs, err = ni.NetAddresses()
+	require.NoError(t, err)
+	require.Len(t, addrs, 3)
+	assert.Equal(t, ni.ListenAddr, addrs[0].DialString())
+	assert.Equal(t, "10.0.0.1:26656", addrs[1].DialString())
+	assert.Equal(t, "192.168.0.1:26656", addrs[2].DialString())
+	for _, addr := range addrs {
+		assert.Equal(t, nodeKey.ID(), addr.ID)
+	}
+
+	// host names are left to be resolved when dialed
+	ni.AdditionalListenAddrs = []string{"nonexistent.invalid:26656"}
+	addrs, err = ni.NetAddresses()
+	require.NoError(t, err)
+	require.Len(t, addrs, 2)
+	assert.Equal(t, "nonexistent.invalid", addrs[1].Hostname)
+	assert.Nil(t, addrs[1].IP)
+	assert.Equal(t, "nonexistent.invalid:26656", addrs[1].DialString())
+	ni.AdditionalListenAddrs = []string{"10.0.0.1:26656", "192.168.0.1:26656"}
+
+	// additional addresses survive a round trip through proto
+	got, err := DefaultNodeInfoFromToProto(ni.ToProto())
+	require.NoError(t, err)
+	assert.Equal(t, ni, got)
+
+	// a peer that only knows about ListenAddr sees the usual node info
+	pb := ni.ToProto()
+	pb.AdditionalListenAddrs = nil
+	got, err = DefaultNodeInfoFromToProto(pb)
+	require.NoError(t, err)
+	require.NoError(t, got.Validate())
+	addrs, err = got.NetAddresses()
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+}
+
+func TestNodeInfoFeatures(t *testing.T) {
+	nodeKey := NodeKey{PrivKey: ed25519.GenPrivKey()}
+	ni := testNodeInfo(nodeKey.ID(), "testing").(DefaultNodeInfo)
+	assert.False(t, ni.SupportsFeature("compression"))
+
+	ni.Features = []string{"compression", "batching"}
+	assert.True(t, ni.SupportsFeature("compression"))
+	assert.True(t, ni.SupportsFeature("batching"))
+	assert.False(t, ni.SupportsFeature("compress"))
+
+	// features survive a round trip through proto
+	got, err := DefaultNodeInfoFromToProto(ni.ToProto())
+	require.NoError(t, err)
+	assert.Equal(t, ni, got)
+
+	// nodes with different features are compatible
+	otherKey := NodeKey{PrivKey: ed25519.GenPrivKey()}
+	other := testNodeInfo(otherKey.ID(), "other").(DefaultNodeInfo)
+	require.NoError(t, ni.CompatibleWith(other))
+	require.NoError(t, other.CompatibleWith(ni))
+}
+
+func TestNodeInfoOther(t *testing.T) {
+	nodeKey := NodeKey{PrivKey: ed25519.GenPrivKey()}
+	ni := testNodeInfo(nodeKey.ID(), "testing").(DefaultNodeInfo)
+	assert.True(t, ni.TxIndexEnabled())
+	assert.Equal(t, ni.Other.RPCAddress, ni.RPCAddress())
+	_, ok := ni.OtherField("grpc_address")
+	assert.False(t, ok)
+
+	ni.Other.TxIndex = "off"
+	assert.False(t, ni.TxIndexEnabled())
+
+	// fields we don't know survive a round trip through proto
+	ni.Other.Extra = map[string]string{"grpc_address": "tcp://127.0.0.1:26670"}
+	got, err := DefaultNodeInfoFromToProto(ni.ToProto())
+	require.NoError(t, err)
+	assert.Equal(t, ni, got)
+	addr, ok := got.OtherField("grpc_address")
+	assert.True(t, ok)
+	assert.Equal(t, "tcp://127.0.0.1:26670", addr)
+}
@@ -0,0 +1,73 @@
+package p2ptest
+
+import (
+	"time"
+
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
+	"github.com/cometbft/cometbft/p2p"
+)
+
+// RecordedEnvelope is an envelope handed to a RecordingReactor, with the time
+// it was received.
+type RecordedEnvelope struct {
+	p2p.Envelope
+	ReceivedAt time.Time
+}
+
+// RecordingReactor wraps a reactor and records the envelopes passed to its
+// Receive, in the order they were, before forwarding them. Other methods are
+// forwarded as is. Optional interfaces of the wrapped reactor, e.g.
+// p2p.PeerStateReactor, are not exposed.
+type RecordingReactor struct {
+	p2p.Reactor
+
+	mtx      cmtsync.Mutex
+	received []RecordedEnvelope
+}
+
+var _ p2p.Reactor = (*RecordingReactor)(nil)
+
+// NewRecordingReactor returns a RecordingReactor wrapping reactor.
+func NewRecordingReactor(reactor p2p.Reactor) *RecordingReactor {
+	return &RecordingReactor{Reactor: reactor}
+}
+
+// Receive records e and forwards it to the wrapped reactor.
+func (r *RecordingReactor) Receive(e p2p.Envelope) {
+	r.mtx.Lock()
+	r.received = append(r.received, RecordedEnvelope{Envelope: e, ReceivedAt: time.Now()})
+	r.mtx.Unlock()
+	r.Reactor.Receive(e)
+}
+
+// Records returns the envelopes received so far, oldest first.
+func (r *RecordingReactor) Records() []RecordedEnvelope {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]RecordedEnvelope(nil), r.received...)
+}
+
+// Received returns the envelopes received so far, oldest first.
+func (r *RecordingReactor) Received() []p2p.Envelope {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	envelopes := make([]p2p.Envelope, len(r.received))
+	for i, rec := range r.received {
+		envelopes[i] = rec.Envelope
+	}
+	return envelopes
+}
+
+// ReceivedOn returns the envelopes received on channel chID so far, oldest
+// first.
+func (r *RecordingReactor) ReceivedOn(chID byte) []p2p.Envelope {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	var envelopes []p2p.Envelope
+	for _, rec := range r.received {
+		if rec.ChannelID == chID {
+			envelopes = append(envelopes, rec.Envelope)
+		}
+	}
+	return envelopes
+}
@@ -0,0 +1,86 @@
+package p2ptest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
+	"github.com/cometbft/cometbft/p2p"
+)
+
+// countingReactor counts the envelopes it receives.
+type countingReactor struct {
+	*peerTrackingReactor
+	mtx      cmtsync.Mutex
+	received int
+}
+
+func (r *countingReactor) Receive(p2p.Envelope) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.received++
+}
+
+func TestRecordingReactor(t *testing.T) {
+	inner := &countingReactor{peerTrackingReactor: newPeerTrackingReactor(0x01)}
+	r := NewRecordingReactor(inner)
+	sw, peers := MakeSwitch([]p2p.Reactor{r}, 2)
+	t.Cleanup(func() {
+		if err := sw.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	// Calls other than Receive reach the wrapped reactor.
+	require.Len(t, inner.added, 2)
+	assert.Equal(t, inner.GetChannels(), r.GetChannels())
+
+	msgs := []*tmp2p.PexAddrs{
+		{Addrs: []tmp2p.NetAddress{{ID: "1"}}},
+		{Addrs: []tmp2p.NetAddress{{ID: "2"}}},
+		{Addrs: []tmp2p.NetAddress{{ID: "3"}}},
+	}
+	r.Receive(p2p.Envelope{Src: peers[0], ChannelID: 0x01, Message: msgs[0]})
+	r.Receive(p2p.Envelope{Src: peers[1], ChannelID: 0x02, Message: msgs[1]})
+	r.Receive(p2p.Envelope{Src: peers[0], ChannelID: 0x01, Message: msgs[2]})
+	assert.Equal(t, 3, inner.received)
+
+	received := r.Received()
+	require.Len(t, received, 3)
+	for i, e := range received {
+		assert.Equal(t, msgs[i], e.Message)
+	}
+	assert.Equal(t, peers[1], received[1].Src)
+
+	onCh1 := r.ReceivedOn(0x01)
+	require.Len(t, onCh1, 2)
+	assert.Equal(t, msgs[0], onCh1[0].Message)
+	assert.Equal(t, msgs[2], onCh1[1].Message)
+
+	records := r.Records()
+	require.Len(t, records, 3)
+	for i := 1; i < len(records); i++ {
+		assert.False(t, records[i].ReceivedAt.Before(records[i-1].ReceivedAt))
+	}
+}
+
+func TestRecordingReactorConcurrentReceive(t *testing.T) {
+	r := NewRecordingReactor(&countingReactor{peerTrackingReactor: newPeerTrackingReactor(0x01)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Receive(p2p.Envelope{ChannelID: 0x01, Message: &tmp2p.PexRequest{}})
+				_ = r.Received()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, r.Received(), 1000)
+}
@@ -0,0 +1,36 @@
+// Package p2ptest provides helpers for testing reactors.
+package p2ptest
+
+import (
+	"fmt"
+
+	"github.com/cometbft/cometbft/config"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/p2p/mock"
+)
+
+// MakeSwitch returns a running switch with the given reactors, added under
+// the names "reactor0", "reactor1", ..., and numPeers mock peers connected to
+// it. Messages sent to the peers go nowhere; see mock.Peer for making sends
+// fail. To deliver a message from a peer, call the reactor's Receive with the
+// peer as the envelope's Src. Stop the switch when done.
+func MakeSwitch(reactors []p2p.Reactor, numPeers int) (*p2p.Switch, []*mock.Peer) {
+	sw := p2p.MakeSwitch(config.TestP2PConfig(), 0, func(_ int, sw *p2p.Switch) *p2p.Switch {
+		for i, reactor := range reactors {
+			sw.AddReactor(fmt.Sprintf("reactor%d", i), reactor)
+		}
+		return sw
+	})
+	if err := sw.Start(); err != nil {
+		panic(err)
+	}
+
+	peers := make([]*mock.Peer, numPeers)
+	for i := range peers {
+		peers[i] = mock.NewPeer(nil)
+		if err := p2p.AddConnectedPeer(sw, peers[i]); err != nil {
+			panic(err)
+		}
+	}
+	return sw, peers
+}
@@ -0,0 +1,58 @@
+package p2ptest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/p2p/conn"
+)
+
+// peerTrackingReactor records the peers added to and removed from it.
+type peerTrackingReactor struct {
+	p2p.BaseReactor
+	chID    byte
+	added   []p2p.Peer
+	removed []p2p.Peer
+}
+
+func newPeerTrackingReactor(chID byte) *peerTrackingReactor {
+	r := &peerTrackingReactor{chID: chID}
+	r.BaseReactor = *p2p.NewBaseReactor("PeerTracking", r)
+	r.SetLogger(log.TestingLogger())
+	return r
+}
+
+func (r *peerTrackingReactor) GetChannels() []*conn.ChannelDescriptor {
+	return []*conn.ChannelDescriptor{{ID: r.chID, Priority: 1}}
+}
+
+func (r *peerTrackingReactor) AddPeer(p p2p.Peer)           { r.added = append(r.added, p) }
+func (r *peerTrackingReactor) RemovePeer(p p2p.Peer, _ any) { r.removed = append(r.removed, p) }
+
+func TestMakeSwitch(t *testing.T) {
+	r0, r1 := newPeerTrackingReactor(0x01), newPeerTrackingReactor(0x02)
+	sw, peers := MakeSwitch([]p2p.Reactor{r0, r1}, 3)
+	require.True(t, sw.IsRunning())
+	require.Len(t, peers, 3)
+
+	assert.Same(t, r0, sw.Reactor("reactor0"))
+	assert.Same(t, r1, sw.Reactor("reactor1"))
+	assert.Equal(t, 3, sw.Peers().Size())
+	for _, r := range []*peerTrackingReactor{r0, r1} {
+		require.Len(t, r.added, 3)
+		for i, p := range peers {
+			assert.Equal(t, p.ID(), r.added[i].ID())
+			assert.True(t, sw.Peers().Has(p.ID()))
+		}
+	}
+
+	require.NoError(t, sw.Stop())
+	assert.Len(t, r0.removed, 3)
+	for _, p := range peers {
+		assert.False(t, p.IsRunning())
+	}
+}
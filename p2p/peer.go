@@ -1,9 +1,13 @@
 package p2p
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"reflect"
+	"slices"
+	"sync/atomic"
+	"testing"
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
@@ -11,6 +15,7 @@ import (
 	"github.com/cometbft/cometbft/internal/cmap"
 	"github.com/cometbft/cometbft/libs/log"
 	"github.com/cometbft/cometbft/libs/service"
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
 	cmtconn "github.com/cometbft/cometbft/p2p/conn"
 	"github.com/cometbft/cometbft/types"
 )
@@ -21,10 +26,20 @@ import (
 // granularity.
 const metricsTickerDuration = 1 * time.Second
 
+// SamePeer returns true if a and b have the same cryptographic ID. Two nil
+// peers are the same, a nil peer is never the same as a non-nil one.
+func SamePeer(a, b Peer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.ID() == b.ID()
+}
+
 // Peer is an interface representing a peer connected on a reactor.
 type Peer interface {
 	service.Service
 	FlushStop()
+	Flush() error // flush pending sends without stopping
 
 	ID() ID               // peer's cryptographic ID
 	RemoteIP() net.IP     // remote IP of the connection
@@ -37,12 +52,61 @@ type Peer interface {
 
 	NodeInfo() NodeInfo // peer's info
 	Status() cmtconn.ConnectionStatus
+
+	// ProtocolVersion returns the protocol versions to speak with the peer:
+	// the P2P version negotiated in the handshake, see NegotiateP2PVersion,
+	// and the Block and App versions the peer advertised.
+	ProtocolVersion() ProtocolVersion
+
+	// SecurityInfo returns how the connection to the peer is secured. It is
+	// the zero value if the connection is not a SecretConnection.
+	SecurityInfo() SecurityInfo
+
 	SocketAddr() *NetAddress // actual address of the socket
+	Addr() *NetAddress       // peer's ID combined with the socket address
+
+	// ConfiguredAddr returns the address we were asked to dial, before DNS
+	// resolution, for outbound peers, and nil for inbound ones.
+	ConfiguredAddr() *NetAddress
+
+	ConnectedSince() time.Time // when the peer was started, zero before
+
+	// SupportsFeature returns whether the peer advertised the feature in its
+	// NodeInfo, so reactors can enable optional behavior with it.
+	SupportsFeature(name string) bool
 
 	HasChannel(chID byte) bool // Does the peer implement this channel?
 	Send(e Envelope) bool      // Send a message to the peer, blocking version
 	TrySend(e Envelope) bool   // Send a message to the peer, non-blocking version
 
+	// SendE is like Send but returns why the message could not be sent:
+	// ErrPeerStopped, ErrObserverPeer, ErrUnknownChannel, ErrChannelRemoved,
+	// ErrQueueFull, ErrSendMemoryCap, ErrMarshal or the error returned by
+	// Envelope.Validate.
+	SendE(e Envelope) error
+
+	// SendWithFallback sends primary to the peer, non-blocking, or fallback if
+	// the send queue of primary's channel is full, e.g. to fall back to a
+	// best-effort channel when a high-priority one is congested. It returns
+	// whether either was queued.
+	SendWithFallback(primary, fallback Envelope) bool
+
+	// TrySendMany sends msgs to the peer on the given channel, non-blocking,
+	// and returns how many were queued before the send queue filled up.
+	TrySendMany(chID byte, msgs []proto.Message) int
+
+	// RemoveChannel stops using a channel on the connection without
+	// disconnecting: queued messages are discarded, sends on the channel fail
+	// with ErrChannelRemoved and messages received on it are dropped. The
+	// channels the peer advertised in its NodeInfo, and so HasChannel, are
+	// unchanged.
+	RemoveChannel(chID byte) error
+
+	// CaptureBuffer returns the raw bytes of the last messages received on
+	// the channel, oldest first, or nil if the channel is not captured, see
+	// PeerCaptureReceive and ReplayCapture.
+	CaptureBuffer(chID byte) [][]byte
+
 	Set(key string, value any)
 	Get(key string) any
 
@@ -58,7 +122,8 @@ type peerConn struct {
 	persistent bool
 	conn       net.Conn // Source connection
 
-	socketAddr *NetAddress
+	socketAddr     *NetAddress
+	configuredAddr *NetAddress // outbound only, see ConfiguredAddr
 
 	// cached RemoteIP()
 	ip net.IP
@@ -115,10 +180,27 @@ type peer struct {
 	mconn *cmtconn.MConnection
 
 	// peer's node info and the channel it knows about
-	// channels = nodeInfo.Channels
+	// channels = nodeInfo.Channels, plus the channels added by addChannels
 	// cached to avoid copying nodeInfo in HasChannel
 	nodeInfo NodeInfo
-	channels []byte
+	channels []byte // guarded by reactorsMtx, only replaced by addChannels
+
+	// versions to speak with the peer, negotiated in the handshake
+	protocolVersion ProtocolVersion
+
+	// reactors for the channels we receive on, and the DedupKey of the
+	// channels that have one. The maps are never modified, only replaced by
+	// addChannels and RemoveChannel.
+	reactorsMtx     cmtsync.RWMutex
+	reactorsByCh    map[byte]Reactor
+	msgTypeByChID   map[byte]proto.Message
+	removedChannels map[byte]struct{}
+	dedupKeys       map[byte]func(proto.Message) string
+
+	// held for reading while a received message is handed to a reactor, see
+	// stopReceiving
+	recvMtx     cmtsync.RWMutex
+	recvStopped bool
 
 	// User data
 	Data *cmap.CMap
@@ -126,10 +208,42 @@ type peer struct {
 	metrics        *Metrics
 	pendingMetrics *peerPendingMetricsCache
 
+	// overrides the MConnConfig flush throttle if set, see PeerFlushPolicy
+	flushPolicy *FlushPolicy
+
+	// log sends on channels the peer does not support, see
+	// PeerLogUnknownChannel
+	logUnknownChannel bool
+
+	// channels whose received messages are logged, see PeerLogReceive
+	logReceive *receiveLogFilter
+
+	// recorded received messages per channel, see PeerCaptureReceive; never
+	// modified after the peer is created
+	captures map[byte]*captureBuffer
+
+	// relative send priority across peers, see PeerPriority
+	priority int
+
+	// messages are only received from the peer, see PeerObserver
+	observer bool
+
+	// bytes of received messages being processed across peers, nil if
+	// unbounded
+	recvBudget *recvBudget
+
 	// When removal of a peer fails, we set this flag
 	removalAttemptFailed bool
+
+	// set once in OnStart, before the connection is started
+	connectedSince time.Time
+
+	// goroutines of the peer itself still running, see resources
+	goroutines atomic.Int32
 }
 
+// PeerOption configures a peer. Options are applied before the peer's
+// MConnection is created.
 type PeerOption func(*peer)
 
 func newPeer(
@@ -149,21 +263,39 @@ func newPeer(
 		Data:           cmap.NewCMap(),
 		metrics:        NopMetrics(),
 		pendingMetrics: newPeerPendingMetricsCache(),
+		reactorsByCh:   reactorsByCh,
+		msgTypeByChID:  msgTypeByChID,
+		dedupKeys:      withDedupKeys(nil, chDescs),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+	if p.flushPolicy != nil {
+		mConfig.FlushThrottle = p.flushPolicy.interval
+	}
+	if p.priority != 0 {
+		scaled := make([]*cmtconn.ChannelDescriptor, len(chDescs))
+		for i, chDesc := range chDescs {
+			scaled[i] = withPriority(chDesc, p.priority)
+		}
+		chDescs = scaled
+	}
+	mConfig.OnSendOverflow = func(chID byte, action cmtconn.OverflowPolicy) {
+		p.metrics.PeerSendQueueOverflowTotal.With("ch_id", fmt.Sprintf("%#x", chID), "action", action.String()).Add(1)
+	}
+	mConfig.OnRecvStale = func(chID byte) {
+		p.metrics.PeerReceiveStaleDropped.With("ch_id", fmt.Sprintf("%#x", chID)).Add(1)
 	}
 
 	p.mconn = createMConnection(
 		pc.conn,
 		p,
-		reactorsByCh,
-		msgTypeByChID,
 		chDescs,
 		onPeerError,
 		mConfig,
 	)
 	p.BaseService = *service.NewBaseService(nil, "Peer", p)
-	for _, option := range options {
-		option(p)
-	}
 
 	return p
 }
@@ -192,10 +324,13 @@ func (p *peer) OnStart() error {
 		return err
 	}
 
+	// Set before the connection starts, so reactors see it in Receive.
+	p.connectedSince = time.Now()
 	if err := p.mconn.Start(); err != nil {
 		return err
 	}
 
+	p.goroutines.Add(1)
 	go p.metricsReporter()
 	return nil
 }
@@ -208,6 +343,13 @@ func (p *peer) FlushStop() {
 	p.mconn.FlushStop() // stop everything and close the conn
 }
 
+// Flush makes sure all successful .Send() calls are written to the connection
+// before returning, e.g. for a latency-critical message, without stopping the
+// peer.
+func (p *peer) Flush() error {
+	return p.mconn.Flush()
+}
+
 // OnStop implements BaseService.
 func (p *peer) OnStop() {
 	if err := p.mconn.Stop(); err != nil { // stop everything and close the conn
@@ -215,6 +357,18 @@ func (p *peer) OnStop() {
 	}
 }
 
+// stopReceiving waits for the reactors to process the messages they are being
+// handed, and drops those received afterwards. The MConnection does not wait
+// for its recvRoutine when stopped, so there may be one in flight after Stop
+// returns.
+//
+// It must not be called from Receive, nor from code that Receive waits on.
+func (p *peer) stopReceiving() {
+	p.recvMtx.Lock()
+	defer p.recvMtx.Unlock()
+	p.recvStopped = true
+}
+
 // ---------------------------------------------------
 // Implements Peer
 
@@ -238,6 +392,11 @@ func (p *peer) NodeInfo() NodeInfo {
 	return p.nodeInfo
 }
 
+// ProtocolVersion implements Peer.
+func (p *peer) ProtocolVersion() ProtocolVersion {
+	return p.protocolVersion
+}
+
 // SocketAddr returns the address of the socket.
 // For outbound peers, it's the address dialed (after DNS resolution).
 // For inbound peers, it's the address returned by the underlying connection
@@ -246,17 +405,51 @@ func (p *peer) SocketAddr() *NetAddress {
 	return p.peerConn.socketAddr
 }
 
+// ConfiguredAddr returns the address the peer was dialed with, including its
+// Hostname if it was given as one, so that reconnecting resolves it again
+// rather than reusing an IP that may be stale. It is nil for inbound peers.
+func (p *peer) ConfiguredAddr() *NetAddress {
+	return p.peerConn.configuredAddr
+}
+
+// Addr returns the socket address of the peer, with the ID set to the peer's
+// authenticated ID. A new NetAddress is returned each time, so callers may
+// modify it.
+func (p *peer) Addr() *NetAddress {
+	addr := *p.peerConn.socketAddr
+	addr.ID = p.ID()
+	return &addr
+}
+
 // Status returns the peer's ConnectionStatus.
 func (p *peer) Status() cmtconn.ConnectionStatus {
 	return p.mconn.Status()
 }
 
+// SecurityInfo implements Peer.
+func (p *peer) SecurityInfo() SecurityInfo {
+	if sc, ok := p.peerConn.conn.(*cmtconn.SecretConnection); ok {
+		return sc.SecurityInfo()
+	}
+	return SecurityInfo{}
+}
+
 // Send msg bytes to the channel identified by chID byte. Returns false if the
 // send queue is full after timeout, specified by MConnection.
 //
 // thread safe.
 func (p *peer) Send(e Envelope) bool {
-	return p.send(e.ChannelID, e.Message, p.mconn.Send)
+	return p.SendE(e) == nil
+}
+
+// SendE is like Send but returns an error explaining why the message was not
+// sent. Use errors.Is to compare it against the ErrPeerStopped,
+// ErrObserverPeer, ErrUnknownChannel, ErrChannelRemoved, ErrQueueFull,
+// ErrSendMemoryCap and ErrMarshal sentinels.
+//
+// thread safe.
+func (p *peer) SendE(e Envelope) error {
+	return p.send(e, p.mconn.SendKeyedE)
 }
 
 // TrySend msg bytes to the channel identified by chID byte. Immediately returns
@@ -264,29 +457,234 @@ func (p *peer) Send(e Envelope) bool {
 //
 // thread safe.
 func (p *peer) TrySend(e Envelope) bool {
-	return p.send(e.ChannelID, e.Message, p.mconn.TrySend)
+	return p.send(e, p.mconn.TrySendKeyedE) == nil
 }
 
-func (p *peer) send(chID byte, msg proto.Message, sendFunc func(byte, []byte) bool) bool {
-	if !p.IsRunning() {
+// SendWithFallback tries to send primary like TrySend and, only if the send
+// queue of its channel is full, fallback.
+//
+// thread safe.
+func (p *peer) SendWithFallback(primary, fallback Envelope) bool {
+	err := p.send(primary, p.mconn.TrySendKeyedE)
+	if errors.Is(err, ErrQueueFull) {
+		err = p.send(fallback, p.mconn.TrySendKeyedE)
+	}
+	return err == nil
+}
+
+// TrySendMany queues msgs on the channel identified by chID, in order and
+// without blocking, and returns the number of messages queued before the send
+// queue filled up. Unlike calling TrySend for each message, whether the peer
+// is running and has the channel is only checked once.
+//
+// thread safe.
+func (p *peer) TrySendMany(chID byte, msgs []proto.Message) int {
+	if !p.IsRunning() || p.observer {
+		return 0
+	}
+	if !p.HasChannel(chID) {
+		p.unknownChannel(chID)
+		return 0
+	}
+	if p.channelRemoved(chID) {
+		return 0
+	}
+	for i, msg := range msgs {
+		e := Envelope{ChannelID: chID, Message: msg}
+		if err := e.Validate(); err != nil {
+			p.Logger.Error("invalid envelope", "err", err)
+			return i
+		}
+		if err := p.sendMsg(chID, msg, p.mconn.TrySendKeyedE); err != nil {
+			return i
+		}
+	}
+	return len(msgs)
+}
+
+// panicOnMessageType makes SendTyped panic on a message of the wrong type.
+var panicOnMessageType = testing.Testing()
+
+// SendTyped sends msg to p on chID like Peer.Send, after checking that msg,
+// once wrapped if it's a Wrapper, is of the MessageType the channel was
+// registered with in its ChannelDescriptor. A mismatch is a bug in the
+// reactor which the peer would only notice as a message it can't decode, so
+// SendTyped logs it and returns false instead of sending msg, and panics on
+// it in tests, to catch the bug early. Peers that don't know the registered
+// types, such as mocks, are sent msg unchecked.
+func SendTyped[M proto.Message](p Peer, chID byte, msg M) bool {
+	if err := checkMessageType(p, chID, msg); err != nil {
+		if panicOnMessageType {
+			panic(err)
+		}
+		// Only peers in this package know the registered types.
+		if p, ok := p.(*peer); ok {
+			p.Logger.Error("Not sending message of the wrong type", "err", err)
+		}
 		return false
+	}
+	return p.Send(Envelope{ChannelID: chID, Message: msg})
+}
+
+// checkMessageType returns an ErrChannelMessageType if the message type
+// registered for chID with p is not that of msg on the wire.
+func checkMessageType(p Peer, chID byte, msg proto.Message) error {
+	cr, ok := p.(channelReactorGetter)
+	if !ok {
+		return nil
+	}
+	_, mt := cr.channelReactor(chID)
+	if mt == nil {
+		// Unknown channels are handled by Send.
+		return nil
+	}
+	if w, ok := msg.(types.Wrapper); ok {
+		msg = w.Wrap()
+	}
+	if want, got := reflect.TypeOf(mt), reflect.TypeOf(msg); want != got {
+		return ErrChannelMessageType{ChannelID: chID, Want: want, Got: got}
+	}
+	return nil
+}
+
+func (p *peer) send(e Envelope, sendFunc keyedSendFunc) error {
+	if err := e.Validate(); err != nil {
+		p.Logger.Error("invalid envelope", "err", err)
+		return err
+	}
+	if err := p.canSend(e.ChannelID); err != nil {
+		return err
+	}
+	return p.sendMsg(e.ChannelID, e.Message, sendFunc)
+}
+
+// canSend returns why messages can't be sent to the peer on chID, if they
+// can't.
+func (p *peer) canSend(chID byte) error {
+	if !p.IsRunning() {
+		return ErrPeerStopped
+	} else if p.observer {
+		return ErrObserverPeer
 	} else if !p.HasChannel(chID) {
-		return false
+		p.unknownChannel(chID)
+		return ErrUnknownChannel
+	} else if p.channelRemoved(chID) {
+		return ErrChannelRemoved
 	}
-	msgType := getMsgType(msg)
+	return nil
+}
+
+// sendMarshaled is like send for a message that is already marshaled, see
+// Switch.Broadcast.
+func (p *peer) sendMarshaled(chID byte, mm marshaledMsg, sendFunc keyedSendFunc) error {
+	if err := p.canSend(chID); err != nil {
+		return err
+	}
+	return p.sendBytes(chID, mm, sendFunc)
+}
+
+// unknownChannel records a send on a channel the peer does not support.
+// Reactors routinely broadcast to peers lacking their channel, so this is
+// only logged if enabled with PeerLogUnknownChannel.
+func (p *peer) unknownChannel(chID byte) {
+	p.metrics.PeerSendUnknownChannelTotal.With("ch_id", fmt.Sprintf("%#x", chID)).Add(1)
+	if p.logUnknownChannel {
+		p.reactorsMtx.RLock()
+		channels := p.channels
+		p.reactorsMtx.RUnlock()
+		p.Logger.Debug("Unknown channel for peer", "channel", chID, "channels", channels)
+	}
+}
+
+// sendMsg marshals msg and hands it to sendFunc, without checking whether
+// the peer can be sent to.
+func (p *peer) sendMsg(chID byte, msg proto.Message, sendFunc keyedSendFunc) error {
+	mm, err := marshalMsg(msg)
+	if err != nil {
+		p.Logger.Error("marshaling message to send", "error", err)
+		return err
+	}
+	return p.sendBytes(chID, mm, sendFunc)
+}
+
+// keyedSendFunc is MConnection.SendKeyedE or TrySendKeyedE.
+type keyedSendFunc func(chID byte, key string, msgBytes []byte) error
+
+// sendBytes hands the bytes of mm to sendFunc, without checking whether the
+// peer can be sent to. Messages deduplicated by the channel's DedupKey count
+// as sent.
+func (p *peer) sendBytes(chID byte, mm marshaledMsg, sendFunc keyedSendFunc) error {
+	start := time.Now()
+	err := sendFunc(chID, p.dedupKey(chID, mm.msg), mm.bytes)
+	p.metrics.PeerSendLatency.With("ch_id", fmt.Sprintf("%#x", chID)).Observe(time.Since(start).Seconds())
+	var notFound cmtconn.ErrChannelNotFound
+	switch {
+	case errors.Is(err, cmtconn.ErrDuplicateKey):
+		p.metrics.PeerSendDeduped.With("ch_id", fmt.Sprintf("%#x", chID)).Add(1)
+		return nil
+	case errors.Is(err, cmtconn.ErrMaxQueuedBytes):
+		p.metrics.PeerSendMemoryCapHit.With("ch_id", fmt.Sprintf("%#x", chID)).Add(1)
+		return ErrSendMemoryCap
+	case errors.Is(err, cmtconn.ErrNotRunning):
+		// Stopped since canSend.
+		return ErrPeerStopped
+	case errors.As(err, &notFound):
+		// Removed since canSend.
+		if p.channelRemoved(chID) {
+			return ErrChannelRemoved
+		}
+		return ErrUnknownChannel
+	case err != nil:
+		return ErrQueueFull
+	}
+	p.pendingMetrics.AddPendingSendBytes(mm.msgType, len(mm.bytes))
+	return nil
+}
+
+// marshaledMsg is a message ready to be handed to the connection. The bytes
+// are never modified once marshaled, so the same marshaledMsg can be sent to
+// several peers.
+type marshaledMsg struct {
+	msg     proto.Message // before wrapping, for DedupKey
+	msgType reflect.Type  // of msg, for metrics
+	bytes   []byte
+}
+
+// marshalMsg wraps msg, if it's a Wrapper, and marshals it.
+func marshalMsg(msg proto.Message) (marshaledMsg, error) {
+	mm := marshaledMsg{msg: msg, msgType: getMsgType(msg)}
 	if w, ok := msg.(types.Wrapper); ok {
 		msg = w.Wrap()
 	}
 	msgBytes, err := proto.Marshal(msg)
 	if err != nil {
-		p.Logger.Error("marshaling message to send", "error", err)
-		return false
+		return marshaledMsg{}, fmt.Errorf("%w: %v", ErrMarshal, err)
 	}
-	res := sendFunc(chID, msgBytes)
-	if res {
-		p.pendingMetrics.AddPendingSendBytes(msgType, len(msgBytes))
+	mm.bytes = msgBytes
+	return mm, nil
+}
+
+// MarshaledMessage is a message marshaled once, with MarshalMessage, to be
+// sent to several peers with SendMarshaled.
+type MarshaledMessage struct {
+	mm marshaledMsg
+}
+
+// MarshalMessage wraps msg, if it's a Wrapper, and marshals it.
+func MarshalMessage(msg proto.Message) (MarshaledMessage, error) {
+	mm, err := marshalMsg(msg)
+	return MarshaledMessage{mm: mm}, err
+}
+
+// SendMarshaled sends msg to p on chID like Peer.Send, handing the bytes msg
+// was marshaled to to the connection rather than marshaling it again. Peers not
+// implemented by this package, such as mocks, are sent the message with Send.
+func SendMarshaled(p Peer, chID byte, msg MarshaledMessage) bool {
+	pp, ok := p.(*peer)
+	if !ok {
+		return p.Send(Envelope{ChannelID: chID, Message: msg.mm.msg})
 	}
-	return res
+	return pp.sendMarshaled(chID, msg.mm, pp.mconn.SendKeyedE) == nil
 }
 
 // Get the data for a given key.
@@ -303,9 +701,13 @@ func (p *peer) Set(key string, data any) {
 	p.Data.Set(key, data)
 }
 
-// HasChannel returns whether the peer reported implementing this channel.
+// HasChannel returns whether the peer reported implementing this channel, or
+// the channel was added since the handshake, see addChannels.
 func (p *peer) HasChannel(chID byte) bool {
-	for _, ch := range p.channels {
+	p.reactorsMtx.RLock()
+	channels := p.channels
+	p.reactorsMtx.RUnlock()
+	for _, ch := range channels {
 		if ch == chID {
 			return true
 		}
@@ -313,6 +715,13 @@ func (p *peer) HasChannel(chID byte) bool {
 	return false
 }
 
+// SupportsFeature returns whether the peer advertised the feature in its
+// NodeInfo.
+func (p *peer) SupportsFeature(name string) bool {
+	info, ok := p.nodeInfo.(DefaultNodeInfo)
+	return ok && info.SupportsFeature(name)
+}
+
 // CloseConn closes original connection. Used for cleaning up in cases where the peer had not been started at all.
 func (p *peer) CloseConn() error {
 	return p.peerConn.conn.Close()
@@ -322,6 +731,12 @@ func (p *peer) SetRemovalFailed() {
 	p.removalAttemptFailed = true
 }
 
+// ConnectedSince returns the time at which the peer was started. Together
+// with the time it was stopped, it gives the duration of the connection.
+func (p *peer) ConnectedSince() time.Time {
+	return p.connectedSince
+}
+
 func (p *peer) GetRemovalFailed() bool {
 	return p.removalAttemptFailed
 }
@@ -342,7 +757,7 @@ func (p *peer) RemoteAddr() net.Addr {
 
 // CanSend returns true if the send queue is not full, false otherwise.
 func (p *peer) CanSend(chID byte) bool {
-	if !p.IsRunning() {
+	if !p.IsRunning() || p.observer {
 		return false
 	}
 	return p.mconn.CanSend(chID)
@@ -356,7 +771,161 @@ func PeerMetrics(metrics *Metrics) PeerOption {
 	}
 }
 
+// PeerLogReceive enables a debug log for every message received on the given
+// channels, or on all channels if none are given, with the peer ID, channel,
+// message type and size. Default: off.
+func PeerLogReceive(chIDs ...byte) PeerOption {
+	return func(p *peer) {
+		p.logReceive = newReceiveLogFilter(chIDs)
+	}
+}
+
+// PeerPriority sets the peer's send priority relative to other peers.
+// Default: 0.
+//
+// The send queues of the peer's channels are scaled by the level: a level
+// L > 0 multiplies their capacity, in messages and in bytes, by 1+L, and a
+// level L < 0 divides it by 1-L. When the links are congested, the queues of
+// lower priority peers fill up first, so that TrySend to them fails, and Send
+// to them blocks, while higher priority peers still get the messages. The
+// switch also services peers with a higher level first when broadcasting.
+//
+// Each peer has its own connection and send routine, so the level does not
+// change how the peer's MConnection picks between its channels: channel
+// priorities keep deciding the order within a connection, the peer priority
+// only biases the queueing across peers.
+func PeerPriority(level int) PeerOption {
+	return func(p *peer) {
+		p.priority = level
+	}
+}
+
+// withPriority returns chDesc with its send queue capacities scaled by the
+// level of a peer, see PeerPriority.
+func withPriority(chDesc *cmtconn.ChannelDescriptor, level int) *cmtconn.ChannelDescriptor {
+	if level == 0 {
+		return chDesc
+	}
+	scaled := chDesc.FillDefaults()
+	scale := func(capacity int64) int64 {
+		if level > 0 {
+			return capacity * int64(1+level)
+		}
+		return max(capacity/int64(1-level), 1)
+	}
+	scaled.SendQueueCapacity = int(scale(int64(scaled.SendQueueCapacity)))
+	if scaled.SendQueueByteCapacity > 0 {
+		scaled.SendQueueByteCapacity = scale(scaled.SendQueueByteCapacity)
+	}
+	return &scaled
+}
+
+// Priority returns the level set with PeerPriority.
+func (p *peer) Priority() int {
+	return p.priority
+}
+
+// PeerObserver makes the peer receive-only: every send to it fails with
+// ErrObserverPeer, so that a monitoring node collects gossip without taking
+// part in it, while received messages are still handed to the reactors. Only
+// the handshake and the MConnection pings and pongs, which keep the connection
+// alive, are sent. Default: off.
+func PeerObserver() PeerOption {
+	return func(p *peer) {
+		p.observer = true
+	}
+}
+
+// IsObserver returns whether the peer was created with PeerObserver.
+func (p *peer) IsObserver() bool {
+	return p.observer
+}
+
+// PeerCaptureReceive records the raw bytes of the last size messages
+// received on each of the given channels, for Peer.CaptureBuffer. It's a
+// debugging aid and disabled by default.
+func PeerCaptureReceive(size int, chIDs ...byte) PeerOption {
+	return func(p *peer) {
+		if size <= 0 {
+			return
+		}
+		p.captures = make(map[byte]*captureBuffer, len(chIDs))
+		for _, chID := range chIDs {
+			p.captures[chID] = newCaptureBuffer(size)
+		}
+	}
+}
+
+// CaptureBuffer returns the messages recorded on the channel, oldest first,
+// or nil if PeerCaptureReceive wasn't enabled for it.
+func (p *peer) CaptureBuffer(chID byte) [][]byte {
+	if b, ok := p.captures[chID]; ok {
+		return b.list()
+	}
+	return nil
+}
+
+// receiveLogFilter selects the channels logged by PeerLogReceive. A nil
+// filter logs nothing.
+type receiveLogFilter struct {
+	channels map[byte]struct{} // all channels if empty
+}
+
+func newReceiveLogFilter(chIDs []byte) *receiveLogFilter {
+	f := &receiveLogFilter{channels: make(map[byte]struct{}, len(chIDs))}
+	for _, chID := range chIDs {
+		f.channels[chID] = struct{}{}
+	}
+	return f
+}
+
+func (f *receiveLogFilter) logs(chID byte) bool {
+	if f == nil {
+		return false
+	}
+	if len(f.channels) == 0 {
+		return true
+	}
+	_, ok := f.channels[chID]
+	return ok
+}
+
+// PeerLogUnknownChannel enables a debug log whenever a message is not sent
+// because the peer does not support its channel. Such sends are always
+// counted in the PeerSendUnknownChannelTotal metric. Default: off.
+func PeerLogUnknownChannel(enabled bool) PeerOption {
+	return func(p *peer) {
+		p.logUnknownChannel = enabled
+	}
+}
+
+// FlushPolicy controls when messages written to a peer's connection are
+// flushed to the network.
+type FlushPolicy struct {
+	interval time.Duration
+}
+
+// FlushImmediate flushes the connection after every batch of packets,
+// minimizing latency at the cost of more, smaller writes.
+var FlushImmediate = FlushPolicy{}
+
+// FlushBatched flushes the connection at most once per interval, batching
+// writes for throughput at the cost of up to interval extra latency.
+func FlushBatched(interval time.Duration) FlushPolicy {
+	return FlushPolicy{interval: interval}
+}
+
+// PeerFlushPolicy overrides the flush throttle of the MConnConfig for this
+// peer. See SwitchPeerFlushPolicy to set it for the peers a switch connects
+// to.
+func PeerFlushPolicy(policy FlushPolicy) PeerOption {
+	return func(p *peer) {
+		p.flushPolicy = &policy
+	}
+}
+
 func (p *peer) metricsReporter() {
+	defer p.goroutines.Add(-1)
 	metricsTicker := time.NewTicker(metricsTickerDuration)
 	defer metricsTicker.Stop()
 
@@ -401,43 +970,203 @@ func (p *peer) metricsReporter() {
 	}
 }
 
+// resources returns the goroutines and queued bytes the peer and its
+// connection hold, see Switch.PeerResourceStats.
+func (p *peer) resources() PeerResources {
+	return PeerResources{
+		Goroutines:  int(p.goroutines.Load()) + p.mconn.Goroutines(),
+		QueuedBytes: p.mconn.QueuedBytes(),
+	}
+}
+
+func (p *peer) channelReactor(chID byte) (Reactor, proto.Message) {
+	p.reactorsMtx.RLock()
+	defer p.reactorsMtx.RUnlock()
+	return p.reactorsByCh[chID], p.msgTypeByChID[chID]
+}
+
+// channelReactorGetter is implemented by peers which know the reactor and
+// message type registered for each of their channels.
+type channelReactorGetter interface {
+	channelReactor(chID byte) (Reactor, proto.Message)
+}
+
+// channelAdder is implemented by peers which can be given channels after
+// they have been created.
+type channelAdder interface {
+	addChannels(
+		chDescs []*cmtconn.ChannelDescriptor,
+		reactorsByCh map[byte]Reactor,
+		msgTypeByChID map[byte]proto.Message,
+	) error
+}
+
+var _ channelAdder = (*peer)(nil)
+
+// addChannels adds the channels among chDescs the peer's connection doesn't
+// have yet and starts dispatching messages received on all channels using
+// the given maps, which must not be modified afterwards. It's used to wire
+// reactors added to a running switch into live peers. The peer can't
+// advertise channels after the handshake, so the added channels are assumed
+// to be implemented by the peer, as by a node running the same reactors,
+// and can be sent on.
+func (p *peer) addChannels(
+	chDescs []*cmtconn.ChannelDescriptor,
+	reactorsByCh map[byte]Reactor,
+	msgTypeByChID map[byte]proto.Message,
+) error {
+	p.reactorsMtx.Lock()
+	defer p.reactorsMtx.Unlock()
+
+	channels := slices.Clip(p.channels) // so appending copies
+	for _, chDesc := range chDescs {
+		if _, ok := p.reactorsByCh[chDesc.ID]; ok {
+			continue
+		}
+		if _, ok := p.removedChannels[chDesc.ID]; ok {
+			continue
+		}
+		if err := p.mconn.AddChannel(withPriority(chDesc, p.priority)); err != nil {
+			return err
+		}
+		if !slices.Contains(channels, chDesc.ID) {
+			channels = append(channels, chDesc.ID)
+		}
+	}
+	p.channels = channels
+	p.reactorsByCh = reactorsByCh
+	p.msgTypeByChID = msgTypeByChID
+	p.dedupKeys = withDedupKeys(p.dedupKeys, chDescs)
+	return nil
+}
+
+// withDedupKeys returns a copy of dedupKeys with the DedupKey of the channels
+// among chDescs that have one.
+func withDedupKeys(
+	dedupKeys map[byte]func(proto.Message) string,
+	chDescs []*cmtconn.ChannelDescriptor,
+) map[byte]func(proto.Message) string {
+	withKeys := make(map[byte]func(proto.Message) string, len(dedupKeys))
+	for id, key := range dedupKeys {
+		withKeys[id] = key
+	}
+	for _, chDesc := range chDescs {
+		if chDesc.DedupKey != nil {
+			withKeys[chDesc.ID] = chDesc.DedupKey
+		}
+	}
+	return withKeys
+}
+
+// dedupKey returns the key of msg on channel chID, or "" if its messages are
+// not deduplicated.
+func (p *peer) dedupKey(chID byte, msg proto.Message) string {
+	p.reactorsMtx.RLock()
+	key, ok := p.dedupKeys[chID]
+	p.reactorsMtx.RUnlock()
+	if !ok || msg == nil {
+		return ""
+	}
+	return key(msg)
+}
+
+// RemoveChannel implements Peer.
+func (p *peer) RemoveChannel(chID byte) error {
+	p.reactorsMtx.Lock()
+	defer p.reactorsMtx.Unlock()
+
+	if _, ok := p.removedChannels[chID]; ok {
+		return nil
+	}
+	if err := p.mconn.RemoveChannel(chID); err != nil {
+		return err
+	}
+
+	reactorsByCh := make(map[byte]Reactor, len(p.reactorsByCh))
+	msgTypeByChID := make(map[byte]proto.Message, len(p.msgTypeByChID))
+	for id, reactor := range p.reactorsByCh {
+		if id != chID {
+			reactorsByCh[id] = reactor
+			msgTypeByChID[id] = p.msgTypeByChID[id]
+		}
+	}
+	removedChannels := map[byte]struct{}{chID: {}}
+	for id := range p.removedChannels {
+		removedChannels[id] = struct{}{}
+	}
+	p.reactorsByCh = reactorsByCh
+	p.msgTypeByChID = msgTypeByChID
+	p.removedChannels = removedChannels
+	return nil
+}
+
+func (p *peer) channelRemoved(chID byte) bool {
+	p.reactorsMtx.RLock()
+	defer p.reactorsMtx.RUnlock()
+	_, ok := p.removedChannels[chID]
+	return ok
+}
+
 // ------------------------------------------------------------------
 // helper funcs
 
 func createMConnection(
 	conn net.Conn,
 	p *peer,
-	reactorsByCh map[byte]Reactor,
-	msgTypeByChID map[byte]proto.Message,
 	chDescs []*cmtconn.ChannelDescriptor,
 	onPeerError func(Peer, any),
 	config cmtconn.MConnConfig,
 ) *cmtconn.MConnection {
 	onReceive := func(chID byte, msgBytes []byte) {
-		reactor := reactorsByCh[chID]
+		// Wait for the budget before taking recvMtx, so stopping the peer
+		// isn't held up.
+		if p.recvBudget != nil {
+			n := p.recvBudget.acquire(int64(len(msgBytes)))
+			defer p.recvBudget.release(n)
+		}
+		p.recvMtx.RLock()
+		defer p.recvMtx.RUnlock()
+		if p.recvStopped {
+			return
+		}
+		reactor, mt := p.channelReactor(chID)
 		if reactor == nil {
+			if p.channelRemoved(chID) {
+				// The message was read before RemoveChannel returned.
+				return
+			}
 			// Note that its ok to panic here as it's caught in the conn._recover,
 			// which does onPeerError.
 			panic(fmt.Sprintf("Unknown channel %X", chID))
 		}
-		mt := msgTypeByChID[chID]
-		msg := proto.Clone(mt)
-		err := proto.Unmarshal(msgBytes, msg)
+		if b, ok := p.captures[chID]; ok {
+			b.add(msgBytes)
+		}
+		msg, err := decodeMsg(mt, msgBytes)
 		if err != nil {
-			panic(fmt.Sprintf("unmarshaling message: %v into type: %s", err, reflect.TypeOf(mt)))
+			p.metrics.PeerReceiveInvalid.With("ch_id", fmt.Sprintf("%#x", chID)).Add(1)
+			panic(err.Error())
 		}
-		if w, ok := msg.(types.Unwrapper); ok {
-			msg, err = w.Unwrap()
-			if err != nil {
-				panic(fmt.Sprintf("unwrapping message: %v", err))
-			}
+		msgType := getMsgType(msg)
+		p.pendingMetrics.AddPendingRecvBytes(msgType, len(msgBytes))
+		if p.logReceive.logs(chID) {
+			p.Logger.Debug("Received message", "peer", p.ID(), "chID", chID,
+				"msgType", buildLabel(msgType), "bytes", len(msgBytes))
 		}
-		p.pendingMetrics.AddPendingRecvBytes(getMsgType(msg), len(msgBytes))
+		start := time.Now()
 		reactor.Receive(Envelope{
 			ChannelID: chID,
 			Src:       p,
 			Message:   msg,
 		})
+		p.metrics.ReactorProcessDuration.
+			With("reactor", reactor.String(), "message_type", p.pendingMetrics.label(msgType)).
+			Observe(time.Since(start).Seconds())
+		if threshold := config.RecvBackpressureThreshold; threshold > 0 && time.Since(start) > threshold {
+			if bpr, ok := reactor.(BackpressureReactor); ok {
+				bpr.OnChannelBackpressure(p, chID)
+			}
+		}
 	}
 
 	onError := func(r any) {
@@ -452,3 +1181,20 @@ func createMConnection(
 		config,
 	)
 }
+
+// decodeMsg unmarshals msgBytes into a new message of the same type as mt,
+// unwrapping it if it's a wrapper.
+func decodeMsg(mt proto.Message, msgBytes []byte) (proto.Message, error) {
+	msg := proto.Clone(mt)
+	if err := proto.Unmarshal(msgBytes, msg); err != nil {
+		return nil, fmt.Errorf("unmarshaling message: %v into type: %s", err, reflect.TypeOf(mt))
+	}
+	if w, ok := msg.(types.Unwrapper); ok {
+		unwrapped, err := w.Unwrap()
+		if err != nil {
+			return nil, fmt.Errorf("unwrapping message: %v", err)
+		}
+		return unwrapped, nil
+	}
+	return msg, nil
+}
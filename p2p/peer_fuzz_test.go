@@ -0,0 +1,162 @@
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cosmos/gogoproto/proto"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	bcproto "github.com/cometbft/cometbft/api/cometbft/blocksync/v1"
+	cmtcons "github.com/cometbft/cometbft/api/cometbft/consensus/v1"
+	protomem "github.com/cometbft/cometbft/api/cometbft/mempool/v1"
+	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
+	ssproto "github.com/cometbft/cometbft/api/cometbft/statesync/v1"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/libs/metrics/prometheus"
+	"github.com/cometbft/cometbft/libs/protoio"
+	cmtconn "github.com/cometbft/cometbft/p2p/conn"
+)
+
+// fuzzChannels are the channels of the built-in reactors with the message
+// types they register, see their GetChannels.
+var fuzzChannels = []struct {
+	id      byte
+	msgType proto.Message
+}{
+	{0x00, &tmp2p.Message{}},         // pex
+	{0x20, &cmtcons.Message{}},       // consensus state
+	{0x21, &cmtcons.Message{}},       // consensus data
+	{0x22, &cmtcons.Message{}},       // consensus votes
+	{0x23, &cmtcons.Message{}},       // consensus vote set bits
+	{0x30, &protomem.Message{}},      // mempool
+	{0x38, &cmtproto.EvidenceList{}}, // evidence
+	{0x40, &bcproto.Message{}},       // blocksync
+	{0x60, &ssproto.Message{}},       // statesync snapshots
+	{0x61, &ssproto.Message{}},       // statesync chunks
+}
+
+// fuzzReactor hands every message it receives to received.
+type fuzzReactor struct {
+	BaseReactor
+	chDescs  []*cmtconn.ChannelDescriptor
+	received chan Envelope
+}
+
+func (r *fuzzReactor) GetChannels() []*cmtconn.ChannelDescriptor { return r.chDescs }
+
+func (r *fuzzReactor) Receive(e Envelope) { r.received <- e }
+
+// FuzzChannelProcessor sends arbitrary bytes as a message on each of the
+// channels of the built-in reactors to a peer, through its MConnection, and
+// checks the peer either decodes them into a message for the reactor or drops
+// them, counted by PeerReceiveInvalid, and fails with an error for the switch
+// to stop it, without taking down the node.
+func FuzzChannelProcessor(f *testing.F) {
+	seeds := []proto.Message{
+		(&tmp2p.PexRequest{}).Wrap(),
+		&cmtcons.Message{Sum: &cmtcons.Message_HasVote{HasVote: &cmtcons.HasVote{Height: 1, Index: 2}}},
+		&protomem.Message{Sum: &protomem.Message_Txs{Txs: &protomem.Txs{Txs: [][]byte{[]byte("tx")}}}},
+		&cmtproto.EvidenceList{},
+		&bcproto.Message{Sum: &bcproto.Message_BlockRequest{BlockRequest: &bcproto.BlockRequest{Height: 1}}},
+		&ssproto.Message{Sum: &ssproto.Message_SnapshotsRequest{SnapshotsRequest: &ssproto.SnapshotsRequest{}}},
+	}
+	for i, msg := range seeds {
+		bz, err := proto.Marshal(msg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(byte(i), bz)
+	}
+	for i := range fuzzChannels {
+		f.Add(byte(i), []byte{})
+		f.Add(byte(i), []byte{0xff, 0xff, 0xff})
+		// A message with the field number of a oneof of another type.
+		f.Add(byte(i), []byte{0x52, 0x00})
+	}
+
+	r := &fuzzReactor{received: make(chan Envelope, 1)}
+	r.BaseReactor = *NewBaseReactor("fuzz", r)
+	reactorsByCh := make(map[byte]Reactor, len(fuzzChannels))
+	msgTypeByChID := make(map[byte]proto.Message, len(fuzzChannels))
+	channels := make([]byte, 0, len(fuzzChannels))
+	for _, ch := range fuzzChannels {
+		r.chDescs = append(r.chDescs, &cmtconn.ChannelDescriptor{ID: ch.id, Priority: 1, MessageType: ch.msgType})
+		reactorsByCh[ch.id] = r
+		msgTypeByChID[ch.id] = ch.msgType
+		channels = append(channels, ch.id)
+	}
+	nodeInfo := DefaultNodeInfo{
+		ProtocolVersion: defaultProtocolVersion,
+		DefaultNodeID:   PubKeyToID(ed25519.GenPrivKey().PubKey()),
+		Network:         "testing",
+		Channels:        channels,
+		Moniker:         "fuzz",
+	}
+
+	invalid := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "peer_receive_invalid"}, []string{"ch_id"})
+	metrics := NopMetrics()
+	metrics.PeerReceiveInvalid = prometheus.NewCounter(invalid)
+	invalidCount := func(t *testing.T, chID byte) float64 {
+		t.Helper()
+		var m dto.Metric
+		if err := invalid.WithLabelValues(fmt.Sprintf("%#x", chID)).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	mconfig := cmtconn.DefaultMConnConfig()
+
+	f.Fuzz(func(t *testing.T, chIdx byte, data []byte) {
+		chID := fuzzChannels[int(chIdx)%len(fuzzChannels)].id
+		invalidBefore := invalidCount(t, chID)
+
+		local, remote := net.Pipe()
+		defer remote.Close()
+		errored := make(chan any, 1)
+		p := newPeer(newPeerConn(false, false, local, nil), mconfig, nodeInfo,
+			reactorsByCh, msgTypeByChID, r.chDescs, func(_ Peer, r any) { errored <- r }, PeerMetrics(metrics))
+		p.SetLogger(log.NewNopLogger())
+		if err := p.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = p.Stop() }()
+
+		// Split into packets as the MConnection of the remote would, so all
+		// messages reach the peer's decoding.
+		go func() {
+			w := protoio.NewDelimitedWriter(remote)
+			for chunk := data; ; chunk = chunk[mconfig.MaxPacketMsgPayloadSize:] {
+				eof := len(chunk) <= mconfig.MaxPacketMsgPayloadSize
+				msg := &tmp2p.PacketMsg{ChannelID: int32(chID), EOF: eof, Data: chunk}
+				if !eof {
+					msg.Data = chunk[:mconfig.MaxPacketMsgPayloadSize]
+				}
+				if _, err := w.WriteMsg(&tmp2p.Packet{Sum: &tmp2p.Packet_PacketMsg{PacketMsg: msg}}); err != nil || eof {
+					return
+				}
+			}
+		}()
+
+		select {
+		case e := <-r.received:
+			if e.ChannelID != chID || e.Src != p || e.Message == nil {
+				t.Fatalf("unexpected envelope %+v", e)
+			}
+			if got := invalidCount(t, chID); got != invalidBefore {
+				t.Fatalf("PeerReceiveInvalid moved to %v for a decoded message", got)
+			}
+		case <-errored:
+			if got := invalidCount(t, chID); got != invalidBefore+1 {
+				t.Fatalf("PeerReceiveInvalid is %v for a dropped message, want %v", got, invalidBefore+1)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was neither received nor rejected")
+		}
+	})
+}
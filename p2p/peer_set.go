@@ -111,7 +111,8 @@ func (ps *PeerSet) Remove(peer Peer) bool {
 	defer ps.mtx.Unlock()
 
 	item, ok := ps.lookup[peer.ID()]
-	if !ok || len(ps.list) == 0 {
+	// A peer replaced by a duplicate connection must not take the new one out.
+	if !ok || item.peer != peer || len(ps.list) == 0 {
 		// Removing the peer has failed so we set a flag to mark that a removal was attempted.
 		// This can happen when the peer add routine from the switch is running in
 		// parallel to the receive routine of MConn.
@@ -4,7 +4,9 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/cosmos/gogoproto/proto"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cometbft/cometbft/crypto/ed25519"
@@ -18,23 +20,38 @@ type mockPeer struct {
 	id ID
 }
 
-func (mp *mockPeer) FlushStop()            { mp.Stop() } //nolint:errcheck // ignore error
-func (*mockPeer) HasChannel(byte) bool     { return true }
-func (*mockPeer) TrySend(Envelope) bool    { return true }
-func (*mockPeer) Send(Envelope) bool       { return true }
-func (*mockPeer) NodeInfo() NodeInfo       { return DefaultNodeInfo{} }
-func (*mockPeer) Status() ConnectionStatus { return ConnectionStatus{} }
-func (mp *mockPeer) ID() ID                { return mp.id }
-func (*mockPeer) IsOutbound() bool         { return false }
-func (*mockPeer) IsPersistent() bool       { return true }
-func (*mockPeer) Get(s string) any         { return s }
-func (*mockPeer) Set(string, any)          {}
-func (mp *mockPeer) RemoteIP() net.IP      { return mp.ip }
-func (*mockPeer) SocketAddr() *NetAddress  { return nil }
-func (mp *mockPeer) RemoteAddr() net.Addr  { return &net.TCPAddr{IP: mp.ip, Port: 8800} }
-func (*mockPeer) CloseConn() error         { return nil }
-func (*mockPeer) SetRemovalFailed()        {}
-func (*mockPeer) GetRemovalFailed() bool   { return false }
+func (mp *mockPeer) FlushStop()                    { mp.Stop() } //nolint:errcheck // ignore error
+func (*mockPeer) Flush() error                     { return nil }
+func (*mockPeer) HasChannel(byte) bool             { return true }
+func (*mockPeer) SupportsFeature(string) bool      { return false }
+func (*mockPeer) CaptureBuffer(byte) [][]byte      { return nil }
+func (*mockPeer) TrySend(Envelope) bool            { return true }
+func (*mockPeer) Send(Envelope) bool               { return true }
+func (*mockPeer) SendE(Envelope) error             { return nil }
+func (*mockPeer) NodeInfo() NodeInfo               { return DefaultNodeInfo{} }
+func (*mockPeer) ProtocolVersion() ProtocolVersion { return ProtocolVersion{} }
+func (*mockPeer) Status() ConnectionStatus         { return ConnectionStatus{} }
+func (*mockPeer) SecurityInfo() SecurityInfo       { return SecurityInfo{} }
+func (mp *mockPeer) ID() ID                        { return mp.id }
+func (*mockPeer) IsOutbound() bool                 { return false }
+func (*mockPeer) IsPersistent() bool               { return true }
+func (*mockPeer) Get(s string) any                 { return s }
+func (*mockPeer) Set(string, any)                  {}
+func (mp *mockPeer) RemoteIP() net.IP              { return mp.ip }
+func (*mockPeer) SocketAddr() *NetAddress          { return nil }
+func (*mockPeer) ConfiguredAddr() *NetAddress      { return nil }
+func (*mockPeer) Addr() *NetAddress                { return nil }
+func (mp *mockPeer) RemoteAddr() net.Addr          { return &net.TCPAddr{IP: mp.ip, Port: 8800} }
+func (*mockPeer) CloseConn() error                 { return nil }
+func (*mockPeer) SetRemovalFailed()                {}
+func (*mockPeer) GetRemovalFailed() bool           { return false }
+func (*mockPeer) ConnectedSince() time.Time        { return time.Time{} }
+
+func (*mockPeer) SendWithFallback(Envelope, Envelope) bool { return true }
+func (*mockPeer) TrySendMany(_ byte, msgs []proto.Message) int {
+	return len(msgs)
+}
+func (*mockPeer) RemoveChannel(byte) error { return nil }
 
 // Returns a mock peer.
 func newMockPeer(ip net.IP) *mockPeer {
@@ -3,12 +3,16 @@ package p2p
 import (
 	"errors"
 	"fmt"
+	"io"
 	golog "log"
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -18,6 +22,8 @@ import (
 	"github.com/cometbft/cometbft/crypto/ed25519"
 	"github.com/cometbft/cometbft/libs/bytes"
 	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/libs/metrics/prometheus"
+	"github.com/cometbft/cometbft/libs/protoio"
 	cmtconn "github.com/cometbft/cometbft/p2p/conn"
 )
 
@@ -41,12 +47,25 @@ func TestPeerBasic(t *testing.T) {
 	})
 
 	assert.True(p.IsRunning())
+	assert.False(p.ConnectedSince().IsZero())
 	assert.True(p.IsOutbound())
 	assert.False(p.IsPersistent())
 	p.persistent = true
 	assert.True(p.IsPersistent())
 	assert.Equal(rp.Addr().DialString(), p.RemoteAddr().String())
 	assert.Equal(rp.ID(), p.ID())
+	assert.Equal(rp.Addr(), p.Addr())
+	assert.Equal(ed25519.KeyType, p.SecurityInfo().RemotePubKeyType)
+	assert.True(SamePeer(p, p))
+}
+
+func TestSamePeer(t *testing.T) {
+	a, b := newMockPeer(nil), newMockPeer(nil)
+	assert.True(t, SamePeer(a, &mockPeer{id: a.ID()}))
+	assert.False(t, SamePeer(a, b))
+	assert.False(t, SamePeer(a, nil))
+	assert.False(t, SamePeer(nil, b))
+	assert.True(t, SamePeer(nil, nil))
 }
 
 func TestPeerSend(t *testing.T) {
@@ -73,6 +92,718 @@ func TestPeerSend(t *testing.T) {
 
 	assert.True(p.CanSend(testCh))
 	assert.True(p.Send(Envelope{ChannelID: testCh, Message: &p2p.Message{}}))
+	assert.False(p.Send(Envelope{ChannelID: testCh}))
+	assert.False(p.TrySend(Envelope{ChannelID: testCh, Message: (*p2p.Message)(nil)}))
+}
+
+func TestPeerSendE(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+
+	require.ErrorIs(t, p.SendE(Envelope{ChannelID: testCh, Message: &p2p.Message{}}), ErrPeerStopped)
+
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	require.NoError(t, p.SendE(Envelope{ChannelID: testCh, Message: &p2p.Message{}}))
+	require.ErrorIs(t, p.SendE(Envelope{ChannelID: 0x7f, Message: &p2p.Message{}}), ErrUnknownChannel)
+	require.ErrorAs(t, p.SendE(Envelope{ChannelID: testCh}), &ErrEnvelopeNilMessage{})
+}
+
+func TestPeerSendBytesErrors(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	// The connection errors of sends that got past canSend, e.g. because the
+	// peer stopped or the channel was removed meanwhile.
+	mm, err := marshalMsg(&p2p.Message{})
+	require.NoError(t, err)
+	send := func(chID byte) error { return p.sendBytes(chID, mm, p.mconn.SendKeyedE) }
+	require.ErrorIs(t, send(0x7f), ErrUnknownChannel)
+	require.NoError(t, p.RemoveChannel(testCh))
+	require.ErrorIs(t, send(testCh), ErrChannelRemoved)
+	require.NoError(t, p.mconn.Stop())
+	require.ErrorIs(t, send(testCh), ErrPeerStopped)
+}
+
+func TestPeerSendUnknownChannelMetric(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	counter := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "unknown_channel"}, []string{"ch_id"})
+	p.metrics.PeerSendUnknownChannelTotal = prometheus.NewCounter(counter)
+	PeerLogUnknownChannel(true)(p)
+
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	assert.False(t, p.Send(Envelope{ChannelID: 0x7f, Message: &p2p.Message{}}))
+	assert.Zero(t, p.TrySendMany(0x7f, []proto.Message{&p2p.Message{}}))
+	assert.True(t, p.Send(Envelope{ChannelID: testCh, Message: &p2p.Message{}}))
+	var m dto.Metric
+	require.NoError(t, counter.WithLabelValues("0x7f").Write(&m))
+	assert.Equal(t, 2.0, m.GetCounter().GetValue())
+}
+
+func TestPeerSendLatencyMetric(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	histogram := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "send_latency"}, []string{"ch_id"})
+	p.metrics.PeerSendLatency = prometheus.NewHistogram(histogram)
+
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	observed := func(chID string) uint64 {
+		m := &dto.Metric{}
+		obs, err := histogram.GetMetricWithLabelValues(chID)
+		require.NoError(t, err)
+		require.NoError(t, obs.(stdprometheus.Histogram).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	assert.True(t, p.Send(Envelope{ChannelID: testCh, Message: &p2p.Message{}}))
+	assert.True(t, p.Send(Envelope{ChannelID: testCh, Message: &p2p.Message{}}))
+	assert.Equal(t, uint64(2), observed(fmt.Sprintf("%#x", testCh)))
+	// Sends that never reach the connection are not observed.
+	assert.False(t, p.Send(Envelope{ChannelID: 0x7f, Message: &p2p.Message{}}))
+	assert.Zero(t, observed("0x7f"))
+}
+
+func TestPeerTrySendMany(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+
+	msgs := []proto.Message{&p2p.Message{}, &p2p.Message{}}
+	assert.Zero(t, p.TrySendMany(testCh, msgs), "peer not running")
+
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	assert.Zero(t, p.TrySendMany(0x7f, msgs), "unknown channel")
+	// sending stops at the first invalid message
+	assert.Equal(t, 1, p.TrySendMany(testCh, []proto.Message{&p2p.Message{}, nil, &p2p.Message{}}))
+
+	many := make([]proto.Message, 100)
+	for i := range many {
+		many[i] = &p2p.Message{}
+	}
+	require.Eventually(t, func() bool { return p.CanSend(testCh) }, time.Second, time.Millisecond)
+	n := p.TrySendMany(testCh, many)
+	assert.Positive(t, n)
+	assert.LessOrEqual(t, n, len(many))
+}
+
+func TestPeerSendWithFallback(t *testing.T) {
+	const primaryCh, fallbackCh = byte(0x01), byte(0x02)
+	chDescs := []*cmtconn.ChannelDescriptor{
+		{ID: primaryCh, Priority: 10, SendQueueCapacity: 1, MessageType: &p2p.Message{}},
+		{ID: fallbackCh, Priority: 1, SendQueueCapacity: 1, MessageType: &p2p.Message{}},
+	}
+	msgTypeByChID := map[byte]proto.Message{primaryCh: &p2p.Message{}, fallbackCh: &p2p.Message{}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "fallback").(DefaultNodeInfo)
+	nodeInfo.Channels = []byte{primaryCh, fallbackCh}
+
+	// Nothing reads from remote, so the sendRoutine blocks writing the first
+	// message and the later ones stay queued.
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+		map[byte]Reactor{}, msgTypeByChID, chDescs, func(Peer, any) {})
+	p.SetLogger(log.TestingLogger())
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	primary := Envelope{ChannelID: primaryCh, Message: &p2p.PexRequest{}}
+	fallback := Envelope{ChannelID: fallbackCh, Message: &p2p.PexRequest{}}
+	require.True(t, p.TrySend(primary))
+	require.Eventually(t, func() bool { return p.CanSend(primaryCh) }, time.Second, time.Millisecond)
+
+	queued := func(chID byte) int {
+		for _, ch := range p.Status().Channels {
+			if ch.ID == chID {
+				return ch.SendQueueSize
+			}
+		}
+		return 0
+	}
+
+	// The fallback is only for a full queue.
+	require.False(t, p.SendWithFallback(Envelope{ChannelID: 0x7f, Message: &p2p.PexRequest{}}, fallback))
+	assert.Equal(t, 0, queued(fallbackCh))
+
+	// The primary channel has room.
+	require.True(t, p.SendWithFallback(primary, fallback))
+	assert.Equal(t, 0, queued(fallbackCh))
+
+	// It is full, so the fallback is sent, until its channel is full too.
+	require.True(t, p.SendWithFallback(primary, fallback))
+	assert.Equal(t, 1, queued(fallbackCh))
+	require.False(t, p.SendWithFallback(primary, fallback))
+}
+
+func TestPeerObserver(t *testing.T) {
+	r := &fuzzReactor{received: make(chan Envelope, 1)}
+	r.BaseReactor = *NewBaseReactor("observer", r)
+	r.chDescs = []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "observed").(DefaultNodeInfo)
+
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+		map[byte]Reactor{testCh: r}, map[byte]proto.Message{testCh: &p2p.Message{}}, r.chDescs,
+		func(Peer, any) {}, PeerObserver())
+	p.SetLogger(log.TestingLogger())
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	require.True(t, p.IsObserver())
+	msg := &p2p.Message{}
+	assert.False(t, p.CanSend(testCh))
+	require.ErrorIs(t, p.SendE(Envelope{ChannelID: testCh, Message: msg}), ErrObserverPeer)
+	assert.False(t, p.TrySend(Envelope{ChannelID: testCh, Message: msg}))
+	assert.Zero(t, p.TrySendMany(testCh, []proto.Message{msg}))
+
+	// Messages from the peer are still received.
+	bz, err := proto.Marshal((&p2p.PexRequest{}).Wrap())
+	require.NoError(t, err)
+	go func() {
+		_, _ = protoio.NewDelimitedWriter(remote).WriteMsg(&p2p.Packet{
+			Sum: &p2p.Packet_PacketMsg{PacketMsg: &p2p.PacketMsg{ChannelID: int32(testCh), EOF: true, Data: bz}},
+		})
+	}()
+	select {
+	case e := <-r.received:
+		assert.Equal(t, byte(testCh), e.ChannelID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message from the observer peer was not received")
+	}
+}
+
+// connectedSinceReactor records the ConnectedSince of the peer of every
+// message it receives.
+type connectedSinceReactor struct {
+	fuzzReactor
+	connectedSince chan time.Time
+}
+
+func (r *connectedSinceReactor) Receive(e Envelope) { r.connectedSince <- e.Src.ConnectedSince() }
+
+func TestPeerConnectedSinceInReceive(t *testing.T) {
+	r := &connectedSinceReactor{connectedSince: make(chan time.Time, 1)}
+	r.BaseReactor = *NewBaseReactor("connectedSince", r)
+	r.chDescs = []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "connected").(DefaultNodeInfo)
+
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+		map[byte]Reactor{testCh: r}, map[byte]proto.Message{testCh: &p2p.Message{}}, r.chDescs,
+		func(Peer, any) {})
+	p.SetLogger(log.TestingLogger())
+
+	// The message is pending before the peer starts, so it's received as soon
+	// as the connection is started.
+	bz, err := proto.Marshal((&p2p.PexRequest{}).Wrap())
+	require.NoError(t, err)
+	go func() {
+		_, _ = protoio.NewDelimitedWriter(remote).WriteMsg(&p2p.Packet{
+			Sum: &p2p.Packet_PacketMsg{PacketMsg: &p2p.PacketMsg{ChannelID: int32(testCh), EOF: true, Data: bz}},
+		})
+	}()
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	select {
+	case connectedSince := <-r.connectedSince:
+		assert.False(t, connectedSince.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+}
+
+func TestSendTyped(t *testing.T) {
+	r := &fuzzReactor{received: make(chan Envelope, 1)}
+	r.BaseReactor = *NewBaseReactor("typed", r)
+	r.chDescs = []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "typed").(DefaultNodeInfo)
+
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	go func() { _, _ = io.Copy(io.Discard, remote) }()
+	p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+		map[byte]Reactor{testCh: r}, map[byte]proto.Message{testCh: &p2p.Message{}}, r.chDescs,
+		func(Peer, any) {})
+	p.SetLogger(log.TestingLogger())
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	// The registered type and a message wrapping into it can be sent.
+	assert.True(t, SendTyped(p, testCh, &p2p.Message{}))
+	assert.True(t, SendTyped(p, testCh, &p2p.PexRequest{}))
+	// Channels the peer doesn't have are left to Send.
+	assert.False(t, SendTyped(p, testCh+1, &p2p.PexRequest{}))
+
+	assert.PanicsWithError(t, ErrChannelMessageType{
+		ChannelID: testCh,
+		Want:      reflect.TypeOf(&p2p.Message{}),
+		Got:       reflect.TypeOf(&p2p.PacketPing{}),
+	}.Error(), func() { SendTyped(p, testCh, &p2p.PacketPing{}) })
+
+	// Outside tests, the message is dropped instead.
+	panicOnMessageType = false
+	t.Cleanup(func() { panicOnMessageType = true })
+	assert.False(t, SendTyped(p, testCh, &p2p.PacketPing{}))
+}
+
+func TestSendMarshaled(t *testing.T) {
+	chDescs := []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	msg, err := MarshalMessage(&p2p.PexRequest{})
+	require.NoError(t, err)
+	want, err := proto.Marshal((&p2p.PexRequest{}).Wrap())
+	require.NoError(t, err)
+
+	// The same bytes are sent to every peer.
+	for i := 0; i < 2; i++ {
+		local, remote := net.Pipe()
+		t.Cleanup(func() { remote.Close() })
+		nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "marshaled").(DefaultNodeInfo)
+		p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+			map[byte]Reactor{}, map[byte]proto.Message{testCh: &p2p.Message{}}, chDescs,
+			func(Peer, any) {})
+		p.SetLogger(log.TestingLogger())
+		require.NoError(t, p.Start())
+		t.Cleanup(func() { _ = p.Stop() })
+
+		require.True(t, SendMarshaled(p, testCh, msg))
+		var packet p2p.Packet
+		_, err = protoio.NewDelimitedReader(remote, 1024).ReadMsg(&packet)
+		require.NoError(t, err)
+		assert.Equal(t, want, packet.GetPacketMsg().Data)
+
+		// Channels the peer doesn't have are refused, as by Send.
+		assert.False(t, SendMarshaled(p, testCh+1, msg))
+	}
+
+	// Other peers are sent the message itself.
+	assert.True(t, SendMarshaled(newMockPeer(nil), testCh, msg))
+}
+
+func TestPeerRecvBudget(t *testing.T) {
+	r := &fuzzReactor{received: make(chan Envelope)}
+	r.BaseReactor = *NewBaseReactor("budget", r)
+	r.chDescs = []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	counter := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "waits"}, []string{})
+	metrics := NopMetrics()
+	metrics.RecvBudgetWaits = prometheus.NewCounter(counter)
+
+	bz, err := proto.Marshal((&p2p.PexRequest{}).Wrap())
+	require.NoError(t, err)
+	// Room for a single message at a time.
+	budget := newRecvBudget(int64(len(bz)), metrics)
+
+	peers := make([]Peer, 2)
+	for i := range peers {
+		nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "budget").(DefaultNodeInfo)
+		local, remote := net.Pipe()
+		t.Cleanup(func() { remote.Close() })
+		p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+			map[byte]Reactor{testCh: r}, map[byte]proto.Message{testCh: &p2p.Message{}}, r.chDescs,
+			func(Peer, any) {}, PeerMetrics(metrics), func(p *peer) { p.recvBudget = budget })
+		p.SetLogger(log.TestingLogger())
+		require.NoError(t, p.Start())
+		t.Cleanup(func() { _ = p.Stop() })
+		peers[i] = p
+
+		go func() {
+			_, _ = protoio.NewDelimitedWriter(remote).WriteMsg(&p2p.Packet{
+				Sum: &p2p.Packet_PacketMsg{PacketMsg: &p2p.PacketMsg{ChannelID: int32(testCh), EOF: true, Data: bz}},
+			})
+		}()
+	}
+
+	// One message is stuck in Receive until it is read below, so the other
+	// waits for the budget.
+	waits := func() float64 {
+		m := &dto.Metric{}
+		require.NoError(t, counter.WithLabelValues().Write(m))
+		return m.GetCounter().GetValue()
+	}
+	require.Eventually(t, func() bool { return waits() == 1 }, 5*time.Second, time.Millisecond)
+
+	first := <-r.received
+	second := <-r.received
+	assert.NotEqual(t, first.Src, second.Src)
+	assert.ElementsMatch(t, peers, []Peer{first.Src, second.Src})
+	require.Eventually(t, func() bool {
+		budget.mtx.Lock()
+		defer budget.mtx.Unlock()
+		return budget.bytes == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestPeerReactorProcessDurationMetric(t *testing.T) {
+	r := &fuzzReactor{received: make(chan Envelope, 1)}
+	r.BaseReactor = *NewBaseReactor("process", r)
+	r.chDescs = []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "process").(DefaultNodeInfo)
+	histogram := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "process"}, []string{"reactor", "message_type"})
+	metrics := NopMetrics()
+	metrics.ReactorProcessDuration = prometheus.NewHistogram(histogram)
+
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	p := newPeer(newPeerConn(true, false, local, nil), cmtconn.DefaultMConnConfig(), nodeInfo,
+		map[byte]Reactor{testCh: r}, map[byte]proto.Message{testCh: &p2p.Message{}}, r.chDescs,
+		func(Peer, any) {}, PeerMetrics(metrics))
+	p.SetLogger(log.TestingLogger())
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	bz, err := proto.Marshal((&p2p.PexRequest{}).Wrap())
+	require.NoError(t, err)
+	go func() {
+		_, _ = protoio.NewDelimitedWriter(remote).WriteMsg(&p2p.Packet{
+			Sum: &p2p.Packet_PacketMsg{PacketMsg: &p2p.PacketMsg{ChannelID: int32(testCh), EOF: true, Data: bz}},
+		})
+	}()
+	<-r.received
+
+	observed := func() uint64 {
+		m := &dto.Metric{}
+		obs, err := histogram.GetMetricWithLabelValues("process", "v1_PexRequest")
+		require.NoError(t, err)
+		require.NoError(t, obs.(stdprometheus.Histogram).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	// The duration is recorded once Receive returns.
+	require.Eventually(t, func() bool { return observed() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestPeerReadTimeout(t *testing.T) {
+	chDescs := []*cmtconn.ChannelDescriptor{{ID: testCh, Priority: 1, MessageType: &p2p.Message{}}}
+	nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "blackholed").(DefaultNodeInfo)
+	mConfig := cmtconn.DefaultMConnConfig()
+	mConfig.ReadTimeout = 100 * time.Millisecond
+
+	// The remote end of a half-open connection never sends anything.
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	errored := make(chan any, 1)
+	p := newPeer(newPeerConn(true, false, local, nil), mConfig, nodeInfo,
+		map[byte]Reactor{}, map[byte]proto.Message{testCh: &p2p.Message{}}, chDescs,
+		func(_ Peer, r any) { errored <- r })
+	p.SetLogger(log.TestingLogger())
+	require.NoError(t, p.Start())
+	t.Cleanup(func() { _ = p.Stop() })
+
+	select {
+	case <-errored:
+	case <-time.After(time.Second):
+		t.Fatal("peer was not errored out after the read timeout")
+	}
+}
+
+func TestPeerRemoveChannel(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	require.NoError(t, p.RemoveChannel(testCh))
+	require.NoError(t, p.RemoveChannel(testCh), "removing twice is a no-op")
+	assert.True(t, p.HasChannel(testCh), "advertised channels are unchanged")
+	require.ErrorIs(t, p.SendE(Envelope{ChannelID: testCh, Message: &p2p.Message{}}), ErrChannelRemoved)
+	assert.Zero(t, p.TrySendMany(testCh, []proto.Message{&p2p.Message{}}))
+	assert.True(t, p.IsRunning())
+
+	require.ErrorIs(t, p.RemoveChannel(0x7f), cmtconn.ErrChannelNotFound{ID: 0x7f})
+}
+
+func TestPeerSendIgnoresMetadata(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var sent [][]byte
+	capture := func(_ byte, _ string, msgBytes []byte) error {
+		sent = append(sent, msgBytes)
+		return nil
+	}
+	msg := &p2p.PexRequest{}
+	require.NoError(t, p.send(Envelope{ChannelID: testCh, Message: msg}, capture))
+	require.NoError(t, p.send(Envelope{ChannelID: testCh, Message: msg, Metadata: map[string]string{"network": "b"}}, capture))
+	require.Len(t, sent, 2)
+	assert.Equal(t, sent[0], sent[1])
+
+	overCap := func(byte, string, []byte) error { return cmtconn.ErrMaxQueuedBytes }
+	err = p.send(Envelope{ChannelID: testCh, Message: msg}, overCap)
+	require.ErrorIs(t, err, ErrSendMemoryCap)
+	queueFull := func(byte, string, []byte) error { return cmtconn.ErrSendQueueFull }
+	err = p.send(Envelope{ChannelID: testCh, Message: msg}, queueFull)
+	require.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestPeerSendDedupKey(t *testing.T) {
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	t.Cleanup(rp.Stop)
+
+	p, err := createOutboundPeerAndPerformHandshake(rp.Addr(), cfg, cmtconn.DefaultMConnConfig())
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	p.dedupKeys = withDedupKeys(nil, []*cmtconn.ChannelDescriptor{{
+		ID: testCh,
+		DedupKey: func(msg proto.Message) string {
+			if addrs, ok := msg.(*p2p.PexAddrs); ok && len(addrs.Addrs) > 0 {
+				return string(addrs.Addrs[0].ID)
+			}
+			return ""
+		},
+	}})
+
+	var keys []string
+	queued := map[string]bool{}
+	send := func(_ byte, key string, _ []byte) error {
+		keys = append(keys, key)
+		if key != "" && queued[key] {
+			return cmtconn.ErrDuplicateKey
+		}
+		queued[key] = true
+		return nil
+	}
+	msg := &p2p.PexAddrs{Addrs: []p2p.NetAddress{{ID: "a"}}}
+	require.NoError(t, p.send(Envelope{ChannelID: testCh, Message: msg}, send))
+	// Deduplicated messages count as sent.
+	require.NoError(t, p.send(Envelope{ChannelID: testCh, Message: msg}, send))
+	require.NoError(t, p.send(Envelope{ChannelID: testCh, Message: &p2p.PexRequest{}}, send))
+	assert.Equal(t, []string{"a", "a", ""}, keys)
+}
+
+func TestReceiveLogFilter(t *testing.T) {
+	var disabled *receiveLogFilter
+	assert.False(t, disabled.logs(testCh))
+
+	all := newReceiveLogFilter(nil)
+	assert.True(t, all.logs(testCh))
+	assert.True(t, all.logs(0x7f))
+
+	some := newReceiveLogFilter([]byte{testCh})
+	assert.True(t, some.logs(testCh))
+	assert.False(t, some.logs(0x7f))
+
+	p := &peer{}
+	PeerLogReceive(0x7f)(p)
+	assert.True(t, p.logReceive.logs(0x7f))
+}
+
+func TestEnvelopeValidate(t *testing.T) {
+	var nilPeer *peer
+	testCases := []struct {
+		name     string
+		envelope Envelope
+		err      error
+	}{
+		{"valid", Envelope{ChannelID: testCh, Message: &p2p.Message{}}, nil},
+		{"valid on channel 0", Envelope{Message: &p2p.Message{}}, nil},
+		{"valid with src", Envelope{ChannelID: testCh, Message: &p2p.Message{}, Src: &peer{}}, nil},
+		{"valid with metadata", Envelope{ChannelID: testCh, Message: &p2p.Message{}, Metadata: map[string]string{"k": "v"}}, nil},
+		{"nil message", Envelope{ChannelID: testCh}, ErrEnvelopeNilMessage{ChannelID: testCh}},
+		{"typed nil message", Envelope{ChannelID: testCh, Message: (*p2p.Message)(nil)}, ErrEnvelopeNilMessage{ChannelID: testCh}},
+		{"nil src", Envelope{ChannelID: testCh, Message: &p2p.Message{}, Src: nilPeer}, ErrEnvelopeNilSrc{ChannelID: testCh}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, tc.envelope.Validate())
+		})
+	}
+}
+
+type slowReactor struct {
+	*TestReactor
+	delay          time.Duration
+	backpressureCh chan byte
+}
+
+func (r *slowReactor) Receive(Envelope) {
+	time.Sleep(r.delay)
+}
+
+func (r *slowReactor) OnChannelBackpressure(_ Peer, chID byte) {
+	r.backpressureCh <- chID
+}
+
+func TestPeerReceiveBackpressure(t *testing.T) {
+	chDescs := []*cmtconn.ChannelDescriptor{
+		{ID: testCh, Priority: 1},
+	}
+	reactor := &slowReactor{
+		TestReactor:    NewTestReactor(chDescs, false),
+		delay:          50 * time.Millisecond,
+		backpressureCh: make(chan byte, 1),
+	}
+	reactorsByCh := map[byte]Reactor{testCh: reactor}
+	msgTypeByChID := map[byte]proto.Message{
+		testCh: &p2p.Message{},
+	}
+
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	mConfig := cmtconn.DefaultMConnConfig()
+	mConfig.RecvBackpressureThreshold = 10 * time.Millisecond
+	p := &peer{
+		metrics:        NopMetrics(),
+		pendingMetrics: newPeerPendingMetricsCache(),
+		reactorsByCh:   reactorsByCh,
+		msgTypeByChID:  msgTypeByChID,
+	}
+	mconn := createMConnection(server, p, chDescs, func(_ Peer, _ any) {}, mConfig)
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	t.Cleanup(func() { _ = mconn.Stop() })
+
+	sender := cmtconn.NewMConnection(client, chDescs, func(byte, []byte) {}, func(any) {})
+	sender.SetLogger(log.TestingLogger())
+	require.NoError(t, sender.Start())
+	t.Cleanup(func() { _ = sender.Stop() })
+
+	msg := &p2p.Message{Sum: &p2p.Message_PexRequest{PexRequest: &p2p.PexRequest{}}}
+	msgBytes, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	require.True(t, sender.Send(testCh, msgBytes))
+
+	select {
+	case chID := <-reactor.backpressureCh:
+		assert.Equal(t, byte(testCh), chID)
+	case <-time.After(time.Second):
+		t.Fatal("expected backpressure notification")
+	}
+}
+
+func TestPeerCaptureReceive(t *testing.T) {
+	chDescs := []*cmtconn.ChannelDescriptor{
+		{ID: testCh, Priority: 1},
+	}
+	msgTypeByChID := map[byte]proto.Message{
+		testCh: &p2p.Message{},
+	}
+
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	p := &peer{
+		metrics:        NopMetrics(),
+		pendingMetrics: newPeerPendingMetricsCache(),
+		reactorsByCh:   map[byte]Reactor{testCh: NewTestReactor(chDescs, false)},
+		msgTypeByChID:  msgTypeByChID,
+	}
+	PeerCaptureReceive(2, testCh)(p)
+	assert.Empty(t, p.CaptureBuffer(testCh))
+	assert.Nil(t, p.CaptureBuffer(0x7f))
+
+	mconn := createMConnection(server, p, chDescs, func(_ Peer, _ any) {}, cmtconn.DefaultMConnConfig())
+	mconn.SetLogger(log.TestingLogger())
+	require.NoError(t, mconn.Start())
+	t.Cleanup(func() { _ = mconn.Stop() })
+
+	sender := cmtconn.NewMConnection(client, chDescs, func(byte, []byte) {}, func(any) {})
+	sender.SetLogger(log.TestingLogger())
+	require.NoError(t, sender.Start())
+	t.Cleanup(func() { _ = sender.Stop() })
+
+	msgs := make([]*p2p.PexAddrs, 3)
+	for i := range msgs {
+		msgs[i] = &p2p.PexAddrs{Addrs: []p2p.NetAddress{{ID: "1", IP: "127.0.0.1", Port: uint32(i)}}}
+		msgBytes, err := proto.Marshal(&p2p.Message{Sum: &p2p.Message_PexAddrs{PexAddrs: msgs[i]}})
+		require.NoError(t, err)
+		require.True(t, sender.Send(testCh, msgBytes))
+	}
+
+	// only the last two messages are kept
+	require.Eventually(t, func() bool {
+		captured := p.CaptureBuffer(testCh)
+		if len(captured) != 2 {
+			return false
+		}
+		var last p2p.Message
+		require.NoError(t, proto.Unmarshal(captured[1], &last))
+		return last.GetPexAddrs().Addrs[0].Port == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// replaying them into a fresh reactor delivers the same messages
+	reactor := NewTestReactor(chDescs, true)
+	require.NoError(t, ReplayCapture(reactor, p, testCh, &p2p.Message{}, p.CaptureBuffer(testCh)))
+	received := reactor.getMsgs(testCh)
+	require.Len(t, received, 2)
+	assert.Equal(t, msgs[1], received[0].Contents)
+	assert.Equal(t, msgs[2], received[1].Contents)
+
+	require.Error(t, ReplayCapture(reactor, p, testCh, &p2p.Message{}, [][]byte{{0xff}}))
 }
 
 func createOutboundPeerAndPerformHandshake(
@@ -94,7 +825,7 @@ func createOutboundPeerAndPerformHandshake(
 	}
 	timeout := 1 * time.Second
 	ourNodeInfo := testNodeInfo(addr.ID, "host_peer")
-	peerNodeInfo, err := handshake(pc.conn, timeout, ourNodeInfo)
+	peerNodeInfo, err := handshake(pc.conn, timeout, MaxNodeInfoSize(), ourNodeInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +925,7 @@ func (rp *remotePeer) Dial(addr *NetAddress) (net.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = handshake(pc.conn, time.Second, rp.nodeInfo())
+	_, err = handshake(pc.conn, time.Second, MaxNodeInfoSize(), rp.nodeInfo())
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +950,7 @@ func (rp *remotePeer) accept() {
 			golog.Fatalf("Failed to create a peer: %+v", err)
 		}
 
-		_, err = handshake(pc.conn, time.Second, rp.nodeInfo())
+		_, err = handshake(pc.conn, time.Second, MaxNodeInfoSize(), rp.nodeInfo())
 		if err != nil {
 			golog.Fatalf("Failed to perform handshake: %+v", err)
 		}
@@ -79,6 +79,11 @@ type AddrBook interface {
 
 	// Persist to disk
 	Save()
+
+	// Serialize and restore the whole book, buckets included, independently
+	// of the on-disk file.
+	ExportAddressBook() ([]byte, error)
+	ImportAddressBook(bz []byte) error
 }
 
 var _ AddrBook = (*addrBook)(nil)
@@ -105,10 +110,36 @@ type addrBook struct {
 	key               string // random prefix for bucket placement
 	routabilityStrict bool
 	hasher            hash.Hash64
+	bucketStrategy    BucketStrategy
 
 	wg sync.WaitGroup
 }
 
+// AddrBookOption sets an optional parameter on the address book.
+type AddrBookOption func(*addrBook)
+
+// WithBucketStrategy makes the address book place addresses in buckets
+// with s instead of the default strategy, which hashes the network groups of
+// addresses with a secret key.
+//
+// Addresses keep the buckets they were placed in when the address book was
+// saved, so changing the strategy of an existing address book only affects
+// addresses placed from then on.
+func WithBucketStrategy(s BucketStrategy) AddrBookOption {
+	return func(a *addrBook) { a.bucketStrategy = s }
+}
+
+// BucketStrategy decides which bucket of the address book an address goes
+// in. The address book calls it while locked, from one goroutine at a time.
+type BucketStrategy interface {
+	// NewBucket returns the index, in [0, numBuckets), of the new-address
+	// bucket for addr, which we learned about from src.
+	NewBucket(addr, src *p2p.NetAddress, numBuckets int) int
+	// OldBucket returns the index, in [0, numBuckets), of the old-address
+	// bucket for addr, once we have connected to it successfully.
+	OldBucket(addr *p2p.NetAddress, numBuckets int) int
+}
+
 func mustNewHasher() hash.Hash64 {
 	key := crypto.CRandBytes(highwayhash.Size)
 	hasher, err := highwayhash.New64(key)
@@ -120,7 +151,7 @@ func mustNewHasher() hash.Hash64 {
 
 // NewAddrBook creates a new address book.
 // Use Start to begin processing asynchronous address updates.
-func NewAddrBook(filePath string, routabilityStrict bool) AddrBook {
+func NewAddrBook(filePath string, routabilityStrict bool, options ...AddrBookOption) AddrBook {
 	am := &addrBook{
 		rand:              cmtrand.NewRand(),
 		ourAddrs:          make(map[string]struct{}),
@@ -130,6 +161,10 @@ func NewAddrBook(filePath string, routabilityStrict bool) AddrBook {
 		filePath:          filePath,
 		routabilityStrict: routabilityStrict,
 	}
+	am.bucketStrategy = hashBucketStrategy{book: am}
+	for _, option := range options {
+		option(am)
+	}
 	am.init()
 	am.BaseService = *service.NewBaseService(nil, "AddrBook", am)
 	return am
@@ -811,8 +846,33 @@ func (a *addrBook) addBadPeer(addr *p2p.NetAddress, banTime time.Duration) bool
 // ---------------------------------------------------------------------
 // calculate bucket placements
 
-// hash(key + sourcegroup + int64(hash(key + group + sourcegroup)) % bucket_per_group) % num_new_buckets.
 func (a *addrBook) calcNewBucket(addr, src *p2p.NetAddress) int {
+	bucket := a.bucketStrategy.NewBucket(addr, src, newBucketCount)
+	if bucket < 0 || bucket >= newBucketCount {
+		panic(fmt.Sprintf("bucket strategy returned new bucket %d, out of [0, %d)", bucket, newBucketCount))
+	}
+	return bucket
+}
+
+func (a *addrBook) calcOldBucket(addr *p2p.NetAddress) int {
+	bucket := a.bucketStrategy.OldBucket(addr, oldBucketCount)
+	if bucket < 0 || bucket >= oldBucketCount {
+		panic(fmt.Sprintf("bucket strategy returned old bucket %d, out of [0, %d)", bucket, oldBucketCount))
+	}
+	return bucket
+}
+
+// hashBucketStrategy is the default BucketStrategy. Keying the hashes with
+// the address book's secret key makes placements unpredictable to others,
+// and hashing network groups rather than addresses limits the number of
+// buckets a single group can fill.
+type hashBucketStrategy struct {
+	book *addrBook
+}
+
+// hash(key + sourcegroup + int64(hash(key + group + sourcegroup)) % bucket_per_group) % num_new_buckets.
+func (s hashBucketStrategy) NewBucket(addr, src *p2p.NetAddress, numBuckets int) int {
+	a := s.book
 	data1 := []byte{}
 	data1 = append(data1, []byte(a.key)...)
 	data1 = append(data1, []byte(a.groupKey(addr))...)
@@ -828,12 +888,13 @@ func (a *addrBook) calcNewBucket(addr, src *p2p.NetAddress) int {
 	data2 = append(data2, hashbuf[:]...)
 
 	hash2 := a.hash(data2)
-	result := int(binary.BigEndian.Uint64(hash2) % newBucketCount)
+	result := int(binary.BigEndian.Uint64(hash2) % uint64(numBuckets))
 	return result
 }
 
 // hash(key + group + int64(hash(key + addr)) % buckets_per_group) % num_old_buckets.
-func (a *addrBook) calcOldBucket(addr *p2p.NetAddress) int {
+func (s hashBucketStrategy) OldBucket(addr *p2p.NetAddress, numBuckets int) int {
+	a := s.book
 	data1 := []byte{}
 	data1 = append(data1, []byte(a.key)...)
 	data1 = append(data1, []byte(addr.String())...)
@@ -850,7 +911,7 @@ func (a *addrBook) calcOldBucket(addr *p2p.NetAddress) int {
 
 	hash2 := a.hash(data2)
 
-	result := int(binary.BigEndian.Uint64(hash2) % oldBucketCount)
+	result := int(binary.BigEndian.Uint64(hash2) % uint64(numBuckets))
 	return result
 }
 
@@ -2,6 +2,7 @@ package pex
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net"
@@ -92,6 +93,136 @@ func TestAddrBookSaveLoad(t *testing.T) {
 	assert.Equal(t, 100, book.Size())
 }
 
+func TestAddrBookExportImport(t *testing.T) {
+	book, fname := createAddrBookWithMOldAndNNewAddrs(t, 10, 20)
+	defer deleteTempFile(fname)
+
+	bz, err := book.ExportAddressBook()
+	require.NoError(t, err)
+
+	// exporting is deterministic
+	bz2, err := book.ExportAddressBook()
+	require.NoError(t, err)
+	assert.Equal(t, bz, bz2)
+
+	fname2 := createTempFileName()
+	defer deleteTempFile(fname2)
+	book2 := NewAddrBook(fname2, true).(*addrBook)
+	book2.SetLogger(log.TestingLogger())
+	err = book2.AddAddress(randIPv4Address(t), randIPv4Address(t))
+	require.NoError(t, err)
+
+	require.NoError(t, book2.ImportAddressBook(bz))
+	assert.Equal(t, 30, book2.Size())
+	assert.Equal(t, book.nOld, book2.nOld)
+	assert.Equal(t, book.nNew, book2.nNew)
+	assert.Equal(t, book.key, book2.key)
+	for i := range book.bucketsOld {
+		assert.Len(t, book2.bucketsOld[i], len(book.bucketsOld[i]))
+	}
+	for i := range book.bucketsNew {
+		assert.Len(t, book2.bucketsNew[i], len(book.bucketsNew[i]))
+	}
+
+	// exporting the imported book yields the same snapshot
+	bz3, err := book2.ExportAddressBook()
+	require.NoError(t, err)
+	assert.Equal(t, bz, bz3)
+}
+
+func TestAddrBookImportInvalid(t *testing.T) {
+	book, fname := createAddrBookWithMOldAndNNewAddrs(t, 1, 1)
+	defer deleteTempFile(fname)
+
+	err := book.ImportAddressBook([]byte(`{"version":2,"key":"","addrs":[]}`))
+	require.ErrorAs(t, err, &ErrAddrBookExportVersion{})
+
+	addr := randIPv4Address(t)
+	bz, err := json.Marshal(&addrBookExport{
+		Version: addrBookExportVersion,
+		Key:     "0123456789abcdef01234567",
+		Addrs: []*knownAddress{{
+			Addr:       addr,
+			Src:        addr,
+			BucketType: bucketTypeOld,
+			Buckets:    []int{oldBucketCount},
+		}},
+	})
+	require.NoError(t, err)
+	err = book.ImportAddressBook(bz)
+	require.ErrorAs(t, err, &ErrAddrBookInvalidBucket{})
+
+	importAddrs := func(book *addrBook, addrs ...*knownAddress) error {
+		bz, err := json.Marshal(&addrBookExport{Version: addrBookExportVersion, Key: "0123456789abcdef01234567", Addrs: addrs})
+		require.NoError(t, err)
+		return book.ImportAddressBook(bz)
+	}
+	newAddr := func(addr *p2p.NetAddress, buckets ...int) *knownAddress {
+		return &knownAddress{Addr: addr, Src: addr, BucketType: bucketTypeNew, Buckets: buckets}
+	}
+
+	// no key
+	bz, err = json.Marshal(&addrBookExport{Version: addrBookExportVersion, Addrs: []*knownAddress{newAddr(addr, 1)}})
+	require.NoError(t, err)
+	err = book.ImportAddressBook(bz)
+	require.ErrorAs(t, err, &ErrAddrBookEmptyKey{})
+
+	// an ID listed twice
+	err = importAddrs(book, newAddr(addr, 1), newAddr(addr, 2))
+	require.ErrorAs(t, err, &ErrAddrBookDuplicateAddr{})
+
+	// a bucket listed twice
+	err = importAddrs(book, newAddr(addr, 1, 1))
+	require.ErrorAs(t, err, &ErrAddrBookInvalidBucket{})
+
+	// the book is left untouched
+	assert.Equal(t, 2, book.Size())
+
+	// old addresses must be where a custom strategy places them
+	fname2 := createTempFileName()
+	defer deleteTempFile(fname2)
+	fixed := NewAddrBook(fname2, true, WithBucketStrategy(fixedBucketStrategy{oldBucket: 5})).(*addrBook)
+	fixed.SetLogger(log.TestingLogger())
+	oldAddr := &knownAddress{Addr: addr, Src: addr, BucketType: bucketTypeOld, Buckets: []int{4}}
+	err = importAddrs(fixed, oldAddr)
+	require.ErrorAs(t, err, &ErrAddrBookInvalidBucket{})
+	oldAddr.Buckets = []int{5}
+	require.NoError(t, importAddrs(fixed, oldAddr))
+	assert.Equal(t, 1, fixed.Size())
+}
+
+func TestAddrBookImportSkipsRefusedAddrs(t *testing.T) {
+	fname := createTempFileName()
+	defer deleteTempFile(fname)
+	book := NewAddrBook(fname, true).(*addrBook)
+	book.SetLogger(log.TestingLogger())
+	pairs := randNetAddressPairs(t, 5)
+	for _, pair := range pairs {
+		require.NoError(t, book.AddAddress(pair.addr, pair.src))
+	}
+	bz, err := book.ExportAddressBook()
+	require.NoError(t, err)
+
+	fname2 := createTempFileName()
+	defer deleteTempFile(fname2)
+	book2 := NewAddrBook(fname2, true).(*addrBook)
+	book2.SetLogger(log.TestingLogger())
+	book2.AddOurAddress(pairs[0].addr)
+	book2.AddPrivateIDs([]string{string(pairs[1].addr.ID), string(pairs[2].src.ID)})
+	require.NoError(t, book2.AddAddress(pairs[3].addr, pairs[3].src))
+	book2.MarkBad(pairs[3].addr, time.Hour)
+
+	// the addresses AddAddress refuses are skipped, the others imported
+	require.NoError(t, book2.ImportAddressBook(bz))
+	assert.Equal(t, 1, book2.Size())
+	assert.True(t, book2.HasAddress(pairs[4].addr))
+	for _, pair := range pairs[:4] {
+		assert.False(t, book2.HasAddress(pair.addr))
+	}
+	assert.True(t, book2.IsBanned(pairs[3].addr))
+	assert.True(t, book2.OurAddress(pairs[0].addr))
+}
+
 func TestAddrBookLookup(t *testing.T) {
 	fname := createTempFileName()
 	defer deleteTempFile(fname)
@@ -713,6 +844,43 @@ func TestAddrBookGroupKey(t *testing.T) {
 	}
 }
 
+type fixedBucketStrategy struct {
+	newBucket, oldBucket int
+}
+
+func (s fixedBucketStrategy) NewBucket(_, _ *p2p.NetAddress, _ int) int { return s.newBucket }
+func (s fixedBucketStrategy) OldBucket(_ *p2p.NetAddress, _ int) int    { return s.oldBucket }
+
+func TestAddrBookBucketStrategy(t *testing.T) {
+	fname := createTempFileName()
+	defer deleteTempFile(fname)
+
+	book := NewAddrBook(fname, true, WithBucketStrategy(fixedBucketStrategy{newBucket: 3, oldBucket: 5})).(*addrBook)
+	book.SetLogger(log.TestingLogger())
+
+	randAddrs := randNetAddressPairs(t, 10)
+	for _, addrSrc := range randAddrs {
+		err := book.AddAddress(addrSrc.addr, addrSrc.src)
+		require.NoError(t, err)
+	}
+	assert.Len(t, book.bucketsNew[3], len(randAddrs))
+	for _, addrSrc := range randAddrs {
+		assert.Equal(t, []int{3}, book.addrLookup[addrSrc.addr.ID].Buckets)
+	}
+
+	good := randAddrs[0].addr
+	book.MarkGood(good.ID)
+	assert.Equal(t, []int{5}, book.addrLookup[good.ID].Buckets)
+	assert.Len(t, book.bucketsOld[5], 1)
+	assert.Len(t, book.bucketsNew[3], len(randAddrs)-1)
+
+	outOfRange := NewAddrBook(fname, true, WithBucketStrategy(fixedBucketStrategy{newBucket: newBucketCount}))
+	outOfRange.SetLogger(log.TestingLogger())
+	assert.Panics(t, func() {
+		_ = outOfRange.AddAddress(randAddrs[1].addr, randAddrs[1].src)
+	})
+}
+
 func assertMOldAndNNewAddrsInSelection(t *testing.T, m, n int, addrs []*p2p.NetAddress, book *addrBook) {
 	t.Helper()
 	nOld, nNew := countOldAndNewAddrsInSelection(addrs, book)
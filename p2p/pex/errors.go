@@ -83,6 +83,47 @@ func (err ErrAddrBookInvalidAddr) Error() string {
 	return fmt.Sprintf("Cannot add invalid address %v: %v", err.Addr, err.AddrErr)
 }
 
+// ErrAddrBookExportVersion is returned when importing an address book
+// exported in an unsupported format version.
+type ErrAddrBookExportVersion struct {
+	Got      int
+	Expected int
+}
+
+func (err ErrAddrBookExportVersion) Error() string {
+	return fmt.Sprintf("unsupported address book export version %d, expected %d", err.Got, err.Expected)
+}
+
+// ErrAddrBookEmptyKey is returned when importing an address book without the
+// key its bucket placements were computed with.
+type ErrAddrBookEmptyKey struct{}
+
+func (ErrAddrBookEmptyKey) Error() string {
+	return "address book export has no key"
+}
+
+// ErrAddrBookInvalidBucket is returned when importing an address whose
+// bucket type or placement is inconsistent.
+type ErrAddrBookInvalidBucket struct {
+	Addr       *p2p.NetAddress
+	BucketType byte
+	BucketIdx  int
+}
+
+func (err ErrAddrBookInvalidBucket) Error() string {
+	return fmt.Sprintf("Invalid bucket (type %d, index %d) for address %v", err.BucketType, err.BucketIdx, err.Addr)
+}
+
+// ErrAddrBookDuplicateAddr is returned when importing an address book that
+// lists the same ID more than once.
+type ErrAddrBookDuplicateAddr struct {
+	Addr *p2p.NetAddress
+}
+
+func (err ErrAddrBookDuplicateAddr) Error() string {
+	return fmt.Sprintf("Duplicate address %v", err.Addr)
+}
+
 // ErrAddressBanned is thrown when the address has been banned and therefore cannot be used.
 type ErrAddressBanned struct {
 	Addr *p2p.NetAddress
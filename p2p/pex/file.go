@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/cometbft/cometbft/internal/tempfile"
+	"github.com/cometbft/cometbft/p2p"
 )
 
 /* Loading & Saving */
@@ -66,8 +69,13 @@ func (a *addrBook) loadFromFile(filePath string) bool {
 	// Restore all the fields...
 	// Restore the key
 	a.key = aJSON.Key
-	// Restore .bucketsNew & .bucketsOld
-	for _, ka := range aJSON.Addrs {
+	a.loadAddrs(aJSON.Addrs)
+	return true
+}
+
+// loadAddrs restores .bucketsNew, .bucketsOld and .addrLookup from addrs.
+func (a *addrBook) loadAddrs(addrs []*knownAddress) {
+	for _, ka := range addrs {
 		for _, bucketIndex := range ka.Buckets {
 			bucket := a.getBucket(ka.BucketType, bucketIndex)
 			bucket[ka.Addr.String()] = ka
@@ -79,5 +87,150 @@ func (a *addrBook) loadFromFile(filePath string) bool {
 			a.nOld++
 		}
 	}
-	return true
+}
+
+/* Exporting & Importing */
+
+// addrBookExportVersion is bumped whenever the export format changes in a way
+// older nodes can't read.
+const addrBookExportVersion = 1
+
+type addrBookExport struct {
+	Version int             `json:"version"`
+	Key     string          `json:"key"`
+	Addrs   []*knownAddress `json:"addrs"`
+}
+
+// ExportAddressBook serializes the address book, including the bucket
+// placement of every address and the key used to compute it. The output is
+// deterministic: addresses are sorted by ID.
+func (a *addrBook) ExportAddressBook() ([]byte, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	addrs := make([]*knownAddress, 0, len(a.addrLookup))
+	for _, ka := range a.addrLookup {
+		kaCopy := *ka
+		kaCopy.Buckets = slices.Clone(ka.Buckets)
+		slices.Sort(kaCopy.Buckets)
+		addrs = append(addrs, &kaCopy)
+	}
+	slices.SortFunc(addrs, func(x, y *knownAddress) int {
+		return strings.Compare(string(x.ID()), string(y.ID()))
+	})
+
+	return json.Marshal(&addrBookExport{
+		Version: addrBookExportVersion,
+		Key:     a.key,
+		Addrs:   addrs,
+	})
+}
+
+// ImportAddressBook replaces the contents of the address book with a snapshot
+// produced by ExportAddressBook. Our own addresses, private IDs and banned
+// peers are kept, and the addresses of the snapshot AddAddress would refuse
+// for being one of them, or having a private source, are skipped. Nothing is
+// changed if the snapshot is invalid, e.g. if it has no key, lists an ID
+// twice or, with a custom BucketStrategy, has an old address in another
+// bucket than the strategy places it in. New addresses may have been placed
+// for sources that are not in the snapshot, so only the range of their
+// buckets is checked.
+func (a *addrBook) ImportAddressBook(bz []byte) error {
+	var export addrBookExport
+	if err := json.Unmarshal(bz, &export); err != nil {
+		return err
+	}
+	if export.Version != addrBookExportVersion {
+		return ErrAddrBookExportVersion{Got: export.Version, Expected: addrBookExportVersion}
+	}
+	if export.Key == "" {
+		return ErrAddrBookEmptyKey{}
+	}
+	ids := make(map[p2p.ID]struct{}, len(export.Addrs))
+	for _, ka := range export.Addrs {
+		if err := validateImportedAddr(ka); err != nil {
+			return err
+		}
+		if _, ok := ids[ka.ID()]; ok {
+			return ErrAddrBookDuplicateAddr{Addr: ka.Addr}
+		}
+		ids[ka.ID()] = struct{}{}
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	// The default strategy hashes with a key drawn by every process, so its
+	// placements can't be recomputed.
+	if _, ok := a.bucketStrategy.(hashBucketStrategy); !ok {
+		for _, ka := range export.Addrs {
+			if ka.isOld() && ka.Buckets[0] != a.calcOldBucket(ka.Addr) {
+				return ErrAddrBookInvalidBucket{Addr: ka.Addr, BucketType: ka.BucketType, BucketIdx: ka.Buckets[0]}
+			}
+		}
+	}
+
+	addrs := make([]*knownAddress, 0, len(export.Addrs))
+	for _, ka := range export.Addrs {
+		if a.refusesImportedAddr(ka) {
+			continue
+		}
+		addrs = append(addrs, ka)
+	}
+	if skipped := len(export.Addrs) - len(addrs); skipped > 0 {
+		a.Logger.Info("Skipped addresses of imported address book", "skipped", skipped)
+	}
+
+	a.init()
+	a.key = export.Key
+	a.addrLookup = make(map[p2p.ID]*knownAddress, len(addrs))
+	a.nNew, a.nOld = 0, 0
+	a.loadAddrs(addrs)
+	return nil
+}
+
+// refusesImportedAddr returns whether ka is one of our addresses, a private
+// or banned peer, or was received from a private peer, which AddAddress
+// refuses.
+func (a *addrBook) refusesImportedAddr(ka *knownAddress) bool {
+	if _, ok := a.badPeers[ka.ID()]; ok {
+		return true
+	}
+	if _, ok := a.privateIDs[ka.ID()]; ok {
+		return true
+	}
+	if ka.Src != nil {
+		if _, ok := a.privateIDs[ka.Src.ID]; ok {
+			return true
+		}
+	}
+	_, ok := a.ourAddrs[ka.Addr.String()]
+	return ok
+}
+
+func validateImportedAddr(ka *knownAddress) error {
+	if ka == nil || ka.Addr == nil {
+		return ErrAddrBookNilAddr{}
+	}
+	if err := ka.Addr.Valid(); err != nil {
+		return ErrAddrBookInvalidAddr{Addr: ka.Addr, AddrErr: err}
+	}
+	var bucketCount, maxBuckets int
+	switch ka.BucketType {
+	case bucketTypeNew:
+		bucketCount, maxBuckets = newBucketCount, maxNewBucketsPerAddress
+	case bucketTypeOld:
+		bucketCount, maxBuckets = oldBucketCount, 1
+	default:
+		return ErrAddrBookInvalidBucket{Addr: ka.Addr, BucketType: ka.BucketType}
+	}
+	if len(ka.Buckets) == 0 || len(ka.Buckets) > maxBuckets {
+		return ErrAddrBookInvalidBucket{Addr: ka.Addr, BucketType: ka.BucketType}
+	}
+	for i, idx := range ka.Buckets {
+		if idx < 0 || idx >= bucketCount || slices.Contains(ka.Buckets[:i], idx) {
+			return ErrAddrBookInvalidBucket{Addr: ka.Addr, BucketType: ka.BucketType, BucketIdx: idx}
+		}
+	}
+	return nil
 }
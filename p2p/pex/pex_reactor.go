@@ -80,6 +80,8 @@ type Reactor struct {
 
 	// seed/crawled mode fields
 	crawlPeerInfos map[p2p.ID]crawlPeerInfo
+
+	metrics *p2p.Metrics
 }
 
 func (r *Reactor) minReceiveRequestInterval() time.Duration {
@@ -104,6 +106,21 @@ type ReactorConfig struct {
 	// Seeds is a list of addresses reactor may use
 	// if it can't connect to peers in the addrbook.
 	Seeds []string
+
+	// RequestThrottleInterval is the minimum time between two PEX requests
+	// from the same peer that we are willing to answer. Requests arriving
+	// sooner are dropped instead of serviced, and the peer stays connected.
+	// The first request after a peer connects is always answered. If zero,
+	// peers requesting too often are disconnected instead.
+	RequestThrottleInterval time.Duration
+}
+
+// ReactorOption sets an optional parameter on the Reactor.
+type ReactorOption func(*Reactor)
+
+// WithMetrics sets the metrics used to report throttled PEX requests.
+func WithMetrics(metrics *p2p.Metrics) ReactorOption {
+	return func(r *Reactor) { r.metrics = metrics }
 }
 
 type _attemptsToDial struct {
@@ -112,7 +129,7 @@ type _attemptsToDial struct {
 }
 
 // NewReactor creates new PEX reactor.
-func NewReactor(b AddrBook, config *ReactorConfig) *Reactor {
+func NewReactor(b AddrBook, config *ReactorConfig, options ...ReactorOption) *Reactor {
 	r := &Reactor{
 		book:                 b,
 		config:               config,
@@ -121,6 +138,10 @@ func NewReactor(b AddrBook, config *ReactorConfig) *Reactor {
 		requestsSent:         cmap.NewCMap(),
 		lastReceivedRequests: cmap.NewCMap(),
 		crawlPeerInfos:       make(map[p2p.ID]crawlPeerInfo),
+		metrics:              p2p.NopMetrics(),
+	}
+	for _, option := range options {
+		option(r)
 	}
 	r.BaseReactor = *p2p.NewBaseReactor("PEX", r)
 	return r
@@ -256,6 +277,13 @@ func (r *Reactor) Receive(e p2p.Envelope) {
 				e.Src.FlushStop()
 				r.Switch.StopPeerGracefully(e.Src)
 			}()
+		} else if r.config.RequestThrottleInterval > 0 {
+			if r.throttleRequest(e.Src) {
+				r.Logger.Debug("Dropping PEX request received too soon", "peer", e.Src)
+				r.metrics.PexRequestsThrottled.With("peer_id", string(e.Src.ID())).Add(1)
+				return
+			}
+			r.SendAddrs(e.Src, r.book.GetSelection())
 		} else {
 			// Check we're not receiving requests too frequently.
 			if err := r.receiveRequest(e.Src); err != nil {
@@ -321,6 +349,22 @@ func (r *Reactor) receiveRequest(src Peer) error {
 	return nil
 }
 
+// throttleRequest reports whether a request from src arrived less than
+// RequestThrottleInterval after the last one we answered. Only answered
+// requests reset the interval, so a peer that keeps flooding us does not
+// starve itself forever.
+func (r *Reactor) throttleRequest(src Peer) bool {
+	id := string(src.ID())
+	now := time.Now()
+	if v := r.lastReceivedRequests.Get(id); v != nil {
+		if now.Sub(v.(time.Time)) < r.config.RequestThrottleInterval {
+			return true
+		}
+	}
+	r.lastReceivedRequests.Set(id, now)
+	return false
+}
+
 // RequestAddrs asks peer for more addresses if we do not already have a
 // request out for this peer.
 func (r *Reactor) RequestAddrs(p Peer) {
@@ -9,12 +9,15 @@ import (
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
 	"github.com/cometbft/cometbft/config"
 	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/libs/metrics/prometheus"
 	"github.com/cometbft/cometbft/p2p"
 	"github.com/cometbft/cometbft/p2p/mock"
 	"github.com/cometbft/cometbft/types"
@@ -171,6 +174,47 @@ func TestPEXReactorRequestMessageAbuse(t *testing.T) {
 	assert.True(t, book.IsBanned(peerAddr))
 }
 
+func TestPEXReactorRequestThrottling(t *testing.T) {
+	book := NewAddrBook(filepath.Join(t.TempDir(), "addrbook.json"), true)
+	book.SetLogger(log.TestingLogger())
+	counter := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "pex_throttled"}, []string{"peer_id"})
+	metrics := p2p.NopMetrics()
+	metrics.PexRequestsThrottled = prometheus.NewCounter(counter)
+	r := NewReactor(book, &ReactorConfig{RequestThrottleInterval: time.Hour}, WithMetrics(metrics))
+	r.SetLogger(log.TestingLogger())
+
+	sw := createSwitchAndAddReactors(r)
+	sw.SetAddrBook(book)
+
+	peer := mock.NewPeer(nil)
+	p2p.AddPeerToSwitchPeerSet(sw, peer)
+	id := string(peer.ID())
+	throttled := func() float64 {
+		var m dto.Metric
+		require.NoError(t, counter.WithLabelValues(id).Write(&m))
+		return m.GetCounter().GetValue()
+	}
+
+	// the first request is always served
+	r.Receive(p2p.Envelope{ChannelID: PexChannel, Src: peer, Message: &tmp2p.PexRequest{}})
+	require.True(t, r.lastReceivedRequests.Has(id))
+	served := r.lastReceivedRequests.Get(id)
+	assert.Zero(t, throttled())
+
+	// the following ones are dropped, but the peer stays connected
+	r.Receive(p2p.Envelope{ChannelID: PexChannel, Src: peer, Message: &tmp2p.PexRequest{}})
+	r.Receive(p2p.Envelope{ChannelID: PexChannel, Src: peer, Message: &tmp2p.PexRequest{}})
+	assert.Equal(t, 2.0, throttled())
+	assert.Equal(t, served, r.lastReceivedRequests.Get(id))
+	assert.True(t, sw.Peers().Has(peer.ID()))
+
+	// reconnecting gets a fresh first request
+	r.RemovePeer(peer, nil)
+	r.Receive(p2p.Envelope{ChannelID: PexChannel, Src: peer, Message: &tmp2p.PexRequest{}})
+	assert.Equal(t, 2.0, throttled())
+	assert.NotEqual(t, served, r.lastReceivedRequests.Get(id))
+}
+
 func TestPEXReactorAddrsMessageAbuse(t *testing.T) {
 	r, book := createReactor(&ReactorConfig{})
 	defer teardownReactor(book)
@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"sync"
+
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
+)
+
+// recvBudget bounds the bytes of received messages being processed at once
+// across all peers, see config.P2PConfig.MaxRecvBufferedBytes. A peer acquires
+// the size of a message before decoding it and releases it once the reactor's
+// Receive returns. While the budget is exhausted, acquire blocks the peer's
+// receive routine, so its connection stops being read.
+type recvBudget struct {
+	maxBytes int64
+	metrics  *Metrics
+
+	mtx   cmtsync.Mutex
+	cond  *sync.Cond
+	bytes int64
+}
+
+func newRecvBudget(maxBytes int64, metrics *Metrics) *recvBudget {
+	b := &recvBudget{maxBytes: maxBytes, metrics: metrics}
+	b.cond = sync.NewCond(&b.mtx)
+	return b
+}
+
+// acquire waits until n bytes fit in the budget and takes them. A message
+// larger than the whole budget takes all of it, once nothing else does. It
+// returns the number of bytes to release.
+func (b *recvBudget) acquire(n int64) int64 {
+	n = min(n, b.maxBytes)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.bytes+n > b.maxBytes {
+		b.metrics.RecvBudgetWaits.Add(1)
+		for b.bytes+n > b.maxBytes {
+			b.cond.Wait()
+		}
+	}
+	b.bytes += n
+	b.metrics.RecvBudgetBytes.Set(float64(b.bytes))
+	return n
+}
+
+func (b *recvBudget) release(n int64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.bytes -= n
+	b.metrics.RecvBudgetBytes.Set(float64(b.bytes))
+	b.cond.Broadcast()
+}
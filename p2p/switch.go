@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
@@ -12,6 +14,7 @@ import (
 	"github.com/cometbft/cometbft/internal/cmap"
 	"github.com/cometbft/cometbft/internal/rand"
 	"github.com/cometbft/cometbft/libs/service"
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
 	"github.com/cometbft/cometbft/p2p/conn"
 )
 
@@ -39,6 +42,7 @@ func MConnConfig(cfg *config.P2PConfig) conn.MConnConfig {
 	mConfig.SendRate = cfg.SendRate
 	mConfig.RecvRate = cfg.RecvRate
 	mConfig.MaxPacketMsgPayloadSize = cfg.MaxPacketMsgPayloadSize
+	mConfig.MaxQueuedBytes = cfg.MaxPeerQueuedBytes
 	mConfig.TestFuzz = cfg.TestFuzz
 	mConfig.TestFuzzConfig = cfg.TestFuzzConfig
 	return mConfig
@@ -72,7 +76,12 @@ type PeerFilterFunc func(IPeerSet, Peer) error
 type Switch struct {
 	service.BaseService
 
-	config        *config.P2PConfig
+	config *config.P2PConfig
+
+	// Once the switch is running, reactors, chDescs, reactorsByCh and
+	// msgTypeByChID are replaced rather than modified by AddReactor, while
+	// holding reactorsMtx.
+	reactorsMtx   cmtsync.RWMutex
 	reactors      map[string]Reactor
 	chDescs       []*conn.ChannelDescriptor
 	reactorsByCh  map[byte]Reactor
@@ -80,8 +89,9 @@ type Switch struct {
 	peers         *PeerSet
 	dialing       *cmap.CMap
 	reconnecting  *cmap.CMap
-	nodeInfo      NodeInfo // our node info
-	nodeKey       *NodeKey // our node privkey
+	stoppedPeers  *cmap.CMap // see PeerResourceStats
+	nodeInfo      NodeInfo   // our node info
+	nodeKey       *NodeKey   // our node privkey
 	addrBook      AddrBook
 	// peers addresses with whom we'll maintain constant connection
 	persistentPeersAddrs []*NetAddress
@@ -92,6 +102,15 @@ type Switch struct {
 	filterTimeout time.Duration
 	peerFilters   []PeerFilterFunc
 
+	logUnknownChannel bool               // see SwitchLogUnknownChannel
+	logReceive        *receiveLogFilter  // see SwitchLogReceive
+	capture           *captureConfig     // see SwitchCaptureReceive
+	peerPriorities    map[ID]int         // see SwitchPeerPriority
+	peerFlushPolicies map[ID]FlushPolicy // see SwitchPeerFlushPolicy
+	observerPeers     map[ID]struct{}    // see SwitchObserverPeers
+	evictionScore     PeerScoreFunc      // see SwitchPeerEviction
+	recvBudget        *recvBudget        // nil unless MaxRecvBufferedBytes is set
+
 	rng *rand.Rand // seed for randomizing dial times and orders
 
 	metrics *Metrics
@@ -121,6 +140,7 @@ func NewSwitch(
 		peers:                NewPeerSet(),
 		dialing:              cmap.NewCMap(),
 		reconnecting:         cmap.NewCMap(),
+		stoppedPeers:         cmap.NewCMap(),
 		metrics:              NopMetrics(),
 		transport:            transport,
 		filterTimeout:        defaultFilterTimeout,
@@ -137,6 +157,10 @@ func NewSwitch(
 		option(sw)
 	}
 
+	if cfg.MaxRecvBufferedBytes > 0 {
+		sw.recvBudget = newRecvBudget(cfg.MaxRecvBufferedBytes, sw.metrics)
+	}
+
 	return sw
 }
 
@@ -150,6 +174,81 @@ func SwitchPeerFilters(filters ...PeerFilterFunc) SwitchOption {
 	return func(sw *Switch) { sw.peerFilters = filters }
 }
 
+// SwitchLogUnknownChannel enables the debug log on sends to peers that do not
+// support the channel, see PeerLogUnknownChannel.
+func SwitchLogUnknownChannel(enabled bool) SwitchOption {
+	return func(sw *Switch) { sw.logUnknownChannel = enabled }
+}
+
+// SwitchLogReceive enables the debug log of messages received from all peers
+// on the given channels, or on all channels if none are given, see
+// PeerLogReceive.
+func SwitchLogReceive(chIDs ...byte) SwitchOption {
+	return func(sw *Switch) { sw.logReceive = newReceiveLogFilter(chIDs) }
+}
+
+// SwitchCaptureReceive records the last size messages received from every
+// peer on the given channels, see PeerCaptureReceive.
+func SwitchCaptureReceive(size int, chIDs ...byte) SwitchOption {
+	return func(sw *Switch) { sw.capture = &captureConfig{size: size, chIDs: chIDs} }
+}
+
+// SwitchPeerPriority gives the peer with the given ID a send priority relative
+// to other peers once it connects, see PeerPriority. Can be passed several
+// times for different peers.
+func SwitchPeerPriority(id ID, level int) SwitchOption {
+	return func(sw *Switch) {
+		if sw.peerPriorities == nil {
+			sw.peerPriorities = make(map[ID]int)
+		}
+		sw.peerPriorities[id] = level
+	}
+}
+
+// SwitchPeerFlushPolicy sets the flush policy of the peer with the given ID
+// once it connects, see PeerFlushPolicy, e.g. to flush immediately to a
+// validator behind a sentry. Can be passed several times for different peers.
+func SwitchPeerFlushPolicy(id ID, policy FlushPolicy) SwitchOption {
+	return func(sw *Switch) {
+		if sw.peerFlushPolicies == nil {
+			sw.peerFlushPolicies = make(map[ID]FlushPolicy)
+		}
+		sw.peerFlushPolicies[id] = policy
+	}
+}
+
+// SwitchObserverPeers makes the peers with the given IDs observers whenever
+// they connect, see PeerObserver. As this is keyed by ID, a reconnected peer
+// stays an observer. Broadcasts skip observers. Can be passed several times.
+func SwitchObserverPeers(ids ...ID) SwitchOption {
+	return func(sw *Switch) {
+		if sw.observerPeers == nil {
+			sw.observerPeers = make(map[ID]struct{}, len(ids))
+		}
+		for _, id := range ids {
+			sw.observerPeers[id] = struct{}{}
+		}
+	}
+}
+
+// PeerScoreFunc returns how valuable a peer is, higher is better. It may be
+// called for a peer that is not started yet.
+type PeerScoreFunc func(Peer) int
+
+// SwitchPeerEviction makes the switch, once it has MaxNumInboundPeers inbound
+// peers, accept a new inbound peer that scores higher than the lowest scoring
+// one by stopping the latter. Persistent and unconditional peers are never
+// evicted. If score is nil, peers are scored by their PeerPriority. Default:
+// new inbound peers are rejected when full.
+func SwitchPeerEviction(score PeerScoreFunc) SwitchOption {
+	return func(sw *Switch) {
+		if score == nil {
+			score = peerPriority
+		}
+		sw.evictionScore = score
+	}
+}
+
 // WithMetrics sets the metrics.
 func WithMetrics(metrics *Metrics) SwitchOption {
 	return func(sw *Switch) { sw.metrics = metrics }
@@ -159,8 +258,16 @@ func WithMetrics(metrics *Metrics) SwitchOption {
 // Switch setup
 
 // AddReactor adds the given reactor to the switch.
-// NOTE: Not goroutine safe.
+//
+// If the switch is already running, the reactor is started, its channels are
+// added to the connected peers and advertised to the ones connecting from now
+// on, and it's given every connected peer through InitPeer and AddPeer.
+// NOTE: Not goroutine safe before the switch is started.
 func (sw *Switch) AddReactor(name string, reactor Reactor) Reactor {
+	if sw.IsRunning() {
+		return sw.addReactorLive(name, reactor)
+	}
+
 	for _, chDesc := range reactor.GetChannels() {
 		chID := chDesc.ID
 		// No two reactors can share the same channel.
@@ -176,6 +283,107 @@ func (sw *Switch) AddReactor(name string, reactor Reactor) Reactor {
 	return reactor
 }
 
+func (sw *Switch) addReactorLive(name string, reactor Reactor) Reactor {
+	_, reactorsByCh, _ := sw.peerReactors()
+	for _, chDesc := range reactor.GetChannels() {
+		if reactorsByCh[chDesc.ID] != nil {
+			panic(fmt.Sprintf("Channel %X has multiple reactors %v & %v", chDesc.ID, reactorsByCh[chDesc.ID], reactor))
+		}
+	}
+
+	reactor.SetSwitch(sw)
+	if err := reactor.Start(); err != nil {
+		sw.Logger.Error("Error starting reactor", "reactor", name, "err", err)
+		return reactor
+	}
+
+	// Peers added from now on get the reactor in addPeer, so only the ones
+	// connected at this point are wired here.
+	peers := sw.installReactor(name, reactor)
+
+	if t, ok := sw.transport.(interface{ AddChannel(chID byte) }); ok {
+		for _, chDesc := range reactor.GetChannels() {
+			t.AddChannel(chDesc.ID)
+		}
+	}
+
+	chDescs, reactorsByCh, msgTypeByChID := sw.peerReactors()
+	for _, p := range peers {
+		if !p.IsRunning() {
+			continue // being removed
+		}
+		// Initialize the peer before its messages can be routed to the reactor.
+		initPeer(name, reactor, p)
+		if ca, ok := p.(channelAdder); ok {
+			if err := ca.addChannels(chDescs, reactorsByCh, msgTypeByChID); err != nil {
+				sw.Logger.Error("Error adding reactor channels to peer", "reactor", name, "peer", p, "err", err)
+				continue
+			}
+		}
+		reactor.AddPeer(p)
+	}
+
+	sw.Logger.Info("Added reactor", "name", name)
+	return reactor
+}
+
+// installReactor replaces the reactor maps with copies including reactor and
+// returns the peers connected at that point.
+func (sw *Switch) installReactor(name string, reactor Reactor) []Peer {
+	sw.reactorsMtx.Lock()
+	defer sw.reactorsMtx.Unlock()
+
+	reactors := make(map[string]Reactor, len(sw.reactors)+1)
+	for n, r := range sw.reactors {
+		reactors[n] = r
+	}
+	reactorsByCh := make(map[byte]Reactor, len(sw.reactorsByCh))
+	for chID, r := range sw.reactorsByCh {
+		reactorsByCh[chID] = r
+	}
+	msgTypeByChID := make(map[byte]proto.Message, len(sw.msgTypeByChID))
+	for chID, mt := range sw.msgTypeByChID {
+		msgTypeByChID[chID] = mt
+	}
+	chDescs := make([]*conn.ChannelDescriptor, len(sw.chDescs))
+	copy(chDescs, sw.chDescs)
+
+	for _, chDesc := range reactor.GetChannels() {
+		chID := chDesc.ID
+		// No two reactors can share the same channel.
+		if reactorsByCh[chID] != nil {
+			panic(fmt.Sprintf("Channel %X has multiple reactors %v & %v", chID, reactorsByCh[chID], reactor))
+		}
+		chDescs = append(chDescs, chDesc)
+		reactorsByCh[chID] = reactor
+		msgTypeByChID[chID] = chDesc.MessageType
+	}
+	reactors[name] = reactor
+
+	sw.reactors = reactors
+	sw.chDescs = chDescs
+	sw.reactorsByCh = reactorsByCh
+	sw.msgTypeByChID = msgTypeByChID
+
+	return sw.peers.Copy()
+}
+
+// peerReactors returns what peers need to dispatch received messages to
+// reactors. None of the returned values may be modified.
+func (sw *Switch) peerReactors() ([]*conn.ChannelDescriptor, map[byte]Reactor, map[byte]proto.Message) {
+	sw.reactorsMtx.RLock()
+	defer sw.reactorsMtx.RUnlock()
+	return sw.chDescs, sw.reactorsByCh, sw.msgTypeByChID
+}
+
+// reactorList returns the reactors of the switch. The returned map may not
+// be modified.
+func (sw *Switch) reactorList() map[string]Reactor {
+	sw.reactorsMtx.RLock()
+	defer sw.reactorsMtx.RUnlock()
+	return sw.reactors
+}
+
 // RemoveReactor removes the given Reactor from the Switch.
 // NOTE: Not goroutine safe.
 func (sw *Switch) RemoveReactor(name string, reactor Reactor) {
@@ -197,13 +405,21 @@ func (sw *Switch) RemoveReactor(name string, reactor Reactor) {
 // Reactors returns a map of reactors registered on the switch.
 // NOTE: Not goroutine safe.
 func (sw *Switch) Reactors() map[string]Reactor {
-	return sw.reactors
+	return sw.reactorList()
 }
 
 // Reactor returns the reactor with the given name.
 // NOTE: Not goroutine safe.
 func (sw *Switch) Reactor(name string) Reactor {
-	return sw.reactors[name]
+	return sw.reactorList()[name]
+}
+
+// ReactorForChannel returns the reactor handling the channel with the given
+// ID, or nil if no reactor registered it.
+func (sw *Switch) ReactorForChannel(chID byte) Reactor {
+	sw.reactorsMtx.RLock()
+	defer sw.reactorsMtx.RUnlock()
+	return sw.reactorsByCh[chID]
 }
 
 // SetNodeInfo sets the switch's NodeInfo for checking compatibility and handshaking with other nodes.
@@ -230,7 +446,7 @@ func (sw *Switch) SetNodeKey(nodeKey *NodeKey) {
 // OnStart implements BaseService. It starts all the reactors and peers.
 func (sw *Switch) OnStart() error {
 	// Start reactors
-	for _, reactor := range sw.reactors {
+	for _, reactor := range sw.reactorList() {
 		err := reactor.Start()
 		if err != nil {
 			return ErrStart{reactor, err}
@@ -240,53 +456,266 @@ func (sw *Switch) OnStart() error {
 	// Start accepting Peers.
 	go sw.acceptRoutine()
 
+	go sw.bandwidthMetricsRoutine()
+
+	if sw.config.PeerInactivityTimeout > 0 {
+		go sw.inactivityRoutine()
+	}
+
 	return nil
 }
 
 // OnStop implements BaseService. It stops all peers and reactors.
 func (sw *Switch) OnStop() {
 	// Stop peers
-	for _, p := range sw.peers.Copy() {
+	peers := sw.peers.Copy()
+	for _, p := range peers {
 		sw.stopAndRemovePeer(p, nil)
 	}
+	// and wait for the messages they already read to be processed, so that
+	// reactors do not receive any once stopped.
+	for _, p := range peers {
+		if pp, ok := p.(*peer); ok {
+			pp.stopReceiving()
+		}
+	}
 
 	// Stop reactors
 	sw.Logger.Debug("Switch: Stopping reactors")
-	for _, reactor := range sw.reactors {
+	for _, reactor := range sw.reactorList() {
 		if err := reactor.Stop(); err != nil {
 			sw.Logger.Error("error while stopped reactor", "reactor", reactor, "err", err)
 		}
 	}
 }
 
+// peerBytes is the number of bytes sent to and received from a peer.
+type peerBytes struct {
+	sent, received int64
+}
+
+// bandwidthMetricsRoutine periodically reports the traffic with all peers
+// in the Node* metrics.
+func (sw *Switch) bandwidthMetricsRoutine() {
+	ticker := time.NewTicker(metricsTickerDuration)
+	defer ticker.Stop()
+
+	last := make(map[Peer]peerBytes)
+	for {
+		select {
+		case <-ticker.C:
+			last = sw.reportBandwidth(last)
+		case <-sw.Quit():
+			return
+		}
+	}
+}
+
+// reportBandwidth adds the bytes exchanged with each peer since the counts
+// in last to the node totals, sets the node rates and returns the current
+// counts. Traffic with a peer since the previous report is lost when it
+// disconnects.
+func (sw *Switch) reportBandwidth(last map[Peer]peerBytes) map[Peer]peerBytes {
+	var sent, received, sendRate, recvRate int64
+	current := make(map[Peer]peerBytes, len(last))
+	sw.peers.ForEach(func(p Peer) {
+		status := p.Status()
+		bytes := peerBytes{sent: status.SendMonitor.Bytes, received: status.RecvMonitor.Bytes}
+		prev := last[p]
+		sent += bytes.sent - prev.sent
+		received += bytes.received - prev.received
+		sendRate += status.SendMonitor.CurRate
+		recvRate += status.RecvMonitor.CurRate
+		current[p] = bytes
+	})
+	sw.metrics.NodeSendBytesTotal.Add(float64(sent))
+	sw.metrics.NodeReceiveBytesTotal.Add(float64(received))
+	sw.metrics.NodeSendRate.Set(float64(sendRate))
+	sw.metrics.NodeReceiveRate.Set(float64(recvRate))
+	return current
+}
+
+// inactivityRoutine periodically disconnects inactive peers, see
+// stopInactivePeers.
+func (sw *Switch) inactivityRoutine() {
+	ticker := time.NewTicker(sw.config.PeerInactivityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sw.stopInactivePeers(time.Now())
+		case <-sw.Quit():
+			return
+		}
+	}
+}
+
+// stopInactivePeers stops the peers that have received nothing for longer
+// than PeerInactivityTimeout as of now. Unless PeerInactivityCountPings is
+// set, pings and pongs don't count. Persistent and unconditional peers are
+// kept regardless.
+func (sw *Switch) stopInactivePeers(now time.Time) {
+	for _, peer := range sw.peers.Copy() {
+		if peer.IsPersistent() || sw.IsPeerUnconditional(peer.ID()) {
+			continue
+		}
+		status := peer.Status()
+		last := status.LastMsgReceiveTime
+		if sw.config.PeerInactivityCountPings {
+			last = status.LastReceiveTime
+		}
+		if idle := now.Sub(last); idle > sw.config.PeerInactivityTimeout {
+			sw.metrics.PeerDisconnectsTotal.With("reason", "inactive").Add(1)
+			sw.StopPeerForError(peer, ErrPeerInactive{Idle: idle})
+		}
+	}
+}
+
 // ---------------------------------------------------------------------
 // Peers
 
 // Broadcast runs a go routine for each attempted send, which will block trying
-// to send for defaultSendTimeoutSeconds.
+// to send for defaultSendTimeoutSeconds. The message is marshaled once for all
+// peers.
+//
+// The goroutines are started in order of PeerPriority, highest first, and
+// the send queues of higher priority peers are larger, see PeerPriority.
 //
 // NOTE: Broadcast uses goroutines, so order of broadcast may not be preserved.
 func (sw *Switch) Broadcast(e Envelope) {
-	sw.peers.ForEach(func(p Peer) {
-		go func(peer Peer) {
-			success := peer.Send(e)
-			_ = success
-		}(p)
-	})
+	sw.broadcast(e, nil, nil, false)
 }
 
 // TryBroadcast runs a go routine for each attempted send.
 // If the send queue of the destination channel and peer are full, the message will not be sent. To make sure that messages are indeed sent to all destination, use `Broadcast`.
 //
+// The goroutines are started in order of PeerPriority, highest first, and
+// the send queues of higher priority peers are larger, see PeerPriority.
+//
 // NOTE: TryBroadcast uses goroutines, so order of broadcast may not be preserved.
 func (sw *Switch) TryBroadcast(e Envelope) {
-	sw.peers.ForEach(func(p Peer) {
-		go func(peer Peer) {
-			peer.TrySend(e)
-		}(p)
+	sw.broadcast(e, nil, nil, true)
+}
+
+// MessageVariant is a form of a broadcast message for the peers that
+// advertise Feature in their NodeInfo, see BroadcastVariants.
+type MessageVariant struct {
+	Feature string
+	Message proto.Message
+}
+
+// BroadcastVariants is like Broadcast, but peers that support the feature of
+// one of the variants are sent that variant's message instead of e.Message.
+// The first variant a peer supports wins. Each message is marshaled once,
+// however many peers it is sent to.
+func (sw *Switch) BroadcastVariants(e Envelope, variants ...MessageVariant) {
+	sw.broadcast(e, variants, nil, false)
+}
+
+// BroadcastFiltered is like Broadcast, but only sends e to the peers for
+// which filter returns true. It waits for all sends to complete and returns
+// the number of peers the message was queued for.
+func (sw *Switch) BroadcastFiltered(e Envelope, filter func(Peer) bool) int {
+	results := sw.broadcast(e, nil, filter, false)
+	delivered := 0
+	for i := 0; i < cap(results); i++ {
+		if <-results {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// broadcast sends the message to every peer accepted by filter, or all peers
+// if it's nil, and returns a channel with room for the outcome of each send.
+// Nothing is sent, and the channel is nil, if the message is invalid.
+func (sw *Switch) broadcast(e Envelope, variants []MessageVariant, filter func(Peer) bool, try bool) chan bool {
+	// The last message is the default one, for peers that support none of
+	// the variants.
+	msgs := make([]proto.Message, 0, len(variants)+1)
+	for _, v := range variants {
+		msgs = append(msgs, v.Message)
+	}
+	msgs = append(msgs, e.Message)
+	marshaled := make([]marshaledMsg, len(msgs))
+	for i, msg := range msgs {
+		env := Envelope{ChannelID: e.ChannelID, Message: msg}
+		if err := env.Validate(); err != nil {
+			sw.Logger.Error("Not broadcasting invalid envelope", "err", err)
+			return nil
+		}
+		mm, err := marshalMsg(msg)
+		if err != nil {
+			sw.Logger.Error("Not broadcasting message", "err", err)
+			return nil
+		}
+		marshaled[i] = mm
+	}
+
+	peers := sw.peersByPriority()
+	matching := peers[:0]
+	for _, p := range peers {
+		if !isObserver(p) && (filter == nil || filter(p)) {
+			matching = append(matching, p)
+		}
+	}
+	peers = matching
+	results := make(chan bool, len(peers))
+	for _, p := range peers {
+		i := len(variants)
+		for j, v := range variants {
+			if p.SupportsFeature(v.Feature) {
+				i = j
+				break
+			}
+		}
+		go func(p Peer, msg proto.Message, mm marshaledMsg) {
+			pp, ok := p.(*peer)
+			switch {
+			case !ok && try:
+				results <- p.TrySend(Envelope{ChannelID: e.ChannelID, Message: msg})
+			case !ok:
+				results <- p.Send(Envelope{ChannelID: e.ChannelID, Message: msg})
+			case try:
+				results <- pp.sendMarshaled(e.ChannelID, mm, pp.mconn.TrySendKeyedE) == nil
+			default:
+				results <- pp.sendMarshaled(e.ChannelID, mm, pp.mconn.SendKeyedE) == nil
+			}
+		}(p, msgs[i], marshaled[i])
+	}
+	return results
+}
+
+// peersByPriority returns the connected peers sorted by PeerPriority, highest
+// first. Peers with the same priority keep the order of the peer set.
+func (sw *Switch) peersByPriority() []Peer {
+	peers := sw.peers.Copy()
+	sortPeersByPriority(peers)
+	return peers
+}
+
+func sortPeersByPriority(peers []Peer) {
+	sort.SliceStable(peers, func(i, j int) bool {
+		return peerPriority(peers[i]) > peerPriority(peers[j])
 	})
 }
 
+// peerPriority returns the level set with PeerPriority, or 0 for peers that
+// do not support priorities.
+func peerPriority(p Peer) int {
+	if pp, ok := p.(interface{ Priority() int }); ok {
+		return pp.Priority()
+	}
+	return 0
+}
+
+// isObserver returns whether p was created with PeerObserver.
+func isObserver(p Peer) bool {
+	op, ok := p.(interface{ IsObserver() bool })
+	return ok && op.IsObserver()
+}
+
 // NumPeers returns the count of outbound/inbound and outbound-dialing peers.
 // unconditional peers are not counted here.
 func (sw *Switch) NumPeers() (outbound, inbound, dialing int) {
@@ -316,6 +745,45 @@ func (sw *Switch) Peers() IPeerSet {
 	return sw.peers
 }
 
+// PeerResources are the resources held by a peer, see
+// Switch.PeerResourceStats.
+type PeerResources struct {
+	// Goroutines of the peer and its connection still running.
+	Goroutines int
+	// Bytes of the messages queued for sending to the peer.
+	QueuedBytes int64
+}
+
+// PeerResourceStats returns the resources held by each peer. Stopped peers are
+// reported until all their goroutines have returned, summed with those of a
+// new connection to the same peer, so that a peer stuck in the map after being
+// stopped leaks goroutines. Peers not created by the switch's transport are
+// not reported.
+func (sw *Switch) PeerResourceStats() map[ID]PeerResources {
+	stats := make(map[ID]PeerResources)
+	add := func(p *peer) {
+		res := p.resources()
+		total := stats[p.ID()]
+		total.Goroutines += res.Goroutines
+		total.QueuedBytes += res.QueuedBytes
+		stats[p.ID()] = total
+	}
+	for _, key := range sw.stoppedPeers.Keys() {
+		p, ok := sw.stoppedPeers.Get(key).(*peer)
+		if !ok || p.resources().Goroutines == 0 {
+			sw.stoppedPeers.Delete(key)
+			continue
+		}
+		add(p)
+	}
+	sw.peers.ForEach(func(p Peer) {
+		if p, ok := p.(*peer); ok {
+			add(p)
+		}
+	})
+	return stats
+}
+
 // StopPeerForError disconnects from a peer due to external error.
 // If the peer is persistent, it will attempt to reconnect.
 // TODO: make record depending on reason.
@@ -328,19 +796,19 @@ func (sw *Switch) StopPeerForError(peer Peer, reason any) {
 	sw.stopAndRemovePeer(peer, reason)
 
 	if peer.IsPersistent() {
-		var addr *NetAddress
-		if peer.IsOutbound() { // socket address for outbound peers
-			addr = peer.SocketAddr()
-		} else { // self-reported address for inbound peers
+		var addrs []*NetAddress
+		if peer.IsOutbound() { // configured address for outbound peers, so hostnames are resolved again
+			addrs = []*NetAddress{peer.ConfiguredAddr()}
+		} else { // self-reported addresses for inbound peers
 			var err error
-			addr, err = peer.NodeInfo().NetAddress()
+			addrs, err = peer.NodeInfo().NetAddresses()
 			if err != nil {
 				sw.Logger.Error("Wanted to reconnect to inbound peer, but self-reported address is wrong",
 					"peer", peer, "err", err)
 				return
 			}
 		}
-		go sw.reconnectToPeer(addr)
+		go sw.reconnectToPeer(addrs[0], addrs[1:]...)
 	}
 }
 
@@ -352,6 +820,12 @@ func (sw *Switch) StopPeerGracefully(peer Peer) {
 }
 
 func (sw *Switch) stopAndRemovePeer(peer Peer, reason any) {
+	sw.stopAndRemovePeerFrom(sw.reactorList(), peer, reason)
+}
+
+// stopAndRemovePeerFrom is stopAndRemovePeer for callers that hold
+// reactorsMtx and pass the reactors.
+func (sw *Switch) stopAndRemovePeerFrom(reactors map[string]Reactor, peer Peer, reason any) {
 	// Returning early if the peer is already stopped prevents data races because
 	// this function may be called from multiple places at once.
 	if err := peer.Stop(); err != nil {
@@ -359,9 +833,13 @@ func (sw *Switch) stopAndRemovePeer(peer Peer, reason any) {
 		return
 	}
 
+	sw.stoppedPeers.Set(string(peer.ID()), peer)
 	sw.transport.Cleanup(peer)
-	for _, reactor := range sw.reactors {
+	for name, reactor := range reactors {
 		reactor.RemovePeer(peer, reason)
+		if _, ok := reactor.(PeerStateReactor); ok {
+			peer.Set(PeerStateKey(name), nil)
+		}
 	}
 
 	// Removing a peer should go last to avoid a situation where a peer
@@ -379,20 +857,23 @@ func (sw *Switch) stopAndRemovePeer(peer Peer, reason any) {
 }
 
 // reconnectToPeer tries to reconnect to the addr, first repeatedly
-// with a fixed interval, then with exponential backoff.
+// with a fixed interval, then with exponential backoff. Each attempt
+// falls back to the given fallbacks, in order, if addr can't be dialed.
 // If no success after all that, it stops trying, and leaves it
 // to the PEX/Addrbook to find the peer with the addr again
 // NOTE: this will keep trying even if the handshake or auth fails.
 // TODO: be more explicit with error types so we only retry on certain failures
 //   - ie. if we're getting ErrDuplicatePeer we can stop
 //     because the addrbook got us the peer back already
-func (sw *Switch) reconnectToPeer(addr *NetAddress) {
+func (sw *Switch) reconnectToPeer(addr *NetAddress, fallbacks ...*NetAddress) {
 	if sw.reconnecting.Has(string(addr.ID)) {
 		return
 	}
 	sw.reconnecting.Set(string(addr.ID), addr)
 	defer sw.reconnecting.Delete(string(addr.ID))
 
+	addrs := append([]*NetAddress{addr}, fallbacks...)
+
 	start := time.Now()
 	sw.Logger.Info("Reconnecting to peer", "addr", addr)
 	for i := 0; i < reconnectAttempts; i++ {
@@ -400,7 +881,7 @@ func (sw *Switch) reconnectToPeer(addr *NetAddress) {
 			return
 		}
 
-		err := sw.DialPeerWithAddress(addr)
+		err := sw.DialPeerWithAddresses(addrs)
 		if err == nil {
 			return // success
 		} else if _, ok := err.(ErrCurrentlyDialingOrExistingAddress); ok {
@@ -424,7 +905,7 @@ func (sw *Switch) reconnectToPeer(addr *NetAddress) {
 		sleepIntervalSeconds := math.Pow(reconnectBackOffBaseSeconds, float64(i))
 		sw.randomSleep(time.Duration(sleepIntervalSeconds) * time.Second)
 
-		err := sw.DialPeerWithAddress(addr)
+		err := sw.DialPeerWithAddresses(addrs)
 		if err == nil {
 			return // success
 		} else if _, ok := err.(ErrCurrentlyDialingOrExistingAddress); ok {
@@ -460,6 +941,17 @@ func isPrivateAddr(err error) bool {
 	return ok && e.PrivateAddr()
 }
 
+// DialResult is the outcome of dialing a single address, as reported by
+// DialPeersAsyncWithResults.
+type DialResult struct {
+	Addr *NetAddress
+	// Err is nil if the peer was dialed and added successfully.
+	Err error
+	// Duration is the time spent dialing, excluding the random delay before
+	// the dial.
+	Duration time.Duration
+}
+
 // DialPeersAsync dials a list of peers asynchronously in random order.
 // Used to dial peers from config on startup or from unsafe-RPC (trusted sources).
 // It ignores ErrNetAddressLookup. However, if there are other errors, first
@@ -482,7 +974,20 @@ func (sw *Switch) DialPeersAsync(peers []string) error {
 	return nil
 }
 
-func (sw *Switch) dialPeersAsync(netAddrs []*NetAddress) {
+// DialPeersAsyncWithResults dials the given addresses asynchronously in random
+// order, like DialPeersAsync, and emits one DialResult per address as each
+// dial completes. The returned channel is buffered to hold every result and
+// is closed once all dials are done, so callers may read it lazily or not at
+// all.
+// An error is returned if the switch is not running.
+func (sw *Switch) DialPeersAsyncWithResults(addrs []*NetAddress) (<-chan DialResult, error) {
+	if !sw.IsRunning() {
+		return nil, ErrSwitchNotRunning
+	}
+	return sw.dialPeersAsync(addrs), nil
+}
+
+func (sw *Switch) dialPeersAsync(netAddrs []*NetAddress) <-chan DialResult {
 	ourAddr := sw.NetAddress()
 
 	// TODO: this code feels like it's in the wrong place.
@@ -508,21 +1013,30 @@ func (sw *Switch) dialPeersAsync(netAddrs []*NetAddress) {
 		sw.addrBook.Save()
 	}
 
+	results := make(chan DialResult, len(netAddrs))
+	var wg sync.WaitGroup
+	wg.Add(len(netAddrs))
+
 	// permute the list, dial them in random order.
 	perm := sw.rng.Perm(len(netAddrs))
 	for i := 0; i < len(perm); i++ {
 		go func(i int) {
+			defer wg.Done()
+
 			j := perm[i]
 			addr := netAddrs[j]
 
 			if addr.Same(ourAddr) {
 				sw.Logger.Debug("Ignore attempt to connect to ourselves", "addr", addr, "ourAddr", ourAddr)
+				results <- DialResult{Addr: addr, Err: ErrSwitchConnectToSelf{Addr: addr}}
 				return
 			}
 
 			sw.randomSleep(0)
 
+			start := time.Now()
 			err := sw.DialPeerWithAddress(addr)
+			results <- DialResult{Addr: addr, Err: err, Duration: time.Since(start)}
 			if err != nil {
 				switch err.(type) {
 				case ErrSwitchConnectToSelf, ErrSwitchDuplicatePeerID, ErrCurrentlyDialingOrExistingAddress:
@@ -533,6 +1047,13 @@ func (sw *Switch) dialPeersAsync(netAddrs []*NetAddress) {
 			}
 		}(i)
 	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
 }
 
 // DialPeerWithAddress dials the given peer and runs sw.addPeer if it connects
@@ -550,6 +1071,31 @@ func (sw *Switch) DialPeerWithAddress(addr *NetAddress) error {
 	return sw.addOutboundPeerWithConfig(addr, sw.config)
 }
 
+// DialPeerWithAddresses dials the given addresses of a single peer in order,
+// e.g. the ones it advertises in its NodeInfo, and stops at the first one that
+// connects and authenticates successfully. The switch only uses it to redial
+// inbound persistent peers: dials from the address book, e.g. by PEX, go to
+// the single address it knows through DialPeerWithAddress. If none does, the error for the
+// last address is returned. ErrCurrentlyDialingOrExistingAddress is returned
+// right away, since the remaining addresses belong to the same peer.
+func (sw *Switch) DialPeerWithAddresses(addrs []*NetAddress) error {
+	if len(addrs) == 0 {
+		return errors.New("no addresses to dial")
+	}
+
+	var err error
+	for _, addr := range addrs {
+		err = sw.DialPeerWithAddress(addr)
+		if err == nil {
+			return nil
+		} else if _, ok := err.(ErrCurrentlyDialingOrExistingAddress); ok {
+			return err
+		}
+		sw.Logger.Debug("Error dialing peer address", "addr", addr, "err", err)
+	}
+	return err
+}
+
 // sleep for interval plus some random amount of ms on [0, dialRandomizerIntervalMilliseconds].
 func (sw *Switch) randomSleep(interval time.Duration) {
 	r := time.Duration(sw.rng.Int63n(dialRandomizerIntervalMilliseconds)) * time.Millisecond
@@ -623,15 +1169,44 @@ func (sw *Switch) IsPeerPersistent(na *NetAddress) bool {
 	return false
 }
 
+// countRejection updates the PeerRejectedUnknownChannels metric if the peer
+// was rejected for advertising too many unknown channels, the
+// HandshakeRejectedTooLarge one if for sending too large a NodeInfo, and the
+// PeerRejectedOverload one if by the accept policy.
+func (sw *Switch) countRejection(err ErrRejected) {
+	if err.IsOverloaded() {
+		sw.metrics.PeerRejectedOverload.Add(1)
+	}
+	var (
+		unknownErr  ErrTooManyUnknownChannels
+		tooLargeErr ErrNodeInfoTooLarge
+	)
+	if err.IsIncompatible() && errors.As(err.err, &unknownErr) {
+		sw.metrics.PeerRejectedUnknownChannels.Add(1)
+	}
+	if err.IsAuthFailure() && errors.As(err.err, &tooLargeErr) {
+		sw.metrics.HandshakeRejectedTooLarge.Add(1)
+	}
+}
+
 func (sw *Switch) acceptRoutine() {
 	for {
+		chDescs, reactorsByCh, msgTypeByChID := sw.peerReactors()
 		p, err := sw.transport.Accept(peerConfig{
-			chDescs:       sw.chDescs,
+			chDescs:       chDescs,
 			onPeerError:   sw.StopPeerForError,
-			reactorsByCh:  sw.reactorsByCh,
-			msgTypeByChID: sw.msgTypeByChID,
+			reactorsByCh:  reactorsByCh,
+			msgTypeByChID: msgTypeByChID,
 			metrics:       sw.metrics,
 			isPersistent:  sw.IsPeerPersistent,
+
+			logUnknownChannel: sw.logUnknownChannel,
+			logReceive:        sw.logReceive,
+			capture:           sw.capture,
+			priorities:        sw.peerPriorities,
+			flushPolicies:     sw.peerFlushPolicies,
+			observers:         sw.observerPeers,
+			recvBudget:        sw.recvBudget,
 		})
 		if err != nil {
 			switch err := err.(type) {
@@ -643,6 +1218,10 @@ func (sw *Switch) acceptRoutine() {
 					sw.addrBook.RemoveAddress(&addr)
 					sw.addrBook.AddOurAddress(&addr)
 				}
+				if err.IsRejectedByPolicy() {
+					sw.metrics.PeerRejectedByPolicy.Add(1)
+				}
+				sw.countRejection(err)
 
 				sw.Logger.Info(
 					"Inbound Peer rejected",
@@ -680,24 +1259,27 @@ func (sw *Switch) acceptRoutine() {
 			break
 		}
 
+		var evicted *displacedPeer
 		if !sw.IsPeerUnconditional(p.NodeInfo().ID()) {
 			// Ignore connection if we already have enough peers.
 			_, in, _ := sw.NumPeers()
 			if in >= sw.config.MaxNumInboundPeers {
-				sw.Logger.Info(
-					"Ignoring inbound connection: already have enough inbound peers",
-					"address", p.SocketAddr(),
-					"have", in,
-					"max", sw.config.MaxNumInboundPeers,
-				)
+				if evicted = sw.evictionCandidate(p); evicted == nil {
+					sw.Logger.Info(
+						"Ignoring inbound connection: already have enough inbound peers",
+						"address", p.SocketAddr(),
+						"have", in,
+						"max", sw.config.MaxNumInboundPeers,
+					)
 
-				sw.transport.Cleanup(p)
+					sw.transport.Cleanup(p)
 
-				continue
+					continue
+				}
 			}
 		}
 
-		if err := sw.addPeer(p); err != nil {
+		if err := sw.addPeerDisplacing(p, evicted); err != nil {
 			sw.transport.Cleanup(p)
 			if p.IsRunning() {
 				_ = p.Stop()
@@ -711,6 +1293,35 @@ func (sw *Switch) acceptRoutine() {
 	}
 }
 
+// evictionCandidate returns the lowest scoring inbound peer to make room for
+// the inbound peer p, if eviction is enabled and p scores higher, or nil. The
+// candidate is only stopped once p passed all checks, see addPeerDisplacing.
+func (sw *Switch) evictionCandidate(p Peer) *displacedPeer {
+	if sw.evictionScore == nil {
+		return nil
+	}
+	var (
+		lowest      Peer
+		lowestScore int
+	)
+	for _, existing := range sw.peers.Copy() {
+		if existing.IsOutbound() || existing.IsPersistent() || sw.IsPeerUnconditional(existing.ID()) {
+			continue
+		}
+		if score := sw.evictionScore(existing); lowest == nil || score < lowestScore {
+			lowest, lowestScore = existing, score
+		}
+	}
+	if lowest == nil || sw.evictionScore(p) <= lowestScore {
+		return nil
+	}
+	return &displacedPeer{
+		peer:   lowest,
+		reason: ErrPeerEvicted{By: p.ID()},
+		label:  "evicted",
+	}
+}
+
 // dial the peer; make secret connection; authenticate against the dialed ID;
 // add the peer.
 // if dialing fails, start the reconnect loop. If handshake fails, it's over.
@@ -728,16 +1339,26 @@ func (sw *Switch) addOutboundPeerWithConfig(
 		return errors.New("dial err (peerConfig.DialFail == true)")
 	}
 
+	chDescs, reactorsByCh, msgTypeByChID := sw.peerReactors()
 	p, err := sw.transport.Dial(*addr, peerConfig{
-		chDescs:       sw.chDescs,
+		chDescs:       chDescs,
 		onPeerError:   sw.StopPeerForError,
 		isPersistent:  sw.IsPeerPersistent,
-		reactorsByCh:  sw.reactorsByCh,
-		msgTypeByChID: sw.msgTypeByChID,
+		reactorsByCh:  reactorsByCh,
+		msgTypeByChID: msgTypeByChID,
 		metrics:       sw.metrics,
+
+		logUnknownChannel: sw.logUnknownChannel,
+		logReceive:        sw.logReceive,
+		capture:           sw.capture,
+		priorities:        sw.peerPriorities,
+		flushPolicies:     sw.peerFlushPolicies,
+		observers:         sw.observerPeers,
+		recvBudget:        sw.recvBudget,
 	})
 	if err != nil {
 		if e, ok := err.(ErrRejected); ok {
+			sw.countRejection(e)
 			if e.IsSelf() {
 				// Remove the given address from the address book and add to our addresses
 				// to avoid dialing in the future.
@@ -768,10 +1389,116 @@ func (sw *Switch) addOutboundPeerWithConfig(
 	return nil
 }
 
-func (sw *Switch) filterPeer(p Peer) error {
+// initAndAddPeer starts the peer and adds it to the peer set. It returns the
+// reactors to add the peer to. InitPeer and Start are called without holding
+// reactorsMtx, with the reactors registered at that point. The peer is then
+// added to the set under reactorsMtx, so that a reactor added concurrently
+// either sees the peer in the set or is wired here, but never both.
+func (sw *Switch) initAndAddPeer(p Peer, displaced *displacedPeer) (map[string]Reactor, error) {
+	sw.reactorsMtx.RLock()
+	reactors, chDescs, reactorsByCh, msgTypeByChID := sw.reactors, sw.chDescs, sw.reactorsByCh, sw.msgTypeByChID
+	sw.reactorsMtx.RUnlock()
+
+	// The peer was created with the reactors at the time it was dialed or
+	// accepted, add the ones registered since.
+	if ca, ok := p.(channelAdder); ok {
+		if err := ca.addChannels(chDescs, reactorsByCh, msgTypeByChID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add some data to the peer, which is required by reactors.
+	for name, reactor := range reactors {
+		p = initPeer(name, reactor, p)
+	}
+
+	// Start the peer's send/recv routines.
+	// Must start it before adding it to the peer set
+	// to prevent Start and Stop from being called concurrently.
+	err := p.Start()
+	if err != nil {
+		// Should never happen
+		sw.Logger.Error("Error starting peer", "err", err, "peer", p)
+		return nil, err
+	}
+
+	if displaced != nil {
+		sw.Logger.Info("Disconnecting peer to make room for a new one",
+			"peer", displaced.peer.ID(), "for", p.ID(), "reason", displaced.reason)
+		sw.metrics.PeerDisconnectsTotal.With("reason", displaced.label).Add(1)
+		sw.stopAndRemovePeerFrom(reactors, displaced.peer, displaced.reason)
+	}
+
+	// Add the peer to PeerSet. Do this before starting the reactors
+	// so that if Receive errors, we will find the peer and remove it.
+	// Add should not err since we already checked peers.Has().
+	sw.reactorsMtx.RLock()
+	err = sw.peers.Add(p)
+	latest, chDescs, reactorsByCh, msgTypeByChID := sw.reactors, sw.chDescs, sw.reactorsByCh, sw.msgTypeByChID
+	sw.reactorsMtx.RUnlock()
+	if err != nil {
+		if _, ok := err.(ErrPeerRemoval); ok {
+			sw.Logger.Error("Error starting peer ",
+				" err ", "Peer has already errored and removal was attempted.",
+				"peer", p.ID())
+		}
+		return nil, err
+	}
+
+	// Wire the reactors added since the peer was initialized, which didn't
+	// see it in the set.
+	if len(latest) != len(reactors) {
+		// Initialize the peer before its messages can be routed to them.
+		for name, reactor := range latest {
+			if _, ok := reactors[name]; !ok {
+				p = initPeer(name, reactor, p)
+			}
+		}
+		if ca, ok := p.(channelAdder); ok {
+			if err := ca.addChannels(chDescs, reactorsByCh, msgTypeByChID); err != nil {
+				sw.Logger.Error("Error adding reactor channels to peer", "peer", p, "err", err)
+				return reactors, nil
+			}
+		}
+	}
+
+	return latest, nil
+}
+
+// initPeer calls InitPeer and, if the reactor implements PeerStateReactor,
+// stores its state for the peer.
+func initPeer(name string, reactor Reactor, p Peer) Peer {
+	p = reactor.InitPeer(p)
+	if psr, ok := reactor.(PeerStateReactor); ok {
+		p.Set(PeerStateKey(name), psr.InitPeerState(p))
+	}
+	return p
+}
+
+// displacedPeer is a connected peer that a new peer takes the place of. It is
+// only stopped once the new peer passed all checks, right before the new one
+// is added, so that a rejected newcomer doesn't cost a connection.
+type displacedPeer struct {
+	peer   Peer
+	reason error  // passed to the reactors' RemovePeer
+	label  string // reason label of PeerDisconnectsTotal
+}
+
+// filterPeer checks whether p can be added. If p is a duplicate connection to
+// a connected peer that it should replace, see replacesDuplicate, it returns
+// that peer as displaced.
+func (sw *Switch) filterPeer(p Peer) (*displacedPeer, error) {
 	// Avoid duplicate
-	if sw.peers.Has(p.ID()) {
-		return ErrRejected{id: p.ID(), isDuplicate: true}
+	var displaced *displacedPeer
+	if existing := sw.peers.Get(p.ID()); existing != nil {
+		if !sw.replacesDuplicate(p, existing) {
+			return nil, ErrRejected{id: p.ID(), isDuplicate: true}
+		}
+		displaced = &displacedPeer{
+			peer:   existing,
+			reason: ErrPeerReplaced{Outbound: p.IsOutbound()},
+			label:  "duplicate",
+		}
 	}
 
 	errc := make(chan error, len(sw.peerFilters))
@@ -786,22 +1513,47 @@ func (sw *Switch) filterPeer(p Peer) error {
 		select {
 		case err := <-errc:
 			if err != nil {
-				return ErrRejected{id: p.ID(), err: err, isFiltered: true}
+				return nil, ErrRejected{id: p.ID(), err: err, isFiltered: true}
 			}
 		case <-time.After(sw.filterTimeout):
-			return ErrFilterTimeout{}
+			return nil, ErrFilterTimeout{}
 		}
 	}
 
-	return nil
+	return displaced, nil
+}
+
+// replacesDuplicate reports whether p, a new connection to a node we are
+// already connected to through existing, should take its place. When two
+// nodes dial each other at the same time, each ends up with an inbound and an
+// outbound connection to the other, and they must agree on which one to keep:
+// both keep the one dialed by the node with the lexicographically smaller ID.
+// A new connection in the same direction as the existing one never replaces
+// it.
+func (sw *Switch) replacesDuplicate(p, existing Peer) bool {
+	if p.IsOutbound() == existing.IsOutbound() {
+		return false
+	}
+	weDial := sw.nodeInfo.ID() < p.ID()
+	return p.IsOutbound() == weDial
 }
 
 // addPeer starts up the Peer and adds it to the Switch. Error is returned if
 // the peer is filtered out or failed to start or can't be added.
 func (sw *Switch) addPeer(p Peer) error {
-	if err := sw.filterPeer(p); err != nil {
+	return sw.addPeerDisplacing(p, nil)
+}
+
+// addPeerDisplacing is addPeer that stops evicted to make room for p, once p
+// passed all checks. A duplicate connection p replaces takes its place.
+func (sw *Switch) addPeerDisplacing(p Peer, evicted *displacedPeer) error {
+	displaced, err := sw.filterPeer(p)
+	if err != nil {
 		return err
 	}
+	if displaced == nil {
+		displaced = evicted
+	}
 
 	p.SetLogger(sw.Logger.With("peer", p.SocketAddr()))
 
@@ -813,36 +1565,14 @@ func (sw *Switch) addPeer(p Peer) error {
 		return nil
 	}
 
-	// Add some data to the peer, which is required by reactors.
-	for _, reactor := range sw.reactors {
-		p = reactor.InitPeer(p)
-	}
-
-	// Start the peer's send/recv routines.
-	// Must start it before adding it to the peer set
-	// to prevent Start and Stop from being called concurrently.
-	err := p.Start()
+	reactors, err := sw.initAndAddPeer(p, displaced)
 	if err != nil {
-		// Should never happen
-		sw.Logger.Error("Error starting peer", "err", err, "peer", p)
-		return err
-	}
-
-	// Add the peer to PeerSet. Do this before starting the reactors
-	// so that if Receive errors, we will find the peer and remove it.
-	// Add should not err since we already checked peers.Has().
-	if err := sw.peers.Add(p); err != nil {
-		if _, ok := err.(ErrPeerRemoval); ok {
-			sw.Logger.Error("Error starting peer ",
-				" err ", "Peer has already errored and removal was attempted.",
-				"peer", p.ID())
-		}
 		return err
 	}
 	sw.metrics.Peers.Add(float64(1))
 
 	// Start all the reactor protocols on the peer.
-	for _, reactor := range sw.reactors {
+	for _, reactor := range reactors {
 		reactor.AddPeer(p)
 	}
 
@@ -10,12 +10,15 @@ import (
 	"net/http/httptest"
 	"regexp"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -23,6 +26,7 @@ import (
 	"github.com/cometbft/cometbft/config"
 	"github.com/cometbft/cometbft/crypto/ed25519"
 	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/libs/metrics/prometheus"
 	cmtsync "github.com/cometbft/cometbft/libs/sync"
 	"github.com/cometbft/cometbft/p2p/conn"
 )
@@ -173,6 +177,474 @@ func TestSwitches(t *testing.T) {
 		s2.Reactor("bar").(*TestReactor), 200*time.Millisecond, 5*time.Second)
 }
 
+type addPeerReactor struct {
+	*TestReactor
+	added chan Peer
+}
+
+func (r *addPeerReactor) AddPeer(p Peer) {
+	r.added <- p
+}
+
+func TestSwitchAddReactorWhileRunning(t *testing.T) {
+	s1, s2 := MakeSwitchPair(initSwitchFunc)
+	t.Cleanup(func() {
+		if err := s2.Stop(); err != nil {
+			t.Error(err)
+		}
+		if err := s1.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	const chID = byte(0x42)
+	newReactor := func() *addPeerReactor {
+		return &addPeerReactor{
+			TestReactor: NewTestReactor([]*conn.ChannelDescriptor{
+				{ID: chID, Priority: 10, MessageType: &p2pproto.Message{}},
+			}, true),
+			added: make(chan Peer, 1),
+		}
+	}
+	r1, r2 := newReactor(), newReactor()
+	s1.AddReactor("baz", r1)
+	s2.AddReactor("baz", r2)
+
+	// the reactors are started and given the peers already connected
+	for _, r := range []*addPeerReactor{r1, r2} {
+		require.True(t, r.IsRunning())
+		select {
+		case <-r.added:
+		case <-time.After(time.Second):
+			t.Fatal("reactor was not given the connected peer")
+		}
+	}
+	assert.Equal(t, r1, s1.Reactor("baz"))
+	assert.Equal(t, r1, s1.ReactorForChannel(chID))
+	assert.Equal(t, s1.Reactor("foo"), s1.ReactorForChannel(0x01))
+	assert.Nil(t, s1.ReactorForChannel(0x7f))
+	assert.True(t, s1.transport.(*MultiplexTransport).nodeInfo.(DefaultNodeInfo).HasChannel(chID))
+
+	// the peers can send on the new channel, although it wasn't in the
+	// NodeInfo exchanged in the handshake, and its messages are routed to the
+	// new reactor
+	msg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "1"}}}
+	p := s2.Peers().Copy()[0]
+	assert.False(t, p.NodeInfo().(DefaultNodeInfo).HasChannel(chID))
+	assert.True(t, p.HasChannel(chID))
+	require.NoError(t, p.SendE(Envelope{ChannelID: chID, Message: msg}))
+	require.True(t, p.Send(Envelope{ChannelID: chID, Message: msg}))
+	require.Eventually(t, func() bool { return len(r1.getMsgs(chID)) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, msg.Addrs, r1.getMsgs(chID)[0].Contents.(*p2pproto.PexAddrs).Addrs)
+
+	// channels can still not be shared
+	assert.Panics(t, func() { s1.AddReactor("qux", newReactor()) })
+}
+
+type blockingInitReactor struct {
+	*TestReactor
+	once     sync.Once
+	entered  chan struct{}
+	released chan struct{}
+}
+
+func (r *blockingInitReactor) InitPeer(p Peer) Peer {
+	r.once.Do(func() {
+		close(r.entered)
+		<-r.released
+	})
+	return p
+}
+
+func TestSwitchAddReactorWhilePeerInits(t *testing.T) {
+	blocking := &blockingInitReactor{
+		TestReactor: NewTestReactor([]*conn.ChannelDescriptor{
+			{ID: 0x41, Priority: 10, MessageType: &p2pproto.Message{}},
+		}, true),
+		entered:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	switches := MakeSwitches(cfg, 2, func(i int, sw *Switch) *Switch {
+		sw = initSwitchFunc(i, sw)
+		if i == 0 {
+			sw.AddReactor("blocking", blocking)
+		}
+		return sw
+	})
+	require.NoError(t, StartSwitches(switches))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			_ = sw.Stop()
+		}
+	})
+	connected := make(chan struct{})
+	go func() {
+		Connect2Switches(switches, 0, 1)
+		close(connected)
+	}()
+
+	// A reactor can be added while a peer is being initialized, and the peer
+	// is then wired into it.
+	<-blocking.entered
+	late := &addPeerReactor{
+		TestReactor: NewTestReactor([]*conn.ChannelDescriptor{
+			{ID: 0x42, Priority: 10, MessageType: &p2pproto.Message{}},
+		}, true),
+		added: make(chan Peer, 2),
+	}
+	added := make(chan struct{})
+	go func() {
+		switches[0].AddReactor("late", late)
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AddReactor blocked on the peer being initialized")
+	}
+	close(blocking.released)
+	<-connected
+
+	select {
+	case p := <-late.added:
+		assert.Equal(t, switches[1].NodeInfo().ID(), p.ID())
+	case <-time.After(5 * time.Second):
+		t.Fatal("late reactor was not given the new peer")
+	}
+	assert.Empty(t, late.added, "late reactor was given the peer twice")
+	reactor, _ := switches[0].Peers().Copy()[0].(*peer).channelReactor(0x42)
+	assert.Equal(t, late, reactor)
+}
+
+type peerStateReactor struct {
+	*TestReactor
+	missingState chan struct{}
+}
+
+func (*peerStateReactor) InitPeerState(p Peer) any {
+	return p.ID()
+}
+
+func (r *peerStateReactor) AddPeer(p Peer) {
+	if p.Get(PeerStateKey("state")) != p.ID() {
+		r.missingState <- struct{}{}
+	}
+}
+
+func (r *peerStateReactor) Receive(e Envelope) {
+	if e.Src.Get(PeerStateKey("state")) != e.Src.ID() {
+		r.missingState <- struct{}{}
+	}
+	r.TestReactor.Receive(e)
+}
+
+func TestSwitchPeerStateReactor(t *testing.T) {
+	const chID = byte(0x42)
+	newReactor := func() *peerStateReactor {
+		return &peerStateReactor{
+			TestReactor: NewTestReactor([]*conn.ChannelDescriptor{
+				{ID: chID, Priority: 10, MessageType: &p2pproto.Message{}},
+			}, true),
+			missingState: make(chan struct{}, 10),
+		}
+	}
+	r1, r2 := newReactor(), newReactor()
+	s1, s2 := MakeSwitchPair(func(i int, sw *Switch) *Switch {
+		sw = initSwitchFunc(i, sw)
+		if i == 0 {
+			sw.AddReactor("state", r1)
+		} else {
+			sw.AddReactor("state", r2)
+		}
+		return sw
+	})
+	t.Cleanup(func() {
+		if err := s1.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	p := s1.Peers().Copy()[0]
+	assert.Equal(t, p.ID(), p.Get(PeerStateKey("state")))
+
+	s2.Broadcast(Envelope{ChannelID: chID, Message: &p2pproto.PexRequest{}})
+	require.Eventually(t, func() bool { return len(r1.getMsgs(chID)) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, r1.missingState)
+	assert.Empty(t, r2.missingState)
+
+	// the state is cleared once the peer is removed
+	require.NoError(t, s2.Stop())
+	require.Eventually(t, func() bool { return s1.Peers().Size() == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, p.Get(PeerStateKey("state")))
+}
+
+func TestSwitchBandwidthMetrics(t *testing.T) {
+	received := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "node_receive_bytes"}, nil)
+	s1, s2 := MakeSwitchPair(func(i int, sw *Switch) *Switch {
+		sw = initSwitchFunc(i, sw)
+		if i == 0 {
+			sw.metrics = NopMetrics()
+			sw.metrics.NodeReceiveBytesTotal = prometheus.NewCounter(received)
+		}
+		return sw
+	})
+	t.Cleanup(func() {
+		if err := s1.Stop(); err != nil {
+			t.Error(err)
+		}
+		if err := s2.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	msg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "1", IP: "127.0.0.1", Port: 8080}}}
+	s2.Broadcast(Envelope{ChannelID: 0x00, Message: msg})
+	require.Eventually(t, func() bool {
+		var m dto.Metric
+		require.NoError(t, received.WithLabelValues().Write(&m))
+		return m.GetCounter().GetValue() >= float64(proto.Size(msg))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	last := s1.reportBandwidth(nil)
+	require.Len(t, last, 1)
+	for _, bytes := range last {
+		assert.Positive(t, bytes.received)
+	}
+}
+
+func TestSwitchBroadcastVariants(t *testing.T) {
+	switches := MakeSwitches(cfg, 3, initSwitchFunc)
+	ni := switches[1].NodeInfo().(DefaultNodeInfo)
+	ni.Features = []string{"v2"}
+	switches[1].SetNodeInfo(ni)
+	StartAndConnectSwitches(switches, ConnectStarSwitches(0))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			if err := sw.Stop(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	defaultMsg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "1"}}}
+	v2Msg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "2"}}}
+	switches[0].BroadcastVariants(
+		Envelope{ChannelID: 0x00, Message: defaultMsg},
+		MessageVariant{Feature: "v3", Message: &p2pproto.PexRequest{}},
+		MessageVariant{Feature: "v2", Message: v2Msg},
+	)
+	received := func(sw *Switch) proto.Message {
+		var msgs []PeerMessage
+		require.Eventually(t, func() bool {
+			msgs = sw.Reactor("foo").(*TestReactor).getMsgs(0x00)
+			return len(msgs) > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		return msgs[0].Contents
+	}
+	assert.Equal(t, v2Msg.String(), received(switches[1]).String())
+	assert.Equal(t, defaultMsg.String(), received(switches[2]).String())
+}
+
+func TestSwitchBroadcastFiltered(t *testing.T) {
+	switches := MakeSwitches(cfg, 3, initSwitchFunc)
+	StartAndConnectSwitches(switches, ConnectStarSwitches(0))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			if err := sw.Stop(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	target := switches[2].NodeInfo().ID()
+	msg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "1"}}}
+	n := switches[0].BroadcastFiltered(Envelope{ChannelID: 0x00, Message: msg}, func(p Peer) bool {
+		return p.ID() == target
+	})
+	assert.Equal(t, 1, n)
+	require.Eventually(t, func() bool {
+		return len(switches[2].Reactor("foo").(*TestReactor).getMsgs(0x00)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, switches[1].Reactor("foo").(*TestReactor).getMsgs(0x00))
+
+	assert.Zero(t, switches[0].BroadcastFiltered(Envelope{ChannelID: 0x00, Message: msg}, func(Peer) bool { return false }))
+}
+
+func TestSwitchObserverPeers(t *testing.T) {
+	switches := MakeSwitches(cfg, 3, initSwitchFunc)
+	observer := switches[1].NodeInfo().ID()
+	SwitchObserverPeers(observer)(switches[0])
+	StartAndConnectSwitches(switches, ConnectStarSwitches(0))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			if err := sw.Stop(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	p := switches[0].Peers().Get(observer)
+	require.NotNil(t, p)
+	assert.True(t, isObserver(p))
+	assert.False(t, isObserver(switches[0].Peers().Get(switches[2].NodeInfo().ID())))
+
+	msg := &p2pproto.PexAddrs{Addrs: []p2pproto.NetAddress{{ID: "1"}}}
+	n := switches[0].BroadcastFiltered(Envelope{ChannelID: 0x00, Message: msg}, func(Peer) bool { return true })
+	assert.Equal(t, 1, n)
+	require.Eventually(t, func() bool {
+		return len(switches[2].Reactor("foo").(*TestReactor).getMsgs(0x00)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, switches[1].Reactor("foo").(*TestReactor).getMsgs(0x00))
+
+	// The observer's messages are still received.
+	require.True(t, switches[1].Peers().Get(switches[0].NodeInfo().ID()).Send(Envelope{ChannelID: 0x00, Message: msg}))
+	require.Eventually(t, func() bool {
+		return len(switches[0].Reactor("foo").(*TestReactor).getMsgs(0x00)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSwitchPeerFlushPolicy(t *testing.T) {
+	switches := MakeSwitches(cfg, 2, func(_ int, sw *Switch) *Switch { return sw })
+	dialer, listener := switches[0], switches[1]
+	SwitchPeerFlushPolicy(listener.NodeInfo().ID(), FlushImmediate)(dialer)
+	SwitchPeerFlushPolicy(dialer.NodeInfo().ID(), FlushBatched(50*time.Millisecond))(listener)
+	SwitchPeerFlushPolicy("0123456789abcdef0123456789abcdef01234567", FlushBatched(time.Second))(listener)
+	require.NoError(t, StartSwitches(switches))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			_ = sw.Stop()
+		}
+	})
+
+	require.NoError(t, dialer.DialPeerWithAddress(listener.NetAddress()))
+	require.Eventually(t, func() bool {
+		return listener.Peers().Has(dialer.NodeInfo().ID())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Both the dialed and the accepted peer get the policy set for their ID.
+	outbound := dialer.Peers().Get(listener.NodeInfo().ID()).(*peer)
+	require.NotNil(t, outbound.flushPolicy)
+	assert.Equal(t, FlushImmediate, *outbound.flushPolicy)
+	inbound := listener.Peers().Get(dialer.NodeInfo().ID()).(*peer)
+	require.NotNil(t, inbound.flushPolicy)
+	assert.Equal(t, FlushBatched(50*time.Millisecond), *inbound.flushPolicy)
+}
+
+// stopCheckingReactor takes a while to process each message, and records whether it
+// was stopped before it was done with one.
+type stopCheckingReactor struct {
+	BaseReactor
+	receiving       chan struct{}
+	received        chan struct{}
+	receivedStopped atomic.Bool
+}
+
+func (*stopCheckingReactor) GetChannels() []*conn.ChannelDescriptor {
+	return []*conn.ChannelDescriptor{{ID: 0x00, Priority: 1, MessageType: &p2pproto.Message{}}}
+}
+
+func (r *stopCheckingReactor) Receive(Envelope) {
+	select {
+	case r.receiving <- struct{}{}:
+	default:
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !r.IsRunning() {
+		r.receivedStopped.Store(true)
+	}
+	r.received <- struct{}{}
+}
+
+func TestSwitchStopWaitsForReceives(t *testing.T) {
+	sw1, sw2 := MakeSwitchPair(func(_ int, sw *Switch) *Switch {
+		r := &stopCheckingReactor{receiving: make(chan struct{}, 1), received: make(chan struct{}, 1)}
+		r.BaseReactor = *NewBaseReactor("stopChecking", r)
+		sw.AddReactor("stopChecking", r)
+		return sw
+	})
+	r := sw2.Reactor("stopChecking").(*stopCheckingReactor)
+	t.Cleanup(func() {
+		if err := sw1.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Keep a message in flight while the switch stops.
+	sw1.Broadcast(Envelope{ChannelID: 0x00, Message: &p2pproto.PexRequest{}})
+	select {
+	case <-r.receiving:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+	require.NoError(t, sw2.Stop())
+	<-r.received
+	assert.False(t, r.receivedStopped.Load(), "reactor was stopped while processing a message")
+}
+
+func TestSortPeersByPriority(t *testing.T) {
+	low, high := &peer{}, &peer{}
+	PeerPriority(-1)(low)
+	PeerPriority(5)(high)
+	mid := &peer{nodeInfo: DefaultNodeInfo{Moniker: "mid"}}
+	other := &peer{nodeInfo: DefaultNodeInfo{Moniker: "other"}}
+
+	peers := []Peer{low, mid, high, other}
+	sortPeersByPriority(peers)
+	// peers with the same priority keep their order
+	assert.Equal(t, []Peer{high, mid, other, low}, peers)
+}
+
+func TestSwitchTryBroadcastPeerPriority(t *testing.T) {
+	sw := MakeSwitch(cfg, 1, func(_ int, sw *Switch) *Switch { return sw })
+	require.NoError(t, sw.Start())
+	t.Cleanup(func() { _ = sw.Stop() })
+
+	chDescs := []*conn.ChannelDescriptor{{ID: testCh, Priority: 1, SendQueueCapacity: 2, MessageType: &p2pproto.Message{}}}
+	newStuckPeer := func(options ...PeerOption) *peer {
+		nodeInfo := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "stuck").(DefaultNodeInfo)
+		nodeInfo.Channels = []byte{testCh}
+		// Nothing reads from remote, so the link is congested.
+		local, remote := net.Pipe()
+		t.Cleanup(func() { remote.Close() })
+		p := newPeer(newPeerConn(true, false, local, nil), conn.DefaultMConnConfig(), nodeInfo,
+			map[byte]Reactor{}, map[byte]proto.Message{testCh: &p2pproto.Message{}}, chDescs,
+			func(Peer, any) {}, options...)
+		p.SetLogger(log.TestingLogger())
+		require.NoError(t, p.Start())
+		t.Cleanup(func() { _ = p.Stop() })
+		require.NoError(t, sw.peers.Add(p))
+		return p
+	}
+	low, high := newStuckPeer(), newStuckPeer(PeerPriority(2))
+
+	queued := func(p *peer) int {
+		return p.Status().Channels[0].SendQueueSize
+	}
+	// A message too large for the write buffer keeps the send routines busy,
+	// so the messages broadcast next stay queued. It counts as queued until
+	// it is sent in full.
+	big := &p2pproto.PexAddrs{Addrs: make([]p2pproto.NetAddress, 5000)}
+	for i := range big.Addrs {
+		big.Addrs[i] = p2pproto.NetAddress{ID: strconv.Itoa(i), IP: "127.0.0.1", Port: 26656}
+	}
+	for _, p := range []*peer{low, high} {
+		require.True(t, p.TrySend(Envelope{ChannelID: testCh, Message: big}))
+		require.Eventually(t, func() bool { return p.Status().Channels[0].RecentlySent > 0 }, 5*time.Second, time.Millisecond)
+	}
+
+	for i := 0; i < 20; i++ {
+		results := sw.broadcast(Envelope{ChannelID: testCh, Message: &p2pproto.PexRequest{}}, nil, nil, true)
+		for j := 0; j < cap(results); j++ {
+			<-results
+		}
+	}
+
+	// The send queues of the high priority peer are three times as large, so
+	// it gets the messages the low priority one can't take.
+	assert.Equal(t, 1+2, queued(low))
+	assert.Equal(t, 1+6, queued(high))
+}
+
 func assertMsgReceivedWithTimeout(
 	t *testing.T,
 	msg proto.Message,
@@ -231,6 +703,31 @@ func TestSwitchFiltersOutItself(t *testing.T) {
 	assertNoPeersAfterTimeout(t, s1, 100*time.Millisecond)
 }
 
+func TestSwitchPeerResourceStats(t *testing.T) {
+	s1, s2 := MakeSwitchPair(initSwitchFunc)
+	t.Cleanup(func() {
+		if err := s2.Stop(); err != nil {
+			t.Error(err)
+		}
+		if err := s1.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	peer := s1.Peers().Copy()[0]
+
+	// The send and receive routines, and the metrics reporter.
+	stats := s1.PeerResourceStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 3, stats[peer.ID()].Goroutines)
+	assert.Zero(t, stats[peer.ID()].QueuedBytes)
+
+	s1.StopPeerGracefully(peer)
+	assert.Eventually(t, func() bool {
+		_, ok := s1.PeerResourceStats()[peer.ID()]
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond, "stopped peer's goroutines did not exit")
+}
+
 func TestSwitchPeerFilter(t *testing.T) {
 	var (
 		filters = []PeerFilterFunc{
@@ -362,6 +859,109 @@ func TestSwitchPeerFilterDuplicate(t *testing.T) {
 	}
 }
 
+func TestSwitchSimultaneousDial(t *testing.T) {
+	dial := func(from, to *Switch) {
+		p, err := from.transport.Dial(*to.NetAddress(), peerConfig{
+			chDescs:      from.chDescs,
+			onPeerError:  from.StopPeerForError,
+			isPersistent: from.IsPeerPersistent,
+			reactorsByCh: from.reactorsByCh,
+		})
+		require.NoError(t, err)
+		if err := from.addPeer(p); err != nil {
+			from.transport.Cleanup(p)
+			_ = p.Stop()
+		}
+	}
+
+	for _, smallerFirst := range []bool{true, false} {
+		t.Run(fmt.Sprintf("smallerFirst=%v", smallerFirst), func(t *testing.T) {
+			switches := MakeSwitches(cfg, 2, func(_ int, sw *Switch) *Switch { return sw })
+			require.NoError(t, StartSwitches(switches))
+			t.Cleanup(func() {
+				for _, sw := range switches {
+					_ = sw.Stop()
+				}
+			})
+			smaller, larger := switches[0], switches[1]
+			if larger.NodeInfo().ID() < smaller.NodeInfo().ID() {
+				smaller, larger = larger, smaller
+			}
+
+			// Both nodes dial each other before either sees the other's
+			// connection, so each gets an inbound and an outbound one.
+			if smallerFirst {
+				dial(smaller, larger)
+				dial(larger, smaller)
+			} else {
+				dial(larger, smaller)
+				dial(smaller, larger)
+			}
+
+			// Both keep the connection dialed by the node with the smaller ID.
+			require.Eventually(t, func() bool {
+				p1 := smaller.Peers().Get(larger.NodeInfo().ID())
+				p2 := larger.Peers().Get(smaller.NodeInfo().ID())
+				return smaller.Peers().Size() == 1 && larger.Peers().Size() == 1 &&
+					p1 != nil && p1.IsRunning() && p1.IsOutbound() &&
+					p2 != nil && p2.IsRunning() && !p2.IsOutbound()
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestSwitchKeepsDuplicateWhenReplacementRejected(t *testing.T) {
+	switches := MakeSwitches(cfg, 2, func(_ int, sw *Switch) *Switch { return sw })
+	require.NoError(t, StartSwitches(switches))
+	t.Cleanup(func() {
+		for _, sw := range switches {
+			_ = sw.Stop()
+		}
+	})
+	smaller, larger := switches[0], switches[1]
+	if larger.NodeInfo().ID() < smaller.NodeInfo().ID() {
+		smaller, larger = larger, smaller
+	}
+
+	// The smaller node has an inbound connection from the larger one, which
+	// its own outbound connection would replace.
+	p, err := larger.transport.Dial(*smaller.NetAddress(), peerConfig{
+		chDescs:      larger.chDescs,
+		onPeerError:  larger.StopPeerForError,
+		isPersistent: larger.IsPeerPersistent,
+		reactorsByCh: larger.reactorsByCh,
+	})
+	require.NoError(t, err)
+	require.NoError(t, larger.addPeer(p))
+	require.Eventually(t, func() bool {
+		return smaller.Peers().Has(larger.NodeInfo().ID())
+	}, 5*time.Second, 10*time.Millisecond)
+	existing := smaller.Peers().Get(larger.NodeInfo().ID())
+
+	// The replacement fails a peer filter, the existing peer must stay. The
+	// larger node rejects its side of the new connection too, so that it
+	// doesn't replace the existing connection with it.
+	reject := []PeerFilterFunc{func(IPeerSet, Peer) error {
+		return errors.New("rejected")
+	}}
+	smaller.peerFilters = reject
+	larger.peerFilters = reject
+	p, err = smaller.transport.Dial(*larger.NetAddress(), peerConfig{
+		chDescs:      smaller.chDescs,
+		onPeerError:  smaller.StopPeerForError,
+		isPersistent: smaller.IsPeerPersistent,
+		reactorsByCh: smaller.reactorsByCh,
+	})
+	require.NoError(t, err)
+	err = smaller.addPeer(p)
+	require.Error(t, err)
+	smaller.transport.Cleanup(p)
+	_ = p.Stop()
+
+	assert.True(t, existing.IsRunning())
+	assert.Equal(t, existing, smaller.Peers().Get(larger.NodeInfo().ID()))
+}
+
 func assertNoPeersAfterTimeout(t *testing.T, sw *Switch, timeout time.Duration) {
 	t.Helper()
 	time.Sleep(timeout)
@@ -410,6 +1010,39 @@ func TestSwitchStopsNonPersistentPeerOnError(t *testing.T) {
 	assert.False(p.IsRunning())
 }
 
+func TestSwitchStopInactivePeers(t *testing.T) {
+	sw1, sw2 := MakeSwitchPair(func(i int, sw *Switch) *Switch {
+		c := *sw.config
+		c.PeerInactivityTimeout = time.Minute
+		sw.config = &c
+		return initSwitchFunc(i, sw)
+	})
+	t.Cleanup(func() {
+		if err := sw1.Stop(); err != nil {
+			t.Error(err)
+		}
+		if err := sw2.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	require.Len(t, sw1.Peers().Copy(), 1)
+	later := time.Now().Add(2 * time.Minute)
+
+	// Not inactive yet.
+	sw1.stopInactivePeers(time.Now())
+	assert.Len(t, sw1.Peers().Copy(), 1)
+
+	// Unconditional peers are kept.
+	err := sw1.AddUnconditionalPeerIDs([]string{string(sw2.NodeInfo().ID())})
+	require.NoError(t, err)
+	sw1.stopInactivePeers(later)
+	assert.Len(t, sw1.Peers().Copy(), 1)
+
+	delete(sw1.unconditionalPeerIDs, sw2.NodeInfo().ID())
+	sw1.stopInactivePeers(later)
+	assert.Empty(t, sw1.Peers().Copy())
+}
+
 func TestSwitchStopPeerForError(t *testing.T) {
 	s := httptest.NewServer(promhttp.Handler())
 	defer s.Close()
@@ -570,6 +1203,80 @@ func TestSwitchDialPeersAsync(t *testing.T) {
 	require.NotNil(t, sw.Peers().Get(rp.ID()))
 }
 
+func TestSwitchDialPeersAsyncWithResults(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+
+	sw := MakeSwitch(cfg, 1, initSwitchFunc)
+
+	_, err := sw.DialPeersAsyncWithResults(nil)
+	require.ErrorIs(t, err, ErrSwitchNotRunning)
+
+	err = sw.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := sw.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	defer rp.Stop()
+
+	// an address nobody listens on
+	unreachable := NewNetAddressIPPort(net.ParseIP("127.0.0.1"), 1)
+	unreachable.ID = PubKeyToID(ed25519.GenPrivKey().PubKey())
+
+	results, err := sw.DialPeersAsyncWithResults([]*NetAddress{rp.Addr(), unreachable, sw.NetAddress()})
+	require.NoError(t, err)
+
+	got := make(map[string]error)
+	for res := range results {
+		got[res.Addr.String()] = res.Err
+	}
+	require.Len(t, got, 3)
+	require.NoError(t, got[rp.Addr().String()])
+	require.Error(t, got[unreachable.String()])
+	require.IsType(t, ErrSwitchConnectToSelf{}, got[sw.NetAddress().String()])
+	require.NotNil(t, sw.Peers().Get(rp.ID()))
+}
+
+func TestSwitchDialPeerWithAddresses(t *testing.T) {
+	sw := MakeSwitch(cfg, 1, initSwitchFunc)
+	err := sw.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := sw.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	err = sw.DialPeerWithAddresses(nil)
+	require.Error(t, err)
+
+	rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: cfg}
+	rp.Start()
+	defer rp.Stop()
+
+	// the first addresses of the peer are unreachable or don't resolve, the
+	// last one works and is resolved when dialed
+	unreachable := NewNetAddressIPPort(net.ParseIP("127.0.0.1"), 1)
+	unreachable.ID = rp.ID()
+	unresolvable, err := newUnresolvedNetAddressString(IDAddressString(rp.ID(), "nonexistent.invalid:26656"))
+	require.NoError(t, err)
+	hostname, err := newUnresolvedNetAddressString(IDAddressString(rp.ID(), net.JoinHostPort("localhost", strconv.Itoa(int(rp.Addr().Port)))))
+	require.NoError(t, err)
+
+	err = sw.DialPeerWithAddresses([]*NetAddress{unreachable, unresolvable, hostname})
+	require.NoError(t, err)
+	require.NotNil(t, sw.Peers().Get(rp.ID()))
+
+	err = sw.DialPeerWithAddresses([]*NetAddress{rp.Addr()})
+	require.IsType(t, ErrCurrentlyDialingOrExistingAddress{}, err)
+}
+
 func waitUntilSwitchHasAtLeastNPeers(sw *Switch, n int) {
 	for i := 0; i < 20; i++ {
 		time.Sleep(250 * time.Millisecond)
@@ -710,6 +1417,79 @@ func (errorTransport) Cleanup(Peer) {
 	panic("not implemented")
 }
 
+func TestSwitchPeerEviction(t *testing.T) {
+	conf := *cfg
+	conf.MaxNumInboundPeers = 2
+
+	var scores sync.Map
+	score := func(p Peer) int {
+		s, _ := scores.Load(p.ID())
+		n, _ := s.(int)
+		return n
+	}
+	var banned sync.Map
+	filter := func(_ IPeerSet, p Peer) error {
+		if _, ok := banned.Load(p.ID()); ok {
+			return errors.New("banned")
+		}
+		return nil
+	}
+	sw := MakeSwitch(&conf, 1, initSwitchFunc, SwitchPeerEviction(score), SwitchPeerFilters(filter))
+	require.NoError(t, sw.Start())
+	t.Cleanup(func() {
+		if err := sw.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	connect := func(s int, ban bool) *remotePeer {
+		rp := &remotePeer{PrivKey: ed25519.GenPrivKey(), Config: &conf}
+		scores.Store(rp.ID(), s)
+		if ban {
+			banned.Store(rp.ID(), struct{}{})
+		}
+		rp.Start()
+		t.Cleanup(rp.Stop)
+		c, err := rp.Dial(sw.NetAddress())
+		require.NoError(t, err)
+		// keep reading so that the connection stays open
+		go func() {
+			one := make([]byte, 1)
+			for {
+				if _, err := c.Read(one); err != nil {
+					return
+				}
+			}
+		}()
+		return rp
+	}
+	hasPeer := func(rp *remotePeer) func() bool {
+		return func() bool { return sw.Peers().Has(rp.ID()) }
+	}
+
+	low1, low2 := connect(1, false), connect(2, false)
+	require.Eventually(t, func() bool { return sw.Peers().Size() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// A higher scoring peer takes the place of the lowest scoring one.
+	high := connect(3, false)
+	require.Eventually(t, hasPeer(high), 5*time.Second, 10*time.Millisecond)
+	assert.False(t, sw.Peers().Has(low1.ID()))
+	assert.True(t, sw.Peers().Has(low2.ID()))
+
+	// One that doesn't score higher is rejected.
+	rejected := connect(2, false)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, sw.Peers().Has(rejected.ID()))
+	assert.Equal(t, 2, sw.Peers().Size())
+
+	// A higher scoring one that is filtered out evicts nobody.
+	filtered := connect(4, true)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, sw.Peers().Has(filtered.ID()))
+	assert.True(t, sw.Peers().Has(low2.ID()))
+	assert.True(t, sw.Peers().Has(high.ID()))
+}
+
 func TestSwitchAcceptRoutineErrorCases(t *testing.T) {
 	sw := NewSwitch(cfg, errorTransport{ErrFilterTimeout{}})
 	assert.NotPanics(t, func() {
@@ -27,10 +27,34 @@ func (ni mockNodeInfo) NetAddress() (*NetAddress, error) { return ni.addr, nil }
 func (mockNodeInfo) Validate() error                     { return nil }
 func (mockNodeInfo) CompatibleWith(NodeInfo) error       { return nil }
 
+func (ni mockNodeInfo) NetAddresses() ([]*NetAddress, error) {
+	return []*NetAddress{ni.addr}, nil
+}
+
 func AddPeerToSwitchPeerSet(sw *Switch, peer Peer) {
 	sw.peers.Add(peer) //nolint:errcheck // ignore error
 }
 
+// AddConnectedPeer adds a peer that is already running, e.g. a mock.Peer, to
+// the switch as if it had just connected: the reactors init and add it.
+func AddConnectedPeer(sw *Switch, p Peer) error {
+	sw.reactorsMtx.RLock()
+	reactors := sw.reactors
+	sw.reactorsMtx.RUnlock()
+
+	for name, reactor := range reactors {
+		p = initPeer(name, reactor, p)
+	}
+	if err := sw.peers.Add(p); err != nil {
+		return err
+	}
+	sw.metrics.Peers.Add(float64(1))
+	for _, reactor := range reactors {
+		reactor.AddPeer(p)
+	}
+	return nil
+}
+
 func CreateRandomPeer(outbound bool) Peer {
 	addr, netAddr := CreateRoutableAddr()
 	p := &peer{
@@ -187,7 +211,7 @@ func (sw *Switch) addPeerWithConnection(conn net.Conn) error {
 		return err
 	}
 
-	ni, err := handshake(conn, time.Second, sw.nodeInfo)
+	ni, err := handshake(conn, time.Second, MaxNodeInfoSize(), sw.nodeInfo)
 	if err != nil {
 		if err := conn.Close(); err != nil {
 			sw.Logger.Error("Error closing connection", "err", err)
@@ -195,6 +219,11 @@ func (sw *Switch) addPeerWithConnection(conn net.Conn) error {
 		return err
 	}
 
+	pv := negotiateProtocolVersion(sw.nodeInfo, ni)
+	options := []PeerOption{func(p *peer) { p.protocolVersion = pv }}
+	if _, ok := sw.observerPeers[ni.ID()]; ok {
+		options = append(options, PeerObserver())
+	}
 	p := newPeer(
 		pc,
 		MConnConfig(sw.config),
@@ -203,6 +232,7 @@ func (sw *Switch) addPeerWithConnection(conn net.Conn) error {
 		sw.msgTypeByChID,
 		sw.chDescs,
 		sw.StopPeerForError,
+		options...,
 	)
 
 	if err = sw.addPeer(p); err != nil {
@@ -2,16 +2,21 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/cosmos/gogoproto/proto"
 	"golang.org/x/net/netutil"
+	"golang.org/x/net/proxy"
 
 	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
 	"github.com/cometbft/cometbft/crypto"
 	"github.com/cometbft/cometbft/libs/protoio"
+	cmtsync "github.com/cometbft/cometbft/libs/sync"
 	"github.com/cometbft/cometbft/p2p/conn"
 )
 
@@ -52,6 +57,20 @@ type peerConfig struct {
 	reactorsByCh  map[byte]Reactor
 	msgTypeByChID map[byte]proto.Message
 	metrics       *Metrics
+	// see PeerLogUnknownChannel
+	logUnknownChannel bool
+	// see PeerLogReceive, nil if disabled
+	logReceive *receiveLogFilter
+	// see PeerCaptureReceive, nil if disabled
+	capture *captureConfig
+	// see PeerPriority, keyed by node ID
+	priorities map[ID]int
+	// see PeerFlushPolicy, keyed by node ID
+	flushPolicies map[ID]FlushPolicy
+	// see PeerObserver, keyed by node ID
+	observers map[ID]struct{}
+	// shared by all peers of the switch, nil if disabled
+	recvBudget *recvBudget
 }
 
 // Transport emits and connects to Peers. The implementation of Peer is left to
@@ -101,6 +120,35 @@ func ConnDuplicateIPFilter() ConnFilterFunc {
 	}
 }
 
+// AcceptPolicy is consulted for every inbound connection, before the
+// handshake and the filters, and returns an error to reject it, e.g. because
+// the node is overloaded. The connection is closed right away, so the dialing
+// peer backs off as for any other failed dial instead of taking up resources
+// for the handshake.
+type AcceptPolicy func(remote net.Addr) error
+
+// MaxGoroutinesAcceptPolicy rejects inbound connections while more than n
+// goroutines are running.
+func MaxGoroutinesAcceptPolicy(n int) AcceptPolicy {
+	return func(net.Addr) error {
+		if num := runtime.NumGoroutine(); num > n {
+			return fmt.Errorf("%d goroutines running, the maximum is %d", num, n)
+		}
+		return nil
+	}
+}
+
+// MaxPeersAcceptPolicy rejects inbound connections while peers has n peers or
+// more, inbound or outbound.
+func MaxPeersAcceptPolicy(peers IPeerSet, n int) AcceptPolicy {
+	return func(net.Addr) error {
+		if size := peers.Size(); size >= n {
+			return fmt.Errorf("%d peers connected, the maximum is %d", size, n)
+		}
+		return nil
+	}
+}
+
 // MultiplexTransportOption sets an optional parameter on the
 // MultiplexTransport.
 type MultiplexTransportOption func(*MultiplexTransport)
@@ -132,23 +180,100 @@ func MultiplexTransportMaxIncomingConnections(n int) MultiplexTransportOption {
 	return func(mt *MultiplexTransport) { mt.maxIncomingConnections = n }
 }
 
+// MultiplexTransportPeerIDPolicy sets the lists of peer IDs that are allowed
+// or denied on inbound connections. An empty allowed list allows every peer
+// that is not denied; the denied list takes precedence. Default: no policy.
+func MultiplexTransportPeerIDPolicy(allowed, denied []ID) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) {
+		mt.allowedIDs = make(map[ID]struct{}, len(allowed))
+		for _, id := range allowed {
+			mt.allowedIDs[id] = struct{}{}
+		}
+		mt.deniedIDs = make(map[ID]struct{}, len(denied))
+		for _, id := range denied {
+			mt.deniedIDs[id] = struct{}{}
+		}
+	}
+}
+
+// MultiplexTransportMaxUnknownChannels sets the maximum number of channels a
+// peer may advertise in its NodeInfo that we don't have a reactor for. Peers
+// advertising more are rejected as incompatible. Default: 0 (unlimited).
+func MultiplexTransportMaxUnknownChannels(n int) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) { mt.maxUnknownChannels = n }
+}
+
+// MultiplexTransportMaxNodeInfoSize sets the maximum size in bytes of the
+// NodeInfo a peer may send in the handshake. Default: MaxNodeInfoSize().
+func MultiplexTransportMaxNodeInfoSize(n int) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) {
+		if n > 0 {
+			mt.maxNodeInfoSize = n
+		}
+	}
+}
+
+// MultiplexTransportAcceptPolicy sets the policy consulted before upgrading
+// inbound connections, see AcceptPolicy. Default: nil (accept all).
+func MultiplexTransportAcceptPolicy(policy AcceptPolicy) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) { mt.acceptPolicy = policy }
+}
+
+// MultiplexTransportConnTimeouts sets the read and write timeouts of the
+// connections of the peers dialed and accepted, see conn.MConnConfig.ReadTimeout
+// and WriteTimeout. Default: those of the MConnConfig given to
+// NewMultiplexTransport.
+func MultiplexTransportConnTimeouts(read, write time.Duration) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) {
+		mt.mConfig.ReadTimeout = read
+		mt.mConfig.WriteTimeout = write
+	}
+}
+
+// MultiplexTransportSOCKS5Proxy makes the transport dial peers through the
+// SOCKS5 proxy at address, such as a Tor client, authenticating with username
+// and password if username is not empty. Hostnames of the peers are resolved
+// by the proxy. Accepted connections are unaffected.
+func MultiplexTransportSOCKS5Proxy(address, username, password string) MultiplexTransportOption {
+	return func(mt *MultiplexTransport) {
+		var auth *proxy.Auth
+		if username != "" {
+			auth = &proxy.Auth{User: username, Password: password}
+		}
+		// SOCKS5 never fails, and returns a dialer with DialContext.
+		dialer, _ := proxy.SOCKS5("tcp", address, auth, proxy.Direct)
+		mt.proxyDialer = dialer.(proxy.ContextDialer)
+		mt.proxyAddr = address
+	}
+}
+
 // MultiplexTransport accepts and dials tcp connections and upgrades them to
 // multiplexed peers.
 type MultiplexTransport struct {
 	netAddr                NetAddress
 	listener               net.Listener
 	maxIncomingConnections int // see MaxIncomingConnections
+	maxUnknownChannels     int // see MaxUnknownChannels
+	maxNodeInfoSize        int // see MaxNodeInfoSize
 
 	acceptc chan accept
 	closec  chan struct{}
 
 	// Lookup table for duplicate ip and id checks.
-	conns       ConnSet
-	connFilters []ConnFilterFunc
+	conns        ConnSet
+	connFilters  []ConnFilterFunc
+	acceptPolicy AcceptPolicy
+
+	// Inbound peer ID policy, see MultiplexTransportPeerIDPolicy.
+	allowedIDs map[ID]struct{}
+	deniedIDs  map[ID]struct{}
 
 	dialTimeout      time.Duration
 	filterTimeout    time.Duration
+	proxyDialer      proxy.ContextDialer // see MultiplexTransportSOCKS5Proxy
+	proxyAddr        string
 	handshakeTimeout time.Duration
+	nodeInfoMtx      cmtsync.RWMutex // AddChannel may be called while accepting or dialing
 	nodeInfo         NodeInfo
 	nodeKey          NodeKey
 	resolver         IPResolver
@@ -177,6 +302,7 @@ func NewMultiplexTransport(
 		dialTimeout:      defaultDialTimeout,
 		filterTimeout:    defaultFilterTimeout,
 		handshakeTimeout: defaultHandshakeTimeout,
+		maxNodeInfoSize:  MaxNodeInfoSize(),
 		mConfig:          mConfig,
 		nodeInfo:         nodeInfo,
 		nodeKey:          nodeKey,
@@ -202,7 +328,7 @@ func (mt *MultiplexTransport) Accept(cfg peerConfig) (Peer, error) {
 
 		cfg.outbound = false
 
-		return mt.wrapPeer(a.conn, a.nodeInfo, cfg, a.netAddr), nil
+		return mt.wrapPeer(a.conn, a.nodeInfo, cfg, a.netAddr, nil), nil
 	case <-mt.closec:
 		return nil, ErrTransportClosed{}
 	}
@@ -213,11 +339,19 @@ func (mt *MultiplexTransport) Dial(
 	addr NetAddress,
 	cfg peerConfig,
 ) (Peer, error) {
-	c, err := addr.DialTimeout(mt.dialTimeout)
+	c, err := mt.dial(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	// If addr has a Hostname, the IP we connected to is the one it resolves
+	// to now, which may not be addr.IP anymore.
+	socketAddr := addr
+	socketAddr.Hostname = ""
+	if tcpAddr, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		socketAddr.IP = tcpAddr.IP
+	}
+
 	if mt.mConfig.TestFuzz {
 		// so we have time to do peer handshakes and get set up.
 		c = FuzzConnAfterFromConfig(c, 10*time.Second, mt.mConfig.TestFuzzConfig)
@@ -235,11 +369,45 @@ func (mt *MultiplexTransport) Dial(
 
 	cfg.outbound = true
 
-	p := mt.wrapPeer(secretConn, nodeInfo, cfg, &addr)
+	p := mt.wrapPeer(secretConn, nodeInfo, cfg, &socketAddr, &addr)
 
 	return p, nil
 }
 
+// dial connects to addr, through the SOCKS5 proxy if one is set.
+func (mt *MultiplexTransport) dial(addr NetAddress) (net.Conn, error) {
+	if mt.proxyDialer == nil {
+		return addr.DialTimeout(mt.dialTimeout)
+	}
+
+	ctx := context.Background()
+	if mt.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mt.dialTimeout)
+		defer cancel()
+	}
+	host := addr.Hostname
+	if host == "" {
+		host = addr.IP.String()
+	}
+	target := net.JoinHostPort(host, strconv.FormatUint(uint64(addr.Port), 10))
+	c, err := mt.proxyDialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, ErrProxyDial{Proxy: mt.proxyAddr, Addr: target, Err: err}
+	}
+	return proxiedConn{Conn: c, remoteAddr: &net.TCPAddr{IP: addr.IP, Port: int(addr.Port)}}, nil
+}
+
+// proxiedConn is a connection to a peer through a proxy. It reports the
+// address of the peer rather than that of the proxy as its remote address,
+// for the connection filters and the peer's socket address.
+type proxiedConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c proxiedConn) RemoteAddr() net.Addr { return c.remoteAddr }
+
 // Close implements transportLifecycle.
 func (mt *MultiplexTransport) Close() error {
 	close(mt.closec)
@@ -275,6 +443,9 @@ func (mt *MultiplexTransport) Listen(addr NetAddress) error {
 // This is a bit messy at the moment but is cleaned up in the following version
 // when NodeInfo changes from an interface to a concrete type.
 func (mt *MultiplexTransport) AddChannel(chID byte) {
+	mt.nodeInfoMtx.Lock()
+	defer mt.nodeInfoMtx.Unlock()
+
 	if ni, ok := mt.nodeInfo.(DefaultNodeInfo); ok {
 		if !ni.HasChannel(chID) {
 			ni.Channels = append(ni.Channels, chID)
@@ -330,7 +501,10 @@ func (mt *MultiplexTransport) acceptPeers() {
 				netAddr    *NetAddress
 			)
 
-			err := mt.filterConn(c)
+			err := mt.checkAcceptPolicy(c)
+			if err == nil {
+				err = mt.filterConn(c)
+			}
 			if err == nil {
 				secretConn, nodeInfo, err = mt.upgrade(c, nil)
 				if err == nil {
@@ -365,6 +539,19 @@ func (mt *MultiplexTransport) cleanup(c net.Conn) error {
 	return c.Close()
 }
 
+// checkAcceptPolicy closes c, before spending anything on the handshake, if
+// the accept policy rejects it.
+func (mt *MultiplexTransport) checkAcceptPolicy(c net.Conn) error {
+	if mt.acceptPolicy == nil {
+		return nil
+	}
+	if err := mt.acceptPolicy(c.RemoteAddr()); err != nil {
+		_ = c.Close()
+		return ErrRejected{conn: c, err: err, isOverloaded: true}
+	}
+	return nil
+}
+
 func (mt *MultiplexTransport) filterConn(c net.Conn) (err error) {
 	defer func() {
 		if err != nil {
@@ -407,6 +594,18 @@ func (mt *MultiplexTransport) filterConn(c net.Conn) (err error) {
 	return nil
 }
 
+func (mt *MultiplexTransport) checkPeerIDPolicy(id ID) error {
+	if _, ok := mt.deniedIDs[id]; ok {
+		return errors.New("peer ID is denied")
+	}
+	if len(mt.allowedIDs) > 0 {
+		if _, ok := mt.allowedIDs[id]; !ok {
+			return errors.New("peer ID is not allowed")
+		}
+	}
+	return nil
+}
+
 func (mt *MultiplexTransport) upgrade(
 	c net.Conn,
 	dialedAddr *NetAddress,
@@ -443,7 +642,24 @@ func (mt *MultiplexTransport) upgrade(
 		}
 	}
 
-	nodeInfo, err = handshake(secretConn, mt.handshakeTimeout, mt.nodeInfo)
+	// For incoming conns, apply the peer ID policy before spending any more
+	// resources on the connection.
+	if dialedAddr == nil {
+		if err := mt.checkPeerIDPolicy(connID); err != nil {
+			return nil, nil, ErrRejected{
+				conn:               c,
+				id:                 connID,
+				err:                err,
+				isRejectedByPolicy: true,
+			}
+		}
+	}
+
+	mt.nodeInfoMtx.RLock()
+	ourNodeInfo := mt.nodeInfo
+	mt.nodeInfoMtx.RUnlock()
+
+	nodeInfo, err = handshake(secretConn, mt.handshakeTimeout, mt.maxNodeInfoSize, ourNodeInfo)
 	if err != nil {
 		return nil, nil, ErrRejected{
 			conn:          c,
@@ -475,7 +691,7 @@ func (mt *MultiplexTransport) upgrade(
 	}
 
 	// Reject self.
-	if mt.nodeInfo.ID() == nodeInfo.ID() {
+	if ourNodeInfo.ID() == nodeInfo.ID() {
 		return nil, nil, ErrRejected{
 			addr:   *NewNetAddress(nodeInfo.ID(), c.RemoteAddr()),
 			conn:   c,
@@ -484,7 +700,16 @@ func (mt *MultiplexTransport) upgrade(
 		}
 	}
 
-	if err := mt.nodeInfo.CompatibleWith(nodeInfo); err != nil {
+	if err := ourNodeInfo.CompatibleWith(nodeInfo); err != nil {
+		return nil, nil, ErrRejected{
+			conn:           c,
+			err:            err,
+			id:             nodeInfo.ID(),
+			isIncompatible: true,
+		}
+	}
+
+	if err := mt.checkUnknownChannels(ourNodeInfo, nodeInfo); err != nil {
 		return nil, nil, ErrRejected{
 			conn:           c,
 			err:            err,
@@ -496,16 +721,44 @@ func (mt *MultiplexTransport) upgrade(
 	return secretConn, nodeInfo, nil
 }
 
+// checkUnknownChannels returns ErrTooManyUnknownChannels if the peer
+// advertises more channels we don't know about than allowed.
+func (mt *MultiplexTransport) checkUnknownChannels(ourNodeInfo, peerNodeInfo NodeInfo) error {
+	if mt.maxUnknownChannels <= 0 {
+		return nil
+	}
+	ours, ok := ourNodeInfo.(DefaultNodeInfo)
+	if !ok {
+		return nil
+	}
+	theirs, ok := peerNodeInfo.(DefaultNodeInfo)
+	if !ok {
+		return nil
+	}
+
+	unknown := 0
+	for _, ch := range theirs.Channels {
+		if !ours.HasChannel(ch) {
+			unknown++
+		}
+	}
+	if unknown > mt.maxUnknownChannels {
+		return ErrTooManyUnknownChannels{Unknown: unknown, Max: mt.maxUnknownChannels}
+	}
+	return nil
+}
+
 func (mt *MultiplexTransport) wrapPeer(
 	c net.Conn,
 	ni NodeInfo,
 	cfg peerConfig,
 	socketAddr *NetAddress,
+	configuredAddr *NetAddress,
 ) Peer {
 	persistent := false
 	if cfg.isPersistent != nil {
 		if cfg.outbound {
-			persistent = cfg.isPersistent(socketAddr)
+			persistent = cfg.isPersistent(configuredAddr)
 		} else {
 			selfReportedAddr, err := ni.NetAddress()
 			if err == nil {
@@ -520,7 +773,34 @@ func (mt *MultiplexTransport) wrapPeer(
 		c,
 		socketAddr,
 	)
+	peerConn.configuredAddr = configuredAddr
 
+	mt.nodeInfoMtx.RLock()
+	pv := negotiateProtocolVersion(mt.nodeInfo, ni)
+	mt.nodeInfoMtx.RUnlock()
+	options := []PeerOption{
+		PeerMetrics(cfg.metrics),
+		PeerLogUnknownChannel(cfg.logUnknownChannel),
+		func(p *peer) { p.protocolVersion = pv },
+	}
+	if cfg.logReceive != nil {
+		options = append(options, func(p *peer) { p.logReceive = cfg.logReceive })
+	}
+	if cfg.capture != nil {
+		options = append(options, PeerCaptureReceive(cfg.capture.size, cfg.capture.chIDs...))
+	}
+	if level, ok := cfg.priorities[ni.ID()]; ok {
+		options = append(options, PeerPriority(level))
+	}
+	if policy, ok := cfg.flushPolicies[ni.ID()]; ok {
+		options = append(options, PeerFlushPolicy(policy))
+	}
+	if _, ok := cfg.observers[ni.ID()]; ok {
+		options = append(options, PeerObserver())
+	}
+	if cfg.recvBudget != nil {
+		options = append(options, func(p *peer) { p.recvBudget = cfg.recvBudget })
+	}
 	p := newPeer(
 		peerConn,
 		mt.mConfig,
@@ -529,15 +809,18 @@ func (mt *MultiplexTransport) wrapPeer(
 		cfg.msgTypeByChID,
 		cfg.chDescs,
 		cfg.onPeerError,
-		PeerMetrics(cfg.metrics),
+		options...,
 	)
 
 	return p
 }
 
+// handshake exchanges NodeInfos over c, rejecting the peer's with
+// ErrNodeInfoTooLarge if it announces one of more than maxNodeInfoSize bytes.
 func handshake(
 	c net.Conn,
 	timeout time.Duration,
+	maxNodeInfoSize int,
 	nodeInfo NodeInfo,
 ) (NodeInfo, error) {
 	if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
@@ -557,8 +840,12 @@ func handshake(
 		errc <- err
 	}(errc, c)
 	go func(errc chan<- error, c net.Conn) {
-		protoReader := protoio.NewDelimitedReader(c, MaxNodeInfoSize())
+		protoReader := protoio.NewDelimitedReader(c, maxNodeInfoSize)
 		_, err := protoReader.ReadMsg(&pbpeerNodeInfo)
+		var tooLarge protoio.ErrMessageTooLarge
+		if errors.As(err, &tooLarge) {
+			err = ErrNodeInfoTooLarge{Size: tooLarge.Size, Max: tooLarge.Max}
+		}
 		errc <- err
 	}(errc, c)
 
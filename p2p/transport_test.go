@@ -2,10 +2,13 @@ package p2p
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -88,6 +91,79 @@ func TestTransportMultiplexConnFilter(t *testing.T) {
 	}
 }
 
+func TestTransportMultiplexAcceptPolicy(t *testing.T) {
+	mt := newMultiplexTransport(
+		emptyNodeInfo(),
+		NodeKey{
+			PrivKey: ed25519.GenPrivKey(),
+		},
+	)
+	id := mt.nodeKey.ID()
+
+	filtered := make(chan struct{}, 1)
+	MultiplexTransportConnFilters(
+		func(_ ConnSet, _ net.Conn, _ []net.IP) error {
+			filtered <- struct{}{}
+			return nil
+		},
+	)(mt)
+	MultiplexTransportAcceptPolicy(func(net.Addr) error {
+		return errors.New("overloaded")
+	})(mt)
+
+	addr, err := NewNetAddressString(IDAddressString(id, "127.0.0.1:0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mt.Listen(*addr); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewNetAddress(id, mt.listener.Addr()).Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = mt.Accept(peerConfig{})
+	if e, ok := err.(ErrRejected); ok {
+		if !e.IsOverloaded() {
+			t.Errorf("expected peer to be rejected for overload, got %v", err)
+		}
+	} else {
+		t.Errorf("expected ErrRejected, got %v", err)
+	}
+
+	// The connection is closed without going through the filters or the
+	// handshake.
+	if err := c.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("expected connection to be closed, got %v", err)
+	}
+	select {
+	case <-filtered:
+		t.Error("connection rejected by the accept policy was filtered")
+	default:
+	}
+}
+
+func TestMaxPeersAcceptPolicy(t *testing.T) {
+	peers := NewPeerSet()
+	policy := MaxPeersAcceptPolicy(peers, 1)
+	if err := policy(nil); err != nil {
+		t.Errorf("expected connection to be accepted, got %v", err)
+	}
+	if err := peers.Add(newMockPeer(net.IP{127, 0, 0, 1})); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy(nil); err == nil {
+		t.Error("expected connection to be rejected")
+	}
+}
+
 func TestTransportMultiplexConnFilterTimeout(t *testing.T) {
 	mt := newMultiplexTransport(
 		emptyNodeInfo(),
@@ -305,7 +381,7 @@ func TestTransportMultiplexAcceptNonBlocking(t *testing.T) {
 			return
 		}
 
-		_, err = handshake(sc, 200*time.Millisecond,
+		_, err = handshake(sc, 200*time.Millisecond, MaxNodeInfoSize(),
 			testNodeInfo(
 				PubKeyToID(ed25519.GenPrivKey().PubKey()),
 				"slow_peer",
@@ -461,6 +537,202 @@ func TestTransportMultiplexDialRejectWrongID(t *testing.T) {
 	}
 }
 
+func TestTransportMultiplexDialConfiguredAddr(t *testing.T) {
+	mt := testSetupMultiplexTransport(t)
+	t.Cleanup(func() { _ = mt.Close() })
+
+	pv := ed25519.GenPrivKey()
+	dialer := newMultiplexTransport(
+		testNodeInfo(PubKeyToID(pv.PubKey()), defaultNodeName),
+		NodeKey{PrivKey: pv},
+	)
+
+	// A hostname whose IP changed since it was last resolved.
+	addr := *NewNetAddress(mt.nodeKey.ID(), mt.listener.Addr())
+	addr.IP = net.ParseIP("10.255.255.1")
+	addr.Hostname = "localhost"
+
+	p, err := dialer.Dial(addr, peerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := *p.ConfiguredAddr(), addr; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	socketAddr := p.SocketAddr()
+	if have, want := socketAddr.String(), IDAddressString(addr.ID, mt.listener.Addr().String()); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	if socketAddr.Hostname != "" {
+		t.Errorf("socket address has hostname %q", socketAddr.Hostname)
+	}
+
+	p, err = mt.Accept(peerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ConfiguredAddr() != nil {
+		t.Errorf("inbound peer has configured address %v", p.ConfiguredAddr())
+	}
+}
+
+func TestTransportMultiplexDialSOCKS5Proxy(t *testing.T) {
+	mt := testSetupMultiplexTransport(t)
+	t.Cleanup(func() { _ = mt.Close() })
+	addr := *NewNetAddress(mt.nodeKey.ID(), mt.listener.Addr())
+
+	proxyAddr, targets := testSOCKS5Proxy(t, 0x00)
+	pv := ed25519.GenPrivKey()
+	dialer := newMultiplexTransport(
+		testNodeInfo(PubKeyToID(pv.PubKey()), defaultNodeName),
+		NodeKey{PrivKey: pv},
+	)
+	MultiplexTransportSOCKS5Proxy(proxyAddr, "", "")(dialer)
+
+	p, err := dialer.Dial(addr, peerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := <-targets, addr.DialString(); have != want {
+		t.Errorf("proxy connected to %v, want %v", have, want)
+	}
+	// The peer's address is the one dialed, not the proxy's.
+	if have, want := p.SocketAddr().String(), addr.String(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	if _, err := mt.Accept(peerConfig{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransportMultiplexDialSOCKS5ProxyErrors(t *testing.T) {
+	mt := testSetupMultiplexTransport(t)
+	t.Cleanup(func() { _ = mt.Close() })
+	addr := *NewNetAddress(mt.nodeKey.ID(), mt.listener.Addr())
+
+	// The proxy can't reach the peer.
+	refusingProxy, _ := testSOCKS5Proxy(t, 0x05)
+	// Nothing listens on the proxy's address anymore.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedProxy := ln.Addr().String()
+	ln.Close()
+
+	for _, proxyAddr := range []string{refusingProxy, closedProxy} {
+		pv := ed25519.GenPrivKey()
+		dialer := newMultiplexTransport(
+			testNodeInfo(PubKeyToID(pv.PubKey()), defaultNodeName),
+			NodeKey{PrivKey: pv},
+		)
+		MultiplexTransportSOCKS5Proxy(proxyAddr, "", "")(dialer)
+
+		_, err := dialer.Dial(addr, peerConfig{})
+		var e ErrProxyDial
+		if !errors.As(err, &e) {
+			t.Fatalf("expected ErrProxyDial, got %v", err)
+		}
+		if e.Proxy != proxyAddr {
+			t.Errorf("have proxy %v, want %v", e.Proxy, proxyAddr)
+		}
+	}
+}
+
+// testSOCKS5Proxy runs a SOCKS5 proxy which sends the address of each
+// connection it is asked for on targets. If reply is not 0, it fails the
+// requests with it as the reply code instead of connecting.
+func testSOCKS5Proxy(t *testing.T, reply byte) (addr string, targets <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	targetc := make(chan string, 1)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				target, err := readSOCKS5Request(c)
+				if err != nil {
+					return
+				}
+				if reply != 0x00 {
+					_, _ = c.Write([]byte{0x05, reply, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				targetc <- target
+				tc, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer tc.Close()
+				if _, err := c.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+					return
+				}
+				go func() { _, _ = io.Copy(tc, c) }()
+				_, _ = io.Copy(c, tc)
+			}()
+		}
+	}()
+	return ln.Addr().String(), targetc
+}
+
+// readSOCKS5Request accepts the connection without authentication and
+// returns the address the client asks to connect to.
+func readSOCKS5Request(c net.Conn) (string, error) {
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(c, greeting); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(c, make([]byte, greeting[1])); err != nil {
+		return "", err
+	}
+	if _, err := c.Write([]byte{0x05, 0x00}); err != nil {
+		return "", err
+	}
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(c, req); err != nil {
+		return "", err
+	}
+	var host string
+	switch req[3] {
+	case 0x01, 0x04:
+		ip := make(net.IP, 4)
+		if req[3] == 0x04 {
+			ip = make(net.IP, 16)
+		}
+		if _, err := io.ReadFull(c, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case 0x03:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(c, n); err != nil {
+			return "", err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(c, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", fmt.Errorf("unknown address type %d", req[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(c, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))), nil
+}
+
 func TestTransportMultiplexRejectIncompatible(t *testing.T) {
 	mt := testSetupMultiplexTransport(t)
 
@@ -497,6 +769,137 @@ func TestTransportMultiplexRejectIncompatible(t *testing.T) {
 	}
 }
 
+func TestTransportMultiplexNegotiateP2PVersion(t *testing.T) {
+	mt := testSetupMultiplexTransport(t)
+	t.Cleanup(func() { _ = mt.Close() })
+
+	pv := ed25519.GenPrivKey()
+	ni := testNodeInfo(PubKeyToID(pv.PubKey()), "dialer").(DefaultNodeInfo)
+	ni.ProtocolVersion.P2P = defaultProtocolVersion.P2P + 1
+	dialer := newMultiplexTransport(ni, NodeKey{PrivKey: pv})
+
+	addr := NewNetAddress(mt.nodeKey.ID(), mt.listener.Addr())
+	dialed, err := dialer.Dial(*addr, peerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := mt.Accept(peerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The newer dialer speaks the version of the listener, and the listener
+	// keeps its own.
+	want := defaultProtocolVersion
+	if have := dialed.ProtocolVersion(); have != want {
+		t.Errorf("dialer: have %v, want %v", have, want)
+	}
+	if have := accepted.ProtocolVersion(); have != want {
+		t.Errorf("listener: have %v, want %v", have, want)
+	}
+	if have, want := accepted.NodeInfo().(DefaultNodeInfo).ProtocolVersion.P2P, ni.ProtocolVersion.P2P; have != want {
+		t.Errorf("advertised version: have %d, want %d", have, want)
+	}
+}
+
+func TestTransportMultiplexPeerIDPolicy(t *testing.T) {
+	dialerKey := ed25519.GenPrivKey()
+	dialerID := PubKeyToID(dialerKey.PubKey())
+	otherID := PubKeyToID(ed25519.GenPrivKey().PubKey())
+
+	testCases := []struct {
+		name     string
+		allowed  []ID
+		denied   []ID
+		rejected bool
+	}{
+		{"no policy", nil, nil, false},
+		{"allowed", []ID{otherID, dialerID}, nil, false},
+		{"not allowed", []ID{otherID}, nil, true},
+		{"denied", nil, []ID{dialerID}, true},
+		{"other denied", nil, []ID{otherID}, false},
+		{"denied takes precedence", []ID{dialerID}, []ID{dialerID}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pv := ed25519.GenPrivKey()
+			id := PubKeyToID(pv.PubKey())
+			mt := newMultiplexTransport(testNodeInfo(id, "transport"), NodeKey{PrivKey: pv})
+			MultiplexTransportPeerIDPolicy(tc.allowed, tc.denied)(mt)
+
+			addr, err := NewNetAddressString(IDAddressString(id, "127.0.0.1:0"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mt.Listen(*addr); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = mt.Close() })
+
+			go func() {
+				dialer := newMultiplexTransport(
+					testNodeInfo(dialerID, defaultNodeName),
+					NodeKey{PrivKey: dialerKey},
+				)
+				_, _ = dialer.Dial(*NewNetAddress(id, mt.listener.Addr()), peerConfig{})
+			}()
+
+			_, err = mt.Accept(peerConfig{})
+			if !tc.rejected {
+				if err != nil {
+					t.Errorf("expected peer to be accepted, got %v", err)
+				}
+				return
+			}
+			if e, ok := err.(ErrRejected); ok {
+				if !e.IsRejectedByPolicy() {
+					t.Errorf("expected peer to be rejected by policy, got %v", e)
+				}
+			} else {
+				t.Errorf("expected ErrRejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransportMultiplexMaxUnknownChannels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		channels []byte
+		rejected bool
+	}{
+		{"known only", []byte{testCh}, false},
+		{"within limit", []byte{testCh, 0x10, 0x11}, false},
+		{"over limit", []byte{testCh, 0x10, 0x11, 0x12}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := testSetupMultiplexTransport(t)
+			MultiplexTransportMaxUnknownChannels(2)(mt)
+
+			go func() {
+				pv := ed25519.GenPrivKey()
+				ni := testNodeInfo(PubKeyToID(pv.PubKey()), defaultNodeName).(DefaultNodeInfo)
+				ni.Channels = tc.channels
+				dialer := newMultiplexTransport(ni, NodeKey{PrivKey: pv})
+				_, _ = dialer.Dial(*NewNetAddress(mt.nodeKey.ID(), mt.listener.Addr()), peerConfig{})
+			}()
+
+			_, err := mt.Accept(peerConfig{})
+			if !tc.rejected {
+				if err != nil {
+					t.Errorf("expected peer to be accepted, got %v", err)
+				}
+				return
+			}
+			var unknownErr ErrTooManyUnknownChannels
+			if e, ok := err.(ErrRejected); !ok || !e.IsIncompatible() || !errors.As(e.err, &unknownErr) {
+				t.Errorf("expected peer to be rejected for unknown channels, got %v", err)
+			}
+		})
+	}
+}
+
 func TestTransportMultiplexRejectSelf(t *testing.T) {
 	mt := testSetupMultiplexTransport(t)
 
@@ -607,7 +1010,7 @@ func TestTransportHandshake(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	ni, err := handshake(c, 20*time.Millisecond, emptyNodeInfo())
+	ni, err := handshake(c, 20*time.Millisecond, MaxNodeInfoSize(), emptyNodeInfo())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -617,6 +1020,27 @@ func TestTransportHandshake(t *testing.T) {
 	}
 }
 
+func TestTransportHandshakeNodeInfoTooLarge(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	bloated := testNodeInfo(PubKeyToID(ed25519.GenPrivKey().PubKey()), "bloated").(DefaultNodeInfo)
+	bloated.Moniker = strings.Repeat("x", 2048)
+	go func() {
+		_, _ = handshake(c2, time.Second, MaxNodeInfoSize(), bloated)
+	}()
+
+	_, err := handshake(c1, time.Second, 1024, emptyNodeInfo())
+	var tooLarge ErrNodeInfoTooLarge
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected ErrNodeInfoTooLarge, got %v", err)
+	}
+	if tooLarge.Max != 1024 || tooLarge.Size <= 2048 {
+		t.Errorf("have %+v, want a size over 2048 and a max of 1024", tooLarge)
+	}
+}
+
 func TestTransportAddChannel(t *testing.T) {
 	mt := newMultiplexTransport(
 		emptyNodeInfo(),
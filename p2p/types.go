@@ -1,6 +1,8 @@
 package p2p
 
 import (
+	"reflect"
+
 	"github.com/cosmos/gogoproto/proto"
 
 	tmp2p "github.com/cometbft/cometbft/api/cometbft/p2p/v1"
@@ -11,6 +13,15 @@ import (
 type (
 	ChannelDescriptor = conn.ChannelDescriptor
 	ConnectionStatus  = conn.ConnectionStatus
+	SecurityInfo      = conn.SecurityInfo
+	OverflowPolicy    = conn.OverflowPolicy
+)
+
+// Overflow policies for ChannelDescriptor.OverflowPolicy.
+const (
+	OverflowBlock      = conn.OverflowBlock
+	OverflowDropNewest = conn.OverflowDropNewest
+	OverflowDropOldest = conn.OverflowDropOldest
 )
 
 // Envelope contains a message with sender routing info.
@@ -18,6 +29,33 @@ type Envelope struct {
 	Src       Peer          // sender (empty if outbound)
 	Message   proto.Message // message payload
 	ChannelID byte
+
+	// Metadata carries optional in-process annotations, e.g. to let a
+	// wrapping reactor route a message without parsing it again. It is never
+	// sent over the wire, and envelopes received from peers have none.
+	Metadata map[string]string
+}
+
+// Validate performs basic sanity checks on the envelope: Message must be set
+// and, if Src is set, it must not be a nil peer. Any ChannelID is accepted,
+// as 0x00 is a valid channel (used by PEX).
+func (e Envelope) Validate() error {
+	if isNil(e.Message) {
+		return ErrEnvelopeNilMessage{ChannelID: e.ChannelID}
+	}
+	if e.Src != nil && isNil(e.Src) {
+		return ErrEnvelopeNilSrc{ChannelID: e.ChannelID}
+	}
+	return nil
+}
+
+// isNil returns true if v is nil or an interface holding a nil pointer.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
 }
 
 var (
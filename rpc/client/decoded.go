@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+)
+
+// DecodedEvent is an ABCI event with its attributes collected by key.
+type DecodedEvent struct {
+	Type string
+	// Values of each attribute, in the order the application emitted them.
+	// Events may repeat a key, hence a slice.
+	Attributes map[string][]string
+}
+
+// Get returns the first value of the attribute key, if any.
+func (e DecodedEvent) Get(key string) (string, bool) {
+	values := e.Attributes[key]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// Int64 returns the first value of the attribute key parsed as an int64.
+func (e DecodedEvent) Int64(key string) (int64, error) {
+	value, err := e.mustGet(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("attribute %q of event %q: %w", key, e.Type, err)
+	}
+	return n, nil
+}
+
+// Uint64 returns the first value of the attribute key parsed as a uint64.
+func (e DecodedEvent) Uint64(key string) (uint64, error) {
+	value, err := e.mustGet(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("attribute %q of event %q: %w", key, e.Type, err)
+	}
+	return n, nil
+}
+
+// Bool returns the first value of the attribute key parsed as a bool.
+func (e DecodedEvent) Bool(key string) (bool, error) {
+	value, err := e.mustGet(key)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("attribute %q of event %q: %w", key, e.Type, err)
+	}
+	return b, nil
+}
+
+func (e DecodedEvent) mustGet(key string) (string, error) {
+	value, ok := e.Get(key)
+	if !ok {
+		return "", fmt.Errorf("event %q has no attribute %q", e.Type, key)
+	}
+	return value, nil
+}
+
+// DecodedEvents holds events indexed by type. Events of the same type are
+// kept in the order they were emitted.
+type DecodedEvents map[string][]DecodedEvent
+
+// DecodeEvents decodes events and indexes them by type.
+func DecodeEvents(events []abci.Event) DecodedEvents {
+	decoded := make(DecodedEvents)
+	for _, ev := range events {
+		attrs := make(map[string][]string, len(ev.Attributes))
+		for _, attr := range ev.Attributes {
+			attrs[attr.Key] = append(attrs[attr.Key], attr.Value)
+		}
+		decoded[ev.Type] = append(decoded[ev.Type], DecodedEvent{Type: ev.Type, Attributes: attrs})
+	}
+	return decoded
+}
+
+// DecodedTxResult is the result of executing a transaction, with its events
+// decoded.
+type DecodedTxResult struct {
+	Result *abci.ExecTxResult
+	Events DecodedEvents
+}
+
+// DecodedBlockResults is ctypes.ResultBlockResults with all events decoded,
+// see BlockResultsDecoded.
+type DecodedBlockResults struct {
+	Height                int64
+	TxResults             []DecodedTxResult
+	FinalizeBlockEvents   DecodedEvents
+	ValidatorUpdates      []abci.ValidatorUpdate
+	ConsensusParamUpdates *cmtproto.ConsensusParams
+	AppHash               []byte
+}
+
+// BlockResultsDecoded calls BlockResults for the block at height, or the
+// latest one if height is nil, and decodes the events of the block and of
+// each of its transactions.
+func BlockResultsDecoded(ctx context.Context, c SignClient, height *int64) (*DecodedBlockResults, error) {
+	res, err := c.BlockResults(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	txResults := make([]DecodedTxResult, len(res.TxResults))
+	for i, txRes := range res.TxResults {
+		txResults[i] = DecodedTxResult{Result: txRes}
+		if txRes != nil {
+			txResults[i].Events = DecodeEvents(txRes.Events)
+		}
+	}
+	return &DecodedBlockResults{
+		Height:                res.Height,
+		TxResults:             txResults,
+		FinalizeBlockEvents:   DecodeEvents(res.FinalizeBlockEvents),
+		ValidatorUpdates:      res.ValidatorUpdates,
+		ConsensusParamUpdates: res.ConsensusParamUpdates,
+		AppHash:               res.AppHash,
+	}, nil
+}
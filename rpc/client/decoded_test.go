@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/rpc/client"
+	"github.com/cometbft/cometbft/rpc/client/mocks"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+func TestBlockResultsDecoded(t *testing.T) {
+	transfer := func(amount string) abci.Event {
+		return abci.Event{Type: "transfer", Attributes: []abci.EventAttribute{
+			{Key: "recipient", Value: "alice", Index: true},
+			{Key: "amount", Value: amount},
+			{Key: "recipient", Value: "bob"},
+		}}
+	}
+	c := &mocks.Client{}
+	c.On("BlockResults", mock.Anything, mock.Anything).Return(&ctypes.ResultBlockResults{
+		Height: 7,
+		TxResults: []*abci.ExecTxResult{
+			{Code: 0, Events: []abci.Event{transfer("10"), transfer("-3"), {Type: "message"}}},
+			{Code: 1},
+		},
+		FinalizeBlockEvents: []abci.Event{{Type: "rewards", Attributes: []abci.EventAttribute{{Key: "paid", Value: "true"}}}},
+	}, nil)
+
+	res, err := client.BlockResultsDecoded(context.Background(), c, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, res.Height)
+	require.Len(t, res.TxResults, 2)
+
+	events := res.TxResults[0].Events
+	require.Len(t, events["transfer"], 2)
+	assert.Len(t, events["message"], 1)
+	assert.Equal(t, []string{"alice", "bob"}, events["transfer"][0].Attributes["recipient"])
+	recipient, ok := events["transfer"][0].Get("recipient")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", recipient)
+	amount, err := events["transfer"][1].Int64("amount")
+	require.NoError(t, err)
+	assert.EqualValues(t, -3, amount)
+	_, err = events["transfer"][1].Uint64("amount")
+	require.Error(t, err)
+	_, err = events["transfer"][0].Int64("missing")
+	require.Error(t, err)
+
+	assert.EqualValues(t, 1, res.TxResults[1].Result.Code)
+	assert.Empty(t, res.TxResults[1].Events)
+
+	paid, err := res.FinalizeBlockEvents["rewards"][0].Bool("paid")
+	require.NoError(t, err)
+	assert.True(t, paid)
+}
@@ -95,6 +95,44 @@ func TestBlockEvents(t *testing.T) {
 	}
 }
 
+// subscribe to ABCI responses and make sure they are streamed in height order.
+func TestABCIResponsesSubscription(t *testing.T) {
+	for _, c := range GetClients() {
+		t.Run(reflect.TypeOf(c).String(), func(t *testing.T) {
+			if !c.IsRunning() {
+				err := c.Start()
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					if err := c.Stop(); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), waitForEventTimeout)
+			defer cancel()
+
+			resCh, err := client.SubscribeABCIResponses(ctx, c, "TestABCIResponsesSubscription")
+			require.NoError(t, err)
+
+			var firstHeight int64
+			for i := int64(0); i < 2; i++ {
+				select {
+				case res := <-resCh:
+					require.NotNil(t, res)
+					if firstHeight == 0 {
+						firstHeight = res.Height
+					}
+					require.Equal(t, firstHeight+i, res.Height)
+					require.NotEmpty(t, res.AppHash)
+				case <-ctx.Done():
+					t.Fatal("timed out waiting for ABCI responses")
+				}
+			}
+		})
+	}
+}
+
 func TestTxEventsSentWithBroadcastTxAsync(t *testing.T) { testTxEventsSent(t, "async") }
 func TestTxEventsSentWithBroadcastTxSync(t *testing.T)  { testTxEventsSent(t, "sync") }
 
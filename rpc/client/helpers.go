@@ -2,13 +2,20 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/cometbft/cometbft/types"
 )
 
 const WaitThreshold = 10
 
+// blockRangeWorkers is the number of Block requests BlockRange keeps in
+// flight at once.
+const blockRangeWorkers = 8
+
 // Waiter is informed of current height, decided whether to quit early.
 type Waiter func(delta int64) (abort error)
 
@@ -84,3 +91,111 @@ func WaitForOneEvent(c EventsClient, evtTyp string, timeout time.Duration) (type
 		return nil, ErrEventTimeout
 	}
 }
+
+// SubscribeABCIResponses subscribes to the FinalizeBlock responses of every
+// committed block and streams them on the returned channel until ctx is
+// done, at which point the subscription is removed and the channel closed.
+func SubscribeABCIResponses(ctx context.Context, c EventsClient, subscriber string) (<-chan *ctypes.ResultFinalizeBlock, error) {
+	query := types.EventQueryFinalizeBlock.String()
+	eventCh, err := c.Subscribe(ctx, subscriber, query)
+	if err != nil {
+		return nil, ErrSubscribe{Source: err}
+	}
+
+	out := make(chan *ctypes.ResultFinalizeBlock)
+	go func() {
+		defer close(out)
+		defer func() {
+			// ctx is already done at this point, so use a fresh one.
+			_ = c.Unsubscribe(context.Background(), subscriber, query)
+		}()
+		for {
+			select {
+			case event, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				data, ok := event.Data.(types.EventDataFinalizeBlock)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- ctypes.NewResultFinalizeBlock(data):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// BlockRange fetches the blocks at heights from through to (inclusive),
+// issuing several requests concurrently. It returns the blocks it could
+// fetch in ascending height order and, keyed by height, the error for each
+// one it could not, so callers can carry on with what is available. The
+// error map is nil if every block was fetched.
+func BlockRange(ctx context.Context, c SignClient, from, to int64) ([]*ctypes.ResultBlock, map[int64]error) {
+	if from > to {
+		return nil, nil
+	}
+
+	var (
+		results = make([]*ctypes.ResultBlock, to-from+1)
+		errs    map[int64]error
+		errsMtx sync.Mutex
+		wg      sync.WaitGroup
+	)
+	heights := make(chan int64)
+	for i := 0; i < blockRangeWorkers && int64(i) <= to-from; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range heights {
+				res, err := fetchBlock(ctx, c, h)
+				if err != nil {
+					errsMtx.Lock()
+					if errs == nil {
+						errs = make(map[int64]error)
+					}
+					errs[h] = err
+					errsMtx.Unlock()
+					continue
+				}
+				results[h-from] = res
+			}
+		}()
+	}
+	for h := from; h <= to; h++ {
+		heights <- h
+	}
+	close(heights)
+	wg.Wait()
+
+	blocks := make([]*ctypes.ResultBlock, 0, len(results))
+	for _, res := range results {
+		if res != nil {
+			blocks = append(blocks, res)
+		}
+	}
+	return blocks, errs
+}
+
+func fetchBlock(ctx context.Context, c SignClient, height int64) (*ctypes.ResultBlock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, err := c.Block(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.Block == nil {
+		return nil, fmt.Errorf("no block returned for height %d", height)
+	}
+	if res.Block.Height != height {
+		return nil, fmt.Errorf("requested block at height %d, got %d", height, res.Block.Height)
+	}
+	return res, nil
+}
@@ -1,27 +1,31 @@
 package client_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cometbft/cometbft/rpc/client"
-	"github.com/cometbft/cometbft/rpc/client/mock"
+	clientmock "github.com/cometbft/cometbft/rpc/client/mock"
+	"github.com/cometbft/cometbft/rpc/client/mocks"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
 )
 
 func TestWaitForHeight(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	// test with error result - immediate failure
-	m := &mock.StatusMock{
-		Call: mock.Call{
+	m := &clientmock.StatusMock{
+		Call: clientmock.Call{
 			Error: errors.New("bye"),
 		},
 	}
-	r := mock.NewStatusRecorder(m)
+	r := clientmock.NewStatusRecorder(m)
 
 	// connection failure always leads to error
 	err := client.WaitForHeight(r, 8, nil)
@@ -31,7 +35,7 @@ func TestWaitForHeight(t *testing.T) {
 	require.Len(r.Calls, 1)
 
 	// now set current block height to 10
-	m.Call = mock.Call{
+	m.Call = clientmock.Call{
 		Response: &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: 10}},
 	}
 
@@ -75,3 +79,42 @@ func TestWaitForHeight(t *testing.T) {
 	require.True(ok)
 	assert.Equal(int64(15), postr.SyncInfo.LatestBlockHeight)
 }
+
+func TestBlockRange(t *testing.T) {
+	errMissing := errors.New("missing")
+	c := &mocks.Client{}
+	c.On("Block", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, h *int64) *ctypes.ResultBlock {
+			if *h%5 == 0 {
+				return nil
+			}
+			return &ctypes.ResultBlock{Block: &types.Block{Header: types.Header{Height: *h}}}
+		},
+		func(_ context.Context, h *int64) error {
+			if *h%5 == 0 {
+				return errMissing
+			}
+			return nil
+		},
+	)
+
+	blocks, errs := client.BlockRange(context.Background(), c, 1, 23)
+	require.Len(t, blocks, 19)
+	for i := 1; i < len(blocks); i++ {
+		assert.Less(t, blocks[i-1].Block.Height, blocks[i].Block.Height)
+	}
+	require.Len(t, errs, 4)
+	for _, h := range []int64{5, 10, 15, 20} {
+		assert.ErrorIs(t, errs[h], errMissing)
+	}
+
+	blocks, errs = client.BlockRange(context.Background(), c, 1, 4)
+	assert.Len(t, blocks, 4)
+	assert.Nil(t, errs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	blocks, errs = client.BlockRange(ctx, c, 1, 3)
+	assert.Empty(t, blocks)
+	assert.Len(t, errs, 3)
+}
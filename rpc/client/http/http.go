@@ -299,6 +299,19 @@ func (c *baseRPCClient) UnconfirmedTx(
 	return result, nil
 }
 
+func (c *baseRPCClient) UnconfirmedTxStatus(
+	ctx context.Context,
+	hash []byte,
+) (*ctypes.ResultTxStatus, error) {
+	result := new(ctypes.ResultTxStatus)
+	params := map[string]any{"hash": hash}
+	_, err := c.caller.Call(ctx, "unconfirmed_tx_status", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *baseRPCClient) UnconfirmedTxs(
 	ctx context.Context,
 	limit *int,
@@ -342,6 +355,15 @@ func (c *baseRPCClient) NetInfo(ctx context.Context) (*ctypes.ResultNetInfo, err
 	return result, nil
 }
 
+func (c *baseRPCClient) NetInfoDetailed(ctx context.Context) (*ctypes.ResultNetInfoDetailed, error) {
+	result := new(ctypes.ResultNetInfoDetailed)
+	_, err := c.caller.Call(ctx, "net_info_detailed", map[string]any{}, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *baseRPCClient) DumpConsensusState(ctx context.Context) (*ctypes.ResultDumpConsensusState, error) {
 	result := new(ctypes.ResultDumpConsensusState)
 	_, err := c.caller.Call(ctx, "dump_consensus_state", map[string]any{}, result)
@@ -109,7 +109,13 @@ type StatusClient interface {
 // usually.
 type NetworkClient interface {
 	NetInfo(ctx context.Context) (*ctypes.ResultNetInfo, error)
+	// NetInfoDetailed is like NetInfo, with statistics on the connection to
+	// each peer: bytes sent and received, uptime, latency and features.
+	NetInfoDetailed(ctx context.Context) (*ctypes.ResultNetInfoDetailed, error)
+	// DumpConsensusState returns the full consensus state, including the
+	// round state of every peer. Useful for debugging a stuck network.
 	DumpConsensusState(ctx context.Context) (*ctypes.ResultDumpConsensusState, error)
+	// ConsensusState returns a lighter summary of our own round state.
 	ConsensusState(ctx context.Context) (*ctypes.ResultConsensusState, error)
 	ConsensusParams(ctx context.Context, height *int64) (*ctypes.ResultConsensusParams, error)
 	Health(ctx context.Context) (*ctypes.ResultHealth, error)
@@ -135,6 +141,7 @@ type EventsClient interface {
 // MempoolClient shows us data about current mempool state.
 type MempoolClient interface {
 	UnconfirmedTx(ctx context.Context, hash []byte) (*ctypes.ResultUnconfirmedTx, error)
+	UnconfirmedTxStatus(ctx context.Context, hash []byte) (*ctypes.ResultTxStatus, error)
 	UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error)
 	NumUnconfirmedTxs(ctx context.Context) (*ctypes.ResultUnconfirmedTxs, error)
 	CheckTx(ctx context.Context, tx types.Tx) (*ctypes.ResultCheckTx, error)
@@ -114,6 +114,10 @@ func (c *Local) UnconfirmedTx(_ context.Context, hash []byte) (*ctypes.ResultUnc
 	return c.env.UnconfirmedTx(c.ctx, hash)
 }
 
+func (c *Local) UnconfirmedTxStatus(_ context.Context, hash []byte) (*ctypes.ResultTxStatus, error) {
+	return c.env.UnconfirmedTxStatus(c.ctx, hash)
+}
+
 func (c *Local) UnconfirmedTxs(_ context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
 	return c.env.UnconfirmedTxs(c.ctx, limit)
 }
@@ -130,6 +134,10 @@ func (c *Local) NetInfo(context.Context) (*ctypes.ResultNetInfo, error) {
 	return c.env.NetInfo(c.ctx)
 }
 
+func (c *Local) NetInfoDetailed(context.Context) (*ctypes.ResultNetInfoDetailed, error) {
+	return c.env.NetInfoDetailed(c.ctx)
+}
+
 func (c *Local) DumpConsensusState(context.Context) (*ctypes.ResultDumpConsensusState, error) {
 	return c.env.DumpConsensusState(c.ctx)
 }
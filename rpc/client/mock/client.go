@@ -124,6 +124,10 @@ func (c Client) NetInfo(_ context.Context) (*ctypes.ResultNetInfo, error) {
 	return c.env.NetInfo(&rpctypes.Context{})
 }
 
+func (c Client) NetInfoDetailed(_ context.Context) (*ctypes.ResultNetInfoDetailed, error) {
+	return c.env.NetInfoDetailed(&rpctypes.Context{})
+}
+
 func (c Client) ConsensusState(_ context.Context) (*ctypes.ResultConsensusState, error) {
 	return c.env.GetConsensusState(&rpctypes.Context{})
 }
@@ -565,6 +565,29 @@ func (_m *Client) NetInfo(_a0 context.Context) (*coretypes.ResultNetInfo, error)
 	return r0, r1
 }
 
+// NetInfoDetailed provides a mock function with given fields: _a0
+func (_m *Client) NetInfoDetailed(_a0 context.Context) (*coretypes.ResultNetInfoDetailed, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *coretypes.ResultNetInfoDetailed
+	if rf, ok := ret.Get(0).(func(context.Context) *coretypes.ResultNetInfoDetailed); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coretypes.ResultNetInfoDetailed)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NumUnconfirmedTxs provides a mock function with given fields: _a0
 func (_m *Client) NumUnconfirmedTxs(_a0 context.Context) (*coretypes.ResultUnconfirmedTxs, error) {
 	ret := _m.Called(_a0)
@@ -820,6 +843,29 @@ func (_m *Client) UnconfirmedTx(ctx context.Context, hash []byte) (*coretypes.Re
 	return r0, r1
 }
 
+// UnconfirmedTxStatus provides a mock function with given fields: ctx, hash
+func (_m *Client) UnconfirmedTxStatus(ctx context.Context, hash []byte) (*coretypes.ResultTxStatus, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *coretypes.ResultTxStatus
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *coretypes.ResultTxStatus); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coretypes.ResultTxStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UnconfirmedTxs provides a mock function with given fields: ctx, limit
 func (_m *Client) UnconfirmedTxs(ctx context.Context, limit *int) (*coretypes.ResultUnconfirmedTxs, error) {
 	ret := _m.Called(ctx, limit)
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// RetryPolicy configures WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a call is made before giving up,
+	// including the first one. Values below 2 disable retrying.
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry. It doubles
+	// after every attempt, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// IsTransient reports whether a call that failed with the error should be
+	// retried. Defaults to IsTransientError.
+	IsTransient func(error) bool
+}
+
+// DefaultRetryPolicy returns a policy that makes up to 5 attempts over about
+// 1.5 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+}
+
+// IsTransientError reports whether err is a network error that may go away
+// if the call is retried: a timeout, a refused or reset connection, or a
+// connection closed mid-response. Errors returned by the node itself, e.g. for
+// a height that is not available, are not transient.
+func IsTransientError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// WithRetry returns a Client that retries the idempotent calls Block,
+// BlockByHash, BlockResults, Commit, Header, Status, Validators and Tx on
+// transient errors, waiting between attempts as configured by policy. All
+// other calls, in particular the broadcasts, are passed to c unchanged and
+// never retried. Retrying stops early if the call's context is done.
+func WithRetry(c Client, policy RetryPolicy) Client {
+	if policy.IsTransient == nil {
+		policy.IsTransient = IsTransientError
+	}
+	return &retryClient{Client: c, policy: policy}
+}
+
+type retryClient struct {
+	Client
+	policy RetryPolicy
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// transient, or the policy runs out of attempts. It returns the last error.
+func (c *retryClient) retry(ctx context.Context, fn func() error) error {
+	backoff := c.policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.policy.MaxAttempts || !c.policy.IsTransient(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if c.policy.MaxBackoff > 0 && backoff > c.policy.MaxBackoff {
+			backoff = c.policy.MaxBackoff
+		}
+	}
+}
+
+func (c *retryClient) Block(ctx context.Context, height *int64) (res *ctypes.ResultBlock, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Block(ctx, height)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) BlockByHash(ctx context.Context, hash []byte) (res *ctypes.ResultBlock, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.BlockByHash(ctx, hash)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) BlockResults(ctx context.Context, height *int64) (res *ctypes.ResultBlockResults, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.BlockResults(ctx, height)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) Commit(ctx context.Context, height *int64) (res *ctypes.ResultCommit, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Commit(ctx, height)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) Header(ctx context.Context, height *int64) (res *ctypes.ResultHeader, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Header(ctx, height)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) Status(ctx context.Context) (res *ctypes.ResultStatus, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Status(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) Validators(ctx context.Context, height *int64, page, perPage *int) (res *ctypes.ResultValidators, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Validators(ctx, height, page, perPage)
+		return err
+	})
+	return res, err
+}
+
+func (c *retryClient) Tx(ctx context.Context, hash []byte, prove bool) (res *ctypes.ResultTx, err error) {
+	err = c.retry(ctx, func() error {
+		res, err = c.Client.Tx(ctx, hash, prove)
+		return err
+	})
+	return res, err
+}
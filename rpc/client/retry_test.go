@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cometbft/cometbft/rpc/client"
+	"github.com/cometbft/cometbft/rpc/client/mocks"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+)
+
+func TestWithRetry(t *testing.T) {
+	transient := fmt.Errorf("post failed: %w", syscall.ECONNREFUSED)
+	policy := client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		m := &mocks.Client{}
+		status := &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: 5}}
+		m.On("Status", mock.Anything).Return(nil, transient).Twice()
+		m.On("Status", mock.Anything).Return(status, nil).Once()
+
+		res, err := client.WithRetry(m, policy).Status(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, status, res)
+		m.AssertNumberOfCalls(t, "Status", 3)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		m := &mocks.Client{}
+		m.On("Block", mock.Anything, mock.Anything).Return(nil, transient)
+
+		_, err := client.WithRetry(m, policy).Block(context.Background(), nil)
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		m.AssertNumberOfCalls(t, "Block", 3)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		m := &mocks.Client{}
+		m.On("Tx", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("tx not found"))
+
+		_, err := client.WithRetry(m, policy).Tx(context.Background(), []byte{1}, false)
+		require.Error(t, err)
+		m.AssertNumberOfCalls(t, "Tx", 1)
+	})
+
+	t.Run("never retries broadcasts", func(t *testing.T) {
+		m := &mocks.Client{}
+		m.On("BroadcastTxSync", mock.Anything, mock.Anything).Return(nil, transient)
+
+		_, err := client.WithRetry(m, policy).BroadcastTxSync(context.Background(), types.Tx("tx"))
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		m.AssertNumberOfCalls(t, "BroadcastTxSync", 1)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		m := &mocks.Client{}
+		m.On("Validators", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, transient)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		slow := client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}
+		_, err := client.WithRetry(m, slow).Validators(ctx, nil, nil, nil)
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		m.AssertNumberOfCalls(t, "Validators", 1)
+	})
+}
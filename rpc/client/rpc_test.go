@@ -128,6 +128,17 @@ func TestNetInfo(t *testing.T) {
 	}
 }
 
+func TestNetInfoDetailed(t *testing.T) {
+	for i, c := range GetClients() {
+		nc, ok := c.(client.NetworkClient)
+		require.True(t, ok, "%d", i)
+		netinfo, err := nc.NetInfoDetailed(context.Background())
+		require.NoError(t, err, "%d: %+v", i, err)
+		assert.True(t, netinfo.Listening)
+		assert.Empty(t, netinfo.Peers)
+	}
+}
+
 func TestDumpConsensusState(t *testing.T) {
 	for i, c := range GetClients() {
 		// FIXME: fix server so it doesn't panic on invalid input
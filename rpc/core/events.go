@@ -10,6 +10,7 @@ import (
 	cmtquery "github.com/cometbft/cometbft/libs/pubsub/query"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	"github.com/cometbft/cometbft/types"
 )
 
 const (
@@ -114,6 +115,13 @@ func (env *Environment) Subscribe(ctx *rpctypes.Context, query string) (*ctypes.
 	return &ctypes.ResultSubscribe{}, nil
 }
 
+// SubscribeABCIResponses subscribes to the FinalizeBlock responses of every
+// committed block via WebSocket. It is a shorthand for subscribing to
+// "tm.event='FinalizeBlock'".
+func (env *Environment) SubscribeABCIResponses(ctx *rpctypes.Context) (*ctypes.ResultSubscribe, error) {
+	return env.Subscribe(ctx, types.EventQueryFinalizeBlock.String())
+}
+
 // Unsubscribe from events via WebSocket.
 // More: https://docs.cometbft.com/main/rpc/#/Websocket/unsubscribe
 func (env *Environment) Unsubscribe(ctx *rpctypes.Context, query string) (*ctypes.ResultUnsubscribe, error) {
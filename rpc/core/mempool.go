@@ -14,6 +14,7 @@ import (
 var (
 	ErrEndpointClosedCatchingUp = errors.New("endpoint is closed while node is catching up")
 	ErrorEmptyTxHash            = errors.New("transaction hash cannot be empty")
+	ErrTxNotInMempool           = errors.New("transaction not found in mempool")
 )
 
 // -----------------------------------------------------------------------------
@@ -196,6 +197,28 @@ func (env *Environment) UnconfirmedTx(_ *rpctypes.Context, hash []byte) (*ctypes
 	}, nil
 }
 
+// UnconfirmedTxStatus gets how long the unconfirmed transaction with the
+// given hash has been in the mempool, its position in the order txs are
+// reaped for the next block, and the priority of its lane.
+func (env *Environment) UnconfirmedTxStatus(_ *rpctypes.Context, hash []byte) (*ctypes.ResultTxStatus, error) {
+	if len(hash) == 0 {
+		return nil, ErrorEmptyTxHash
+	}
+
+	status, ok := env.Mempool.TxStatus(types.TxKey(hash))
+	if !ok {
+		return nil, ErrTxNotInMempool
+	}
+	return &ctypes.ResultTxStatus{
+		Hash:      hash,
+		Lane:      string(status.Lane),
+		Priority:  uint32(status.Priority),
+		Position:  status.Position,
+		Timestamp: status.Timestamp,
+		Age:       time.Since(status.Timestamp),
+	}, nil
+}
+
 // UnconfirmedTxs gets unconfirmed transactions (maximum ?limit entries)
 // including their number.
 // More: https://docs.cometbft.com/main/rpc/#/Info/unconfirmed_txs
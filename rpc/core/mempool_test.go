@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mempl "github.com/cometbft/cometbft/mempool"
+	mpmocks "github.com/cometbft/cometbft/mempool/mocks"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	"github.com/cometbft/cometbft/types"
+)
+
+func TestUnconfirmedTxStatus(t *testing.T) {
+	tx := types.Tx("tx")
+	timestamp := time.Now().Add(-time.Minute)
+	mp := &mpmocks.Mempool{}
+	mp.On("TxStatus", tx.Key()).Return(mempl.TxStatus{Lane: "foo", Priority: 3, Position: 2, Timestamp: timestamp}, true)
+	mp.On("TxStatus", types.Tx("missing").Key()).Return(mempl.TxStatus{}, false)
+	env := &Environment{Mempool: mp}
+
+	res, err := env.UnconfirmedTxStatus(&rpctypes.Context{}, tx.Hash())
+	require.NoError(t, err)
+	assert.EqualValues(t, tx.Hash(), res.Hash)
+	assert.Equal(t, "foo", res.Lane)
+	assert.EqualValues(t, 3, res.Priority)
+	assert.Equal(t, 2, res.Position)
+	assert.Equal(t, timestamp, res.Timestamp)
+	assert.GreaterOrEqual(t, res.Age, time.Minute)
+
+	_, err = env.UnconfirmedTxStatus(&rpctypes.Context{}, types.Tx("missing").Hash())
+	require.ErrorIs(t, err, ErrTxNotInMempool)
+
+	_, err = env.UnconfirmedTxStatus(&rpctypes.Context{}, nil)
+	require.ErrorIs(t, err, ErrorEmptyTxHash)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cometbft/cometbft/p2p"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
@@ -16,20 +17,18 @@ func (env *Environment) NetInfo(*rpctypes.Context) (*ctypes.ResultNetInfo, error
 	peers := make([]ctypes.Peer, 0)
 	var err error
 	env.P2PPeers.Peers().ForEach(func(peer p2p.Peer) {
-		nodeInfo, ok := peer.NodeInfo().(p2p.DefaultNodeInfo)
-		if !ok {
-			err = ErrInvalidNodeType{
-				PeerID:   string(peer.ID()),
-				Expected: fmt.Sprintf("%T", p2p.DefaultNodeInfo{}),
-				Actual:   fmt.Sprintf("%T", peer.NodeInfo()),
-			}
+		var nodeInfo p2p.DefaultNodeInfo
+		if nodeInfo, err = defaultNodeInfo(peer); err != nil {
 			return
 		}
 		peers = append(peers, ctypes.Peer{
 			NodeInfo:         nodeInfo,
 			IsOutbound:       peer.IsOutbound(),
+			ProtocolVersion:  peer.ProtocolVersion(),
 			ConnectionStatus: peer.Status(),
+			SecurityInfo:     peer.SecurityInfo(),
 			RemoteIP:         peer.RemoteIP().String(),
+			ConnectedSince:   peer.ConnectedSince(),
 		})
 	})
 	if err != nil {
@@ -46,6 +45,57 @@ func (env *Environment) NetInfo(*rpctypes.Context) (*ctypes.ResultNetInfo, error
 	}, nil
 }
 
+// NetInfoDetailed returns the same info as NetInfo, plus statistics on the
+// connection to each peer.
+func (env *Environment) NetInfoDetailed(*rpctypes.Context) (*ctypes.ResultNetInfoDetailed, error) {
+	peers := make([]ctypes.PeerDetailed, 0)
+	var err error
+	env.P2PPeers.Peers().ForEach(func(peer p2p.Peer) {
+		var nodeInfo p2p.DefaultNodeInfo
+		if nodeInfo, err = defaultNodeInfo(peer); err != nil {
+			return
+		}
+		status := peer.Status()
+		connectedSince := peer.ConnectedSince()
+		peers = append(peers, ctypes.PeerDetailed{
+			NodeInfo:         nodeInfo,
+			IsOutbound:       peer.IsOutbound(),
+			IsPersistent:     peer.IsPersistent(),
+			ProtocolVersion:  peer.ProtocolVersion(),
+			ConnectionStatus: status,
+			SecurityInfo:     peer.SecurityInfo(),
+			RemoteIP:         peer.RemoteIP().String(),
+			ConnectedSince:   connectedSince,
+			Uptime:           time.Since(connectedSince),
+			BytesSent:        status.SendMonitor.Bytes,
+			BytesReceived:    status.RecvMonitor.Bytes,
+			Latency:          status.PingRTT,
+			Features:         nodeInfo.Features,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultNetInfoDetailed{
+		Listening: env.P2PTransport.IsListening(),
+		Listeners: env.P2PTransport.Listeners(),
+		NPeers:    len(peers),
+		Peers:     peers,
+	}, nil
+}
+
+func defaultNodeInfo(peer p2p.Peer) (p2p.DefaultNodeInfo, error) {
+	nodeInfo, ok := peer.NodeInfo().(p2p.DefaultNodeInfo)
+	if !ok {
+		return p2p.DefaultNodeInfo{}, ErrInvalidNodeType{
+			PeerID:   string(peer.ID()),
+			Expected: fmt.Sprintf("%T", p2p.DefaultNodeInfo{}),
+			Actual:   fmt.Sprintf("%T", peer.NodeInfo()),
+		}
+	}
+	return nodeInfo, nil
+}
+
 // UnsafeDialSeeds dials the given seeds (comma-separated id@IP:PORT).
 func (env *Environment) UnsafeDialSeeds(_ *rpctypes.Context, seeds []string) (*ctypes.ResultDialSeeds, error) {
 	if len(seeds) == 0 {
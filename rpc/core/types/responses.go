@@ -60,6 +60,32 @@ type ResultBlockResults struct {
 	AppHash               []byte                      `json:"app_hash"`
 }
 
+// ABCI results of a block, as streamed by the FinalizeBlock subscription.
+// It mirrors ResultBlockResults so that subscribers and pollers of
+// /block_results can share the same handling code.
+type ResultFinalizeBlock struct {
+	Height                int64                       `json:"height"`
+	TxResults             []*abcitypes.ExecTxResult   `json:"txs_results"`
+	FinalizeBlockEvents   []abcitypes.Event           `json:"finalize_block_events"`
+	ValidatorUpdates      []abcitypes.ValidatorUpdate `json:"validator_updates"`
+	ConsensusParamUpdates *cmtproto.ConsensusParams   `json:"consensus_param_updates"`
+	AppHash               []byte                      `json:"app_hash"`
+}
+
+// NewResultFinalizeBlock builds a ResultFinalizeBlock from the FinalizeBlock
+// event data published on the event bus.
+func NewResultFinalizeBlock(data types.EventDataFinalizeBlock) *ResultFinalizeBlock {
+	res := data.ResultFinalizeBlock
+	return &ResultFinalizeBlock{
+		Height:                data.Height,
+		TxResults:             res.TxResults,
+		FinalizeBlockEvents:   res.Events,
+		ValidatorUpdates:      res.ValidatorUpdates,
+		ConsensusParamUpdates: res.ConsensusParamUpdates,
+		AppHash:               res.AppHash,
+	}
+}
+
 // NewResultCommit is a helper to initialize the ResultCommit with
 // the embedded struct.
 func NewResultCommit(header *types.Header, commit *types.Commit,
@@ -108,7 +134,7 @@ func (s *ResultStatus) TxIndexEnabled() bool {
 	if s == nil {
 		return false
 	}
-	return s.NodeInfo.Other.TxIndex == "on"
+	return s.NodeInfo.TxIndexEnabled()
 }
 
 // Info about peer connections.
@@ -119,6 +145,14 @@ type ResultNetInfo struct {
 	Peers     []Peer   `json:"peers"`
 }
 
+// Info about peer connections, with statistics on each of them.
+type ResultNetInfoDetailed struct {
+	Listening bool           `json:"listening"`
+	Listeners []string       `json:"listeners"`
+	NPeers    int            `json:"n_peers"`
+	Peers     []PeerDetailed `json:"peers"`
+}
+
 // Log from dialing seeds.
 type ResultDialSeeds struct {
 	Log string `json:"log"`
@@ -131,10 +165,35 @@ type ResultDialPeers struct {
 
 // A peer.
 type Peer struct {
-	NodeInfo         p2p.DefaultNodeInfo  `json:"node_info"`
-	IsOutbound       bool                 `json:"is_outbound"`
+	NodeInfo   p2p.DefaultNodeInfo `json:"node_info"`
+	IsOutbound bool                `json:"is_outbound"`
+	// Versions spoken with the peer, see p2p.Peer.ProtocolVersion.
+	ProtocolVersion  p2p.ProtocolVersion  `json:"protocol_version"`
 	ConnectionStatus p2p.ConnectionStatus `json:"connection_status"`
+	SecurityInfo     p2p.SecurityInfo     `json:"security_info"`
 	RemoteIP         string               `json:"remote_ip"`
+	ConnectedSince   time.Time            `json:"connected_since"`
+}
+
+// A peer, with statistics on its connection.
+type PeerDetailed struct {
+	NodeInfo     p2p.DefaultNodeInfo `json:"node_info"`
+	IsOutbound   bool                `json:"is_outbound"`
+	IsPersistent bool                `json:"is_persistent"`
+	// Versions spoken with the peer, see p2p.Peer.ProtocolVersion.
+	ProtocolVersion  p2p.ProtocolVersion  `json:"protocol_version"`
+	ConnectionStatus p2p.ConnectionStatus `json:"connection_status"`
+	SecurityInfo     p2p.SecurityInfo     `json:"security_info"`
+	RemoteIP         string               `json:"remote_ip"`
+	ConnectedSince   time.Time            `json:"connected_since"`
+	// Time since ConnectedSince.
+	Uptime        time.Duration `json:"uptime"`
+	BytesSent     int64         `json:"bytes_sent"`
+	BytesReceived int64         `json:"bytes_received"`
+	// Round-trip time of the last ping the peer answered, 0 if none yet.
+	Latency time.Duration `json:"latency"`
+	// Optional features the peer advertised in its NodeInfo.
+	Features []string `json:"features"`
 }
 
 // Validators for a height.
@@ -221,6 +280,17 @@ type ResultUnconfirmedTx struct {
 	Tx types.Tx `json:"tx"`
 }
 
+// Status of a mempool tx.
+type ResultTxStatus struct {
+	Hash     bytes.HexBytes `json:"hash"`
+	Lane     string         `json:"lane"`
+	Priority uint32         `json:"priority"`
+	// Index of the tx in the order the mempool reaps txs, counting from 0.
+	Position  int           `json:"position"`
+	Timestamp time.Time     `json:"timestamp"`
+	Age       time.Duration `json:"age"`
+}
+
 // List of mempool txs.
 type ResultUnconfirmedTxs struct {
 	Count      int        `json:"n_txs"`
@@ -167,9 +167,29 @@ func (blockExec *BlockExecutor) CreateProposalBlock(
 		return nil, err
 	}
 
+	// Let the mempool reap the txs the app declined after the others next time.
+	if declined := declinedTxs(txs, txl); len(declined) > 0 {
+		blockExec.mempool.MarkDeprioritized(declined)
+	}
+
 	return state.MakeBlock(height, txl, commit, evidence, proposerAddr), nil
 }
 
+// declinedTxs returns the keys of the reaped txs that are not in proposed.
+func declinedTxs(reaped, proposed types.Txs) []types.TxKey {
+	included := make(map[types.TxKey]struct{}, len(proposed))
+	for _, tx := range proposed {
+		included[tx.Key()] = struct{}{}
+	}
+	var declined []types.TxKey
+	for _, tx := range reaped {
+		if _, ok := included[tx.Key()]; !ok {
+			declined = append(declined, tx.Key())
+		}
+	}
+	return declined
+}
+
 func (blockExec *BlockExecutor) ProcessProposal(
 	block *types.Block,
 	state State,
@@ -738,6 +758,13 @@ func fireEvents(
 		logger.Error("Failed publishing new block events", "err", err)
 	}
 
+	if err := eventBus.PublishEventFinalizeBlock(types.EventDataFinalizeBlock{
+		Height:              block.Height,
+		ResultFinalizeBlock: *abciResponse,
+	}); err != nil {
+		logger.Error("Failed publishing finalize block", "err", err)
+	}
+
 	if len(block.Evidence.Evidence) != 0 {
 		for _, ev := range block.Evidence.Evidence {
 			if err := eventBus.PublishEventNewEvidence(types.EventDataNewEvidence{
@@ -807,6 +807,8 @@ func TestPrepareProposalReorderTxs(t *testing.T) {
 	txs := test.MakeNTxs(height, 10)
 	mp := &mpmocks.Mempool{}
 	mp.On("ReapMaxBytesMaxGas", mock.Anything, mock.Anything).Return(txs)
+	// the txs the app dropped are deprioritized
+	mp.On("MarkDeprioritized", []types.TxKey{txs[0].Key(), txs[1].Key()}).Return()
 
 	txs = txs[2:]
 	txs = append(txs[len(txs)/2:], txs[:len(txs)/2]...)
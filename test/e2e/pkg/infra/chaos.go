@@ -0,0 +1,63 @@
+package infra
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	e2e "github.com/cometbft/cometbft/test/e2e/pkg"
+)
+
+// ChaosNetworkCommands returns the tc commands that shape the traffic from
+// node to each of the other nodes of the testnet on the given interface, in
+// the chaos network mode. Every link gets its own netem qdisc, with a latency
+// picked at random up to the testnet's ChaosMaxLatency, the testnet's jitter
+// and reordering, and a filter on the destination IP.
+func ChaosNetworkCommands(node *e2e.Node, iface string, useInternalIP bool) []string {
+	testnet := node.Testnet
+	protocol, match := "ip", "ip"
+	if testnet.IPv6() {
+		protocol, match = "ipv6", "ip6"
+	}
+
+	cmds := []string{
+		fmt.Sprintf("tc qdisc del dev %s root 2> /dev/null || true", iface),
+		fmt.Sprintf("tc qdisc add dev %s root handle 1: htb default 1", iface),
+		// Traffic to anything other than a node is left untouched.
+		fmt.Sprintf("tc class add dev %s parent 1: classid 1:1 htb rate 1gbit", iface),
+	}
+	minor := 0x10
+	for _, peer := range testnet.Nodes {
+		if peer.Name == node.Name {
+			continue
+		}
+		ip := peer.ExternalIP
+		if useInternalIP {
+			ip = peer.InternalIP
+		}
+		netem := fmt.Sprintf("netem delay %dus %dus",
+			chaosLinkLatency(node, peer).Microseconds(), testnet.ChaosJitter.Microseconds())
+		if testnet.ChaosReorder > 0 {
+			netem += fmt.Sprintf(" reorder %g%%", testnet.ChaosReorder)
+		}
+		cmds = append(cmds,
+			fmt.Sprintf("tc class add dev %s parent 1: classid 1:%x htb rate 1gbit", iface, minor),
+			fmt.Sprintf("tc qdisc add dev %s parent 1:%x handle %x: %s", iface, minor, minor, netem),
+			fmt.Sprintf("tc filter add dev %s protocol %s parent 1: prio 1 u32 match %s dst %s flowid 1:%x",
+				iface, protocol, match, ip, minor),
+		)
+		minor++
+	}
+	return cmds
+}
+
+// chaosLinkLatency picks the latency of the link from node to peer. It is
+// derived from their names, so that it is the same on every run of the
+// testnet, while the two directions of a link usually differ.
+func chaosLinkLatency(node, peer *e2e.Node) time.Duration {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(node.Name + "->" + peer.Name))
+	r := rand.New(rand.NewSource(int64(h.Sum64()))) //nolint: gosec
+	return time.Duration(r.Int63n(int64(node.Testnet.ChaosMaxLatency) + 1))
+}
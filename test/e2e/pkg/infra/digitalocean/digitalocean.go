@@ -86,6 +86,21 @@ func (p Provider) SetLatency(ctx context.Context, node *e2e.Node) error {
 	return execAnsible(ctx, p.Testnet.Dir, playbookFile, []string{node.ExternalIP.String()})
 }
 
+// SetChaosNetwork shapes the traffic from the given node to the other nodes
+// for the chaos network mode.
+func (p Provider) SetChaosNetwork(ctx context.Context, node *e2e.Node) error {
+	playbook := "- name: e2e custom playbook\n" +
+		"  hosts: all\n" +
+		"  tasks:\n"
+	playbook = ansibleAddShellTasks(playbook, "set chaos network", infra.ChaosNetworkCommands(node, "eth0", false)...)
+
+	playbookFile := getNextPlaybookFilename()
+	if err := p.writePlaybook(playbookFile, playbook); err != nil {
+		return err
+	}
+	return execAnsible(ctx, p.Testnet.Dir, playbookFile, []string{node.ExternalIP.String()})
+}
+
 func (p Provider) StopTestnet(ctx context.Context) error {
 	nodeIPs := make([]string, len(p.Testnet.Nodes))
 	for i, n := range p.Testnet.Nodes {
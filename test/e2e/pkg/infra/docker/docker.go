@@ -5,6 +5,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	e2e "github.com/cometbft/cometbft/test/e2e/pkg"
@@ -90,6 +91,11 @@ func (p Provider) SetLatency(ctx context.Context, node *e2e.Node) error {
 		filepath.Join(containerDir, "aws-latencies.csv"), "eth0")
 }
 
+func (p Provider) SetChaosNetwork(ctx context.Context, node *e2e.Node) error {
+	cmds := infra.ChaosNetworkCommands(node, "eth0", true)
+	return ExecVerbose(ctx, "exec", "--privileged", node.Name, "sh", "-c", strings.Join(cmds, " && "))
+}
+
 // dockerComposeBytes generates a Docker Compose config file for a testnet and returns the
 // file as bytes to be written out to disk.
 func dockerComposeBytes(testnet *e2e.Testnet) ([]byte, error) {
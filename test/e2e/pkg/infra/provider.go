@@ -22,6 +22,10 @@ type Provider interface {
 	// Set emulated latencies from a node to other nodes.
 	SetLatency(ctx context.Context, node *e2e.Node) error
 
+	// Set random latencies and packet reordering from a node to other nodes,
+	// for the chaos network mode.
+	SetChaosNetwork(ctx context.Context, node *e2e.Node) error
+
 	// Stops the whole network
 	StopTestnet(ctx context.Context) error
 
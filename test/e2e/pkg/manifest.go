@@ -136,6 +136,19 @@ type Manifest struct {
 	// specific zone assigned.
 	DefaultZone string `toml:"default_zone"`
 
+	// ChaosMaxLatency enables the chaos network mode when set: each link from
+	// a node to another gets a random latency between 0 and this value,
+	// applied with tc/netem. It cannot be combined with zones.
+	ChaosMaxLatency time.Duration `toml:"chaos_max_latency"`
+
+	// ChaosJitter is the random variation added to the latency of every
+	// packet in the chaos network mode, which also reorders packets.
+	ChaosJitter time.Duration `toml:"chaos_jitter"`
+
+	// ChaosReorder is the percentage of packets sent right away, ahead of the
+	// delayed ones, in the chaos network mode.
+	ChaosReorder float64 `toml:"chaos_reorder"`
+
 	// PbtsEnableHeight configures the first height during which
 	// the chain will start using Proposer-Based Timestamps (PBTS)
 	// to create and validate new blocks.
@@ -0,0 +1,114 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metrics scrapes the node's Prometheus endpoint and returns every sample as
+// a flat map. Keys are the metric name followed by its labels, sorted by
+// name, e.g. `cometbft_p2p_message_send_bytes_total{message_type="Vote"}`.
+// Histograms and summaries are reported as their _sum and _count series,
+// plus one _bucket series per bucket for histograms.
+func (n Node) Metrics(ctx context.Context) (map[string]float64, error) {
+	if !n.Prometheus {
+		return nil, fmt.Errorf("node %v does not have Prometheus enabled", n.Name)
+	}
+
+	//nolint:nosprintfhostport
+	url := fmt.Sprintf("http://%s:%v/metrics", n.ExternalIP, n.PrometheusProxyPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scraping %v metrics: %w", n.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %v metrics: unexpected status %v", n.Name, resp.Status)
+	}
+
+	metrics, err := parseMetrics(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v metrics: %w", n.Name, err)
+	}
+	return metrics, nil
+}
+
+// Metrics scrapes all nodes with Prometheus enabled and sums each sample
+// across them. Use Node.Metrics to look at a single node.
+func (t Testnet) Metrics(ctx context.Context) (map[string]float64, error) {
+	total := make(map[string]float64)
+	for _, node := range t.Nodes {
+		if !node.Prometheus {
+			continue
+		}
+		metrics, err := node.Metrics(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range metrics {
+			total[key] += value
+		}
+	}
+	return total, nil
+}
+
+func parseMetrics(r io.Reader) (map[string]float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]float64)
+	for name, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := m.GetLabel()
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				metrics[metricKey(name, labels)] = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				metrics[metricKey(name, labels)] = m.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				metrics[metricKey(name, labels)] = m.GetUntyped().GetValue()
+			case dto.MetricType_SUMMARY:
+				metrics[metricKey(name+"_sum", labels)] = m.GetSummary().GetSampleSum()
+				metrics[metricKey(name+"_count", labels)] = float64(m.GetSummary().GetSampleCount())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				metrics[metricKey(name+"_sum", labels)] = h.GetSampleSum()
+				metrics[metricKey(name+"_count", labels)] = float64(h.GetSampleCount())
+				for _, b := range h.GetBucket() {
+					le := fmt.Sprintf("%g", b.GetUpperBound())
+					bucketLabels := append(labels[:len(labels):len(labels)], &dto.LabelPair{Name: strPtr("le"), Value: &le})
+					metrics[metricKey(name+"_bucket", bucketLabels)] = float64(b.GetCumulativeCount())
+				}
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func metricKey(name string, labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func strPtr(s string) *string { return &s }
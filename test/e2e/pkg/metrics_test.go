@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetrics(t *testing.T) {
+	testcases := []struct {
+		name   string
+		input  string
+		expect map[string]float64
+		errMsg string
+	}{
+		{
+			name:   "empty",
+			input:  "",
+			expect: map[string]float64{},
+		},
+		{
+			name: "counter and gauge",
+			input: `# TYPE cometbft_p2p_message_send_bytes_total counter
+cometbft_p2p_message_send_bytes_total{message_type="Vote",chain_id="test"} 42
+cometbft_p2p_message_send_bytes_total{message_type="Proposal",chain_id="test"} 7
+# TYPE cometbft_p2p_peers gauge
+cometbft_p2p_peers 3
+`,
+			expect: map[string]float64{
+				`cometbft_p2p_message_send_bytes_total{chain_id="test",message_type="Vote"}`:     42,
+				`cometbft_p2p_message_send_bytes_total{chain_id="test",message_type="Proposal"}`: 7,
+				"cometbft_p2p_peers": 3,
+			},
+		},
+		{
+			name:   "untyped",
+			input:  "cometbft_untyped{a=\"b\"} 1.5\n",
+			expect: map[string]float64{`cometbft_untyped{a="b"}`: 1.5},
+		},
+		{
+			name: "summary",
+			input: `# TYPE rpc_duration summary
+rpc_duration{quantile="0.5"} 0.2
+rpc_duration_sum 10
+rpc_duration_count 50
+`,
+			expect: map[string]float64{"rpc_duration_sum": 10, "rpc_duration_count": 50},
+		},
+		{
+			name: "histogram",
+			input: `# TYPE block_interval histogram
+block_interval_bucket{chain_id="test",le="0.5"} 1
+block_interval_bucket{chain_id="test",le="1"} 3
+block_interval_bucket{chain_id="test",le="+Inf"} 4
+block_interval_sum{chain_id="test"} 3.5
+block_interval_count{chain_id="test"} 4
+`,
+			expect: map[string]float64{
+				`block_interval_bucket{chain_id="test",le="0.5"}`:  1,
+				`block_interval_bucket{chain_id="test",le="1"}`:    3,
+				`block_interval_bucket{chain_id="test",le="+Inf"}`: 4,
+				`block_interval_sum{chain_id="test"}`:              3.5,
+				`block_interval_count{chain_id="test"}`:            4,
+			},
+		},
+		{
+			name:   "malformed value",
+			input:  "cometbft_p2p_peers three\n",
+			errMsg: "expected float as value",
+		},
+		{
+			name:   "malformed labels",
+			input:  "cometbft_p2p_peers{chain_id=test} 3\n",
+			errMsg: "expected '\"' at start of label value",
+		},
+		{
+			name:   "unknown type",
+			input:  "# TYPE cometbft_p2p_peers meter\ncometbft_p2p_peers 3\n",
+			errMsg: "unknown metric type",
+		},
+		{
+			name:   "type after samples",
+			input:  "cometbft_p2p_peers 3\n# TYPE cometbft_p2p_peers gauge\n",
+			errMsg: "second TYPE line",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics, err := parseMetrics(strings.NewReader(tc.input))
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, metrics)
+		})
+	}
+}
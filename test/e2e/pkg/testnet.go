@@ -28,6 +28,7 @@ import (
 	grpcclient "github.com/cometbft/cometbft/rpc/grpc/client"
 	grpcprivileged "github.com/cometbft/cometbft/rpc/grpc/client/privileged"
 	"github.com/cometbft/cometbft/test/e2e/app"
+	"github.com/cometbft/cometbft/test/e2e/pkg/exec"
 	"github.com/cometbft/cometbft/types"
 )
 
@@ -335,7 +336,10 @@ func NewTestnetFromManifest(manifest Manifest, file string, ifd InfrastructureDa
 	for heightStr, validators := range manifest.ValidatorUpdatesMap {
 		height, err := strconv.Atoi(heightStr)
 		if err != nil {
-			return nil, fmt.Errorf("invalid validator update height %q: %w", height, err)
+			return nil, fmt.Errorf("invalid validator update height %q: %w", heightStr, err)
+		}
+		if height < 0 {
+			return nil, fmt.Errorf("invalid validator update height %v: must not be negative", height)
 		}
 		valUpdate := map[string]int64{}
 		for name, power := range validators {
@@ -365,6 +369,9 @@ func (t Testnet) Validate() error {
 	if err := t.validateZones(t.Nodes); err != nil {
 		return err
 	}
+	if err := t.validateChaosNetwork(); err != nil {
+		return err
+	}
 	if t.BlockMaxBytes > types.MaxBlockSizeBytes {
 		return fmt.Errorf("value of BlockMaxBytes cannot be higher than %d", types.MaxBlockSizeBytes)
 	}
@@ -497,6 +504,28 @@ func (Testnet) validateZones(nodes []*Node) error {
 	return nil
 }
 
+func (t Testnet) validateChaosNetwork() error {
+	if t.ChaosMaxLatency < 0 || t.ChaosJitter < 0 {
+		return errors.New("chaos network latency and jitter must not be negative")
+	}
+	if t.ChaosReorder < 0 || t.ChaosReorder > 100 {
+		return fmt.Errorf("chaos network reorder must be a percentage between 0 and 100; got %v", t.ChaosReorder)
+	}
+	if !t.ChaosNetworkIsSet() {
+		if t.ChaosJitter > 0 || t.ChaosReorder > 0 {
+			return errors.New("chaos network jitter and reorder require chaos_max_latency to be set")
+		}
+		return nil
+	}
+	// Both would replace the root qdisc of the nodes' interface.
+	for _, node := range t.Nodes {
+		if node.ZoneIsSet() {
+			return fmt.Errorf("chaos network cannot be combined with zones; node %s has zone %s", node.Name, node.Zone)
+		}
+	}
+	return nil
+}
+
 // Validate validates a node.
 func (n Node) Validate(testnet Testnet) error {
 	if n.Name == "" {
@@ -619,6 +648,71 @@ func (t Testnet) ArchiveNodes() []*Node {
 	return nodes
 }
 
+// AssertAllSubmittedTxsCommitted waits until each of txs is included in a
+// committed block, as seen by the first archive node. It returns an error
+// listing the txs that did not make it within the given duration.
+func (t Testnet) AssertAllSubmittedTxsCommitted(ctx context.Context, txs []types.Tx, within time.Duration) error {
+	nodes := t.ArchiveNodes()
+	if len(nodes) == 0 {
+		return errors.New("no archive node to fetch blocks from")
+	}
+	client, err := nodes[0].Client()
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[types.TxKey]types.Tx, len(txs))
+	for _, tx := range txs {
+		pending[tx.Key()] = tx
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, within)
+	defer cancel()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var height int64 // last height whose txs were checked
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d of %d txs not committed within %v: %s",
+				len(pending), len(txs), within, formatTxHashes(pending))
+		case <-timer.C:
+		}
+
+		status, err := client.Status(ctx)
+		if err == nil {
+			height = max(height, status.SyncInfo.EarliestBlockHeight-1)
+			for height < status.SyncInfo.LatestBlockHeight {
+				h := height + 1
+				res, err := client.Block(ctx, &h)
+				if err != nil {
+					break
+				}
+				for _, tx := range res.Block.Txs {
+					delete(pending, tx.Key())
+				}
+				height = h
+			}
+		}
+		timer.Reset(time.Second)
+	}
+	return nil
+}
+
+// formatTxHashes returns the sorted hashes of up to 10 of txs.
+func formatTxHashes(txs map[types.TxKey]types.Tx) string {
+	const maxShown = 10
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, fmt.Sprintf("%X", tx.Hash()))
+	}
+	slices.Sort(hashes)
+	if len(hashes) > maxShown {
+		return fmt.Sprintf("%s and %d more", strings.Join(hashes[:maxShown], ", "), len(hashes)-maxShown)
+	}
+	return strings.Join(hashes, ", ")
+}
+
 // RandomNode returns a random non-seed node.
 func (t Testnet) RandomNode() *Node {
 	for {
@@ -634,6 +728,12 @@ func (t Testnet) IPv6() bool {
 	return t.IP.IP.To4() == nil
 }
 
+// ChaosNetworkIsSet returns if the links between nodes get random latencies
+// and packet reordering.
+func (t Testnet) ChaosNetworkIsSet() bool {
+	return t.ChaosMaxLatency > 0
+}
+
 // HasPerturbations returns whether the network has any perturbations.
 func (t Testnet) HasPerturbations() bool {
 	for _, node := range t.Nodes {
@@ -731,6 +831,86 @@ func (n Node) ClientInternalIP() (*rpchttp.HTTP, error) {
 	return rpchttp.New(fmt.Sprintf("http://%s:%v/v1", n.InternalIP, n.RPCProxyPort))
 }
 
+// WaitForMempoolSize polls the node's mempool until it holds at least size
+// transactions, returning the context error if ctx expires first.
+func (n Node) WaitForMempoolSize(ctx context.Context, size int) error {
+	client, err := n.Client()
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %v mempool to reach %d txs: %w", n.Name, size, ctx.Err())
+		case <-timer.C:
+			res, err := client.NumUnconfirmedTxs(ctx)
+			if err == nil && res.Total >= size {
+				return nil
+			}
+			timer.Reset(200 * time.Millisecond)
+		}
+	}
+}
+
+// Restart restarts the node's Docker container and waits until it commits a
+// block above the height it had reached before. It returns an error if the
+// node comes back at a lower height or with a different block at that
+// height, i.e. it rolled back or diverged while recovering.
+func (n Node) Restart(ctx context.Context) error {
+	client, err := n.Client()
+	if err != nil {
+		return err
+	}
+	before, err := client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("getting %v status before restart: %w", n.Name, err)
+	}
+	height, hash := before.SyncInfo.LatestBlockHeight, before.SyncInfo.LatestBlockHash
+
+	compose := filepath.Join(n.Testnet.Dir, "docker-compose.yml")
+	if err := exec.Command(ctx, "docker", "compose", "-f", compose, "restart", n.Name); err != nil {
+		return fmt.Errorf("restarting %v: %w", n.Name, err)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	recovered := false
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %v to resume after height %d: %w", n.Name, height, ctx.Err())
+		case <-timer.C:
+		}
+		timer.Reset(time.Second)
+
+		status, err := client.Status(ctx)
+		if err != nil {
+			continue // not up yet
+		}
+		if !recovered {
+			if status.SyncInfo.LatestBlockHeight < height {
+				return fmt.Errorf("%v rolled back from height %d to %d on restart",
+					n.Name, height, status.SyncInfo.LatestBlockHeight)
+			}
+			block, err := client.Block(ctx, &height)
+			if err != nil {
+				continue
+			}
+			if !bytes.Equal(block.BlockID.Hash, hash) {
+				return fmt.Errorf("%v has block %X at height %d after restart, had %X",
+					n.Name, block.BlockID.Hash, height, hash)
+			}
+			recovered = true
+		}
+		if status.SyncInfo.LatestBlockHeight > height {
+			return nil
+		}
+	}
+}
+
 // GRPCClient creates a gRPC client for the node.
 func (n Node) GRPCClient(ctx context.Context) (grpcclient.Client, error) {
 	return grpcclient.New(
@@ -0,0 +1,56 @@
+package e2e
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestnetFromManifestValidatorUpdates(t *testing.T) {
+	testcases := []struct {
+		name    string
+		updates map[string]map[string]int64
+		expect  map[int64]map[string]int64
+		errMsg  string
+	}{
+		{
+			name:    "valid heights",
+			updates: map[string]map[string]int64{"0": {"validator01": 50}, "10": {"validator01": 0}},
+			expect:  map[int64]map[string]int64{0: {"validator01": 50}, 10: {"validator01": 0}},
+		},
+		{
+			name:    "unparsable height",
+			updates: map[string]map[string]int64{"ten": {"validator01": 50}},
+			errMsg:  `invalid validator update height "ten"`,
+		},
+		{
+			name:    "negative height",
+			updates: map[string]map[string]int64{"-1": {"validator01": 50}},
+			errMsg:  "invalid validator update height -1: must not be negative",
+		},
+		{
+			name:    "unknown validator",
+			updates: map[string]map[string]int64{"10": {"validator02": 50}},
+			errMsg:  `unknown validator "validator02" for update at height 10`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest := Manifest{
+				NodesMap:            map[string]*ManifestNode{"validator01": {}},
+				ValidatorUpdatesMap: tc.updates,
+			}
+			ifd, err := NewDockerInfrastructureData(manifest)
+			require.NoError(t, err)
+
+			testnet, err := NewTestnetFromManifest(manifest, filepath.Join(t.TempDir(), "testnet.toml"), ifd, "")
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, testnet.ValidatorUpdates)
+		})
+	}
+}
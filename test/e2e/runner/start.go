@@ -70,6 +70,12 @@ func Start(ctx context.Context, testnet *e2e.Testnet, p infra.Provider) error {
 				return err
 			}
 		}
+		if testnet.ChaosNetworkIsSet() {
+			logger.Info("setting chaos network", "node", node.Name)
+			if err := p.SetChaosNetwork(ctx, node); err != nil {
+				return err
+			}
+		}
 	}
 
 	networkHeight := testnet.InitialHeight
@@ -138,6 +144,12 @@ func Start(ctx context.Context, testnet *e2e.Testnet, p infra.Provider) error {
 				return err
 			}
 		}
+		if testnet.ChaosNetworkIsSet() {
+			logger.Info("setting chaos network", "node", node.Name)
+			if err := p.SetChaosNetwork(ctx, node); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	e2e "github.com/cometbft/cometbft/test/e2e/pkg"
+	"github.com/cometbft/cometbft/types"
 )
 
 // Tests that block headers are identical across nodes where present.
@@ -150,12 +151,10 @@ func TestBlock_Time(t *testing.T) {
 	blocks := fetchBlockChain(t)
 	testnet := loadTestnet(t)
 
-	lastBlock := blocks[0]
+	require.NoError(t, AssertBlockTimesValid(blocks, 0))
+
 	valSchedule := newValidatorSchedule(t, &testnet)
 	for _, block := range blocks[1:] {
-		require.Less(t, lastBlock.Time, block.Time)
-		lastBlock = block
-
 		valSchedule.Increment(t, 1)
 		if testnet.PbtsEnableHeight == 0 || block.Height < testnet.PbtsEnableHeight {
 			expTime := block.LastCommit.MedianTime(valSchedule.Set)
@@ -163,3 +162,25 @@ func TestBlock_Time(t *testing.T) {
 		}
 	}
 }
+
+func TestAssertBlockTimesValid(t *testing.T) {
+	start := time.Now()
+	makeBlocks := func(offsets ...time.Duration) []*types.Block {
+		blocks := make([]*types.Block, len(offsets))
+		for i, offset := range offsets {
+			blocks[i] = &types.Block{Header: types.Header{Height: int64(i + 1), Time: start.Add(offset)}}
+		}
+		return blocks
+	}
+
+	require.NoError(t, AssertBlockTimesValid(nil, time.Second))
+	require.NoError(t, AssertBlockTimesValid(makeBlocks(0, time.Second, 2*time.Second), time.Second))
+	require.NoError(t, AssertBlockTimesValid(makeBlocks(0, time.Hour), 0))
+
+	err := AssertBlockTimesValid(makeBlocks(0, time.Second, time.Second), time.Minute)
+	require.ErrorContains(t, err, "block 3 ")
+	err = AssertBlockTimesValid(makeBlocks(0, time.Second, -time.Second), time.Minute)
+	require.ErrorContains(t, err, "block 3 ")
+	err = AssertBlockTimesValid(makeBlocks(0, time.Second, time.Second+time.Minute+1), time.Minute)
+	require.ErrorContains(t, err, "block 3 ")
+}
@@ -2,15 +2,18 @@ package e2e_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	abci "github.com/cometbft/cometbft/abci/types"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
 	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
 	rpctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/cometbft/cometbft/test/e2e/app"
@@ -188,11 +191,9 @@ func fetchBlockChain(t *testing.T) []*types.Block {
 		from = blocks[len(blocks)-1].Height + 1
 	}
 
-	for h := from; h <= to; h++ {
-		resp, err := client.Block(ctx, &(h))
-		require.NoError(t, err)
-		require.NotNil(t, resp.Block)
-		require.Equal(t, h, resp.Block.Height, "unexpected block height %v", resp.Block.Height)
+	results, errs := rpcclient.BlockRange(ctx, client, from, to)
+	require.Empty(t, errs, "failed to fetch blocks")
+	for _, resp := range results {
 		blocks = append(blocks, resp.Block)
 	}
 	require.NotEmpty(t, blocks, "blockchain does not contain any blocks")
@@ -201,6 +202,26 @@ func fetchBlockChain(t *testing.T) []*types.Block {
 	return blocks
 }
 
+// AssertBlockTimesValid checks that the time of each block is strictly
+// greater than the time of the block before it and, if maxInterval is
+// positive, at most maxInterval greater. It returns an error naming the height
+// of the first block that violates either bound. The blocks must be
+// contiguous and in height order, as returned by fetchBlockChain.
+func AssertBlockTimesValid(blocks []*types.Block, maxInterval time.Duration) error {
+	for i := 1; i < len(blocks); i++ {
+		prev, block := blocks[i-1], blocks[i]
+		if !block.Time.After(prev.Time) {
+			return fmt.Errorf("block %d has time %v, not after time %v of block %d",
+				block.Height, block.Time, prev.Time, prev.Height)
+		}
+		if interval := block.Time.Sub(prev.Time); maxInterval > 0 && interval > maxInterval {
+			return fmt.Errorf("block %d has time %v, %v after block %d, more than the maximum of %v",
+				block.Height, block.Time, interval, prev.Height, maxInterval)
+		}
+	}
+	return nil
+}
+
 // fetchABCIRequests go through the logs of a specific node and collect all ABCI requests (each slice represents requests from beginning until the first crash,
 // and then between two crashes) for a specific node.
 func fetchABCIRequests(t *testing.T, nodeName string) ([][]*abci.Request, error) {
@@ -2,6 +2,7 @@ package e2e_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -45,3 +46,39 @@ func TestNet_Peers(t *testing.T) {
 		}
 	})
 }
+
+// Tests that, with random latencies and reordered packets between nodes, the
+// network still commits blocks and all nodes agree on their app hashes.
+func TestNet_ChaosAppHash(t *testing.T) {
+	testnet := loadTestnet(t)
+	if !testnet.ChaosNetworkIsSet() {
+		t.Skip("chaos network not set")
+	}
+	blocks := fetchBlockChain(t)
+	require.Greater(t, len(blocks), 1, "network did not produce blocks")
+	last := blocks[len(blocks)-1].Height
+
+	testFullNodesOrValidators(t, 0, func(t *testing.T, node e2e.Node) {
+		t.Helper()
+		client, err := node.Client()
+		require.NoError(t, err)
+
+		var first int64
+		require.Eventually(t, func() bool {
+			status, err := client.Status(ctx)
+			require.NoError(t, err)
+			first = status.SyncInfo.EarliestBlockHeight
+			return status.SyncInfo.LatestBlockHeight >= last
+		}, 30*time.Second, 500*time.Millisecond, "node did not reach height %d", last)
+
+		for _, block := range blocks {
+			if block.Height < first+int64(node.RetainBlocks) {
+				continue
+			}
+			resp, err := client.Header(ctx, &block.Height)
+			require.NoError(t, err)
+			require.Equal(t, block.AppHash, resp.Header.AppHash,
+				"app hash mismatch at height %d", block.Height)
+		}
+	})
+}
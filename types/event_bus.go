@@ -153,6 +153,10 @@ func (b *EventBus) PublishEventNewBlockEvents(data EventDataNewBlockEvents) erro
 	return b.pubsub.PublishWithEvents(ctx, data, events)
 }
 
+func (b *EventBus) PublishEventFinalizeBlock(data EventDataFinalizeBlock) error {
+	return b.Publish(EventFinalizeBlock, data)
+}
+
 func (b *EventBus) PublishEventNewBlockHeader(data EventDataNewBlockHeader) error {
 	return b.Publish(EventNewBlockHeader, data)
 }
@@ -263,6 +267,10 @@ func (NopEventBus) PublishEventNewBlockEvents(EventDataNewBlockEvents) error {
 	return nil
 }
 
+func (NopEventBus) PublishEventFinalizeBlock(EventDataFinalizeBlock) error {
+	return nil
+}
+
 func (NopEventBus) PublishEventNewEvidence(EventDataNewEvidence) error {
 	return nil
 }
@@ -339,6 +339,45 @@ func TestEventBusPublishEventNewBlockEvents(t *testing.T) {
 	}
 }
 
+func TestEventBusPublishEventFinalizeBlock(t *testing.T) {
+	eventBus := NewEventBus()
+	err := eventBus.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := eventBus.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	query := "tm.event='FinalizeBlock'"
+	finalizeSub, err := eventBus.Subscribe(context.Background(), "test", cmtquery.MustCompile(query))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		msg := <-finalizeSub.Out()
+		edt := msg.Data().(EventDataFinalizeBlock)
+		assert.Equal(t, int64(1), edt.Height)
+		assert.Equal(t, []byte("apphash"), edt.ResultFinalizeBlock.AppHash)
+		close(done)
+	}()
+
+	err = eventBus.PublishEventFinalizeBlock(EventDataFinalizeBlock{
+		Height: 1,
+		ResultFinalizeBlock: abci.FinalizeBlockResponse{
+			TxResults: []*abci.ExecTxResult{{Data: []byte("foo")}},
+			AppHash:   []byte("apphash"),
+		},
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("did not receive a finalize block event after 1 sec.")
+	}
+}
+
 func TestEventBusPublishEventNewEvidence(t *testing.T) {
 	eventBus := NewEventBus()
 	err := eventBus.Start()
@@ -388,7 +427,7 @@ func TestEventBusPublish(t *testing.T) {
 		}
 	})
 
-	const numEventsExpected = 14
+	const numEventsExpected = 15
 
 	sub, err := eventBus.Subscribe(context.Background(), "test", cmtquery.All, numEventsExpected)
 	require.NoError(t, err)
@@ -413,6 +452,8 @@ func TestEventBusPublish(t *testing.T) {
 	require.NoError(t, err)
 	err = eventBus.PublishEventNewBlockEvents(EventDataNewBlockEvents{Height: 1})
 	require.NoError(t, err)
+	err = eventBus.PublishEventFinalizeBlock(EventDataFinalizeBlock{Height: 1})
+	require.NoError(t, err)
 	err = eventBus.PublishEventVote(EventDataVote{})
 	require.NoError(t, err)
 	err = eventBus.PublishEventNewRoundStep(EventDataRoundState{})
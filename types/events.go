@@ -19,6 +19,7 @@ const (
 	EventNewBlock            = "NewBlock"
 	EventNewBlockHeader      = "NewBlockHeader"
 	EventNewBlockEvents      = "NewBlockEvents"
+	EventFinalizeBlock       = "FinalizeBlock"
 	EventNewEvidence         = "NewEvidence"
 	EventPendingTx           = "PendingTx"
 	EventTx                  = "Tx"
@@ -51,6 +52,7 @@ func init() {
 	cmtjson.RegisterType(EventDataNewBlock{}, "tendermint/event/NewBlock")
 	cmtjson.RegisterType(EventDataNewBlockHeader{}, "tendermint/event/NewBlockHeader")
 	cmtjson.RegisterType(EventDataNewBlockEvents{}, "tendermint/event/NewBlockEvents")
+	cmtjson.RegisterType(EventDataFinalizeBlock{}, "tendermint/event/FinalizeBlock")
 	cmtjson.RegisterType(EventDataNewEvidence{}, "tendermint/event/NewEvidence")
 	cmtjson.RegisterType(EventDataTx{}, "tendermint/event/Tx")
 	cmtjson.RegisterType(EventDataRoundState{}, "tendermint/event/RoundState")
@@ -80,6 +82,13 @@ type EventDataNewBlockEvents struct {
 	NumTxs int64        `json:"num_txs,string"` // Number of txs in a block
 }
 
+// EventDataFinalizeBlock carries the application's full FinalizeBlock
+// response for a committed block, without the block itself.
+type EventDataFinalizeBlock struct {
+	Height              int64                      `json:"height"`
+	ResultFinalizeBlock abci.FinalizeBlockResponse `json:"result_finalize_block"`
+}
+
 type EventDataNewEvidence struct {
 	Height   int64    `json:"height"`
 	Evidence Evidence `json:"evidence"`
@@ -157,6 +166,7 @@ var (
 	EventQueryNewBlock            = QueryForEvent(EventNewBlock)
 	EventQueryNewBlockHeader      = QueryForEvent(EventNewBlockHeader)
 	EventQueryNewBlockEvents      = QueryForEvent(EventNewBlockEvents)
+	EventQueryFinalizeBlock       = QueryForEvent(EventFinalizeBlock)
 	EventQueryNewEvidence         = QueryForEvent(EventNewEvidence)
 	EventQueryNewRound            = QueryForEvent(EventNewRound)
 	EventQueryNewRoundStep        = QueryForEvent(EventNewRoundStep)
@@ -183,6 +193,7 @@ type BlockEventPublisher interface {
 	PublishEventNewBlock(block EventDataNewBlock) error
 	PublishEventNewBlockHeader(header EventDataNewBlockHeader) error
 	PublishEventNewBlockEvents(events EventDataNewBlockEvents) error
+	PublishEventFinalizeBlock(data EventDataFinalizeBlock) error
 	PublishEventNewEvidence(evidence EventDataNewEvidence) error
 	PublishEventPendingTx(tx EventDataPendingTx) error
 	PublishEventTx(tx EventDataTx) error
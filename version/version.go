@@ -10,6 +10,11 @@ const (
 	// P2PProtocol versions all p2p behavior and msgs.
 	// This includes proposer selection.
 	P2PProtocol uint64 = 9
+	// P2PProtocolMin is the oldest P2P protocol version this software can
+	// still speak, to peers that have not upgraded yet. Every release on an
+	// older P2P version is also on an older BlockProtocol, so peers below it
+	// were already incompatible.
+	P2PProtocolMin uint64 = 8
 
 	// BlockProtocol versions all block data structures and processing.
 	// This includes validity of blocks and state updates.